@@ -2,11 +2,22 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/pandusatrianura/code-with-umam-second-meeting/api/middleware"
 	route "github.com/pandusatrianura/code-with-umam-second-meeting/api/router"
+	auditLogHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/delivery/http"
+	auditLogRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/repository"
+	auditLogService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/service"
 	categoryHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/delivery/http"
 	categoryRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/repository"
 	categoryService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/service"
@@ -16,43 +27,218 @@ import (
 	productHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/delivery/http"
 	productRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/repository"
 	productService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/service"
+	reportHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/delivery/http"
+	reportRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/repository"
+	reportService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/service"
+	stockHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/delivery/http"
+	stockRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/repository"
+	stockService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/service"
+	transactionHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/delivery/http"
+	transactionRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/repository"
+	transactionService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/service"
+	userHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/delivery/http"
+	userRepository "github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/repository"
+	userService "github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/migrations"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/metrics"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/migration"
+	"github.com/spf13/viper"
+)
+
+// Defaults for the HTTP server timeouts, used when the matching HTTP_* setting is not configured.
+const (
+	DefaultReadTimeout     = 15 * time.Second
+	DefaultWriteTimeout    = 30 * time.Second
+	DefaultIdleTimeout     = 60 * time.Second
+	DefaultShutdownTimeout = 30 * time.Second
 )
 
 type Server struct {
-	addr string
-	db   *database.DB
+	addr            string
+	db              *database.DB
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	// notifier sends the low stock alerts raised by requests, the ones still pending are sent before serve returns.
+	notifier alert.Notifier
 }
 
-// NewAPIServer initializes and returns a new Server instance configured to listen to the specified address.
+// NewAPIServer initializes and returns a new Server instance configured to listen to the specified address. The
+// read, write and idle timeouts of the HTTP server and the time allowed to drain requests on shutdown are read from
+// HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT, HTTP_IDLE_TIMEOUT and HTTP_SHUTDOWN_TIMEOUT.
 func NewAPIServer(addr string, db *database.DB) *Server {
 	return &Server{
 		addr: addr,
 		db:   db,
+		httpServer: &http.Server{
+			Addr:         addr,
+			ReadTimeout:  durationOr(viper.GetDuration("HTTP_READ_TIMEOUT"), DefaultReadTimeout),
+			WriteTimeout: durationOr(viper.GetDuration("HTTP_WRITE_TIMEOUT"), DefaultWriteTimeout),
+			IdleTimeout:  durationOr(viper.GetDuration("HTTP_IDLE_TIMEOUT"), DefaultIdleTimeout),
+		},
+		shutdownTimeout: durationOr(viper.GetDuration("HTTP_SHUTDOWN_TIMEOUT"), DefaultShutdownTimeout),
 	}
 }
 
-// Run starts the server, initializes dependencies, registers routes, and listens for incoming HTTP requests.
+func durationOr(d, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+
+	return d
+}
+
+// Run starts the server, initializes dependencies, registers routes, and listens for incoming HTTP requests until
+// SIGINT or SIGTERM. It then stops accepting connections, drains in-flight requests and closes the database.
 func (s *Server) Run() error {
+	defer s.closeDB()
+
+	notifier := alert.NewNotifier(viper.GetString("ALERT_WEBHOOK_URL"), slog.Default())
+	s.notifier = notifier
+
+	tokens, err := auth.NewTokenManager(viper.GetString("JWT_SECRET"), viper.GetDuration("JWT_TTL"))
+	if err != nil {
+		return fmt.Errorf("JWT_SECRET: %w", err)
+	}
+
+	healthRepo := healthRepository.NewHealthRepository(s.db)
+	appMigrations, err := migration.Load(migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	var migrationVersions []int64
+	for _, m := range appMigrations {
+		migrationVersions = append(migrationVersions, m.Version)
+	}
+
+	healthSvc := healthService.NewHealthService(healthRepo, viper.GetDuration("HEALTH_CHECK_TIMEOUT"),
+		healthService.DatabaseChecker(healthRepo),
+		healthService.MigrationsChecker(healthRepo, migrationVersions),
+		healthService.PoolChecker(healthRepo),
+	)
+	healthHandle := healthHandler.NewHealthHandler(healthSvc)
+
+	// The health endpoints of the modules report the readiness checks.
+	ready := healthSvc.Check
 
 	categoriesRepo := categoryRepository.NewCategoryRepository(s.db)
-	categoriesSvc := categoryService.NewCategoryService(categoriesRepo)
+	categoriesSvc := categoryService.NewCategoryService(categoriesRepo, ready)
 	categoriesHandler := categoryHandler.NewCategoryHandler(categoriesSvc)
 
 	productsRepo := productRepository.NewProductRepository(s.db)
-	productsSvc := productService.NewProductService(productsRepo)
+	productsSvc := productService.NewProductService(productsRepo, notifier, ready)
 	productsHandler := productHandler.NewProductHandler(productsSvc)
 
-	healthRepo := healthRepository.NewHealthRepository(s.db)
-	healthSvc := healthService.NewHealthService(healthRepo)
-	healthHandle := healthHandler.NewHealthHandler(healthSvc)
+	transactionsRepo := transactionRepository.NewTransactionRepository(s.db)
+	transactionsSvc := transactionService.NewTransactionService(transactionsRepo, notifier, ready)
+	transactionsHandler := transactionHandler.NewTransactionHandler(transactionsSvc)
+
+	reportsRepo := reportRepository.NewReportRepository(s.db)
+	reportsSvc := reportService.NewReportService(reportsRepo, ready)
+	reportsHandler := reportHandler.NewReportHandler(reportsSvc)
+
+	stocksRepo := stockRepository.NewStockRepository(s.db)
+	stocksSvc := stockService.NewStockService(stocksRepo, notifier, ready)
+	stocksHandler := stockHandler.NewStockHandler(stocksSvc)
+
+	usersRepo := userRepository.NewUserRepository(s.db)
+	usersSvc := userService.NewUserService(usersRepo, tokens, ready)
+	usersHandler := userHandler.NewUserHandler(usersSvc)
+
+	created, err := usersSvc.EnsureAdmin(context.Background(), viper.GetString("ADMIN_USERNAME"), viper.GetString("ADMIN_PASSWORD"))
+	if err != nil {
+		return fmt.Errorf("create initial admin: %w", err)
+	}
+
+	if created {
+		log.Println("Created initial admin user", viper.GetString("ADMIN_USERNAME"))
+	}
+
+	auditLogsRepo := auditLogRepository.NewAuditLogRepository(s.db)
+	auditLogsSvc := auditLogService.NewAuditLogService(auditLogsRepo, ready)
+	auditLogsHandler := auditLogHandler.NewAuditLogHandler(auditLogsSvc)
 
-	r := route.NewRouter(categoriesHandler, productsHandler, healthHandle)
+	r := route.NewRouter(categoriesHandler, productsHandler, transactionsHandler, reportsHandler, stocksHandler, usersHandler, auditLogsHandler, healthHandle, tokens)
 	routes := r.RegisterRoutes()
 	router := http.NewServeMux()
-	router.Handle("/api/", http.StripPrefix("/api", routes))
+	router.Handle("/api/", middleware.RequestID(middleware.LogRequests(slog.Default())(http.StripPrefix("/api", middleware.Metrics(routes)))))
+	router.Handle("GET /metrics", metrics.Handler())
+
+	if s.db != nil && s.db.DB != nil {
+		if err := metrics.RegisterDB(s.db.DB); err != nil {
+			return err
+		}
+	}
+
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
 	addr := fmt.Sprintf("%s%s", "0.0.0.0", s.addr)
 	log.Println("Starting server on", addr)
-	return http.ListenAndServe(s.addr, router)
+	return s.serve(ctx, listener, router)
+}
+
+// serve handles requests on listener until the server fails or ctx is done. On ctx done it stops accepting new
+// connections and waits up to the shutdown timeout for in-flight requests, such as a checkout, to finish. Requests
+// still running after that are cut off; their database transactions are rolled back when their context is cancelled.
+// The low stock alerts still being sent get the rest of the shutdown timeout.
+func (s *Server) serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	s.httpServer.Handler = handler
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down server, draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	shutdownErr := s.httpServer.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		_ = s.httpServer.Close()
+	}
+
+	if s.notifier != nil {
+		if err := s.notifier.Close(shutdownCtx); err != nil {
+			log.Printf("Failed to send pending low stock alerts: %v", err)
+		}
+	}
+
+	if shutdownErr != nil {
+		return fmt.Errorf("shutdown: %w", shutdownErr)
+	}
+
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	log.Println("Server stopped")
+	return nil
+}
+
+// closeDB closes the database once the server has stopped serving requests.
+func (s *Server) closeDB() {
+	if s.db == nil || s.db.DB == nil {
+		return
+	}
+
+	if err := s.db.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
 }
@@ -1,11 +1,20 @@
 package api
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/spf13/viper"
 )
 
 func TestNewAPIServer(t *testing.T) {
@@ -36,6 +45,232 @@ func TestNewAPIServer(t *testing.T) {
 	}
 }
 
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return testConn{}, nil }
+
+type testConn struct{}
+
+func (testConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (testConn) Close() error                        { return nil }
+func (testConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("api_test_driver", testDriver{})
+}
+
+func TestNewAPIServerTimeouts(t *testing.T) {
+	tests := []struct {
+		name         string
+		settings     map[string]string
+		wantRead     time.Duration
+		wantWrite    time.Duration
+		wantIdle     time.Duration
+		wantShutdown time.Duration
+	}{
+		{name: "defaults", wantRead: DefaultReadTimeout, wantWrite: DefaultWriteTimeout, wantIdle: DefaultIdleTimeout, wantShutdown: DefaultShutdownTimeout},
+		{
+			name:         "configured",
+			settings:     map[string]string{"HTTP_READ_TIMEOUT": "5s", "HTTP_WRITE_TIMEOUT": "10s", "HTTP_IDLE_TIMEOUT": "2m", "HTTP_SHUTDOWN_TIMEOUT": "45s"},
+			wantRead:     5 * time.Second,
+			wantWrite:    10 * time.Second,
+			wantIdle:     2 * time.Minute,
+			wantShutdown: 45 * time.Second,
+		},
+		{name: "invalid", settings: map[string]string{"HTTP_READ_TIMEOUT": "soon", "HTTP_SHUTDOWN_TIMEOUT": "-1s"}, wantRead: DefaultReadTimeout, wantWrite: DefaultWriteTimeout, wantIdle: DefaultIdleTimeout, wantShutdown: DefaultShutdownTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Reset()
+			defer viper.Reset()
+			for key, value := range tt.settings {
+				viper.Set(key, value)
+			}
+
+			srv := NewAPIServer(":8080", nil)
+			if srv.httpServer.Addr != ":8080" {
+				t.Fatalf("http addr = %q, want :8080", srv.httpServer.Addr)
+			}
+			if srv.httpServer.ReadTimeout != tt.wantRead || srv.httpServer.WriteTimeout != tt.wantWrite || srv.httpServer.IdleTimeout != tt.wantIdle {
+				t.Fatalf("timeouts = %v/%v/%v, want %v/%v/%v", srv.httpServer.ReadTimeout, srv.httpServer.WriteTimeout, srv.httpServer.IdleTimeout, tt.wantRead, tt.wantWrite, tt.wantIdle)
+			}
+			if srv.shutdownTimeout != tt.wantShutdown {
+				t.Fatalf("shutdown timeout = %v, want %v", srv.shutdownTimeout, tt.wantShutdown)
+			}
+		})
+	}
+}
+
+func TestServerServeDrainsInFlightRequests(t *testing.T) {
+	oldWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(oldWriter)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	srv := NewAPIServer(listener.Addr().String(), nil)
+	srv.shutdownTimeout = 5 * time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.serve(ctx, listener, handler)
+	}()
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Post("http://"+listener.Addr().String()+"/transactions/checkout", "application/json", nil)
+		if err != nil {
+			status <- 0
+			return
+		}
+		_ = resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	cancel()
+
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned before the in-flight request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if _, err := net.DialTimeout("tcp", listener.Addr().String(), time.Second); err == nil {
+		t.Fatal("expected new connections to be refused during shutdown")
+	}
+
+	close(release)
+	if got := <-status; got != http.StatusCreated {
+		t.Fatalf("in-flight status = %d, want %d", got, http.StatusCreated)
+	}
+	if err := <-served; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+}
+
+// closeNotifier records when Close is called and blocks it until release is closed.
+type closeNotifier struct {
+	closing chan struct{}
+	release chan struct{}
+}
+
+func (n *closeNotifier) NotifyLowStock(context.Context, alert.LowStock) {}
+
+func (n *closeNotifier) Close(ctx context.Context) error {
+	close(n.closing)
+	<-n.release
+	return nil
+}
+
+func TestServerServeDrainsAlerts(t *testing.T) {
+	oldWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(oldWriter)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	notifier := &closeNotifier{closing: make(chan struct{}), release: make(chan struct{})}
+	srv := NewAPIServer(listener.Addr().String(), nil)
+	srv.notifier = notifier
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.serve(ctx, listener, http.NotFoundHandler())
+	}()
+
+	cancel()
+	<-notifier.closing
+
+	select {
+	case err := <-served:
+		t.Fatalf("serve returned before the pending alerts were sent: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(notifier.release)
+	if err := <-served; err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+}
+
+func TestServerServeShutdownTimeout(t *testing.T) {
+	oldWriter := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(oldWriter)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	})
+
+	notifier := &closeNotifier{closing: make(chan struct{}), release: make(chan struct{})}
+	close(notifier.release)
+	srv := NewAPIServer(listener.Addr().String(), nil)
+	srv.shutdownTimeout = 50 * time.Millisecond
+	srv.notifier = notifier
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.serve(ctx, listener, handler)
+	}()
+
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/")
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+	}()
+
+	<-started
+	cancel()
+
+	if err := <-served; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("serve error = %v, want deadline exceeded", err)
+	}
+	select {
+	case <-notifier.closing:
+	default:
+		t.Fatal("expected the notifier to be closed when the shutdown times out")
+	}
+}
+
+func TestServerCloseDB(t *testing.T) {
+	db, err := database.Open("api_test_driver", "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+
+	(&Server{db: db}).closeDB()
+	if err := db.Ping(); err == nil {
+		t.Fatal("expected database to be closed")
+	}
+
+	(&Server{}).closeDB()
+	(&Server{db: &database.DB{}}).closeDB()
+}
+
 func TestServerRun(t *testing.T) {
 	oldWriter := log.Writer()
 	log.SetOutput(io.Discard)
@@ -0,0 +1,63 @@
+// Package middleware holds the http.Handler wrappers shared by the API routes.
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+// RequireRole only lets a request through when it carries a valid "Authorization: Bearer <token>" header issued to
+// one of roles. The claims of the token are added to the request context, see auth.FromContext. Requests without a
+// valid token get a 401, requests from any other role a 403.
+func RequireRole(tokens *auth.TokenManager, roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				response.Error(w, http.StatusUnauthorized, constants.UnauthorizedErrorCode, constants.ErrUnauthorized, errors.New("missing bearer token"))
+				return
+			}
+
+			claims, err := tokens.Parse(token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				response.Error(w, http.StatusUnauthorized, constants.UnauthorizedErrorCode, constants.ErrUnauthorized, errors.New("invalid or expired token"))
+				return
+			}
+
+			if !hasRole(claims.Role, roles) {
+				response.Error(w, http.StatusForbidden, constants.ForbiddenErrorCode, constants.ErrForbidden, fmt.Errorf("role %q is not allowed", claims.Role))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), claims)))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+func hasRole(role string, roles []string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
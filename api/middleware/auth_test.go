@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+func TestRequireRole(t *testing.T) {
+	tokens, err := auth.NewTokenManager("test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("new token manager: %v", err)
+	}
+	otherTokens, _ := auth.NewTokenManager("other-secret", time.Hour)
+
+	issue := func(m *auth.TokenManager, role string) string {
+		token, _, err := m.Issue(4, "siti", role)
+		if err != nil {
+			t.Fatalf("issue token: %v", err)
+		}
+		return token
+	}
+
+	cases := []struct {
+		name       string
+		header     string
+		wantStatus int
+		wantMsg    string
+		wantCalled bool
+	}{
+		{name: "missing", wantStatus: http.StatusUnauthorized, wantMsg: "unauthorized: missing bearer token"},
+		{name: "wrong-scheme", header: "Basic " + issue(tokens, auth.RoleAdmin), wantStatus: http.StatusUnauthorized, wantMsg: "unauthorized: missing bearer token"},
+		{name: "empty-token", header: "Bearer  ", wantStatus: http.StatusUnauthorized, wantMsg: "unauthorized: missing bearer token"},
+		{name: "garbage", header: "Bearer abc.def.ghi", wantStatus: http.StatusUnauthorized, wantMsg: "unauthorized: invalid or expired token"},
+		{name: "other-secret", header: "Bearer " + issue(otherTokens, auth.RoleAdmin), wantStatus: http.StatusUnauthorized, wantMsg: "unauthorized: invalid or expired token"},
+		{name: "wrong-role", header: "Bearer " + issue(tokens, auth.RoleCashier), wantStatus: http.StatusForbidden, wantMsg: `forbidden: role "cashier" is not allowed`},
+		{name: "ok", header: "Bearer " + issue(tokens, auth.RoleAdmin), wantStatus: http.StatusNoContent, wantCalled: true},
+		{name: "ok-lowercase-scheme", header: "bearer " + issue(tokens, auth.RoleAdmin), wantStatus: http.StatusNoContent, wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				claims, ok := auth.FromContext(r.Context())
+				if !ok || claims.UserID != 4 || claims.Username != "siti" || claims.Role != auth.RoleAdmin {
+					t.Fatalf("unexpected claims: %+v", claims)
+				}
+				w.WriteHeader(http.StatusNoContent)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+
+			RequireRole(tokens, auth.RoleAdmin)(next).ServeHTTP(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("next called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if tc.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Fatalf("missing WWW-Authenticate header")
+			}
+			if tc.wantMsg == "" {
+				return
+			}
+			var resp response.APIResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.Message != tc.wantMsg {
+				t.Fatalf("message = %v, want %q", resp.Message, tc.wantMsg)
+			}
+		})
+	}
+}
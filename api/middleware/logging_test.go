@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/logging"
+)
+
+func TestRequestID(t *testing.T) {
+	cases := []struct {
+		name     string
+		header   string
+		wantKeep bool
+	}{
+		{name: "missing"},
+		{name: "propagated", header: "checkout-42.a:b_c", wantKeep: true},
+		{name: "unsafe", header: "abc\ninjected"},
+		{name: "spaces", header: "abc def"},
+		{name: "too-long", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = logging.RequestID(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/transactions/checkout", nil)
+			if tc.header != "" {
+				req.Header.Set(HeaderRequestID, tc.header)
+			}
+			rec := httptest.NewRecorder()
+			RequestID(next).ServeHTTP(rec, req)
+
+			if got == "" {
+				t.Fatalf("expected a request ID in the context")
+			}
+			if tc.wantKeep && got != tc.header {
+				t.Fatalf("request ID = %q, want %q", got, tc.header)
+			}
+			if !tc.wantKeep && got == tc.header {
+				t.Fatalf("expected a new request ID, got %q", got)
+			}
+			if header := rec.Header().Get(HeaderRequestID); header != got {
+				t.Fatalf("response header = %q, want %q", header, got)
+			}
+		})
+	}
+}
+
+func TestLogRequests(t *testing.T) {
+	cases := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus float64
+		wantBytes  float64
+		wantLevel  string
+	}{
+		{
+			name:       "ok",
+			handler:    func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("hello")) },
+			wantStatus: http.StatusOK,
+			wantBytes:  5,
+			wantLevel:  "INFO",
+		},
+		{
+			name:       "not-found",
+			handler:    func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			wantStatus: http.StatusNotFound,
+			wantBytes:  19,
+			wantLevel:  "INFO",
+		},
+		{
+			name: "server-error",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.WriteHeader(http.StatusOK)
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantLevel:  "ERROR",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/products?page=2", nil)
+			req.Header.Set(HeaderRequestID, "req-1")
+			rec := httptest.NewRecorder()
+			RequestID(LogRequests(logger)(tc.handler)).ServeHTTP(rec, req)
+
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("decode log record %q: %v", buf.String(), err)
+			}
+			if record["level"] != tc.wantLevel || record["msg"] != "request" {
+				t.Fatalf("unexpected record: %v", record)
+			}
+			if record["method"] != http.MethodGet || record["path"] != "/api/products" || record[logging.RequestIDKey] != "req-1" {
+				t.Fatalf("unexpected record: %v", record)
+			}
+			if record["status"] != tc.wantStatus || record["bytes"] != tc.wantBytes {
+				t.Fatalf("status/bytes = %v/%v, want %v/%v", record["status"], record["bytes"], tc.wantStatus, tc.wantBytes)
+			}
+			if _, ok := record["duration"]; !ok {
+				t.Fatalf("expected duration in %v", record)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/metrics"
+)
+
+// Metrics counts requests and observes their duration by route pattern and status code, and tracks the requests in
+// flight. It must wrap the ServeMux the routes are registered on, which fills in the matched pattern of the request.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		began := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		route := routeLabel(r.Pattern)
+		method := methodLabel(r.Method)
+		metrics.HTTPRequests.WithLabelValues(method, route, strconv.Itoa(rec.status)).Inc()
+		metrics.HTTPDuration.WithLabelValues(method, route).Observe(time.Since(began).Seconds())
+	})
+}
+
+// routeLabel returns the path of a "METHOD /path" route pattern, or metrics.UnmatchedRoute when no route matched.
+func routeLabel(pattern string) string {
+	if pattern == "" {
+		return metrics.UnmatchedRoute
+	}
+
+	if _, path, found := strings.Cut(pattern, " "); found {
+		return path
+	}
+
+	return pattern
+}
+
+// methodLabel keeps the standard methods and folds any other into OTHER, so clients cannot create series at will.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /products/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if got := testutil.ToFloat64(metrics.HTTPInFlight); got != 1 {
+			t.Fatalf("in flight = %v, want 1", got)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("POST /transactions/checkout", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		status string
+	}{
+		{name: "pattern", method: http.MethodGet, path: "/products/7", route: "/products/{id}", status: "404"},
+		{name: "created", method: http.MethodPost, path: "/transactions/checkout", route: "/transactions/checkout", status: "201"},
+		{name: "unmatched", method: http.MethodGet, path: "/nope/123", route: metrics.UnmatchedRoute, status: "404"},
+		{name: "odd-method", method: "BREW", path: "/nope", route: metrics.UnmatchedRoute, status: "404"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := methodLabel(tc.method)
+			counter := metrics.HTTPRequests.WithLabelValues(method, tc.route, tc.status)
+			before := testutil.ToFloat64(counter)
+
+			rec := httptest.NewRecorder()
+			Metrics(mux).ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if got := testutil.ToFloat64(counter); got != before+1 {
+				t.Fatalf("requests{%s,%s,%s} = %v, want %v", method, tc.route, tc.status, got, before+1)
+			}
+			if got := testutil.ToFloat64(metrics.HTTPInFlight); got != 0 {
+				t.Fatalf("in flight = %v, want 0", got)
+			}
+		})
+	}
+}
+
+func TestMethodLabel(t *testing.T) {
+	if got := methodLabel(http.MethodPatch); got != http.MethodPatch {
+		t.Fatalf("methodLabel(PATCH) = %q", got)
+	}
+	if got := methodLabel("BREW"); got != "OTHER" {
+		t.Fatalf("methodLabel(BREW) = %q, want OTHER", got)
+	}
+}
@@ -4,42 +4,102 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/pandusatrianura/code-with-umam-second-meeting/api/middleware"
+	auditLogsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/delivery/http"
 	categoriesHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/delivery/http"
 	healthHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/delivery/http"
 	productsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/delivery/http"
+	reportsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/delivery/http"
+	stocksHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/delivery/http"
+	transactionsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/delivery/http"
+	usersHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/delivery/http"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/scalar"
 )
 
 type Router struct {
-	categories *categoriesHandler.CategoryHandler
-	products   *productsHandler.ProductHandler
-	health     *healthHandler.HealthHandler
+	categories   *categoriesHandler.CategoryHandler
+	products     *productsHandler.ProductHandler
+	transactions *transactionsHandler.TransactionHandler
+	reports      *reportsHandler.ReportHandler
+	stocks       *stocksHandler.StockHandler
+	users        *usersHandler.UserHandler
+	auditLogs    *auditLogsHandler.AuditLogHandler
+	health       *healthHandler.HealthHandler
+	tokens       *auth.TokenManager
 }
 
-func NewRouter(categoriesHandler *categoriesHandler.CategoryHandler, productHandler *productsHandler.ProductHandler, healthHandler *healthHandler.HealthHandler) *Router {
+func NewRouter(categoriesHandler *categoriesHandler.CategoryHandler, productHandler *productsHandler.ProductHandler, transactionHandler *transactionsHandler.TransactionHandler, reportHandler *reportsHandler.ReportHandler, stockHandler *stocksHandler.StockHandler, userHandler *usersHandler.UserHandler, auditLogHandler *auditLogsHandler.AuditLogHandler, healthHandler *healthHandler.HealthHandler, tokens *auth.TokenManager) *Router {
 	return &Router{
-		categories: categoriesHandler,
-		products:   productHandler,
-		health:     healthHandler,
+		categories:   categoriesHandler,
+		products:     productHandler,
+		transactions: transactionHandler,
+		reports:      reportHandler,
+		stocks:       stockHandler,
+		users:        userHandler,
+		auditLogs:    auditLogHandler,
+		health:       healthHandler,
+		tokens:       tokens,
 	}
 }
 
+// RegisterRoutes registers every route. Health checks, docs and login are public; managing the catalog, stock and
+// users or reading the audit log needs the admin role, everything else (selling and reading) the admin or cashier role.
 func (h *Router) RegisterRoutes() *http.ServeMux {
+	admin := h.requireRole(auth.RoleAdmin)
+	staff := h.requireRole(auth.RoleAdmin, auth.RoleCashier)
+
 	r := http.NewServeMux()
 	r.HandleFunc("GET /health/service", h.health.API)
 	r.HandleFunc("GET /health/db", h.health.DB)
+	r.HandleFunc("GET /health/live", h.health.Live)
+	r.HandleFunc("GET /health/ready", h.health.Ready)
+	r.HandleFunc("POST /auth/login", h.users.Login)
+	r.HandleFunc("GET /users/health", h.users.API)
+	r.Handle("POST /users", admin(h.users.CreateUser))
+	r.Handle("GET /users", admin(h.users.GetAllUsers))
+	r.HandleFunc("GET /audit-logs/health", h.auditLogs.API)
+	r.Handle("GET /audit-logs", admin(h.auditLogs.GetAuditLogs))
 	r.HandleFunc("GET /products/health", h.products.API)
-	r.HandleFunc("POST /products", h.products.CreateProduct)
-	r.HandleFunc("GET /products", h.products.GetAllProducts)
-	r.HandleFunc("GET /products/{id}", h.products.GetProductByID)
-	r.HandleFunc("PUT /products/{id}", h.products.UpdateProduct)
-	r.HandleFunc("DELETE /products/{id}", h.products.DeleteProduct)
+	r.Handle("POST /products", admin(h.products.CreateProduct))
+	r.Handle("POST /products/import", admin(h.products.ImportProducts))
+	r.Handle("GET /products", staff(h.products.GetAllProducts))
+	r.Handle("GET /products/search", staff(h.products.SearchProducts))
+	r.Handle("GET /products/low-stock", staff(h.products.GetLowStockProducts))
+	r.Handle("GET /products/{id}", staff(h.products.GetProductByID))
+	r.Handle("GET /products/by-barcode/{code}", staff(h.products.GetProductByBarcode))
+	r.Handle("PUT /products/{id}", admin(h.products.UpdateProduct))
+	r.Handle("PATCH /products/{id}", admin(h.products.PatchProduct))
+	r.Handle("DELETE /products/{id}", admin(h.products.DeleteProduct))
+	r.Handle("POST /products/{id}/restore", admin(h.products.RestoreProduct))
+	r.HandleFunc("GET /stocks/health", h.stocks.API)
+	r.Handle("POST /products/{id}/stock-adjustments", admin(h.stocks.AdjustStock))
+	// A literal "GET /products/{id}/stock-movements" would conflict with "GET /products/by-barcode/{code}", so
+	// product sub-resources share one pattern that by-barcode is more specific than.
+	r.Handle("GET /products/{id}/{resource}", staff(func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("resource") != "stock-movements" {
+			http.NotFound(w, r)
+			return
+		}
+
+		h.stocks.GetStockMovements(w, r)
+	}))
 	r.HandleFunc("GET /categories/health", h.categories.API)
-	r.HandleFunc("POST /categories", h.categories.CreateCategory)
-	r.HandleFunc("GET /categories", h.categories.GetAllCategories)
-	r.HandleFunc("GET /categories/{id}", h.categories.GetCategoryByID)
-	r.HandleFunc("PUT /categories/{id}", h.categories.UpdateCategory)
-	r.HandleFunc("DELETE /categories/{id}", h.categories.DeleteCategory)
+	r.Handle("POST /categories", admin(h.categories.CreateCategory))
+	r.Handle("GET /categories", staff(h.categories.GetAllCategories))
+	r.Handle("GET /categories/tree", staff(h.categories.GetCategoryTree))
+	r.Handle("GET /categories/{id}", staff(h.categories.GetCategoryByID))
+	r.Handle("PUT /categories/{id}", admin(h.categories.UpdateCategory))
+	r.Handle("PATCH /categories/{id}", admin(h.categories.PatchCategory))
+	r.Handle("DELETE /categories/{id}", admin(h.categories.DeleteCategory))
+	r.Handle("POST /categories/{id}/restore", admin(h.categories.RestoreCategory))
+	r.Handle("GET /categories/{id}/products", staff(h.products.GetProductsByCategory))
+	r.HandleFunc("GET /transactions/health", h.transactions.API)
+	r.Handle("POST /checkout", staff(h.transactions.Checkout))
+	r.Handle("GET /transactions", staff(h.transactions.GetAllTransactions))
+	r.Handle("GET /transactions/{id}", staff(h.transactions.GetTransactionByID))
+	r.HandleFunc("GET /reports/health", h.reports.API)
+	r.Handle("GET /reports/sales", staff(h.reports.GetSalesReport))
 	r.HandleFunc("GET /docs", func(w http.ResponseWriter, r *http.Request) {
 		htmlContent, err := scalar.ApiReferenceHTML(&scalar.Options{
 			SpecURL: "./docs/swagger.json",
@@ -60,3 +120,10 @@ func (h *Router) RegisterRoutes() *http.ServeMux {
 	})
 	return r
 }
+
+func (h *Router) requireRole(roles ...string) func(http.HandlerFunc) http.Handler {
+	requireRole := middleware.RequireRole(h.tokens, roles...)
+	return func(handler http.HandlerFunc) http.Handler {
+		return requireRole(handler)
+	}
+}
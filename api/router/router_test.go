@@ -1,89 +1,236 @@
 package router
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	auditLogsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/delivery/http"
+	auditLogsEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
 	categoriesHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/delivery/http"
 	categoriesEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
 	healthHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/delivery/http"
 	healthEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/entity"
 	productsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/delivery/http"
 	productsEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	reportsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/delivery/http"
+	reportsEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/entity"
+	stocksHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/delivery/http"
+	stocksEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	transactionsHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/delivery/http"
+	transactionsEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	usersHandler "github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/delivery/http"
+	usersEntity "github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
 )
 
 type fakeCategoryService struct{}
 
 type fakeProductService struct{}
 
+type fakeTransactionService struct{}
+
+type fakeReportService struct{}
+
+type fakeStockService struct{}
+
+type fakeAuditLogService struct{}
+
+type fakeUserService struct{}
+
 type fakeHealthService struct{}
 
-func (fakeCategoryService) CreateCategory(*categoriesEntity.RequestCategory) error {
+func (fakeCategoryService) CreateCategory(context.Context, audit.Actor, *categoriesEntity.RequestCategory) error {
+	return nil
+}
+
+func (fakeCategoryService) UpdateCategory(context.Context, audit.Actor, int64, int64, *categoriesEntity.RequestCategory) error {
+	return nil
+}
+
+func (fakeCategoryService) PatchCategory(context.Context, audit.Actor, int64, int64, *categoriesEntity.PatchCategory) error {
 	return nil
 }
 
-func (fakeCategoryService) UpdateCategory(int64, *categoriesEntity.RequestCategory) error {
+func (fakeCategoryService) DeleteCategory(context.Context, audit.Actor, int64, int64, categoriesEntity.DeleteCategoryOptions) error {
 	return nil
 }
 
-func (fakeCategoryService) DeleteCategory(int64) error {
+func (fakeCategoryService) RestoreCategory(context.Context, audit.Actor, int64) error {
 	return nil
 }
 
-func (fakeCategoryService) GetCategoryByID(int64) (*categoriesEntity.ResponseCategory, error) {
+func (fakeCategoryService) GetCategoryByID(context.Context, int64, bool) (*categoriesEntity.ResponseCategory, error) {
 	return &categoriesEntity.ResponseCategory{}, nil
 }
 
-func (fakeCategoryService) GetAllCategories() ([]categoriesEntity.ResponseCategory, error) {
-	return []categoriesEntity.ResponseCategory{}, nil
+func (fakeCategoryService) GetCategoryTree(context.Context) ([]categoriesEntity.ResponseCategoryTree, error) {
+	return []categoriesEntity.ResponseCategoryTree{}, nil
 }
 
-func (fakeCategoryService) API() categoriesEntity.HealthCheck {
+func (fakeCategoryService) GetAllCategories(context.Context, categoriesEntity.CategoryFilter) ([]categoriesEntity.ResponseCategory, *pagination.Meta, error) {
+	return []categoriesEntity.ResponseCategory{}, &pagination.Meta{}, nil
+}
+
+func (fakeCategoryService) API(context.Context) categoriesEntity.HealthCheck {
 	return categoriesEntity.HealthCheck{}
 }
 
-func (fakeProductService) CreateProduct(*productsEntity.RequestProduct) error {
+func (fakeProductService) CreateProduct(context.Context, audit.Actor, *productsEntity.RequestProduct) error {
 	return nil
 }
 
-func (fakeProductService) UpdateProduct(int64, *productsEntity.RequestProduct) error {
+func (fakeProductService) ImportProducts(context.Context, audit.Actor, []productsEntity.ImportProductRow, bool) (*productsEntity.ImportProductReport, error) {
+	return &productsEntity.ImportProductReport{}, nil
+}
+
+func (fakeProductService) UpdateProduct(context.Context, audit.Actor, int64, int64, *productsEntity.RequestProduct) error {
+	return nil
+}
+
+func (fakeProductService) PatchProduct(context.Context, audit.Actor, int64, int64, *productsEntity.PatchProduct) error {
+	return nil
+}
+
+func (fakeProductService) DeleteProduct(context.Context, audit.Actor, int64, int64) error {
 	return nil
 }
 
-func (fakeProductService) DeleteProduct(int64) error {
+func (fakeProductService) RestoreProduct(context.Context, audit.Actor, int64) error {
 	return nil
 }
 
-func (fakeProductService) GetProductByID(int64) (*productsEntity.ResponseProductWithCategories, error) {
+func (fakeProductService) GetProductByID(context.Context, int64) (*productsEntity.ResponseProductWithCategories, error) {
 	return &productsEntity.ResponseProductWithCategories{}, nil
 }
 
-func (fakeProductService) GetAllProducts() ([]productsEntity.ResponseProductWithCategories, error) {
+func (fakeProductService) GetProductByCode(context.Context, string) (*productsEntity.ResponseProductWithCategories, error) {
+	return &productsEntity.ResponseProductWithCategories{}, nil
+}
+
+func (fakeProductService) GetAllProducts(context.Context, productsEntity.ProductFilter) ([]productsEntity.ResponseProductWithCategories, *pagination.Meta, error) {
+	return []productsEntity.ResponseProductWithCategories{}, &pagination.Meta{}, nil
+}
+
+func (fakeProductService) GetProductsByCategory(context.Context, int64, productsEntity.ProductFilter) ([]productsEntity.ResponseProductWithCategories, *pagination.Meta, error) {
+	return []productsEntity.ResponseProductWithCategories{}, &pagination.Meta{}, nil
+}
+
+func (fakeProductService) SearchProducts(context.Context, string, int) ([]productsEntity.ResponseProductWithCategories, error) {
 	return []productsEntity.ResponseProductWithCategories{}, nil
 }
 
-func (fakeProductService) API() productsEntity.HealthCheck {
+func (fakeProductService) GetLowStockProducts(context.Context) ([]productsEntity.ResponseLowStockCategory, error) {
+	return []productsEntity.ResponseLowStockCategory{}, nil
+}
+
+func (fakeProductService) API(context.Context) productsEntity.HealthCheck {
 	return productsEntity.HealthCheck{}
 }
 
+func (fakeTransactionService) Checkout(context.Context, *transactionsEntity.RequestCheckout) (*transactionsEntity.ResponseTransaction, error) {
+	return &transactionsEntity.ResponseTransaction{}, nil
+}
+
+func (fakeTransactionService) GetTransactionByID(context.Context, int64) (*transactionsEntity.ResponseTransaction, error) {
+	return &transactionsEntity.ResponseTransaction{}, nil
+}
+
+func (fakeTransactionService) GetAllTransactions(context.Context, pagination.Params) ([]transactionsEntity.ResponseTransaction, *pagination.Meta, error) {
+	return []transactionsEntity.ResponseTransaction{}, &pagination.Meta{}, nil
+}
+
+func (fakeTransactionService) API(context.Context) transactionsEntity.HealthCheck {
+	return transactionsEntity.HealthCheck{}
+}
+
+func (fakeReportService) GetSalesReport(context.Context, time.Time, time.Time) (*reportsEntity.ResponseSalesReport, error) {
+	return &reportsEntity.ResponseSalesReport{}, nil
+}
+
+func (fakeReportService) API(context.Context) reportsEntity.HealthCheck {
+	return reportsEntity.HealthCheck{}
+}
+
+func (fakeStockService) AdjustStock(context.Context, int64, *stocksEntity.RequestStockAdjustment) (*stocksEntity.ResponseStockMovement, error) {
+	return &stocksEntity.ResponseStockMovement{}, nil
+}
+
+func (fakeStockService) GetStockMovements(context.Context, int64, stocksEntity.StockMovementFilter) ([]stocksEntity.ResponseStockMovement, *pagination.Meta, error) {
+	return []stocksEntity.ResponseStockMovement{}, &pagination.Meta{}, nil
+}
+
+func (fakeStockService) API(context.Context) stocksEntity.HealthCheck {
+	return stocksEntity.HealthCheck{}
+}
+
+func (fakeUserService) Login(context.Context, *usersEntity.RequestLogin) (*usersEntity.ResponseLogin, error) {
+	return &usersEntity.ResponseLogin{}, nil
+}
+
+func (fakeUserService) CreateUser(context.Context, *usersEntity.RequestUser) (*usersEntity.ResponseUser, error) {
+	return &usersEntity.ResponseUser{}, nil
+}
+
+func (fakeUserService) GetAllUsers(context.Context) ([]usersEntity.ResponseUser, error) {
+	return []usersEntity.ResponseUser{}, nil
+}
+
+func (fakeUserService) EnsureAdmin(context.Context, string, string) (bool, error) {
+	return false, nil
+}
+
+func (fakeUserService) API(context.Context) usersEntity.HealthCheck {
+	return usersEntity.HealthCheck{IsHealthy: true}
+}
+
+func (fakeAuditLogService) GetAuditLogs(_ context.Context, filter auditLogsEntity.AuditLogFilter) ([]auditLogsEntity.ResponseAuditLog, *pagination.Meta, error) {
+	return []auditLogsEntity.ResponseAuditLog{}, pagination.NewMeta(filter.Pagination, 0), nil
+}
+
+func (fakeAuditLogService) API(context.Context) auditLogsEntity.HealthCheck {
+	return auditLogsEntity.HealthCheck{IsHealthy: true}
+}
+
 func (fakeHealthService) API() healthEntity.HealthCheck {
 	return healthEntity.HealthCheck{}
 }
 
-func (fakeHealthService) DB() (healthEntity.HealthCheck, error) {
+func (fakeHealthService) DB(context.Context) (healthEntity.HealthCheck, error) {
 	return healthEntity.HealthCheck{}, nil
 }
 
+func (fakeHealthService) Live() healthEntity.Probe {
+	return healthEntity.Probe{Status: healthEntity.StatusUp}
+}
+
+func (fakeHealthService) Ready(context.Context) healthEntity.Probe {
+	return healthEntity.Probe{Status: healthEntity.StatusUp}
+}
+
+func (fakeHealthService) Check(context.Context) error {
+	return nil
+}
+
 func TestNewRouter(t *testing.T) {
 	categories := categoriesHandler.NewCategoryHandler(fakeCategoryService{})
 	products := productsHandler.NewProductHandler(fakeProductService{})
+	transactions := transactionsHandler.NewTransactionHandler(fakeTransactionService{})
+	reports := reportsHandler.NewReportHandler(fakeReportService{})
+	stocks := stocksHandler.NewStockHandler(fakeStockService{})
+	users := usersHandler.NewUserHandler(fakeUserService{})
+	auditLogs := auditLogsHandler.NewAuditLogHandler(fakeAuditLogService{})
 	health := healthHandler.NewHealthHandler(fakeHealthService{})
+	tokens := newTestTokens(t)
 
-	got := NewRouter(categories, products, health)
+	got := NewRouter(categories, products, transactions, reports, stocks, users, auditLogs, health, tokens)
 
 	if got.categories != categories {
 		t.Fatalf("categories handler mismatch")
@@ -91,17 +238,66 @@ func TestNewRouter(t *testing.T) {
 	if got.products != products {
 		t.Fatalf("products handler mismatch")
 	}
+	if got.transactions != transactions {
+		t.Fatalf("transactions handler mismatch")
+	}
+	if got.reports != reports {
+		t.Fatalf("reports handler mismatch")
+	}
+	if got.stocks != stocks {
+		t.Fatalf("stocks handler mismatch")
+	}
+	if got.users != users {
+		t.Fatalf("users handler mismatch")
+	}
+	if got.auditLogs != auditLogs {
+		t.Fatalf("audit logs handler mismatch")
+	}
 	if got.health != health {
 		t.Fatalf("health handler mismatch")
 	}
+	if got.tokens != tokens {
+		t.Fatalf("token manager mismatch")
+	}
 }
 
-func TestRegisterRoutes(t *testing.T) {
+func newTestTokens(t *testing.T) *auth.TokenManager {
+	t.Helper()
+	tokens, err := auth.NewTokenManager("test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("new token manager: %v", err)
+	}
+	return tokens
+}
+
+func newTestRouter(t *testing.T) (*Router, *auth.TokenManager) {
+	t.Helper()
+	tokens := newTestTokens(t)
 	r := NewRouter(
 		categoriesHandler.NewCategoryHandler(fakeCategoryService{}),
 		productsHandler.NewProductHandler(fakeProductService{}),
+		transactionsHandler.NewTransactionHandler(fakeTransactionService{}),
+		reportsHandler.NewReportHandler(fakeReportService{}),
+		stocksHandler.NewStockHandler(fakeStockService{}),
+		usersHandler.NewUserHandler(fakeUserService{}),
+		auditLogsHandler.NewAuditLogHandler(fakeAuditLogService{}),
 		healthHandler.NewHealthHandler(fakeHealthService{}),
+		tokens,
 	)
+	return r, tokens
+}
+
+func bearer(t *testing.T, tokens *auth.TokenManager, role string) string {
+	t.Helper()
+	token, _, err := tokens.Issue(1, "user", role)
+	if err != nil {
+		t.Fatalf("issue token: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	r, tokens := newTestRouter(t)
 	mux := r.RegisterRoutes()
 
 	cases := []struct {
@@ -112,18 +308,46 @@ func TestRegisterRoutes(t *testing.T) {
 	}{
 		{name: "health-service", method: http.MethodGet, path: "/health/service", wantPattern: "GET /health/service"},
 		{name: "health-db", method: http.MethodGet, path: "/health/db", wantPattern: "GET /health/db"},
+		{name: "health-live", method: http.MethodGet, path: "/health/live", wantPattern: "GET /health/live"},
+		{name: "health-ready", method: http.MethodGet, path: "/health/ready", wantPattern: "GET /health/ready"},
+		{name: "auth-login", method: http.MethodPost, path: "/auth/login", wantPattern: "POST /auth/login"},
+		{name: "users-health", method: http.MethodGet, path: "/users/health", wantPattern: "GET /users/health"},
+		{name: "users-create", method: http.MethodPost, path: "/users", wantPattern: "POST /users"},
+		{name: "users-list", method: http.MethodGet, path: "/users", wantPattern: "GET /users"},
 		{name: "products-health", method: http.MethodGet, path: "/products/health", wantPattern: "GET /products/health"},
 		{name: "products-create", method: http.MethodPost, path: "/products", wantPattern: "POST /products"},
+		{name: "products-import", method: http.MethodPost, path: "/products/import", wantPattern: "POST /products/import"},
 		{name: "products-list", method: http.MethodGet, path: "/products", wantPattern: "GET /products"},
+		{name: "products-search", method: http.MethodGet, path: "/products/search?q=susu", wantPattern: "GET /products/search"},
+		{name: "products-low-stock", method: http.MethodGet, path: "/products/low-stock", wantPattern: "GET /products/low-stock"},
 		{name: "products-get", method: http.MethodGet, path: "/products/123", wantPattern: "GET /products/{id}"},
+		{name: "products-by-barcode", method: http.MethodGet, path: "/products/by-barcode/8992761166014", wantPattern: "GET /products/by-barcode/{code}"},
 		{name: "products-update", method: http.MethodPut, path: "/products/123", wantPattern: "PUT /products/{id}"},
+		{name: "products-patch", method: http.MethodPatch, path: "/products/123", wantPattern: "PATCH /products/{id}"},
 		{name: "products-delete", method: http.MethodDelete, path: "/products/123", wantPattern: "DELETE /products/{id}"},
+		{name: "products-restore", method: http.MethodPost, path: "/products/123/restore", wantPattern: "POST /products/{id}/restore"},
+		{name: "stocks-health", method: http.MethodGet, path: "/stocks/health", wantPattern: "GET /stocks/health"},
+		{name: "stock-adjustments", method: http.MethodPost, path: "/products/123/stock-adjustments", wantPattern: "POST /products/{id}/stock-adjustments"},
+		{name: "stock-movements", method: http.MethodGet, path: "/products/123/stock-movements", wantPattern: "GET /products/{id}/{resource}"},
 		{name: "categories-health", method: http.MethodGet, path: "/categories/health", wantPattern: "GET /categories/health"},
 		{name: "categories-create", method: http.MethodPost, path: "/categories", wantPattern: "POST /categories"},
 		{name: "categories-list", method: http.MethodGet, path: "/categories", wantPattern: "GET /categories"},
 		{name: "categories-get", method: http.MethodGet, path: "/categories/123", wantPattern: "GET /categories/{id}"},
+		{name: "categories-tree", method: http.MethodGet, path: "/categories/tree", wantPattern: "GET /categories/tree"},
 		{name: "categories-update", method: http.MethodPut, path: "/categories/123", wantPattern: "PUT /categories/{id}"},
+		{name: "categories-patch", method: http.MethodPatch, path: "/categories/123", wantPattern: "PATCH /categories/{id}"},
 		{name: "categories-delete", method: http.MethodDelete, path: "/categories/123", wantPattern: "DELETE /categories/{id}"},
+		{name: "categories-restore", method: http.MethodPost, path: "/categories/123/restore", wantPattern: "POST /categories/{id}/restore"},
+		{name: "categories-products", method: http.MethodGet, path: "/categories/123/products", wantPattern: "GET /categories/{id}/products"},
+		{name: "audit-logs-health", method: http.MethodGet, path: "/audit-logs/health", wantPattern: "GET /audit-logs/health"},
+		{name: "audit-logs-list", method: http.MethodGet, path: "/audit-logs?entity_type=product", wantPattern: "GET /audit-logs"},
+		{name: "transactions-health", method: http.MethodGet, path: "/transactions/health", wantPattern: "GET /transactions/health"},
+		{name: "checkout", method: http.MethodPost, path: "/checkout", wantPattern: "POST /checkout"},
+		{name: "transactions-list", method: http.MethodGet, path: "/transactions", wantPattern: "GET /transactions"},
+		{name: "transactions-get", method: http.MethodGet, path: "/transactions/123", wantPattern: "GET /transactions/{id}"},
+		{name: "reports-health", method: http.MethodGet, path: "/reports/health", wantPattern: "GET /reports/health"},
+		{name: "reports-sales", method: http.MethodGet, path: "/reports/sales?from=2024-01-01&to=2024-01-31", wantPattern: "GET /reports/sales"},
+		{name: "barcode-not-stock-movements", method: http.MethodGet, path: "/products/by-barcode/stock-movements", wantPattern: "GET /products/by-barcode/{code}"},
 		{name: "docs", method: http.MethodGet, path: "/docs", wantPattern: "GET /docs"},
 		{name: "method-mismatch", method: http.MethodPost, path: "/health/service", wantPattern: ""},
 		{name: "unknown", method: http.MethodGet, path: "/unknown", wantPattern: ""},
@@ -140,6 +364,17 @@ func TestRegisterRoutes(t *testing.T) {
 		})
 	}
 
+	t.Run("unknown-product-resource", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/products/123/unknown", nil)
+		req.Header.Set("Authorization", bearer(t, tokens, auth.RoleCashier))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("status mismatch: got %d want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+
 	t.Run("docs-response", func(t *testing.T) {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -175,3 +410,67 @@ func TestRegisterRoutes(t *testing.T) {
 		}
 	})
 }
+
+func TestRegisterRoutesAccess(t *testing.T) {
+	r, tokens := newTestRouter(t)
+	mux := r.RegisterRoutes()
+
+	cases := []struct {
+		name       string
+		method     string
+		path       string
+		auth       string
+		wantStatus int
+	}{
+		{name: "public-module-health", method: http.MethodGet, path: "/users/health", wantStatus: http.StatusOK},
+		{name: "public-ready", method: http.MethodGet, path: "/health/ready", wantStatus: http.StatusOK},
+		{name: "public-login", method: http.MethodPost, path: "/auth/login", wantStatus: http.StatusOK},
+		{name: "missing-token", method: http.MethodGet, path: "/products", wantStatus: http.StatusUnauthorized},
+		{name: "bad-token", method: http.MethodGet, path: "/products", auth: "Bearer nope", wantStatus: http.StatusUnauthorized},
+		{name: "cashier-reads", method: http.MethodGet, path: "/products", auth: auth.RoleCashier, wantStatus: http.StatusOK},
+		{name: "cashier-checkout", method: http.MethodPost, path: "/checkout", auth: auth.RoleCashier, wantStatus: http.StatusCreated},
+		{name: "cashier-delete-product", method: http.MethodDelete, path: "/products/1", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "cashier-patch-product", method: http.MethodPatch, path: "/products/1", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "cashier-create-category", method: http.MethodPost, path: "/categories", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "cashier-adjust-stock", method: http.MethodPost, path: "/products/1/stock-adjustments", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "cashier-users", method: http.MethodGet, path: "/users", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "admin-delete-product", method: http.MethodDelete, path: "/products/1", auth: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "cashier-restore-product", method: http.MethodPost, path: "/products/1/restore", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "admin-restore-product", method: http.MethodPost, path: "/products/1/restore", auth: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "cashier-import-products", method: http.MethodPost, path: "/products/import", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "cashier-category-tree", method: http.MethodGet, path: "/categories/tree", auth: auth.RoleCashier, wantStatus: http.StatusOK},
+		{name: "cashier-category-products", method: http.MethodGet, path: "/categories/1/products", auth: auth.RoleCashier, wantStatus: http.StatusOK},
+		{name: "cashier-list-deleted-products", method: http.MethodGet, path: "/products?include_deleted=true", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "admin-list-deleted-products", method: http.MethodGet, path: "/products?include_deleted=true", auth: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "admin-users", method: http.MethodGet, path: "/users", auth: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "cashier-audit-logs", method: http.MethodGet, path: "/audit-logs", auth: auth.RoleCashier, wantStatus: http.StatusForbidden},
+		{name: "admin-audit-logs", method: http.MethodGet, path: "/audit-logs", auth: auth.RoleAdmin, wantStatus: http.StatusOK},
+		{name: "admin-reads", method: http.MethodGet, path: "/transactions", auth: auth.RoleAdmin, wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var body *strings.Reader
+			switch tc.path {
+			case "/checkout":
+				body = strings.NewReader(`{"items":[{"product_id":1,"quantity":1}]}`)
+			default:
+				body = strings.NewReader(`{}`)
+			}
+			req := httptest.NewRequest(tc.method, "http://example.com"+tc.path, body)
+			switch tc.auth {
+			case "":
+			case auth.RoleAdmin, auth.RoleCashier:
+				req.Header.Set("Authorization", bearer(t, tokens, tc.auth))
+			default:
+				req.Header.Set("Authorization", tc.auth)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status mismatch: got %d want %d (%s)", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -2,13 +2,45 @@ package constants
 
 const (
 	SuccessCode = 1000
-	ErrorCode   = 2000
+	// ErrorCode is the code of internal errors and of errors of no known kind.
+	ErrorCode             = 2000
+	ValidationErrorCode   = 2001
+	NotFoundErrorCode     = 2002
+	ConflictErrorCode     = 2003
+	UnauthorizedErrorCode = 2004
+	ForbiddenErrorCode    = 2005
+	// PreconditionFailedErrorCode is the code of writes whose If-Match header names an outdated version.
+	PreconditionFailedErrorCode = 2006
 
 	ErrCategoryNotFound       = "category not found"
 	ErrInvalidCategoryID      = "invalid category id"
 	ErrInvalidCategoryRequest = "invalid category request"
+	ErrInvalidCategoryFilter  = "invalid category filter"
 
 	ErrProductNotFound       = "product not found"
 	ErrInvalidProductID      = "invalid product id"
+	ErrInvalidProductCode    = "invalid product code"
 	ErrInvalidProductRequest = "invalid product request"
+	ErrInvalidProductFilter  = "invalid product filter"
+	ErrInvalidProductSearch  = "invalid product search"
+	ErrInvalidProductImport  = "invalid product import"
+
+	ErrTransactionNotFound      = "transaction not found"
+	ErrInvalidTransactionID     = "invalid transaction id"
+	ErrInvalidTransactionFilter = "invalid transaction filter"
+	ErrInvalidCheckoutRequest   = "invalid checkout request"
+
+	ErrInvalidReportDate = "invalid report date, expected YYYY-MM-DD"
+
+	ErrInvalidStockAdjustmentRequest = "invalid stock adjustment request"
+	ErrInvalidStockMovementFilter    = "invalid stock movement filter"
+
+	ErrUnauthorized        = "unauthorized"
+	ErrForbidden           = "forbidden"
+	ErrInvalidLoginRequest = "invalid login request"
+	ErrInvalidUserRequest  = "invalid user request"
+
+	ErrInvalidAuditLogFilter = "invalid audit log filter"
+
+	ErrInvalidIfMatch = "invalid If-Match header"
 )
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type AuditLogHandler struct {
+	service service.AuditLogService
+}
+
+func NewAuditLogHandler(service service.AuditLogService) *AuditLogHandler {
+	return &AuditLogHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of audit logs API
+// @Description Get health status of audit logs API
+// @Tags audit-logs
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/audit-logs/health [get]
+func (h *AuditLogHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API(r.Context())
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+}
+
+// GetAuditLogs godoc
+// @Summary Get audit logs
+// @Description Get a page of the catalog audit log, newest first (admin only). Each entry has the actor, the changed entity and its state before and after the change.
+// @Tags audit-logs
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param entity_type query string false "Entity type (product, category)"
+// @Param entity_id query int false "Entity ID"
+// @Param from query string false "Start date (YYYY-MM-DD, Asia/Jakarta)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD, Asia/Jakarta)"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/audit-logs [get]
+func (h *AuditLogHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	var (
+		filter entity.AuditLogFilter
+		err    error
+	)
+
+	query := r.URL.Query()
+	filter.EntityType = strings.TrimSpace(query.Get("entity_type"))
+	if filter.EntityType != "" && !slices.Contains(audit.EntityTypes, filter.EntityType) {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidAuditLogFilter, fmt.Errorf("invalid entity type: %s", filter.EntityType))
+		return
+	}
+
+	if idStr := query.Get("entity_id"); idStr != "" {
+		filter.EntityID, err = strconv.ParseInt(idStr, 10, 64)
+		if err != nil || filter.EntityID <= 0 {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidAuditLogFilter, fmt.Errorf("invalid entity id: %s", idStr))
+			return
+		}
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		filter.From, err = datetime.ParseDate(fromStr)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidAuditLogFilter, err)
+			return
+		}
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		filter.To, err = datetime.ParseDate(toStr)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidAuditLogFilter, err)
+			return
+		}
+	}
+
+	filter.Pagination, err = pagination.Parse(query)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidAuditLogFilter, err)
+		return
+	}
+
+	logs, meta, err := h.service.GetAuditLogs(r.Context(), filter)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Audit logs retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Audit logs retrieved successfully", logs, meta)
+}
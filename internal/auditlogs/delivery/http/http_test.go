@@ -0,0 +1,173 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type mockAuditLogService struct {
+	getAuditLogsFn func(entity.AuditLogFilter) ([]entity.ResponseAuditLog, *pagination.Meta, error)
+	apiFn          func() entity.HealthCheck
+}
+
+func (m *mockAuditLogService) GetAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, *pagination.Meta, error) {
+	if m.getAuditLogsFn == nil {
+		return nil, nil, nil
+	}
+	return m.getAuditLogsFn(filter)
+}
+
+func (m *mockAuditLogService) API(ctx context.Context) entity.HealthCheck {
+	if m.apiFn == nil {
+		return entity.HealthCheck{}
+	}
+	return m.apiFn()
+}
+
+func decodeAPIResponse(t *testing.T, rec *httptest.ResponseRecorder) response.APIResponse {
+	t.Helper()
+	var resp response.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestNewAuditLogHandler(t *testing.T) {
+	svc := &mockAuditLogService{}
+	h := NewAuditLogHandler(svc)
+	if h == nil {
+		t.Fatalf("handler is nil")
+	}
+	if h.service != svc {
+		t.Fatalf("service mismatch")
+	}
+}
+
+func TestAuditLogHandlerAPI(t *testing.T) {
+	cases := []struct {
+		name       string
+		health     entity.HealthCheck
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "healthy", health: entity.HealthCheck{Name: "audit logs", IsHealthy: true}, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "audit logs is healthy"},
+		{name: "unhealthy", health: entity.HealthCheck{Name: "audit logs"}, wantStatus: http.StatusServiceUnavailable, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "audit logs is not healthy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewAuditLogHandler(&mockAuditLogService{
+				apiFn: func() entity.HealthCheck { return tc.health },
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/audit-logs/health", nil)
+			h.API(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode || resp.Message != tc.wantMsg {
+				t.Fatalf("unexpected response: %+v", resp)
+			}
+		})
+	}
+}
+
+func TestAuditLogHandlerGetAuditLogs(t *testing.T) {
+	from, _ := datetime.ParseDate("2024-01-01")
+	to, _ := datetime.ParseDate("2024-01-31")
+
+	cases := []struct {
+		name       string
+		query      string
+		svcErr     error
+		wantStatus int
+		wantMsg    string
+		wantFilter entity.AuditLogFilter
+		wantCalled bool
+	}{
+		{name: "bad-entity-type", query: "entity_type=user", wantStatus: http.StatusBadRequest, wantMsg: constants.ErrInvalidAuditLogFilter + ": invalid entity type: user"},
+		{name: "bad-entity-id", query: "entity_id=0", wantStatus: http.StatusBadRequest, wantMsg: constants.ErrInvalidAuditLogFilter + ": invalid entity id: 0"},
+		{name: "bad-from", query: "from=01-01-2024", wantStatus: http.StatusBadRequest, wantMsg: constants.ErrInvalidAuditLogFilter},
+		{name: "bad-to", query: "to=2024-13-01", wantStatus: http.StatusBadRequest, wantMsg: constants.ErrInvalidAuditLogFilter},
+		{name: "bad-page", query: "page=0", wantStatus: http.StatusBadRequest, wantMsg: constants.ErrInvalidAuditLogFilter + ": page must be a positive integer"},
+		{
+			name:       "svc-error",
+			svcErr:     errors.New("boom"),
+			wantStatus: http.StatusInternalServerError,
+			wantMsg:    "Audit logs retrieved failed: boom",
+			wantFilter: entity.AuditLogFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			wantCalled: true,
+		},
+		{
+			name:       "ok",
+			query:      "entity_type=product&entity_id=5&from=2024-01-01&to=2024-01-31&page=2&page_size=10",
+			wantStatus: http.StatusOK,
+			wantMsg:    "Audit logs retrieved successfully",
+			wantFilter: entity.AuditLogFilter{EntityType: "product", EntityID: 5, From: from, To: to, Pagination: pagination.Params{Page: 2, PageSize: 10}},
+			wantCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewAuditLogHandler(&mockAuditLogService{
+				getAuditLogsFn: func(filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, *pagination.Meta, error) {
+					called = true
+					if filter.EntityType != tc.wantFilter.EntityType || filter.EntityID != tc.wantFilter.EntityID ||
+						!filter.From.Equal(tc.wantFilter.From) || !filter.To.Equal(tc.wantFilter.To) ||
+						filter.Pagination.Page != tc.wantFilter.Pagination.Page || filter.Pagination.PageSize != tc.wantFilter.Pagination.PageSize {
+						t.Fatalf("filter = %+v, want %+v", filter, tc.wantFilter)
+					}
+					if tc.svcErr != nil {
+						return nil, nil, tc.svcErr
+					}
+					logs := []entity.ResponseAuditLog{{ID: 1, ActorUsername: "admin", Action: "update", EntityType: "product", EntityID: 5, ChangedFields: []string{"price"}, CreatedAt: time.Now()}}
+					return logs, pagination.NewMeta(filter.Pagination, 11), nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/audit-logs?"+tc.query, nil)
+
+			h.GetAuditLogs(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			msg, _ := resp.Message.(string)
+			if !strings.HasPrefix(msg, tc.wantMsg) {
+				t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.([]any)
+				if !ok || len(data) != 1 {
+					t.Fatalf("unexpected data: %v", resp.Data)
+				}
+				if resp.Meta == nil {
+					t.Fatalf("missing meta")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type AuditLog struct {
+	ID            int64  `json:"id"`
+	ActorID       int64  `json:"actor_id"`
+	ActorUsername string `json:"actor_username"`
+	Action        string `json:"action"`
+	EntityType    string `json:"entity_type"`
+	EntityID      int64  `json:"entity_id"`
+	Before        string `json:"before"`
+	After         string `json:"after"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// ResponseAuditLog is one audit entry. Before is omitted for creates and After for deletes; ChangedFields lists the
+// fields that differ between the two.
+type ResponseAuditLog struct {
+	ID            int64           `json:"id"`
+	ActorID       int64           `json:"actor_id,omitempty"`
+	ActorUsername string          `json:"actor_username"`
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      int64           `json:"entity_id"`
+	Before        json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After         json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	ChangedFields []string        `json:"changed_fields"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// AuditLogFilter narrows and pages the audit log. An empty EntityType, a zero EntityID and zero dates mean no filter.
+// From and To are business days (Asia/Jakarta), both inclusive.
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   int64
+	From       time.Time
+	To         time.Time
+	Pagination pagination.Params
+}
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+)
+
+type AuditLogRepository interface {
+	GetAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, int, error)
+}
+
+type auditLogRepository struct {
+	db *database.DB
+}
+
+func NewAuditLogRepository(db *database.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+// GetAuditLogs returns a page of the audit log matching filter, newest first, and the number of matching entries.
+func (r *auditLogRepository) GetAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, int, error) {
+	var (
+		query      string
+		countQuery string
+		where      string
+		args       []interface{}
+		total      int
+		logs       []entity.ResponseAuditLog
+		err        error
+	)
+
+	where, args = auditLogFilterClause(filter)
+	countQuery = "SELECT COUNT(*) FROM audit_logs" + where
+	query = fmt.Sprintf("SELECT id, COALESCE(actor_id, 0) as actor_id, actor_username, action, entity_type, entity_id, COALESCE(before::text, '') as before, COALESCE(after::text, '') as after, created_at FROM audit_logs%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", where, len(args)+1, len(args)+2)
+
+	err = r.db.WithStmtContext(ctx, countQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&total)
+		}, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var log entity.AuditLog
+			if err := rows.Scan(&log.ID, &log.ActorID, &log.ActorUsername, &log.Action, &log.EntityType, &log.EntityID, &log.Before, &log.After, &log.CreatedAt); err != nil {
+				return err
+			}
+
+			logs = append(logs, toResponseAuditLog(log))
+			return nil
+		}, append(args, filter.Pagination.Limit(), filter.Pagination.Offset())...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// auditLogFilterClause builds the WHERE clause shared by the audit log list and count queries. To is inclusive, so
+// entries are matched up to the start of the following day.
+func auditLogFilterClause(filter entity.AuditLogFilter) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if filter.EntityType != "" {
+		args = append(args, filter.EntityType)
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", len(args)))
+	}
+
+	if filter.EntityID != 0 {
+		args = append(args, filter.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if !filter.To.IsZero() {
+		args = append(args, filter.To.AddDate(0, 0, 1))
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func toResponseAuditLog(log entity.AuditLog) entity.ResponseAuditLog {
+	createdAt, _ := datetime.ParseTime(log.CreatedAt)
+
+	response := entity.ResponseAuditLog{
+		ID:            log.ID,
+		ActorID:       log.ActorID,
+		ActorUsername: log.ActorUsername,
+		Action:        log.Action,
+		EntityType:    log.EntityType,
+		EntityID:      log.EntityID,
+		ChangedFields: audit.ChangedFields([]byte(log.Before), []byte(log.After)),
+		CreatedAt:     createdAt,
+	}
+
+	if log.Before != "" {
+		response.Before = json.RawMessage(log.Before)
+	}
+
+	if log.After != "" {
+		response.After = json.RawMessage(log.After)
+	}
+
+	if response.ChangedFields == nil {
+		response.ChangedFields = []string{}
+	}
+
+	return response
+}
@@ -0,0 +1,287 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type testQuery struct {
+	columns  []string
+	rows     [][]driver.Value
+	queryErr error
+}
+
+type testConfig struct {
+	execErr   map[string]error
+	query     map[string]testQuery
+	commitErr error
+
+	mu        sync.Mutex
+	queryArgs map[string][]driver.Value
+	execArgs  map[string][]driver.Value
+	rolled    bool
+}
+
+func (c *testConfig) getExecErr(query string) error {
+	if c.execErr == nil {
+		return nil
+	}
+	return c.execErr[query]
+}
+
+func (c *testConfig) getQuery(query string) testQuery {
+	if c.query == nil {
+		return testQuery{}
+	}
+	return c.query[query]
+}
+
+func (c *testConfig) recordQuery(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queryArgs == nil {
+		c.queryArgs = make(map[string][]driver.Value)
+	}
+	c.queryArgs[query] = append([]driver.Value(nil), args...)
+}
+
+func (c *testConfig) recordExec(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.execArgs == nil {
+		c.execArgs = make(map[string][]driver.Value)
+	}
+	c.execArgs[query] = append([]driver.Value(nil), args...)
+}
+
+type testDriver struct {
+	cfg *testConfig
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{cfg: d.cfg}, nil
+}
+
+type testConn struct {
+	cfg *testConfig
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+
+func (c *testConn) Close() error { return nil }
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return &testTx{cfg: c.cfg}, nil
+}
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.cfg.getExecErr(s.query); err != nil {
+		return nil, err
+	}
+	s.cfg.recordExec(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.cfg.recordQuery(s.query, args)
+	q := s.cfg.getQuery(s.query)
+	if q.queryErr != nil {
+		return nil, q.queryErr
+	}
+	return &testRows{columns: q.columns, values: q.rows}, nil
+}
+
+type testTx struct {
+	cfg *testConfig
+}
+
+func (t *testTx) Commit() error {
+	return t.cfg.commitErr
+}
+
+func (t *testTx) Rollback() error {
+	t.cfg.mu.Lock()
+	defer t.cfg.mu.Unlock()
+	t.cfg.rolled = true
+	return nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+	idx     int
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	row := r.values[r.idx]
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = row[i]
+		}
+	}
+	r.idx++
+	return nil
+}
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("audit_log_repo_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+const (
+	listColumns = "id, COALESCE(actor_id, 0) as actor_id, actor_username, action, entity_type, entity_id, COALESCE(before::text, '') as before, COALESCE(after::text, '') as after, created_at"
+	countQuery  = "SELECT COUNT(*) FROM audit_logs"
+	listQuery   = "SELECT " + listColumns + " FROM audit_logs ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2"
+)
+
+func TestNewAuditLogRepository(t *testing.T) {
+	db := newTestDB(t, &testConfig{})
+	repo := NewAuditLogRepository(db)
+	r, ok := repo.(*auditLogRepository)
+	if !ok {
+		t.Fatalf("expected auditLogRepository, got %T", repo)
+	}
+	if r.db != db {
+		t.Fatalf("expected db to match")
+	}
+}
+
+func TestAuditLogRepositoryGetAuditLogs(t *testing.T) {
+	errQuery := errors.New("query")
+	page := pagination.Params{Page: 2, PageSize: 10}
+	columns := []string{"id", "actor_id", "actor_username", "action", "entity_type", "entity_id", "before", "after", "created_at"}
+	rows := [][]driver.Value{
+		{int64(2), int64(1), "admin", "update", "product", int64(5), `{"name":"Bebelac","price":10}`, `{"name":"Bebelac","price":12}`, "2024-01-02T03:04:05Z"},
+		{int64(1), int64(0), "system", "create", "product", int64(5), "", `{"name":"Bebelac","price":10}`, "2024-01-01T03:04:05Z"},
+	}
+	count := testQuery{columns: []string{"count"}, rows: [][]driver.Value{{int64(12)}}}
+
+	tests := []struct {
+		name      string
+		cfg       *testConfig
+		filter    entity.AuditLogFilter
+		wantErr   error
+		wantTotal int
+		wantLen   int
+	}{
+		{
+			name:      "ok",
+			cfg:       &testConfig{query: map[string]testQuery{countQuery: count, listQuery: {columns: columns, rows: rows}}},
+			filter:    entity.AuditLogFilter{Pagination: page},
+			wantTotal: 12,
+			wantLen:   2,
+		},
+		{name: "count-error", cfg: &testConfig{query: map[string]testQuery{countQuery: {queryErr: errQuery}}}, filter: entity.AuditLogFilter{Pagination: page}, wantErr: errQuery},
+		{name: "list-error", cfg: &testConfig{query: map[string]testQuery{countQuery: count, listQuery: {queryErr: errQuery}}}, filter: entity.AuditLogFilter{Pagination: page}, wantErr: errQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewAuditLogRepository(db)
+			logs, total, err := repo.GetAuditLogs(context.Background(), tt.filter)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if total != tt.wantTotal || len(logs) != tt.wantLen {
+				t.Fatalf("total = %d, len = %d, want %d and %d", total, len(logs), tt.wantTotal, tt.wantLen)
+			}
+			if args := tt.cfg.queryArgs[listQuery]; !reflect.DeepEqual(args, []driver.Value{int64(10), int64(10)}) {
+				t.Fatalf("unexpected list args: %v", args)
+			}
+
+			update := logs[0]
+			if update.ActorID != 1 || update.ActorUsername != "admin" || update.EntityID != 5 || !reflect.DeepEqual(update.ChangedFields, []string{"price"}) {
+				t.Fatalf("unexpected update entry: %+v", update)
+			}
+			if update.CreatedAt.IsZero() {
+				t.Fatalf("created_at not parsed")
+			}
+
+			create := logs[1]
+			if create.Before != nil || string(create.After) != `{"name":"Bebelac","price":10}` || !reflect.DeepEqual(create.ChangedFields, []string{"name", "price"}) {
+				t.Fatalf("unexpected create entry: %+v", create)
+			}
+		})
+	}
+}
+
+func TestAuditLogFilterClause(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		filter    entity.AuditLogFilter
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{name: "none"},
+		{name: "entity", filter: entity.AuditLogFilter{EntityType: "product", EntityID: 5}, wantWhere: " WHERE entity_type = $1 AND entity_id = $2", wantArgs: []interface{}{"product", int64(5)}},
+		{name: "dates", filter: entity.AuditLogFilter{From: from, To: to}, wantWhere: " WHERE created_at >= $1 AND created_at < $2", wantArgs: []interface{}{from, to.AddDate(0, 0, 1)}},
+		{
+			name:      "all",
+			filter:    entity.AuditLogFilter{EntityType: "category", EntityID: 3, From: from, To: to},
+			wantWhere: " WHERE entity_type = $1 AND entity_id = $2 AND created_at >= $3 AND created_at < $4",
+			wantArgs:  []interface{}{"category", int64(3), from, to.AddDate(0, 0, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, args := auditLogFilterClause(tt.filter)
+			if where != tt.wantWhere {
+				t.Fatalf("where = %q, want %q", where, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
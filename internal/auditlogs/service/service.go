@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type auditLogService struct {
+	auditLogRepository repository.AuditLogRepository
+	ready              func(ctx context.Context) error
+}
+
+type AuditLogService interface {
+	GetAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, *pagination.Meta, error)
+	API(ctx context.Context) entity.HealthCheck
+}
+
+// NewAuditLogService returns a AuditLogService. ready runs the readiness checks whose result API reports.
+func NewAuditLogService(auditLogRepository repository.AuditLogRepository, ready func(ctx context.Context) error) AuditLogService {
+	return &auditLogService{auditLogRepository: auditLogRepository, ready: ready}
+}
+
+// API reports the Audit Logs API healthy when the readiness checks of its dependencies pass.
+func (s *auditLogService) API(ctx context.Context) entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Audit Logs API",
+		IsHealthy: s.ready(ctx) == nil,
+	}
+}
+
+func (s *auditLogService) GetAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, *pagination.Meta, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, nil, apperror.Validation("from date must not be after to date")
+	}
+
+	logs, total, err := s.auditLogRepository.GetAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if logs == nil {
+		logs = []entity.ResponseAuditLog{}
+	}
+
+	return logs, pagination.NewMeta(filter.Pagination, total), nil
+}
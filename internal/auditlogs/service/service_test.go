@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/auditlogs/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type mockAuditLogRepository struct {
+	getAuditLogsFn func(filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, int, error)
+
+	calls int
+}
+
+func (m *mockAuditLogRepository) GetAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, int, error) {
+	m.calls++
+	if m.getAuditLogsFn == nil {
+		return nil, 0, nil
+	}
+	return m.getAuditLogsFn(filter)
+}
+
+var _ repository.AuditLogRepository = (*mockAuditLogRepository)(nil)
+
+func TestNewAuditLogService(t *testing.T) {
+	repo := &mockAuditLogRepository{}
+	svc := NewAuditLogService(repo, func(context.Context) error { return nil })
+	as, ok := svc.(*auditLogService)
+	if !ok {
+		t.Fatalf("expected *auditLogService, got %T", svc)
+	}
+	if as.auditLogRepository != repo || as.ready == nil {
+		t.Fatal("repository not set")
+	}
+}
+
+func TestAuditLogService_API(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &auditLogService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Audit Logs API" || got.IsHealthy != tt.want {
+				t.Fatalf("unexpected healthcheck: %+v", got)
+			}
+		})
+	}
+}
+
+func TestAuditLogService_GetAuditLogs(t *testing.T) {
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	page := pagination.Params{Page: 1, PageSize: 20}
+	logs := []entity.ResponseAuditLog{{ID: 1, Action: "create", EntityType: "product", EntityID: 5}}
+
+	tests := []struct {
+		name      string
+		filter    entity.AuditLogFilter
+		logs      []entity.ResponseAuditLog
+		total     int
+		repoErr   error
+		want      []entity.ResponseAuditLog
+		wantTotal int
+		wantErr   string
+		wantCalls int
+	}{
+		{name: "ok", filter: entity.AuditLogFilter{EntityType: "product", Pagination: page}, logs: logs, total: 1, want: logs, wantTotal: 1, wantCalls: 1},
+		{name: "empty", filter: entity.AuditLogFilter{Pagination: page}, want: []entity.ResponseAuditLog{}, wantCalls: 1},
+		{name: "same-day", filter: entity.AuditLogFilter{From: day, To: day, Pagination: page}, want: []entity.ResponseAuditLog{}, wantCalls: 1},
+		{name: "from-after-to", filter: entity.AuditLogFilter{From: day, To: day.AddDate(0, 0, -1), Pagination: page}, wantErr: "from date must not be after to date"},
+		{name: "repo-err", filter: entity.AuditLogFilter{Pagination: page}, repoErr: errors.New("boom"), wantErr: "boom", wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockAuditLogRepository{
+				getAuditLogsFn: func(filter entity.AuditLogFilter) ([]entity.ResponseAuditLog, int, error) {
+					if !reflect.DeepEqual(filter, tt.filter) {
+						t.Fatalf("filter = %+v, want %+v", filter, tt.filter)
+					}
+					return tt.logs, tt.total, tt.repoErr
+				},
+			}
+			svc := &auditLogService{auditLogRepository: repo}
+			got, meta, err := svc.GetAuditLogs(context.Background(), tt.filter)
+
+			if repo.calls != tt.wantCalls {
+				t.Fatalf("repository calls = %d, want %d", repo.calls, tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected logs: %+v", got)
+			}
+			if meta == nil || meta.TotalItems != tt.wantTotal || meta.Page != 1 {
+				t.Fatalf("unexpected meta: %+v", meta)
+			}
+		})
+	}
+}
@@ -9,6 +9,10 @@ import (
 	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
 )
 
@@ -31,7 +35,7 @@ func NewCategoryHandler(service service.CategoryService) *CategoryHandler {
 // @Router /api/categories/health [get]
 func (h *CategoryHandler) API(w http.ResponseWriter, r *http.Request) {
 	var result response.APIResponse
-	svcHealthCheckResult := h.service.API()
+	svcHealthCheckResult := h.service.API(r.Context())
 	if svcHealthCheckResult.IsHealthy {
 		result.Code = strconv.Itoa(constants.SuccessCode)
 		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
@@ -51,6 +55,7 @@ func (h *CategoryHandler) API(w http.ResponseWriter, r *http.Request) {
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param category body entity.RequestCategory true "Category Data"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
@@ -59,12 +64,12 @@ func (h *CategoryHandler) API(w http.ResponseWriter, r *http.Request) {
 func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var requestCategory entity.RequestCategory
 	if err := response.ParseJSON(r, &requestCategory); err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCategoryRequest, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryRequest, err)
 		return
 	}
 
-	if err := h.service.CreateCategory(&requestCategory); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Category created failed", err)
+	if err := h.service.CreateCategory(r.Context(), audit.ActorFromContext(r.Context()), &requestCategory); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category created failed", err)
 		return
 	}
 
@@ -73,14 +78,18 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 
 // UpdateCategory godoc
 // @Summary Update a category
-// @Description Update a category
+// @Description Update a category. A category updated without parent_id keeps its parent, use PATCH with a null parent_id to make it a top-level one.
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag from GET /api/categories/{id}, the request fails with 412 when the category has changed since"
 // @Param category body entity.RequestCategory true "Category Data"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories/{id} [put]
 func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
@@ -89,17 +98,69 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	idStr := strings.TrimPrefix(r.URL.Path, "/categories/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCategoryID, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, err)
+		return
+	}
+
+	version, err := response.IfMatch(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidIfMatch, err)
 		return
 	}
 
 	if err := response.ParseJSON(r, &requestCategory); err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCategoryRequest, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryRequest, err)
 		return
 	}
 
-	if err := h.service.UpdateCategory(int64(id), &requestCategory); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Category updated failed", err)
+	if err := h.service.UpdateCategory(r.Context(), audit.ActorFromContext(r.Context()), int64(id), version, &requestCategory); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Category updated successfully", nil)
+}
+
+// PatchCategory godoc
+// @Summary Partially update a category
+// @Description Update only the fields sent, as a JSON Merge Patch (RFC 7396). A null description clears it and a null parent_id makes the category a top-level one.
+// @Tags categories
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param If-Match header string false "ETag from GET /api/categories/{id}, the request fails with 412 when the category has changed since"
+// @Param category body entity.RequestCategory true "Category fields to change"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id} [patch]
+func (h *CategoryHandler) PatchCategory(w http.ResponseWriter, r *http.Request) {
+	var patchCategory entity.PatchCategory
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/categories/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, err)
+		return
+	}
+
+	version, err := response.IfMatch(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidIfMatch, err)
+		return
+	}
+
+	if err := response.ParseJSON(r, &patchCategory); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryRequest, err)
+		return
+	}
+
+	if err := h.service.PatchCategory(r.Context(), audit.ActorFromContext(r.Context()), int64(id), version, &patchCategory); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category updated failed", err)
 		return
 	}
 
@@ -108,74 +169,217 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 
 // DeleteCategory godoc
 // @Summary Delete a category
-// @Description Delete a category
+// @Description Delete a category. With strategy=reject, the default, a category that still has products is not deleted; with strategy=reassign its products are moved to target_category_id first, in the same transaction.
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Category ID"
+// @Param strategy query string false "What to do with the products of the category: reject (default) or reassign"
+// @Param target_category_id query int false "Category the products are moved to, required with strategy=reassign"
+// @Param If-Match header string false "ETag from GET /api/categories/{id}, the request fails with 412 when the category has changed since"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories/{id} [delete]
 func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/categories/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCategoryID, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, err)
 		return
 	}
 
-	if err := h.service.DeleteCategory(int64(id)); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Category delete failed", err)
+	version, err := response.IfMatch(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidIfMatch, err)
+		return
+	}
+
+	options := entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject}
+	query := r.URL.Query()
+	if strategy := query.Get("strategy"); strategy != "" {
+		options.Strategy = strategy
+	}
+
+	if targetID := query.Get("target_category_id"); targetID != "" {
+		options.TargetCategoryID, err = strconv.ParseInt(targetID, 10, 64)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, fmt.Errorf("target_category_id must be a number"))
+			return
+		}
+	}
+
+	if err := h.service.DeleteCategory(r.Context(), audit.ActorFromContext(r.Context()), int64(id), version, options); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category delete failed", err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, constants.SuccessCode, "Category deleted successfully", nil)
 }
 
+// RestoreCategory godoc
+// @Summary Restore a category
+// @Description Restore a deleted category
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id}/restore [post]
+func (h *CategoryHandler) RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/categories/"), "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, err)
+		return
+	}
+
+	if err := h.service.RestoreCategory(r.Context(), audit.ActorFromContext(r.Context()), int64(id)); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category restore failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Category restored successfully", nil)
+}
+
 // GetCategoryByID godoc
 // @Summary Get a category by ID
 // @Description Get a category by ID
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Category ID"
+// @Param with_stats query bool false "Add product_count and total_stock_value of the products of the category and its subcategories"
 // @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "Version of the category, send it in If-Match to update or delete it"
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories/{id} [get]
 func (h *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/categories/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidCategoryID, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, err)
 		return
 	}
 
-	category, err := h.service.GetCategoryByID(int64(id))
+	withStats, err := parseWithStats(r)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Category retrieved failed", err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryFilter, err)
 		return
 	}
 
+	category, err := h.service.GetCategoryByID(r.Context(), int64(id), withStats)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category retrieved failed", err)
+		return
+	}
+
+	response.SetETag(w, category.Version)
 	response.Success(w, http.StatusOK, constants.SuccessCode, "Category retrieved successfully", category)
 }
 
+// GetCategoryTree godoc
+// @Summary Get the category tree
+// @Description Get the top-level categories with their subcategories nested in children
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/tree [get]
+func (h *CategoryHandler) GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.service.GetCategoryTree(r.Context())
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Category tree retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Category tree retrieved successfully", tree)
+}
+
 // GetAllCategories godoc
 // @Summary Get all categories
-// @Description Get all categories
+// @Description Get a page of categories, optionally filtered by name and sorted
 // @Tags categories
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (id, name, created_at, updated_at)"
+// @Param name query string false "Case-insensitive name substring"
+// @Param include_deleted query bool false "Also list deleted categories (admin only)"
+// @Param with_stats query bool false "Add product_count and total_stock_value of the products of each category and its subcategories"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/categories [get]
 func (h *CategoryHandler) GetAllCategories(w http.ResponseWriter, r *http.Request) {
-	categories, err := h.service.GetAllCategories()
+	var (
+		filter entity.CategoryFilter
+		err    error
+	)
+
+	query := r.URL.Query()
+	filter.Name = strings.TrimSpace(query.Get("name"))
+	filter.Pagination, err = pagination.Parse(query, entity.CategorySortFields...)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryFilter, err)
+		return
+	}
+
+	if includeDeleted := query.Get("include_deleted"); includeDeleted != "" {
+		filter.IncludeDeleted, err = strconv.ParseBool(includeDeleted)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryFilter, fmt.Errorf("include_deleted must be a boolean"))
+			return
+		}
+	}
+
+	filter.WithStats, err = parseWithStats(r)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Categories retrieved failed", err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryFilter, err)
+		return
+	}
+
+	if filter.IncludeDeleted && !auth.IsAdmin(r.Context()) {
+		response.Error(w, http.StatusForbidden, constants.ForbiddenErrorCode, constants.ErrForbidden, fmt.Errorf("only admins can list deleted categories"))
 		return
 	}
 
-	response.Success(w, http.StatusOK, constants.SuccessCode, "Categories retrieved successfully", categories)
+	categories, meta, err := h.service.GetAllCategories(r.Context(), filter)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Categories retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Categories retrieved successfully", categories, meta)
+}
+
+// parseWithStats reads the with_stats query parameter, false when it is missing.
+func parseWithStats(r *http.Request) (bool, error) {
+	withStats := r.URL.Query().Get("with_stats")
+	if withStats == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(withStats)
+	if err != nil {
+		return false, fmt.Errorf("with_stats must be a boolean")
+	}
+
+	return value, nil
 }
@@ -1,42 +1,62 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
 )
 
 type mockCategoryService struct {
 	createFn  func(*entity.RequestCategory) error
 	updateFn  func(int64, *entity.RequestCategory) error
+	patchFn   func(int64, *entity.PatchCategory) error
 	deleteFn  func(int64) error
+	restoreFn func(int64) error
 	getByIDFn func(int64) (*entity.ResponseCategory, error)
-	getAllFn  func() ([]entity.ResponseCategory, error)
+	getAllFn  func(entity.CategoryFilter) ([]entity.ResponseCategory, *pagination.Meta, error)
+	getTreeFn func() ([]entity.ResponseCategoryTree, error)
 	apiFn     func() entity.HealthCheck
 
 	createCalls  int
 	updateCalls  int
+	patchCalls   int
 	deleteCalls  int
+	restoreCalls int
 	getByIDCalls int
 	getAllCalls  int
 	apiCalls     int
 
+	actor     audit.Actor
 	createReq *entity.RequestCategory
 	updateReq *entity.RequestCategory
 	updateID  int64
+	patchReq  *entity.PatchCategory
+	patchID   int64
 	deleteID  int64
+	deleteOpt entity.DeleteCategoryOptions
+	restoreID int64
 	getByIDID int64
+	withStats bool
+	version   int64
 }
 
-func (m *mockCategoryService) CreateCategory(requestCategory *entity.RequestCategory) error {
+func (m *mockCategoryService) CreateCategory(ctx context.Context, actor audit.Actor, requestCategory *entity.RequestCategory) error {
 	m.createCalls++
+	m.actor = actor
 	m.createReq = requestCategory
 	if m.createFn != nil {
 		return m.createFn(requestCategory)
@@ -44,8 +64,10 @@ func (m *mockCategoryService) CreateCategory(requestCategory *entity.RequestCate
 	return nil
 }
 
-func (m *mockCategoryService) UpdateCategory(id int64, requestCategory *entity.RequestCategory) error {
+func (m *mockCategoryService) UpdateCategory(ctx context.Context, actor audit.Actor, id int64, version int64, requestCategory *entity.RequestCategory) error {
 	m.updateCalls++
+	m.actor = actor
+	m.version = version
 	m.updateID = id
 	m.updateReq = requestCategory
 	if m.updateFn != nil {
@@ -54,33 +76,66 @@ func (m *mockCategoryService) UpdateCategory(id int64, requestCategory *entity.R
 	return nil
 }
 
-func (m *mockCategoryService) DeleteCategory(id int64) error {
+func (m *mockCategoryService) PatchCategory(ctx context.Context, actor audit.Actor, id int64, version int64, patch *entity.PatchCategory) error {
+	m.patchCalls++
+	m.actor = actor
+	m.version = version
+	m.patchID = id
+	m.patchReq = patch
+	if m.patchFn != nil {
+		return m.patchFn(id, patch)
+	}
+	return nil
+}
+
+func (m *mockCategoryService) DeleteCategory(ctx context.Context, actor audit.Actor, id int64, version int64, options entity.DeleteCategoryOptions) error {
 	m.deleteCalls++
+	m.actor = actor
+	m.version = version
 	m.deleteID = id
+	m.deleteOpt = options
 	if m.deleteFn != nil {
 		return m.deleteFn(id)
 	}
 	return nil
 }
 
-func (m *mockCategoryService) GetCategoryByID(id int64) (*entity.ResponseCategory, error) {
+func (m *mockCategoryService) RestoreCategory(ctx context.Context, actor audit.Actor, id int64) error {
+	m.restoreCalls++
+	m.actor = actor
+	m.restoreID = id
+	if m.restoreFn != nil {
+		return m.restoreFn(id)
+	}
+	return nil
+}
+
+func (m *mockCategoryService) GetCategoryByID(ctx context.Context, id int64, withStats bool) (*entity.ResponseCategory, error) {
 	m.getByIDCalls++
 	m.getByIDID = id
+	m.withStats = withStats
 	if m.getByIDFn != nil {
 		return m.getByIDFn(id)
 	}
 	return nil, nil
 }
 
-func (m *mockCategoryService) GetAllCategories() ([]entity.ResponseCategory, error) {
+func (m *mockCategoryService) GetAllCategories(ctx context.Context, filter entity.CategoryFilter) ([]entity.ResponseCategory, *pagination.Meta, error) {
 	m.getAllCalls++
 	if m.getAllFn != nil {
-		return m.getAllFn()
+		return m.getAllFn(filter)
+	}
+	return nil, nil, nil
+}
+
+func (m *mockCategoryService) GetCategoryTree(ctx context.Context) ([]entity.ResponseCategoryTree, error) {
+	if m.getTreeFn != nil {
+		return m.getTreeFn()
 	}
 	return nil, nil
 }
 
-func (m *mockCategoryService) API() entity.HealthCheck {
+func (m *mockCategoryService) API(ctx context.Context) entity.HealthCheck {
 	m.apiCalls++
 	if m.apiFn != nil {
 		return m.apiFn()
@@ -97,6 +152,14 @@ func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
 	return body
 }
 
+func ptrInt(value int) *int {
+	return &value
+}
+
+func ptrInt64(value int64) *int64 {
+	return &value
+}
+
 func TestNewCategoryHandler(t *testing.T) {
 	cases := []struct {
 		name string
@@ -187,7 +250,7 @@ func TestCategoryHandlerCreateCategory(t *testing.T) {
 			name:       "no-body",
 			bodyNil:    true,
 			wantStatus: http.StatusBadRequest,
-			wantCode:   "2000",
+			wantCode:   "2001",
 			wantMsg:    constants.ErrInvalidCategoryRequest,
 			wantCalls:  0,
 		},
@@ -195,7 +258,7 @@ func TestCategoryHandlerCreateCategory(t *testing.T) {
 			name:       "bad-json",
 			body:       strings.NewReader("{"),
 			wantStatus: http.StatusBadRequest,
-			wantCode:   "2000",
+			wantCode:   "2001",
 			wantMsg:    constants.ErrInvalidCategoryRequest,
 			wantCalls:  0,
 		},
@@ -234,6 +297,7 @@ func TestCategoryHandlerCreateCategory(t *testing.T) {
 			} else {
 				req = httptest.NewRequest(http.MethodPost, "/api/categories", tc.body)
 			}
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{UserID: 1, Username: "admin", Role: auth.RoleAdmin}))
 
 			h.CreateCategory(rec, req)
 
@@ -255,6 +319,9 @@ func TestCategoryHandlerCreateCategory(t *testing.T) {
 				if svc.createReq.Name != "A" || svc.createReq.Description != "B" {
 					t.Fatalf("unexpected create request: %#v", svc.createReq)
 				}
+				if svc.actor != (audit.Actor{ID: 1, Username: "admin"}) {
+					t.Fatalf("unexpected actor: %+v", svc.actor)
+				}
 			}
 		})
 	}
@@ -265,18 +332,20 @@ func TestCategoryHandlerUpdateCategory(t *testing.T) {
 		name       string
 		path       string
 		body       *strings.Reader
+		ifMatch    string
 		updateErr  error
 		wantStatus int
 		wantCode   string
 		wantMsg    string
 		wantCalls  int
+		wantVer    int64
 	}{
 		{
 			name:       "bad-id",
 			path:       "/categories/abc",
 			body:       strings.NewReader(`{"name":"A"}`),
 			wantStatus: http.StatusBadRequest,
-			wantCode:   "2000",
+			wantCode:   "2001",
 			wantMsg:    constants.ErrInvalidCategoryID,
 			wantCalls:  0,
 		},
@@ -285,10 +354,32 @@ func TestCategoryHandlerUpdateCategory(t *testing.T) {
 			path:       "/categories/1",
 			body:       strings.NewReader("{"),
 			wantStatus: http.StatusBadRequest,
-			wantCode:   "2000",
+			wantCode:   "2001",
 			wantMsg:    constants.ErrInvalidCategoryRequest,
 			wantCalls:  0,
 		},
+		{
+			name:       "bad-if-match",
+			path:       "/categories/1",
+			body:       strings.NewReader(`{"name":"A"}`),
+			ifMatch:    "abc",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidIfMatch,
+			wantCalls:  0,
+		},
+		{
+			name:       "stale-version",
+			path:       "/categories/1",
+			body:       strings.NewReader(`{"name":"A","description":"B"}`),
+			ifMatch:    `"3"`,
+			updateErr:  apperror.PreconditionFailed("category has been changed since version 3"),
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "2006",
+			wantMsg:    "Category updated failed: category has been changed since version 3",
+			wantCalls:  1,
+			wantVer:    3,
+		},
 		{
 			name:       "service-error",
 			path:       "/categories/1",
@@ -320,6 +411,9 @@ func TestCategoryHandlerUpdateCategory(t *testing.T) {
 			h := NewCategoryHandler(svc)
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodPut, tc.path, tc.body)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			h.UpdateCategory(rec, req)
 
@@ -337,6 +431,9 @@ func TestCategoryHandlerUpdateCategory(t *testing.T) {
 			if svc.updateCalls != tc.wantCalls {
 				t.Fatalf("expected update calls %d, got %d", tc.wantCalls, svc.updateCalls)
 			}
+			if svc.version != tc.wantVer {
+				t.Fatalf("expected version %d, got %d", tc.wantVer, svc.version)
+			}
 			if tc.name == "ok" && svc.updateReq != nil {
 				if svc.updateID != 1 || svc.updateReq.Name != "A" || svc.updateReq.Description != "B" {
 					t.Fatalf("unexpected update request: id=%d req=%#v", svc.updateID, svc.updateReq)
@@ -346,24 +443,188 @@ func TestCategoryHandlerUpdateCategory(t *testing.T) {
 	}
 }
 
+func TestCategoryHandlerPatchCategory(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		body       string
+		ifMatch    string
+		patchErr   error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantCalls  int
+		wantPatch  entity.PatchCategory
+		wantVer    int64
+	}{
+		{
+			name:       "bad-id",
+			path:       "/categories/abc",
+			body:       `{"name":"A"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryID,
+		},
+		{
+			name:       "bad-json",
+			path:       "/categories/1",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryRequest,
+		},
+		{
+			name:       "null-name",
+			path:       "/categories/1",
+			body:       `{"name":null}`,
+			patchErr:   apperror.Invalid(apperror.FieldError{Field: "name", Message: "name must not be null"}),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    "Category updated failed: name must not be null",
+			wantCalls:  1,
+			wantPatch:  entity.PatchCategory{Name: patch.Field[string]{Set: true, Null: true}},
+		},
+		{
+			name:       "stale-version",
+			path:       "/categories/1",
+			body:       `{"description":"B"}`,
+			ifMatch:    `"3"`,
+			patchErr:   apperror.PreconditionFailed("category has been changed since version 3"),
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "2006",
+			wantMsg:    "Category updated failed: category has been changed since version 3",
+			wantCalls:  1,
+			wantPatch:  entity.PatchCategory{Description: patch.Of("B")},
+			wantVer:    3,
+		},
+		{
+			name:       "description-only",
+			path:       "/categories/1",
+			body:       `{"description":"B"}`,
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Category updated successfully",
+			wantCalls:  1,
+			wantPatch:  entity.PatchCategory{Description: patch.Of("B")},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &mockCategoryService{
+				patchFn: func(_ int64, _ *entity.PatchCategory) error {
+					return tc.patchErr
+				},
+			}
+			h := NewCategoryHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, tc.path, strings.NewReader(tc.body))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			h.PatchCategory(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d", tc.wantStatus, rec.Code)
+			}
+			body := decodeBody(t, rec)
+			if body["code"] != tc.wantCode {
+				t.Fatalf("expected code %q, got %v", tc.wantCode, body["code"])
+			}
+			msg, _ := body["message"].(string)
+			if !strings.Contains(msg, tc.wantMsg) {
+				t.Fatalf("expected message to contain %q, got %q", tc.wantMsg, msg)
+			}
+			if svc.patchCalls != tc.wantCalls {
+				t.Fatalf("expected patch calls %d, got %d", tc.wantCalls, svc.patchCalls)
+			}
+			if tc.wantCalls > 0 && (svc.patchID != 1 || svc.version != tc.wantVer || *svc.patchReq != tc.wantPatch) {
+				t.Fatalf("unexpected patch: id=%d version=%d patch=%#v", svc.patchID, svc.version, svc.patchReq)
+			}
+		})
+	}
+}
+
 func TestCategoryHandlerDeleteCategory(t *testing.T) {
 	cases := []struct {
 		name       string
 		path       string
+		ifMatch    string
 		deleteErr  error
 		wantStatus int
 		wantCode   string
 		wantMsg    string
 		wantCalls  int
+		wantVer    int64
+		wantOpt    entity.DeleteCategoryOptions
 	}{
 		{
 			name:       "bad-id",
 			path:       "/categories/abc",
 			wantStatus: http.StatusBadRequest,
-			wantCode:   "2000",
+			wantCode:   "2001",
 			wantMsg:    constants.ErrInvalidCategoryID,
 			wantCalls:  0,
 		},
+		{
+			name:       "bad-if-match",
+			path:       "/categories/1",
+			ifMatch:    `W/"3"`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidIfMatch,
+			wantCalls:  0,
+		},
+		{
+			name:       "stale-version",
+			path:       "/categories/1",
+			ifMatch:    `"3"`,
+			deleteErr:  apperror.PreconditionFailed("category has been changed since version 3"),
+			wantStatus: http.StatusPreconditionFailed,
+			wantCode:   "2006",
+			wantMsg:    "Category delete failed: category has been changed since version 3",
+			wantCalls:  1,
+			wantVer:    3,
+			wantOpt:    entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject},
+		},
+		{
+			name:       "bad-target",
+			path:       "/categories/1?strategy=reassign&target_category_id=abc",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryID,
+			wantCalls:  0,
+		},
+		{
+			name:       "has-products",
+			path:       "/categories/1",
+			deleteErr:  apperror.Conflict("category still has 2 products, reassign them to another category first"),
+			wantStatus: http.StatusConflict,
+			wantCode:   "2003",
+			wantMsg:    "Category delete failed: category still has 2 products",
+			wantCalls:  1,
+			wantOpt:    entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject},
+		},
+		{
+			name:       "invalid-options",
+			path:       "/categories/1?strategy=cascade",
+			deleteErr:  apperror.Invalid(apperror.FieldError{Field: "strategy", Message: "strategy must be reject or reassign"}),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    "strategy must be reject or reassign",
+			wantCalls:  1,
+			wantOpt:    entity.DeleteCategoryOptions{Strategy: "cascade"},
+		},
+		{
+			name:       "reassign",
+			path:       "/categories/1?strategy=reassign&target_category_id=3",
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Category deleted successfully",
+			wantCalls:  1,
+			wantOpt:    entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReassign, TargetCategoryID: 3},
+		},
 		{
 			name:       "service-error",
 			path:       "/categories/1",
@@ -372,6 +633,7 @@ func TestCategoryHandlerDeleteCategory(t *testing.T) {
 			wantCode:   "2000",
 			wantMsg:    "Category delete failed",
 			wantCalls:  1,
+			wantOpt:    entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject},
 		},
 		{
 			name:       "ok",
@@ -380,6 +642,7 @@ func TestCategoryHandlerDeleteCategory(t *testing.T) {
 			wantCode:   "1000",
 			wantMsg:    "Category deleted successfully",
 			wantCalls:  1,
+			wantOpt:    entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject},
 		},
 	}
 
@@ -393,6 +656,9 @@ func TestCategoryHandlerDeleteCategory(t *testing.T) {
 			h := NewCategoryHandler(svc)
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodDelete, tc.path, nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			h.DeleteCategory(rec, req)
 
@@ -410,9 +676,85 @@ func TestCategoryHandlerDeleteCategory(t *testing.T) {
 			if svc.deleteCalls != tc.wantCalls {
 				t.Fatalf("expected delete calls %d, got %d", tc.wantCalls, svc.deleteCalls)
 			}
+			if svc.version != tc.wantVer {
+				t.Fatalf("expected version %d, got %d", tc.wantVer, svc.version)
+			}
 			if tc.name == "ok" && svc.deleteID != 1 {
 				t.Fatalf("unexpected delete id: %d", svc.deleteID)
 			}
+			if svc.deleteOpt != tc.wantOpt {
+				t.Fatalf("expected delete options %+v, got %+v", tc.wantOpt, svc.deleteOpt)
+			}
+		})
+	}
+}
+
+func TestCategoryHandlerRestoreCategory(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		restoreErr error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantCalls  int
+	}{
+		{
+			name:       "bad-id",
+			path:       "/categories/abc/restore",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryID,
+		},
+		{
+			name:       "not-deleted",
+			path:       "/categories/1/restore",
+			restoreErr: apperror.NotFound("deleted category not found"),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "2002",
+			wantMsg:    "Category restore failed: deleted category not found",
+			wantCalls:  1,
+		},
+		{
+			name:       "ok",
+			path:       "/categories/1/restore",
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Category restored successfully",
+			wantCalls:  1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &mockCategoryService{
+				restoreFn: func(_ int64) error {
+					return tc.restoreErr
+				},
+			}
+			h := NewCategoryHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+
+			h.RestoreCategory(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d", tc.wantStatus, rec.Code)
+			}
+			body := decodeBody(t, rec)
+			if body["code"] != tc.wantCode {
+				t.Fatalf("expected code %q, got %v", tc.wantCode, body["code"])
+			}
+			msg, _ := body["message"].(string)
+			if !strings.Contains(msg, tc.wantMsg) {
+				t.Fatalf("expected message to contain %q, got %q", tc.wantMsg, msg)
+			}
+			if svc.restoreCalls != tc.wantCalls {
+				t.Fatalf("expected restore calls %d, got %d", tc.wantCalls, svc.restoreCalls)
+			}
+			if tc.wantCalls > 0 && svc.restoreID != 1 {
+				t.Fatalf("unexpected restore id: %d", svc.restoreID)
+			}
 		})
 	}
 }
@@ -427,12 +769,13 @@ func TestCategoryHandlerGetCategoryByID(t *testing.T) {
 		wantCode   string
 		wantMsg    string
 		wantCalls  int
+		wantStats  bool
 	}{
 		{
 			name:       "bad-id",
 			path:       "/categories/abc",
 			wantStatus: http.StatusBadRequest,
-			wantCode:   "2000",
+			wantCode:   "2001",
 			wantMsg:    constants.ErrInvalidCategoryID,
 			wantCalls:  0,
 		},
@@ -445,6 +788,24 @@ func TestCategoryHandlerGetCategoryByID(t *testing.T) {
 			wantMsg:    "Category retrieved failed",
 			wantCalls:  1,
 		},
+		{
+			name:       "bad-with-stats",
+			path:       "/categories/1?with_stats=maybe",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryFilter,
+			wantCalls:  0,
+		},
+		{
+			name:       "with-stats",
+			path:       "/categories/1?with_stats=true",
+			result:     &entity.ResponseCategory{ID: 1, Name: "A", ProductCount: ptrInt(2), TotalStockValue: ptrInt64(15000)},
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Category retrieved successfully",
+			wantCalls:  1,
+			wantStats:  true,
+		},
 		{
 			name: "ok",
 			path: "/categories/1",
@@ -453,6 +814,7 @@ func TestCategoryHandlerGetCategoryByID(t *testing.T) {
 				Name:        "A",
 				Description: "B",
 				CreatedAt:   time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+				Version:     4,
 			},
 			wantStatus: http.StatusOK,
 			wantCode:   "1000",
@@ -488,11 +850,93 @@ func TestCategoryHandlerGetCategoryByID(t *testing.T) {
 			if svc.getByIDCalls != tc.wantCalls {
 				t.Fatalf("expected getByID calls %d, got %d", tc.wantCalls, svc.getByIDCalls)
 			}
+			if svc.withStats != tc.wantStats {
+				t.Fatalf("expected with stats %v, got %v", tc.wantStats, svc.withStats)
+			}
+			if tc.name == "with-stats" {
+				data, _ := body["data"].(map[string]any)
+				if data["product_count"] != float64(2) || data["total_stock_value"] != float64(15000) {
+					t.Fatalf("unexpected data: %v", data)
+				}
+			}
 			if tc.name == "ok" {
 				data, _ := body["data"].(map[string]any)
 				if data["id"] != float64(1) || data["name"] != "A" || data["description"] != "B" {
 					t.Fatalf("unexpected data: %v", data)
 				}
+				if _, ok := data["product_count"]; ok {
+					t.Fatalf("expected no stats without with_stats, got %v", data)
+				}
+				if _, ok := data["version"]; ok {
+					t.Fatalf("expected version only in the ETag header, got %v", data)
+				}
+				if got := rec.Header().Get("ETag"); got != `"4"` {
+					t.Fatalf("expected ETag %q, got %q", `"4"`, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCategoryHandlerGetCategoryTree(t *testing.T) {
+	cases := []struct {
+		name       string
+		result     []entity.ResponseCategoryTree
+		getErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{
+			name:       "service-error",
+			getErr:     errors.New("boom"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "2000",
+			wantMsg:    "Category tree retrieved failed",
+		},
+		{
+			name: "ok",
+			result: []entity.ResponseCategoryTree{
+				{ID: 1, Name: "Minuman", Children: []entity.ResponseCategoryTree{{ID: 2, Name: "Susu", Children: []entity.ResponseCategoryTree{}}}},
+			},
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Category tree retrieved successfully",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &mockCategoryService{
+				getTreeFn: func() ([]entity.ResponseCategoryTree, error) {
+					return tc.result, tc.getErr
+				},
+			}
+			h := NewCategoryHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/categories/tree", nil)
+
+			h.GetCategoryTree(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d", tc.wantStatus, rec.Code)
+			}
+			body := decodeBody(t, rec)
+			if body["code"] != tc.wantCode {
+				t.Fatalf("expected code %q, got %v", tc.wantCode, body["code"])
+			}
+			msg, _ := body["message"].(string)
+			if !strings.Contains(msg, tc.wantMsg) {
+				t.Fatalf("expected message to contain %q, got %q", tc.wantMsg, msg)
+			}
+			if tc.name == "ok" {
+				data, _ := body["data"].([]any)
+				root, _ := data[0].(map[string]any)
+				children, _ := root["children"].([]any)
+				child, _ := children[0].(map[string]any)
+				if len(data) != 1 || root["name"] != "Minuman" || len(children) != 1 || child["name"] != "Susu" {
+					t.Fatalf("unexpected data: %v", body["data"])
+				}
 			}
 		})
 	}
@@ -501,8 +945,11 @@ func TestCategoryHandlerGetCategoryByID(t *testing.T) {
 func TestCategoryHandlerGetAllCategories(t *testing.T) {
 	cases := []struct {
 		name       string
+		query      string
+		role       string
 		result     []entity.ResponseCategory
 		getErr     error
+		wantFilter *entity.CategoryFilter
 		wantStatus int
 		wantCode   string
 		wantMsg    string
@@ -522,23 +969,98 @@ func TestCategoryHandlerGetAllCategories(t *testing.T) {
 				{ID: 1, Name: "A", Description: "B"},
 				{ID: 2, Name: "C", Description: "D"},
 			},
+			wantFilter: &entity.CategoryFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}},
 			wantStatus: http.StatusOK,
 			wantCode:   "1000",
 			wantMsg:    "Categories retrieved successfully",
 			wantCalls:  1,
 		},
+		{
+			name:       "filtered",
+			query:      "?name=+sus+&page=2&page_size=1&sort=-name",
+			wantFilter: &entity.CategoryFilter{Name: "sus", Pagination: pagination.Params{Page: 2, PageSize: 1, Sort: []pagination.Sort{{Field: "name", Desc: true}}}},
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Categories retrieved successfully",
+			wantCalls:  1,
+		},
+		{
+			name:       "bad-page-size",
+			query:      "?page_size=1000",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryFilter,
+		},
+		{
+			name:       "bad-sort",
+			query:      "?sort=price",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryFilter,
+		},
+		{
+			name:       "include-deleted",
+			query:      "?include_deleted=true",
+			role:       auth.RoleAdmin,
+			wantFilter: &entity.CategoryFilter{IncludeDeleted: true, Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Categories retrieved successfully",
+			wantCalls:  1,
+		},
+		{
+			name:       "with-stats",
+			query:      "?with_stats=1",
+			wantFilter: &entity.CategoryFilter{WithStats: true, Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			wantStatus: http.StatusOK,
+			wantCode:   "1000",
+			wantMsg:    "Categories retrieved successfully",
+			wantCalls:  1,
+		},
+		{
+			name:       "bad-with-stats",
+			query:      "?with_stats=maybe",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryFilter,
+		},
+		{
+			name:       "include-deleted-cashier",
+			query:      "?include_deleted=true",
+			role:       auth.RoleCashier,
+			wantStatus: http.StatusForbidden,
+			wantCode:   "2005",
+			wantMsg:    constants.ErrForbidden,
+		},
+		{
+			name:       "bad-include-deleted",
+			query:      "?include_deleted=maybe",
+			role:       auth.RoleAdmin,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "2001",
+			wantMsg:    constants.ErrInvalidCategoryFilter,
+		},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			svc := &mockCategoryService{
-				getAllFn: func() ([]entity.ResponseCategory, error) {
-					return tc.result, tc.getErr
+				getAllFn: func(filter entity.CategoryFilter) ([]entity.ResponseCategory, *pagination.Meta, error) {
+					if tc.wantFilter != nil && !reflect.DeepEqual(filter, *tc.wantFilter) {
+						t.Fatalf("expected filter %+v, got %+v", *tc.wantFilter, filter)
+					}
+					if tc.getErr != nil {
+						return nil, nil, tc.getErr
+					}
+					return tc.result, pagination.NewMeta(filter.Pagination, 40), nil
 				},
 			}
 			h := NewCategoryHandler(svc)
 			rec := httptest.NewRecorder()
-			req := httptest.NewRequest(http.MethodGet, "/categories", nil)
+			req := httptest.NewRequest(http.MethodGet, "/categories"+tc.query, nil)
+			if tc.role != "" {
+				req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{Role: tc.role}))
+			}
 
 			h.GetAllCategories(rec, req)
 
@@ -556,6 +1078,12 @@ func TestCategoryHandlerGetAllCategories(t *testing.T) {
 			if svc.getAllCalls != tc.wantCalls {
 				t.Fatalf("expected getAll calls %d, got %d", tc.wantCalls, svc.getAllCalls)
 			}
+			if tc.wantStatus == http.StatusOK {
+				meta, _ := body["meta"].(map[string]any)
+				if meta["total_items"] != float64(40) {
+					t.Fatalf("unexpected meta: %v", body["meta"])
+				}
+			}
 			if tc.name == "ok" {
 				data, _ := body["data"].([]any)
 				if len(data) != 2 {
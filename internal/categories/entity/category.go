@@ -1,29 +1,140 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/validation"
+)
 
 type Category struct {
 	ID          int64
 	Name        string
 	Description string
+	ParentID    *int64
 	CreatedAt   string
 	UpdatedAt   string
+	DeletedAt   *string
+	Version     int64
 }
 
+// RequestCategory is a category as sent by clients. A nil ParentID makes a new category a top-level one and keeps the
+// parent of an updated category.
 type RequestCategory struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	ParentID    *int64 `json:"parent_id,omitempty"`
+}
+
+// maxNameLength is the length of the name column.
+const maxNameLength = 255
+
+// Validate checks that the category has a name that fits its column and that a parent, if any, is a valid ID.
+// Whether the parent exists is checked by the service.
+func (r *RequestCategory) Validate() error {
+	v := validation.New()
+	v.Required("name", r.Name)
+	v.MaxLength("name", r.Name, maxNameLength)
+	v.Check(r.ParentID == nil || *r.ParentID > 0, "parent_id", "parent_id must be a positive integer")
+	return v.Err()
+}
+
+// PatchCategory is a JSON Merge Patch of a category: only the members it contains are changed. A null description
+// clears the description and a null parent_id makes the category a top-level one, the name cannot be removed.
+type PatchCategory struct {
+	Name        patch.Field[string] `json:"name"`
+	Description patch.Field[string] `json:"description"`
+	ParentID    patch.Field[*int64] `json:"parent_id"`
+}
+
+// Validate rejects a null name. The patched values are checked on the merged category, see Apply.
+func (p *PatchCategory) Validate() error {
+	v := validation.New()
+	v.Check(!p.Name.Null, "name", "name must not be null")
+	return v.Err()
+}
+
+// Apply merges the patch into category.
+func (p *PatchCategory) Apply(category *RequestCategory) {
+	p.Name.Apply(&category.Name)
+	p.Description.Apply(&category.Description)
+	p.ParentID.Apply(&category.ParentID)
+}
+
+// Strategies for the products of a category being deleted.
+const (
+	// DeleteStrategyReject refuses to delete a category that still has products.
+	DeleteStrategyReject = "reject"
+	// DeleteStrategyReassign moves the products of the category to DeleteCategoryOptions.TargetCategoryID first.
+	DeleteStrategyReassign = "reassign"
+)
+
+// DeleteCategoryOptions tells what happens to the products of a category being deleted. Deleted products are left in
+// the category.
+type DeleteCategoryOptions struct {
+	Strategy         string
+	TargetCategoryID int64
+}
+
+// Validate checks the strategy and that a target other than the deleted category id is given to reassign products,
+// and only then.
+func (o *DeleteCategoryOptions) Validate(id int64) error {
+	v := validation.New()
+	v.Check(o.Strategy == DeleteStrategyReject || o.Strategy == DeleteStrategyReassign, "strategy", "strategy must be %s or %s", DeleteStrategyReject, DeleteStrategyReassign)
+	if o.Strategy == DeleteStrategyReassign {
+		v.Check(o.TargetCategoryID > 0, "target_category_id", "target_category_id is required to reassign products")
+		v.Check(o.TargetCategoryID != id, "target_category_id", "target_category_id must be another category")
+	} else {
+		v.Check(o.TargetCategoryID == 0, "target_category_id", "target_category_id is only used to reassign products")
+	}
+	return v.Err()
 }
 
 type ResponseCategory struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at", omitempty`
-	UpdatedAt   time.Time `json:"updated_at", omitempty`
+	ParentID    *int64    `json:"parent_id"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+	// DeletedAt is set for a deleted category, which is only listed with CategoryFilter.IncludeDeleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// ProductCount and TotalStockValue, the sum of price times stock of the products directly in the category, are
+	// only set when the stats are asked for.
+	ProductCount    *int   `json:"product_count,omitempty"`
+	TotalStockValue *int64 `json:"total_stock_value,omitempty"`
+	// Version counts the changes to the category. It is only read by GetCategoryByID and sent as the ETag header.
+	Version int64 `json:"-"`
 }
 
 type HealthCheck struct {
 	Name      string `json:"name"`
 	IsHealthy bool   `json:"is_healthy"`
 }
+
+// ResponseCategoryTree is a category with its subcategories, as listed by the category tree.
+type ResponseCategoryTree struct {
+	ID          int64                  `json:"id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Children    []ResponseCategoryTree `json:"children"`
+}
+
+// CategoryStats sums up the products of a category and its subcategories. Deleted products are not counted.
+type CategoryStats struct {
+	ProductCount    int
+	TotalStockValue int64
+}
+
+// CategorySortFields lists the fields the category list can be sorted by.
+var CategorySortFields = []string{"id", "name", "created_at", "updated_at"}
+
+// CategoryFilter narrows and pages the category list. An empty Name means no filter. Deleted categories are left out
+// unless IncludeDeleted is set. WithStats adds the stats of the products to every listed category.
+type CategoryFilter struct {
+	Name           string
+	IncludeDeleted bool
+	WithStats      bool
+	Pagination     pagination.Params
+}
@@ -1,21 +1,46 @@
 package repository
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"strings"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
 )
 
+// categorySortColumns maps entity.CategorySortFields to their columns.
+var categorySortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 type CategoryRepository interface {
-	CreateCategory(category *entity.Category) error
-	UpdateCategory(id int64, category *entity.Category) error
-	DeleteCategory(id int64) error
-	GetCategoryByID(id int64) (*entity.ResponseCategory, error)
-	GetAllCategories() ([]entity.ResponseCategory, error)
+	CreateCategory(ctx context.Context, category *entity.Category, entry *audit.Entry) error
+	UpdateCategory(ctx context.Context, id int64, version int64, category *entity.Category, entry *audit.Entry) error
+	PatchCategory(ctx context.Context, id int64, version int64, patch *entity.PatchCategory, entry *audit.Entry) error
+	DeleteCategory(ctx context.Context, id int64, version int64, targetID int64, entry *audit.Entry) error
+	RestoreCategory(ctx context.Context, id int64, entry *audit.Entry) error
+	GetCategoryByID(ctx context.Context, id int64) (*entity.ResponseCategory, error)
+	GetDeletedCategoryByID(ctx context.Context, id int64) (*entity.ResponseCategory, error)
+	GetAllCategories(ctx context.Context, filter entity.CategoryFilter) ([]entity.ResponseCategory, int, error)
+	GetCategoryStats(ctx context.Context, ids []int64) (map[int64]entity.CategoryStats, error)
+	GetCategoryAncestorIDs(ctx context.Context, id int64) ([]int64, error)
+	GetCategoryTree(ctx context.Context) ([]entity.Category, error)
 }
 
+// ancestorsQuery selects the ID of category $1 followed by the IDs of its parent, grandparent and so on. The walk
+// stops at a category it has already visited, so it ends even on a cycle.
+const ancestorsQuery = "WITH RECURSIVE ancestors (id, parent_id, depth, path) AS (SELECT id, parent_id, 0, ARRAY[id] FROM categories WHERE id = $1 UNION ALL SELECT categories.id, categories.parent_id, ancestors.depth + 1, ancestors.path || categories.id FROM categories JOIN ancestors ON categories.id = ancestors.parent_id WHERE NOT categories.id = ANY(ancestors.path)) SELECT id FROM ancestors ORDER BY depth"
+
+// hierarchyLockKey is the key of the advisory lock taken by every change of a category parent, see lockParent.
+const hierarchyLockKey = 7240801
+
 type categoryRepository struct {
 	db *database.DB
 }
@@ -24,79 +49,427 @@ func NewCategoryRepository(db *database.DB) CategoryRepository {
 	return &categoryRepository{db: db}
 }
 
-func (r *categoryRepository) CreateCategory(category *entity.Category) error {
+// CreateCategory inserts the category and writes entry to the audit log with the new category ID in the same
+// transaction.
+func (r *categoryRepository) CreateCategory(ctx context.Context, category *entity.Category, entry *audit.Entry) error {
 	var (
 		query string
 		err   error
 	)
 
-	query = "INSERT INTO categories (name, description, created_at, updated_at) VALUES ($1, $2, $3, $4)"
+	query = "INSERT INTO categories (name, description, parent_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
 
-	err = r.db.WithTx(func(tx *database.Tx) error {
-		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(category.Name, category.Description, "now()", "now()")
-			return err
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&category.ID)
+			}, category.Name, category.Description, category.ParentID, "now()", "now()")
 		})
 
 		if err != nil {
 			return err
 		}
 
+		if entry != nil {
+			entry.EntityID = category.ID
+		}
+
+		if err = audit.Write(ctx, tx, entry); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
 	return err
 }
 
-func (r *categoryRepository) UpdateCategory(id int64, category *entity.Category) error {
+// UpdateCategory updates the category and writes entry to the audit log in the same transaction. A version other
+// than zero is the version the update is based on: when the category has been changed since, nothing is updated and
+// a precondition failed error is returned.
+func (r *categoryRepository) UpdateCategory(ctx context.Context, id int64, version int64, category *entity.Category, entry *audit.Entry) error {
 	var (
 		query string
+		args  []interface{}
 		err   error
 	)
 
-	query = "UPDATE categories SET name = $1, description = $2, updated_at = $3 WHERE id = $4"
+	query, args = whereVersion("UPDATE categories SET name = $1, description = $2, parent_id = $3, updated_at = $4, version = version + 1 WHERE id = $5", []interface{}{category.Name, category.Description, category.ParentID, "now()", id}, version)
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		if category.ParentID != nil {
+			if err = lockParent(ctx, tx, id, *category.ParentID); err != nil {
+				return err
+			}
+		}
+
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return execVersioned(ctx, stmt, version, args...)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if err = audit.Write(ctx, tx, entry); err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// PatchCategory updates only the columns of the members present in patch and writes entry to the audit log in the
+// same transaction. version is checked as by UpdateCategory.
+func (r *categoryRepository) PatchCategory(ctx context.Context, id int64, version int64, patch *entity.PatchCategory, entry *audit.Entry) error {
+	var (
+		columns []string
+		args    []interface{}
+		query   string
+		err     error
+	)
+
+	if patch.Name.Set {
+		args = append(args, patch.Name.Value)
+		columns = append(columns, fmt.Sprintf("name = $%d", len(args)))
+	}
+
+	if patch.Description.Set {
+		args = append(args, patch.Description.Value)
+		columns = append(columns, fmt.Sprintf("description = $%d", len(args)))
+	}
+
+	if patch.ParentID.Set {
+		args = append(args, patch.ParentID.Value)
+		columns = append(columns, fmt.Sprintf("parent_id = $%d", len(args)))
+	}
+
+	if len(columns) == 0 {
+		return nil
+	}
+
+	args = append(args, "now()", id)
+	query = fmt.Sprintf("UPDATE categories SET %s, updated_at = $%d, version = version + 1 WHERE id = $%d", strings.Join(columns, ", "), len(args)-1, len(args))
+	query, args = whereVersion(query, args, version)
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		if patch.ParentID.Set && patch.ParentID.Value != nil {
+			if err = lockParent(ctx, tx, id, *patch.ParentID.Value); err != nil {
+				return err
+			}
+		}
+
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return execVersioned(ctx, stmt, version, args...)
+		})
 
-	err = r.db.WithTx(func(tx *database.Tx) error {
-		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(category.Name, category.Description, "now()", id)
+		if err != nil {
 			return err
+		}
+
+		return audit.Write(ctx, tx, entry)
+	})
+
+	return err
+}
+
+// DeleteCategory marks the category as deleted and writes entry to the audit log in the same transaction. version is
+// checked as by UpdateCategory. With a targetID other than zero the products of the category are moved to that
+// category in the same transaction, each move audited as an update by the actor of entry; with a zero targetID a
+// conflict error is returned while the category still has products. Deleted products are left in the category. A
+// category that still has subcategories is never deleted.
+func (r *categoryRepository) DeleteCategory(ctx context.Context, id int64, version int64, targetID int64, entry *audit.Entry) error {
+	var (
+		query string
+		args  []interface{}
+		err   error
+	)
+
+	query, args = whereVersion("UPDATE categories SET deleted_at = $1, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL", []interface{}{"now()", id}, version)
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return execVersioned(ctx, stmt, version, args...)
 		})
 
 		if err != nil {
 			return err
 		}
 
+		if err = rejectSubcategories(ctx, tx, id); err != nil {
+			return err
+		}
+
+		if targetID == 0 {
+			err = rejectProducts(ctx, tx, id)
+		} else {
+			err = reassignProducts(ctx, tx, id, targetID, entry)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = audit.Write(ctx, tx, entry); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
 	return err
 }
 
-func (r *categoryRepository) DeleteCategory(id int64) error {
+// rejectSubcategories returns a conflict error when category id still has subcategories, which would be left out of
+// the category tree.
+func rejectSubcategories(ctx context.Context, tx *database.Tx, id int64) error {
 	var (
 		query string
+		count int
 		err   error
 	)
 
-	query = "DELETE FROM categories WHERE id = $1"
+	query = "SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL"
+
+	err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&count)
+		}, id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return apperror.Conflict("category still has %d subcategories, move or delete them first", count)
+	}
+
+	return nil
+}
+
+// lockParent checks, within the transaction moving category id under parentID, that the parent exists and is neither
+// the category itself nor one of its subcategories. The service checks the same before the transaction starts, but two
+// concurrent moves, A under B and B under A, would both pass that check. Here every move first takes the same
+// transaction-level advisory lock, so the moves run one after the other and each sees the parents committed by the
+// previous one. The category and the parent are locked until the transaction ends as well.
+func lockParent(ctx context.Context, tx *database.Tx, id int64, parentID int64) error {
+	var (
+		advisoryQuery string
+		lockQuery     string
+		parentQuery   string
+		lockedID      int64
+		foundParentID int64
+		ancestorIDs   []int64
+		err           error
+	)
+
+	advisoryQuery = "SELECT pg_advisory_xact_lock($1)"
+	lockQuery = "SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	parentQuery = "SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE"
+
+	err = tx.WithStmtContext(ctx, advisoryQuery, func(stmt *database.Stmt) error {
+		_, err = stmt.ExecContext(ctx, hierarchyLockKey)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.WithStmtContext(ctx, lockQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&lockedID)
+		}, id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if lockedID == 0 {
+		return apperror.NotFound("category not found")
+	}
+
+	err = tx.WithStmtContext(ctx, parentQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&foundParentID)
+		}, parentID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if foundParentID == 0 {
+		return apperror.Invalid(apperror.FieldError{Field: "parent_id", Message: "parent category not found"})
+	}
+
+	err = tx.WithStmtContext(ctx, ancestorsQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var ancestorID int64
+			if err := rows.Scan(&ancestorID); err != nil {
+				return err
+			}
+
+			ancestorIDs = append(ancestorIDs, ancestorID)
+			return nil
+		}, parentID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, ancestorID := range ancestorIDs {
+		if ancestorID == id {
+			return apperror.Invalid(apperror.FieldError{Field: "parent_id", Message: "parent category must not be the category itself or one of its subcategories"})
+		}
+	}
+
+	return nil
+}
+
+// rejectProducts returns a conflict error when category id still has products.
+func rejectProducts(ctx context.Context, tx *database.Tx, id int64) error {
+	var (
+		query string
+		count int
+		err   error
+	)
+
+	query = "SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL"
+
+	err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&count)
+		}, id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return apperror.Conflict("category still has %d products, reassign them to another category first", count)
+	}
+
+	return nil
+}
+
+// reassignProducts moves the products of category id to category targetID, which is locked so it cannot be deleted
+// meanwhile, and writes an audit entry for each moved product with the actor of entry.
+func reassignProducts(ctx context.Context, tx *database.Tx, id int64, targetID int64, entry *audit.Entry) error {
+	var (
+		query      string
+		target     int64
+		productIDs []int64
+		err        error
+	)
+
+	query = "SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE"
+
+	err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&target)
+		}, targetID)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if target == 0 {
+		return apperror.Invalid(apperror.FieldError{Field: "target_category_id", Message: "target category not found"})
+	}
+
+	query = "UPDATE products SET category_id = $1, updated_at = $2, version = version + 1 WHERE category_id = $3 AND deleted_at IS NULL RETURNING id"
+
+	err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var productID int64
+			if err := rows.Scan(&productID); err != nil {
+				return err
+			}
+
+			productIDs = append(productIDs, productID)
+			return nil
+		}, targetID, "now()", id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		return nil
+	}
+
+	for _, productID := range productIDs {
+		err = audit.Write(ctx, tx, &audit.Entry{
+			Actor:      entry.Actor,
+			Action:     audit.ActionUpdate,
+			EntityType: audit.EntityProduct,
+			EntityID:   productID,
+			Before:     productCategory{CategoryID: id},
+			After:      productCategory{CategoryID: targetID},
+		})
 
-	err = r.db.WithTx(func(tx *database.Tx) error {
-		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(id)
+		if err != nil {
 			return err
+		}
+	}
+
+	return nil
+}
+
+// productCategory is the audited state of a product moved by reassignProducts.
+type productCategory struct {
+	CategoryID int64 `json:"category_id"`
+}
+
+// RestoreCategory clears the deletion mark of a deleted category and writes entry to the audit log in the same
+// transaction. It returns a not found error when the category is not deleted.
+func (r *categoryRepository) RestoreCategory(ctx context.Context, id int64, entry *audit.Entry) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "UPDATE categories SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL"
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			result, err := stmt.ExecContext(ctx, "now()", id)
+			if err != nil {
+				return err
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if affected == 0 {
+				return apperror.NotFound("deleted category not found")
+			}
+
+			return nil
 		})
 
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return audit.Write(ctx, tx, entry)
 	})
 
 	return err
 }
 
-func (r *categoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory, error) {
+func (r *categoryRepository) GetCategoryByID(ctx context.Context, id int64) (*entity.ResponseCategory, error) {
 	var (
 		category     entity.Category
 		respCategory entity.ResponseCategory
@@ -104,11 +477,11 @@ func (r *categoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory
 		query        string
 	)
 
-	query = "SELECT id, name, description, created_at, updated_at FROM categories WHERE id = $1"
+	query = "SELECT id, name, description, parent_id, created_at, updated_at, version FROM categories WHERE id = $1 AND deleted_at IS NULL"
 
-	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
-		err = stmt.Query(func(rows *database.Rows) error {
-			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt); err != nil {
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.ParentID, &category.CreatedAt, &category.UpdatedAt, &category.Version); err != nil {
 				return err
 			}
 
@@ -123,7 +496,7 @@ func (r *categoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory
 	}
 
 	if category.ID == 0 {
-		return nil, errors.New("category not found")
+		return nil, apperror.NotFound("category not found")
 	}
 
 	createdAt, _ := datetime.ParseTime(category.CreatedAt)
@@ -133,38 +506,142 @@ func (r *categoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory
 		ID:          category.ID,
 		Name:        category.Name,
 		Description: category.Description,
+		ParentID:    category.ParentID,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
+		Version:     category.Version,
 	}
 
 	return &respCategory, nil
 }
 
-func (r *categoryRepository) GetAllCategories() ([]entity.ResponseCategory, error) {
+// GetDeletedCategoryByID returns a deleted category, the one RestoreCategory would bring back.
+func (r *categoryRepository) GetDeletedCategoryByID(ctx context.Context, id int64) (*entity.ResponseCategory, error) {
+	var (
+		category entity.Category
+		err      error
+		query    string
+	)
+
+	query = "SELECT id, name, description, parent_id, created_at, updated_at, deleted_at, version FROM categories WHERE id = $1 AND deleted_at IS NOT NULL"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&category.ID, &category.Name, &category.Description, &category.ParentID, &category.CreatedAt, &category.UpdatedAt, &category.DeletedAt, &category.Version)
+		}, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if category.ID == 0 {
+		return nil, apperror.NotFound("deleted category not found")
+	}
+
+	createdAt, _ := datetime.ParseTime(category.CreatedAt)
+	updatedAt, _ := datetime.ParseTime(category.UpdatedAt)
+
+	return &entity.ResponseCategory{
+		ID:          category.ID,
+		Name:        category.Name,
+		Description: category.Description,
+		ParentID:    category.ParentID,
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+		DeletedAt:   datetime.ParseNullTime(category.DeletedAt),
+		Version:     category.Version,
+	}, nil
+}
+
+// whereVersion restricts the statement query with args to the given version of the row, unless version is zero.
+func whereVersion(query string, args []interface{}, version int64) (string, []interface{}) {
+	if version == 0 {
+		return query, args
+	}
+
+	args = append(args, version)
+	return fmt.Sprintf("%s AND version = $%d", query, len(args)), args
+}
+
+// execVersioned runs a statement restricted by whereVersion. When it affects no row the category has been changed
+// since version, or removed, and a precondition failed error is returned.
+func execVersioned(ctx context.Context, stmt *database.Stmt, version int64, args ...interface{}) error {
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return nil
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return apperror.PreconditionFailed("category has been changed since version %d", version)
+	}
+
+	return nil
+}
+
+func (r *categoryRepository) GetAllCategories(ctx context.Context, filter entity.CategoryFilter) ([]entity.ResponseCategory, int, error) {
 	var (
 		categories []entity.Category
+		total      int
+		conditions []string
+		where      string
+		args       []interface{}
 		err        error
 		query      string
+		countQuery string
 	)
 
-	query = "SELECT id, name, description, created_at, updated_at FROM categories"
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if filter.Name != "" {
+		args = append(args, database.EscapeLike(filter.Name))
+		conditions = append(conditions, fmt.Sprintf("name ILIKE '%%' || $%d || '%%' ESCAPE '\\'", len(args)))
+	}
+
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	countQuery = "SELECT COUNT(*) FROM categories" + where
+	query = fmt.Sprintf("SELECT id, name, description, parent_id, created_at, updated_at, deleted_at FROM categories%s %s LIMIT $%d OFFSET $%d", where, filter.Pagination.OrderBy(categorySortColumns, "id"), len(args)+1, len(args)+2)
+
+	err = r.db.WithStmtContext(ctx, countQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&total)
+		}, args...)
+	})
 
-	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
-		err = stmt.Query(func(rows *database.Rows) error {
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
 			var category entity.Category
-			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.ParentID, &category.CreatedAt, &category.UpdatedAt, &category.DeletedAt); err != nil {
 				return err
 			}
 
 			categories = append(categories, category)
 			return nil
-		})
+		}, append(args, filter.Pagination.Limit(), filter.Pagination.Offset())...)
 
 		return err
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	var respCategories []entity.ResponseCategory
@@ -176,12 +653,119 @@ func (r *categoryRepository) GetAllCategories() ([]entity.ResponseCategory, erro
 			ID:          category.ID,
 			Name:        category.Name,
 			Description: category.Description,
+			ParentID:    category.ParentID,
 			CreatedAt:   createdAt,
 			UpdatedAt:   updatedAt,
+			DeletedAt:   datetime.ParseNullTime(category.DeletedAt),
 		}
 
 		respCategories = append(respCategories, respCategory)
 	}
 
-	return respCategories, nil
+	return respCategories, total, nil
+}
+
+// GetCategoryStats counts the products of the given categories and sums up their stock value. Like the category filter
+// of the product list, the products of the subcategories are counted too, at any depth; UNION ends the walk even if the
+// parents form a cycle. Categories without products are missing from the result.
+func (r *categoryRepository) GetCategoryStats(ctx context.Context, ids []int64) (map[int64]entity.CategoryStats, error) {
+	var (
+		placeholders []string
+		args         []interface{}
+		query        string
+		err          error
+	)
+
+	stats := make(map[int64]entity.CategoryStats, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	for _, id := range ids {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query = fmt.Sprintf("WITH RECURSIVE descendants (category_id, id) AS (SELECT id, id FROM categories WHERE id IN (%s) UNION SELECT descendants.category_id, categories.id FROM categories JOIN descendants ON categories.parent_id = descendants.id WHERE categories.deleted_at IS NULL) SELECT descendants.category_id, COUNT(*), COALESCE(SUM(products.price::bigint * products.stock), 0) FROM descendants JOIN products ON products.category_id = descendants.id WHERE products.deleted_at IS NULL GROUP BY descendants.category_id", strings.Join(placeholders, ", "))
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var (
+				id   int64
+				stat entity.CategoryStats
+			)
+			if err := rows.Scan(&id, &stat.ProductCount, &stat.TotalStockValue); err != nil {
+				return err
+			}
+
+			stats[id] = stat
+			return nil
+		}, args...)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// GetCategoryAncestorIDs returns the ID of category id followed by the IDs of its parent, grandparent and so on up to
+// the top-level category, see ancestorsQuery.
+func (r *categoryRepository) GetCategoryAncestorIDs(ctx context.Context, id int64) ([]int64, error) {
+	var (
+		ids   []int64
+		query string
+		err   error
+	)
+
+	query = ancestorsQuery
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var ancestorID int64
+			if err := rows.Scan(&ancestorID); err != nil {
+				return err
+			}
+
+			ids = append(ids, ancestorID)
+			return nil
+		}, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// GetCategoryTree returns every category that can be reached from a top-level category through categories that are
+// not deleted, parents before their children and siblings by name.
+func (r *categoryRepository) GetCategoryTree(ctx context.Context) ([]entity.Category, error) {
+	var (
+		categories []entity.Category
+		query      string
+		err        error
+	)
+
+	query = "WITH RECURSIVE tree (id, name, description, parent_id, depth) AS (SELECT id, name, description, parent_id, 0 FROM categories WHERE parent_id IS NULL AND deleted_at IS NULL UNION ALL SELECT categories.id, categories.name, categories.description, categories.parent_id, tree.depth + 1 FROM categories JOIN tree ON categories.parent_id = tree.id WHERE categories.deleted_at IS NULL) SELECT id, name, description, parent_id FROM tree ORDER BY depth, name, id"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var category entity.Category
+			if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.ParentID); err != nil {
+				return err
+			}
+
+			categories = append(categories, category)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
 }
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
@@ -13,7 +14,10 @@ import (
 	"time"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
 )
 
 type testQuery struct {
@@ -26,18 +30,23 @@ type testConfig struct {
 	prepareErr error
 	execErr    error
 	query      testQuery
+	queries    map[string]testQuery
 	beginErr   error
 	commitErr  error
+	// noRows makes every statement affect no row, as when a row has another version than the one asked for.
+	noRows bool
 
 	mu            sync.Mutex
 	lastExecArgs  []driver.Value
 	lastQueryArgs []driver.Value
+	execQueries   []string
 }
 
-func (c *testConfig) setLastExecArgs(args []driver.Value) {
+func (c *testConfig) setLastExecArgs(query string, args []driver.Value) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.lastExecArgs = append([]driver.Value(nil), args...)
+	c.execQueries = append(c.execQueries, query)
 }
 
 func (c *testConfig) setLastQueryArgs(args []driver.Value) {
@@ -111,16 +120,23 @@ func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
 	if s.cfg.execErr != nil {
 		return nil, s.cfg.execErr
 	}
-	s.cfg.setLastExecArgs(args)
+	s.cfg.setLastExecArgs(s.query, args)
+	if s.cfg.noRows {
+		return driver.RowsAffected(0), nil
+	}
 	return driver.RowsAffected(1), nil
 }
 
 func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
-	if s.cfg.query.queryErr != nil {
-		return nil, s.cfg.query.queryErr
+	q, ok := s.cfg.queries[s.query]
+	if !ok {
+		q = s.cfg.query
+	}
+	if q.queryErr != nil {
+		return nil, q.queryErr
 	}
 	s.cfg.setLastQueryArgs(args)
-	return &testRows{columns: s.cfg.query.columns, values: s.cfg.query.rows}, nil
+	return &testRows{columns: q.columns, values: q.rows}, nil
 }
 
 type testRows struct {
@@ -173,6 +189,14 @@ func mustParseTime(t *testing.T, value string) time.Time {
 	return parsed.In(loc)
 }
 
+func ptrTime(value time.Time) *time.Time {
+	return &value
+}
+
+func ptrInt64(value int64) *int64 {
+	return &value
+}
+
 func TestNewCategoryRepository(t *testing.T) {
 	db := newTestDB(t, &testConfig{})
 	repo := NewCategoryRepository(db)
@@ -189,20 +213,37 @@ func TestNewCategoryRepository(t *testing.T) {
 }
 
 func TestCategoryRepository_CreateCategory(t *testing.T) {
+	inserted := testQuery{columns: []string{"id"}, rows: [][]driver.Value{{int64(8)}}}
+	entry := func() *audit.Entry {
+		return &audit.Entry{Actor: audit.Actor{ID: 1, Username: "admin"}, Action: audit.ActionCreate, EntityType: audit.EntityCategory, After: entity.RequestCategory{Name: "food", Description: "fresh"}}
+	}
+
 	tests := []struct {
 		name      string
 		cfg       testConfig
 		category  entity.Category
+		entry     *audit.Entry
 		wantErr   error
 		wantArgs  []driver.Value
+		wantAudit []driver.Value
 		checkArgs bool
 	}{
 		{
 			name:      "ok",
+			cfg:       testConfig{query: inserted},
 			category:  entity.Category{Name: "food", Description: "fresh"},
-			wantArgs:  []driver.Value{"food", "fresh", "now()", "now()"},
+			entry:     entry(),
+			wantArgs:  []driver.Value{"food", "fresh", nil, "now()", "now()"},
+			wantAudit: []driver.Value{int64(1), "admin", "create", "category", int64(8), nil, `{"name":"food","description":"fresh"}`, "now()"},
 			checkArgs: true,
 		},
+		{
+			name:     "query",
+			cfg:      testConfig{query: testQuery{queryErr: errors.New("query")}},
+			category: entity.Category{Name: "food", Description: "fresh"},
+			entry:    entry(),
+			wantErr:  errors.New("query"),
+		},
 		{
 			name:     "begin",
 			cfg:      testConfig{beginErr: errors.New("begin")},
@@ -216,28 +257,29 @@ func TestCategoryRepository_CreateCategory(t *testing.T) {
 			wantErr:  errors.New("prepare"),
 		},
 		{
-			name:     "exec",
-			cfg:      testConfig{execErr: errors.New("exec")},
+			name:     "audit",
+			cfg:      testConfig{query: inserted, execErr: errors.New("exec")},
 			category: entity.Category{Name: "food", Description: "fresh"},
+			entry:    entry(),
 			wantErr:  errors.New("exec"),
 		},
 		{
 			name:      "commit",
-			cfg:       testConfig{commitErr: errors.New("commit")},
+			cfg:       testConfig{query: inserted, commitErr: errors.New("commit")},
 			category:  entity.Category{Name: "food", Description: "fresh"},
 			wantErr:   errors.New("commit"),
-			wantArgs:  []driver.Value{"food", "fresh", "now()", "now()"},
+			wantArgs:  []driver.Value{"food", "fresh", nil, "now()", "now()"},
 			checkArgs: true,
 		},
 	}
 
-	for _, tt := range tests {
-		tt := tt
+	for i := range tests {
+		tt := &tests[i]
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := tt.cfg
-			db := newTestDB(t, &cfg)
+			cfg := &tt.cfg
+			db := newTestDB(t, cfg)
 			repo := NewCategoryRepository(db)
-			err := repo.CreateCategory(&tt.category)
+			err := repo.CreateCategory(context.Background(), &tt.category, tt.entry)
 			if (err == nil) != (tt.wantErr == nil) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
@@ -245,20 +287,48 @@ func TestCategoryRepository_CreateCategory(t *testing.T) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
 			if tt.checkArgs {
-				if got := cfg.getLastExecArgs(); !reflect.DeepEqual(got, tt.wantArgs) {
+				if got := cfg.getLastQueryArgs(); !reflect.DeepEqual(got, tt.wantArgs) {
 					t.Fatalf("expected args %v, got %v", tt.wantArgs, got)
 				}
+				if tt.category.ID != 8 {
+					t.Fatalf("expected category id 8, got %d", tt.category.ID)
+				}
+			}
+			if tt.wantAudit != nil {
+				if tt.entry.EntityID != 8 {
+					t.Fatalf("expected audit entity id 8, got %d", tt.entry.EntityID)
+				}
+				if got := cfg.getLastExecArgs(); !reflect.DeepEqual(got, tt.wantAudit) {
+					t.Fatalf("expected audit args %v, got %v", tt.wantAudit, got)
+				}
 			}
 		})
 	}
 }
 
+// lockParentQueries answers the queries of lockParent for category 9 moved under category 2, whose ancestors are
+// ancestors.
+func lockParentQueries(ancestors ...int64) map[string]testQuery {
+	rows := make([][]driver.Value, len(ancestors))
+	for i, id := range ancestors {
+		rows[i] = []driver.Value{id}
+	}
+
+	return map[string]testQuery{
+		"SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE": {columns: []string{"id"}, rows: [][]driver.Value{{int64(9)}}},
+		"SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE":  {columns: []string{"id"}, rows: [][]driver.Value{{int64(2)}}},
+		ancestorsQuery: {columns: []string{"id"}, rows: rows},
+	}
+}
+
 func TestCategoryRepository_UpdateCategory(t *testing.T) {
 	tests := []struct {
 		name      string
 		cfg       testConfig
 		id        int64
+		version   int64
 		category  entity.Category
+		entry     *audit.Entry
 		wantErr   error
 		wantArgs  []driver.Value
 		checkArgs bool
@@ -267,9 +337,54 @@ func TestCategoryRepository_UpdateCategory(t *testing.T) {
 			name:      "ok",
 			id:        9,
 			category:  entity.Category{Name: "tech", Description: "gadgets"},
-			wantArgs:  []driver.Value{"tech", "gadgets", "now()", int64(9)},
+			wantArgs:  []driver.Value{"tech", "gadgets", nil, "now()", int64(9)},
+			checkArgs: true,
+		},
+		{
+			name:      "parent",
+			cfg:       testConfig{queries: lockParentQueries(2, 1)},
+			id:        9,
+			category:  entity.Category{Name: "tech", Description: "gadgets", ParentID: ptrInt64(2)},
+			wantArgs:  []driver.Value{"tech", "gadgets", int64(2), "now()", int64(9)},
+			checkArgs: true,
+		},
+		{
+			name:     "cycle",
+			cfg:      testConfig{queries: lockParentQueries(2, 9, 1)},
+			id:       9,
+			category: entity.Category{Name: "tech", Description: "gadgets", ParentID: ptrInt64(2)},
+			wantErr:  errors.New("parent category must not be the category itself or one of its subcategories"),
+		},
+		{
+			name:      "audited",
+			id:        9,
+			category:  entity.Category{Name: "tech", Description: "gadgets"},
+			entry:     &audit.Entry{Actor: audit.Actor{ID: 1, Username: "admin"}, Action: audit.ActionUpdate, EntityType: audit.EntityCategory, EntityID: 9, Before: entity.RequestCategory{Name: "tech"}, After: entity.RequestCategory{Name: "tech", Description: "gadgets"}},
+			wantArgs:  []driver.Value{int64(1), "admin", "update", "category", int64(9), `{"name":"tech","description":""}`, `{"name":"tech","description":"gadgets"}`, "now()"},
 			checkArgs: true,
 		},
+		{
+			name:      "versioned",
+			id:        9,
+			version:   3,
+			category:  entity.Category{Name: "tech", Description: "gadgets"},
+			wantArgs:  []driver.Value{"tech", "gadgets", nil, "now()", int64(9), int64(3)},
+			checkArgs: true,
+		},
+		{
+			name:     "stale-version",
+			cfg:      testConfig{noRows: true},
+			id:       9,
+			version:  3,
+			category: entity.Category{Name: "tech", Description: "gadgets"},
+			wantErr:  errors.New("category has been changed since version 3"),
+		},
+		{
+			name:     "unversioned-no-rows",
+			cfg:      testConfig{noRows: true},
+			id:       9,
+			category: entity.Category{Name: "tech", Description: "gadgets"},
+		},
 		{
 			name:     "begin",
 			cfg:      testConfig{beginErr: errors.New("begin")},
@@ -297,18 +412,18 @@ func TestCategoryRepository_UpdateCategory(t *testing.T) {
 			id:        9,
 			category:  entity.Category{Name: "tech", Description: "gadgets"},
 			wantErr:   errors.New("commit"),
-			wantArgs:  []driver.Value{"tech", "gadgets", "now()", int64(9)},
+			wantArgs:  []driver.Value{"tech", "gadgets", nil, "now()", int64(9)},
 			checkArgs: true,
 		},
 	}
 
-	for _, tt := range tests {
-		tt := tt
+	for i := range tests {
+		tt := &tests[i]
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := tt.cfg
-			db := newTestDB(t, &cfg)
+			cfg := &tt.cfg
+			db := newTestDB(t, cfg)
 			repo := NewCategoryRepository(db)
-			err := repo.UpdateCategory(tt.id, &tt.category)
+			err := repo.UpdateCategory(context.Background(), tt.id, tt.version, &tt.category, tt.entry)
 			if (err == nil) != (tt.wantErr == nil) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
@@ -324,11 +439,141 @@ func TestCategoryRepository_UpdateCategory(t *testing.T) {
 	}
 }
 
+func TestCategoryRepository_PatchCategory(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       *testConfig
+		version   int64
+		patch     entity.PatchCategory
+		wantErr   error
+		wantQuery string
+		wantArgs  []driver.Value
+		// wantLock expects the advisory lock of lockParent before the update.
+		wantLock bool
+	}{
+		{
+			name:      "name-only",
+			patch:     entity.PatchCategory{Name: patch.Of("tech")},
+			wantQuery: "UPDATE categories SET name = $1, updated_at = $2, version = version + 1 WHERE id = $3",
+			wantArgs:  []driver.Value{"tech", "now()", int64(9)},
+		},
+		{
+			name:      "every-member",
+			patch:     entity.PatchCategory{Name: patch.Of("tech"), Description: patch.Field[string]{Set: true, Null: true}},
+			wantQuery: "UPDATE categories SET name = $1, description = $2, updated_at = $3, version = version + 1 WHERE id = $4",
+			wantArgs:  []driver.Value{"tech", "", "now()", int64(9)},
+		},
+		{
+			name:      "parent",
+			cfg:       &testConfig{queries: lockParentQueries(2, 1)},
+			patch:     entity.PatchCategory{ParentID: patch.Of(ptrInt64(2))},
+			wantQuery: "UPDATE categories SET parent_id = $1, updated_at = $2, version = version + 1 WHERE id = $3",
+			wantArgs:  []driver.Value{int64(2), "now()", int64(9)},
+			wantLock:  true,
+		},
+		{
+			name:    "cycle",
+			cfg:     &testConfig{queries: lockParentQueries(2, 9)},
+			patch:   entity.PatchCategory{ParentID: patch.Of(ptrInt64(2))},
+			wantErr: errors.New("parent category must not be the category itself or one of its subcategories"),
+		},
+		{
+			name: "parent-missing",
+			cfg: &testConfig{queries: map[string]testQuery{
+				"SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR UPDATE": {columns: []string{"id"}, rows: [][]driver.Value{{int64(9)}}},
+			}},
+			patch:   entity.PatchCategory{ParentID: patch.Of(ptrInt64(2))},
+			wantErr: errors.New("parent category not found"),
+		},
+		{
+			name:    "category-missing",
+			cfg:     &testConfig{queries: map[string]testQuery{}},
+			patch:   entity.PatchCategory{ParentID: patch.Of(ptrInt64(2))},
+			wantErr: errors.New("category not found"),
+		},
+		{
+			name:      "top-level",
+			patch:     entity.PatchCategory{ParentID: patch.Field[*int64]{Set: true, Null: true}},
+			wantQuery: "UPDATE categories SET parent_id = $1, updated_at = $2, version = version + 1 WHERE id = $3",
+			wantArgs:  []driver.Value{nil, "now()", int64(9)},
+		},
+		{
+			name:      "versioned",
+			version:   3,
+			patch:     entity.PatchCategory{Name: patch.Of("tech")},
+			wantQuery: "UPDATE categories SET name = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND version = $4",
+			wantArgs:  []driver.Value{"tech", "now()", int64(9), int64(3)},
+		},
+		{
+			name:    "stale-version",
+			cfg:     &testConfig{noRows: true},
+			version: 3,
+			patch:   entity.PatchCategory{Name: patch.Of("tech")},
+			wantErr: errors.New("category has been changed since version 3"),
+		},
+		{
+			name:  "empty",
+			patch: entity.PatchCategory{},
+		},
+		{
+			name:    "exec",
+			cfg:     &testConfig{execErr: errors.New("exec")},
+			patch:   entity.PatchCategory{Name: patch.Of("tech")},
+			wantErr: errors.New("exec"),
+		},
+		{
+			name:    "commit",
+			cfg:     &testConfig{commitErr: errors.New("commit")},
+			patch:   entity.PatchCategory{Name: patch.Of("tech")},
+			wantErr: errors.New("commit"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = &testConfig{}
+			}
+			db := newTestDB(t, cfg)
+			repo := NewCategoryRepository(db)
+			err := repo.PatchCategory(context.Background(), 9, tt.version, &tt.patch, nil)
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if tt.wantQuery == "" {
+				if len(cfg.execQueries) != 0 {
+					t.Fatalf("expected no statements, got %v", cfg.execQueries)
+				}
+				return
+			}
+			wantQueries := []string{tt.wantQuery}
+			if tt.wantLock {
+				wantQueries = []string{"SELECT pg_advisory_xact_lock($1)", tt.wantQuery}
+			}
+			if !reflect.DeepEqual(cfg.execQueries, wantQueries) {
+				t.Fatalf("expected queries %q, got %v", wantQueries, cfg.execQueries)
+			}
+			if got := cfg.getLastExecArgs(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Fatalf("expected args %v, got %v", tt.wantArgs, got)
+			}
+		})
+	}
+}
+
 func TestCategoryRepository_DeleteCategory(t *testing.T) {
 	tests := []struct {
 		name      string
 		cfg       testConfig
 		id        int64
+		version   int64
+		entry     *audit.Entry
 		wantErr   error
 		wantArgs  []driver.Value
 		checkArgs bool
@@ -336,9 +581,30 @@ func TestCategoryRepository_DeleteCategory(t *testing.T) {
 		{
 			name:      "ok",
 			id:        4,
-			wantArgs:  []driver.Value{int64(4)},
+			wantArgs:  []driver.Value{"now()", int64(4)},
+			checkArgs: true,
+		},
+		{
+			name:      "audited",
+			id:        4,
+			entry:     &audit.Entry{Actor: audit.System, Action: audit.ActionDelete, EntityType: audit.EntityCategory, EntityID: 4, Before: entity.RequestCategory{Name: "food"}},
+			wantArgs:  []driver.Value{int64(0), "system", "delete", "category", int64(4), `{"name":"food","description":""}`, nil, "now()"},
 			checkArgs: true,
 		},
+		{
+			name:      "versioned",
+			id:        4,
+			version:   3,
+			wantArgs:  []driver.Value{"now()", int64(4), int64(3)},
+			checkArgs: true,
+		},
+		{
+			name:    "stale-version",
+			cfg:     testConfig{noRows: true},
+			id:      4,
+			version: 3,
+			wantErr: errors.New("category has been changed since version 3"),
+		},
 		{
 			name:    "begin",
 			cfg:     testConfig{beginErr: errors.New("begin")},
@@ -362,18 +628,18 @@ func TestCategoryRepository_DeleteCategory(t *testing.T) {
 			cfg:       testConfig{commitErr: errors.New("commit")},
 			id:        4,
 			wantErr:   errors.New("commit"),
-			wantArgs:  []driver.Value{int64(4)},
+			wantArgs:  []driver.Value{"now()", int64(4)},
 			checkArgs: true,
 		},
 	}
 
-	for _, tt := range tests {
-		tt := tt
+	for i := range tests {
+		tt := &tests[i]
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := tt.cfg
-			db := newTestDB(t, &cfg)
+			cfg := &tt.cfg
+			db := newTestDB(t, cfg)
 			repo := NewCategoryRepository(db)
-			err := repo.DeleteCategory(tt.id)
+			err := repo.DeleteCategory(context.Background(), tt.id, tt.version, 0, tt.entry)
 			if (err == nil) != (tt.wantErr == nil) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
@@ -389,6 +655,242 @@ func TestCategoryRepository_DeleteCategory(t *testing.T) {
 	}
 }
 
+func TestCategoryRepository_DeleteCategoryProducts(t *testing.T) {
+	deleteQuery := "UPDATE categories SET deleted_at = $1, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL"
+	countQuery := "SELECT COUNT(*) FROM products WHERE category_id = $1 AND deleted_at IS NULL"
+	subcategoriesQuery := "SELECT COUNT(*) FROM categories WHERE parent_id = $1 AND deleted_at IS NULL"
+	targetQuery := "SELECT id FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE"
+	reassignQuery := "UPDATE products SET category_id = $1, updated_at = $2, version = version + 1 WHERE category_id = $3 AND deleted_at IS NULL RETURNING id"
+	auditQuery := "INSERT INTO audit_logs (actor_id, actor_username, action, entity_type, entity_id, before, after, created_at) VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)"
+	entry := &audit.Entry{Actor: audit.System, Action: audit.ActionDelete, EntityType: audit.EntityCategory, EntityID: 4, Before: entity.RequestCategory{Name: "food"}}
+
+	tests := []struct {
+		name          string
+		queries       map[string]testQuery
+		targetID      int64
+		wantErr       error
+		wantExecs     []string
+		wantQueryArgs []driver.Value
+	}{
+		{
+			name:          "reject-empty",
+			queries:       map[string]testQuery{countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(0)}}}},
+			wantExecs:     []string{deleteQuery, auditQuery},
+			wantQueryArgs: []driver.Value{int64(4)},
+		},
+		{
+			name:    "reject-has-products",
+			queries: map[string]testQuery{countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(2)}}}},
+			wantErr: errors.New("category still has 2 products, reassign them to another category first"),
+		},
+		{
+			name:    "count-error",
+			queries: map[string]testQuery{countQuery: {queryErr: errors.New("count")}},
+			wantErr: errors.New("count"),
+		},
+		{
+			name:     "has-subcategories",
+			targetID: 3,
+			queries:  map[string]testQuery{subcategoriesQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(1)}}}},
+			wantErr:  errors.New("category still has 1 subcategories, move or delete them first"),
+		},
+		{
+			name:     "reassign",
+			targetID: 3,
+			queries: map[string]testQuery{
+				targetQuery:   {columns: []string{"id"}, rows: [][]driver.Value{{int64(3)}}},
+				reassignQuery: {columns: []string{"id"}, rows: [][]driver.Value{{int64(7)}, {int64(8)}}},
+			},
+			wantExecs:     []string{deleteQuery, auditQuery, auditQuery, auditQuery},
+			wantQueryArgs: []driver.Value{int64(3), "now()", int64(4)},
+		},
+		{
+			name:     "reassign-missing-target",
+			targetID: 3,
+			queries:  map[string]testQuery{targetQuery: {columns: []string{"id"}}},
+			wantErr:  errors.New("target category not found"),
+		},
+		{
+			name:     "reassign-error",
+			targetID: 3,
+			queries: map[string]testQuery{
+				targetQuery:   {columns: []string{"id"}, rows: [][]driver.Value{{int64(3)}}},
+				reassignQuery: {queryErr: errors.New("reassign")},
+			},
+			wantErr: errors.New("reassign"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{queries: tt.queries}
+			db := newTestDB(t, cfg)
+			repo := NewCategoryRepository(db)
+			err := repo.DeleteCategory(context.Background(), 4, 0, tt.targetID, entry)
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				if len(cfg.execQueries) > 1 {
+					t.Fatalf("expected no audit entry, got %v", cfg.execQueries)
+				}
+				return
+			}
+			if !reflect.DeepEqual(cfg.execQueries, tt.wantExecs) {
+				t.Fatalf("expected statements %v, got %v", tt.wantExecs, cfg.execQueries)
+			}
+			if got := cfg.getLastQueryArgs(); !reflect.DeepEqual(got, tt.wantQueryArgs) {
+				t.Fatalf("expected query args %v, got %v", tt.wantQueryArgs, got)
+			}
+			wantArgs := []driver.Value{int64(0), "system", "delete", "category", int64(4), `{"name":"food","description":""}`, nil, "now()"}
+			if got := cfg.getLastExecArgs(); !reflect.DeepEqual(got, wantArgs) {
+				t.Fatalf("expected args %v, got %v", wantArgs, got)
+			}
+		})
+	}
+}
+
+func TestCategoryRepository_RestoreCategory(t *testing.T) {
+	restoreQuery := "UPDATE categories SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL"
+	tests := []struct {
+		name      string
+		cfg       *testConfig
+		entry     *audit.Entry
+		wantErr   error
+		wantExecs []string
+		wantArgs  []driver.Value
+	}{
+		{
+			name:      "ok",
+			wantExecs: []string{restoreQuery},
+			wantArgs:  []driver.Value{"now()", int64(4)},
+		},
+		{
+			name:      "audited",
+			entry:     &audit.Entry{Actor: audit.System, Action: audit.ActionRestore, EntityType: audit.EntityCategory, EntityID: 4, After: entity.RequestCategory{Name: "food"}},
+			wantExecs: []string{restoreQuery, "INSERT INTO audit_logs (actor_id, actor_username, action, entity_type, entity_id, before, after, created_at) VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)"},
+			wantArgs:  []driver.Value{int64(0), "system", "restore", "category", int64(4), nil, `{"name":"food","description":""}`, "now()"},
+		},
+		{
+			name:    "not-deleted",
+			cfg:     &testConfig{noRows: true},
+			entry:   &audit.Entry{Actor: audit.System, Action: audit.ActionRestore, EntityType: audit.EntityCategory, EntityID: 4},
+			wantErr: errors.New("deleted category not found"),
+		},
+		{
+			name:    "exec",
+			cfg:     &testConfig{execErr: errors.New("exec")},
+			wantErr: errors.New("exec"),
+		},
+		{
+			name:    "commit",
+			cfg:     &testConfig{commitErr: errors.New("commit")},
+			wantErr: errors.New("commit"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			if cfg == nil {
+				cfg = &testConfig{}
+			}
+			db := newTestDB(t, cfg)
+			repo := NewCategoryRepository(db)
+			err := repo.RestoreCategory(context.Background(), 4, tt.entry)
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				if len(cfg.execQueries) > 1 {
+					t.Fatalf("expected no audit entry, got %v", cfg.execQueries)
+				}
+				return
+			}
+			if !reflect.DeepEqual(cfg.execQueries, tt.wantExecs) {
+				t.Fatalf("expected statements %v, got %v", tt.wantExecs, cfg.execQueries)
+			}
+			if got := cfg.getLastExecArgs(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Fatalf("expected args %v, got %v", tt.wantArgs, got)
+			}
+		})
+	}
+}
+
+func TestCategoryRepository_GetDeletedCategoryByID(t *testing.T) {
+	created := "2024-01-02T03:04:05Z"
+	updated := "2024-01-03T04:05:06Z"
+	deleted := "2024-01-04T05:06:07Z"
+	columns := []string{"id", "name", "description", "parent_id", "created_at", "updated_at", "deleted_at", "version"}
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr error
+		want    *entity.ResponseCategory
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{queries: map[string]testQuery{
+				"SELECT id, name, description, parent_id, created_at, updated_at, deleted_at, version FROM categories WHERE id = $1 AND deleted_at IS NOT NULL": {
+					columns: columns,
+					rows:    [][]driver.Value{{int64(2), "book", "paper", nil, created, updated, deleted, int64(6)}},
+				},
+			}},
+			want: &entity.ResponseCategory{
+				ID:          2,
+				Name:        "book",
+				Description: "paper",
+				CreatedAt:   mustParseTime(t, created),
+				UpdatedAt:   mustParseTime(t, updated),
+				DeletedAt:   ptrTime(mustParseTime(t, deleted)),
+				Version:     6,
+			},
+		},
+		{
+			name:    "notfound",
+			cfg:     &testConfig{query: testQuery{columns: columns}},
+			wantErr: errors.New("deleted category not found"),
+		},
+		{
+			name:    "queryerr",
+			cfg:     &testConfig{query: testQuery{queryErr: errors.New("query")}},
+			wantErr: errors.New("query"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewCategoryRepository(db)
+			got, err := repo.GetDeletedCategoryByID(context.Background(), 2)
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if got.ID != tt.want.ID || got.Name != tt.want.Name || got.Description != tt.want.Description || got.Version != tt.want.Version {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			if got.DeletedAt == nil || !got.DeletedAt.Equal(*tt.want.DeletedAt) {
+				t.Fatalf("expected deleted at %v, got %v", tt.want.DeletedAt, got.DeletedAt)
+			}
+			if args := tt.cfg.getLastQueryArgs(); !reflect.DeepEqual(args, []driver.Value{int64(2)}) {
+				t.Fatalf("expected args [2], got %v", args)
+			}
+		})
+	}
+}
+
 func TestCategoryRepository_GetCategoryByID(t *testing.T) {
 	created := "2024-01-02T03:04:05Z"
 	updated := "2024-01-03T04:05:06Z"
@@ -404,9 +906,9 @@ func TestCategoryRepository_GetCategoryByID(t *testing.T) {
 		{
 			name: "ok",
 			cfg: testConfig{query: testQuery{
-				columns: []string{"id", "name", "description", "created_at", "updated_at"},
+				columns: []string{"id", "name", "description", "parent_id", "created_at", "updated_at", "version"},
 				rows: [][]driver.Value{{
-					int64(2), "book", "paper", created, updated,
+					int64(2), "book", "paper", int64(1), created, updated, int64(5),
 				}},
 			}},
 			id: 2,
@@ -414,8 +916,10 @@ func TestCategoryRepository_GetCategoryByID(t *testing.T) {
 				ID:          2,
 				Name:        "book",
 				Description: "paper",
+				ParentID:    ptrInt64(1),
 				CreatedAt:   mustParseTime(t, created),
 				UpdatedAt:   mustParseTime(t, updated),
+				Version:     5,
 			},
 			wantArgs:  []driver.Value{int64(2)},
 			checkArgs: true,
@@ -437,13 +941,13 @@ func TestCategoryRepository_GetCategoryByID(t *testing.T) {
 		},
 	}
 
-	for _, tt := range tests {
-		tt := tt
+	for i := range tests {
+		tt := &tests[i]
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := tt.cfg
-			db := newTestDB(t, &cfg)
+			cfg := &tt.cfg
+			db := newTestDB(t, cfg)
 			repo := NewCategoryRepository(db)
-			got, err := repo.GetCategoryByID(tt.id)
+			got, err := repo.GetCategoryByID(context.Background(), tt.id)
 			if (err == nil) != (tt.wantErr == nil) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
@@ -454,12 +958,15 @@ func TestCategoryRepository_GetCategoryByID(t *testing.T) {
 				if got == nil {
 					t.Fatalf("expected category")
 				}
-				if got.ID != tt.want.ID || got.Name != tt.want.Name || got.Description != tt.want.Description {
+				if got.ID != tt.want.ID || got.Name != tt.want.Name || got.Description != tt.want.Description || got.Version != tt.want.Version {
 					t.Fatalf("expected %+v, got %+v", tt.want, got)
 				}
 				if !got.CreatedAt.Equal(tt.want.CreatedAt) || !got.UpdatedAt.Equal(tt.want.UpdatedAt) {
 					t.Fatalf("expected times %+v, got %+v", tt.want, got)
 				}
+				if !reflect.DeepEqual(got.ParentID, tt.want.ParentID) {
+					t.Fatalf("expected parent %v, got %v", tt.want.ParentID, got.ParentID)
+				}
 			}
 			if tt.checkArgs {
 				if gotArgs := cfg.getLastQueryArgs(); !reflect.DeepEqual(gotArgs, tt.wantArgs) {
@@ -473,52 +980,128 @@ func TestCategoryRepository_GetCategoryByID(t *testing.T) {
 func TestCategoryRepository_GetAllCategories(t *testing.T) {
 	created := "2024-01-02T03:04:05Z"
 	updated := "2024-01-03T04:05:06Z"
+	deleted := "2024-01-04T05:06:07Z"
+	countQuery := "SELECT COUNT(*) FROM categories WHERE deleted_at IS NULL"
+	filteredCountQuery := "SELECT COUNT(*) FROM categories WHERE deleted_at IS NULL AND name ILIKE '%' || $1 || '%' ESCAPE '\\'"
+	listQuery := "SELECT id, name, description, parent_id, created_at, updated_at, deleted_at FROM categories WHERE deleted_at IS NULL ORDER BY id ASC LIMIT $1 OFFSET $2"
+	filteredListQuery := "SELECT id, name, description, parent_id, created_at, updated_at, deleted_at FROM categories WHERE deleted_at IS NULL AND name ILIKE '%' || $1 || '%' ESCAPE '\\' ORDER BY name DESC, id ASC LIMIT $2 OFFSET $3"
+	allCountQuery := "SELECT COUNT(*) FROM categories"
+	allListQuery := "SELECT id, name, description, parent_id, created_at, updated_at, deleted_at FROM categories ORDER BY id ASC LIMIT $1 OFFSET $2"
+	count := func(n int64) testQuery {
+		return testQuery{columns: []string{"count"}, rows: [][]driver.Value{{n}}}
+	}
+	defaultFilter := entity.CategoryFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}}
 	tests := []struct {
 		name      string
+		filter    entity.CategoryFilter
 		cfg       testConfig
 		wantErr   error
 		want      []entity.ResponseCategory
+		wantTotal int
 		wantArgs  []driver.Value
 		checkArgs bool
 	}{
 		{
-			name: "ok",
-			cfg: testConfig{query: testQuery{
-				columns: []string{"id", "name", "description", "created_at", "updated_at"},
-				rows: [][]driver.Value{
-					{int64(1), "a", "one", created, updated},
-					{int64(2), "b", "two", created, updated},
+			name:   "ok",
+			filter: defaultFilter,
+			cfg: testConfig{
+				queries: map[string]testQuery{
+					countQuery: count(2),
+					listQuery: {
+						columns: []string{"id", "name", "description", "parent_id", "created_at", "updated_at", "deleted_at"},
+						rows: [][]driver.Value{
+							{int64(1), "a", "one", nil, created, updated, nil},
+							{int64(2), "b", "two", nil, created, updated, nil},
+						},
+					},
 				},
-			}},
+			},
 			want: []entity.ResponseCategory{
 				{ID: 1, Name: "a", Description: "one", CreatedAt: mustParseTime(t, created), UpdatedAt: mustParseTime(t, updated)},
 				{ID: 2, Name: "b", Description: "two", CreatedAt: mustParseTime(t, created), UpdatedAt: mustParseTime(t, updated)},
 			},
-			wantArgs:  []driver.Value{},
-			checkArgs: false,
+			wantTotal: 2,
+			wantArgs:  []driver.Value{int64(20), int64(0)},
+			checkArgs: true,
+		},
+		{
+			name: "filtered",
+			filter: entity.CategoryFilter{
+				Name:       "mi_",
+				Pagination: pagination.Params{Page: 2, PageSize: 1, Sort: []pagination.Sort{{Field: "name", Desc: true}}},
+			},
+			cfg: testConfig{
+				queries: map[string]testQuery{
+					filteredCountQuery: count(3),
+					filteredListQuery: {
+						columns: []string{"id", "name", "description", "parent_id", "created_at", "updated_at", "deleted_at"},
+						rows:    [][]driver.Value{{int64(4), "Minuman", "drinks", nil, created, updated, nil}},
+					},
+				},
+			},
+			want: []entity.ResponseCategory{
+				{ID: 4, Name: "Minuman", Description: "drinks", CreatedAt: mustParseTime(t, created), UpdatedAt: mustParseTime(t, updated)},
+			},
+			wantTotal: 3,
+			wantArgs:  []driver.Value{`mi\_`, int64(1), int64(1)},
+			checkArgs: true,
 		},
 		{
-			name: "empty",
-			cfg: testConfig{query: testQuery{
-				columns: []string{"id", "name", "description", "created_at", "updated_at"},
-				rows:    [][]driver.Value{},
-			}},
+			name:   "include-deleted",
+			filter: entity.CategoryFilter{IncludeDeleted: true, Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			cfg: testConfig{
+				queries: map[string]testQuery{
+					allCountQuery: count(2),
+					allListQuery: {
+						columns: []string{"id", "name", "description", "parent_id", "created_at", "updated_at", "deleted_at"},
+						rows: [][]driver.Value{
+							{int64(1), "a", "one", nil, created, updated, nil},
+							{int64(2), "b", "two", nil, created, updated, deleted},
+						},
+					},
+				},
+			},
+			want: []entity.ResponseCategory{
+				{ID: 1, Name: "a", Description: "one", CreatedAt: mustParseTime(t, created), UpdatedAt: mustParseTime(t, updated)},
+				{ID: 2, Name: "b", Description: "two", CreatedAt: mustParseTime(t, created), UpdatedAt: mustParseTime(t, updated), DeletedAt: ptrTime(mustParseTime(t, deleted))},
+			},
+			wantTotal: 2,
+			wantArgs:  []driver.Value{int64(20), int64(0)},
+			checkArgs: true,
+		},
+		{
+			name:   "empty",
+			filter: defaultFilter,
+			cfg: testConfig{
+				query: testQuery{
+					columns: []string{"id", "name", "description", "parent_id", "created_at", "updated_at", "deleted_at"},
+					rows:    [][]driver.Value{},
+				},
+				queries: map[string]testQuery{countQuery: count(0)},
+			},
 			want: nil,
 		},
+		{
+			name:    "counterr",
+			filter:  defaultFilter,
+			cfg:     testConfig{queries: map[string]testQuery{countQuery: {queryErr: errors.New("count")}}},
+			wantErr: errors.New("count"),
+		},
 		{
 			name:    "queryerr",
-			cfg:     testConfig{query: testQuery{queryErr: errors.New("query")}},
+			filter:  defaultFilter,
+			cfg:     testConfig{query: testQuery{queryErr: errors.New("query")}, queries: map[string]testQuery{countQuery: count(2)}},
 			wantErr: errors.New("query"),
 		},
 	}
 
-	for _, tt := range tests {
-		tt := tt
+	for i := range tests {
+		tt := &tests[i]
 		t.Run(tt.name, func(t *testing.T) {
-			cfg := tt.cfg
-			db := newTestDB(t, &cfg)
+			cfg := &tt.cfg
+			db := newTestDB(t, cfg)
 			repo := NewCategoryRepository(db)
-			got, err := repo.GetAllCategories()
+			got, total, err := repo.GetAllCategories(context.Background(), tt.filter)
 			if (err == nil) != (tt.wantErr == nil) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
@@ -526,6 +1109,9 @@ func TestCategoryRepository_GetAllCategories(t *testing.T) {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
 			if tt.wantErr == nil {
+				if total != tt.wantTotal {
+					t.Fatalf("expected total %d, got %d", tt.wantTotal, total)
+				}
 				if len(got) != len(tt.want) {
 					t.Fatalf("expected len %d, got %d", len(tt.want), len(got))
 				}
@@ -536,6 +1122,9 @@ func TestCategoryRepository_GetAllCategories(t *testing.T) {
 					if !got[i].CreatedAt.Equal(tt.want[i].CreatedAt) || !got[i].UpdatedAt.Equal(tt.want[i].UpdatedAt) {
 						t.Fatalf("expected times %+v, got %+v", tt.want[i], got[i])
 					}
+					if (got[i].DeletedAt == nil) != (tt.want[i].DeletedAt == nil) || got[i].DeletedAt != nil && !got[i].DeletedAt.Equal(*tt.want[i].DeletedAt) {
+						t.Fatalf("expected deleted at %v, got %v", tt.want[i].DeletedAt, got[i].DeletedAt)
+					}
 				}
 			}
 			if tt.checkArgs {
@@ -546,3 +1135,163 @@ func TestCategoryRepository_GetAllCategories(t *testing.T) {
 		})
 	}
 }
+
+func TestCategoryRepository_GetCategoryStats(t *testing.T) {
+	tests := []struct {
+		name     string
+		ids      []int64
+		query    testQuery
+		want     map[int64]entity.CategoryStats
+		wantErr  error
+		wantArgs []driver.Value
+	}{
+		{
+			name: "ok",
+			ids:  []int64{3, 4},
+			query: testQuery{
+				columns: []string{"category_id", "count", "total_stock_value"},
+				rows:    [][]driver.Value{{int64(3), int64(2), int64(15000)}},
+			},
+			want:     map[int64]entity.CategoryStats{3: {ProductCount: 2, TotalStockValue: 15000}},
+			wantArgs: []driver.Value{int64(3), int64(4)},
+		},
+		{
+			name: "no-ids",
+			want: map[int64]entity.CategoryStats{},
+		},
+		{
+			name:    "queryerr",
+			ids:     []int64{3},
+			query:   testQuery{queryErr: errors.New("query")},
+			wantErr: errors.New("query"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := "WITH RECURSIVE descendants (category_id, id) AS (SELECT id, id FROM categories WHERE id IN ($1, $2) UNION SELECT descendants.category_id, categories.id FROM categories JOIN descendants ON categories.parent_id = descendants.id WHERE categories.deleted_at IS NULL) SELECT descendants.category_id, COUNT(*), COALESCE(SUM(products.price::bigint * products.stock), 0) FROM descendants JOIN products ON products.category_id = descendants.id WHERE products.deleted_at IS NULL GROUP BY descendants.category_id"
+			if len(tt.ids) == 1 {
+				query = "WITH RECURSIVE descendants (category_id, id) AS (SELECT id, id FROM categories WHERE id IN ($1) UNION SELECT descendants.category_id, categories.id FROM categories JOIN descendants ON categories.parent_id = descendants.id WHERE categories.deleted_at IS NULL) SELECT descendants.category_id, COUNT(*), COALESCE(SUM(products.price::bigint * products.stock), 0) FROM descendants JOIN products ON products.category_id = descendants.id WHERE products.deleted_at IS NULL GROUP BY descendants.category_id"
+			}
+			cfg := &testConfig{queries: map[string]testQuery{query: tt.query}, query: testQuery{queryErr: errors.New("unexpected query")}}
+			db := newTestDB(t, cfg)
+			repo := NewCategoryRepository(db)
+			got, err := repo.GetCategoryStats(context.Background(), tt.ids)
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected stats %+v, got %+v", tt.want, got)
+			}
+			if got := cfg.getLastQueryArgs(); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Fatalf("expected args %v, got %v", tt.wantArgs, got)
+			}
+		})
+	}
+}
+
+func TestCategoryRepository_GetCategoryAncestorIDs(t *testing.T) {
+	query := "WITH RECURSIVE ancestors (id, parent_id, depth, path) AS (SELECT id, parent_id, 0, ARRAY[id] FROM categories WHERE id = $1 UNION ALL SELECT categories.id, categories.parent_id, ancestors.depth + 1, ancestors.path || categories.id FROM categories JOIN ancestors ON categories.id = ancestors.parent_id WHERE NOT categories.id = ANY(ancestors.path)) SELECT id FROM ancestors ORDER BY depth"
+	tests := []struct {
+		name    string
+		query   testQuery
+		want    []int64
+		wantErr error
+	}{
+		{
+			name:  "ok",
+			query: testQuery{columns: []string{"id"}, rows: [][]driver.Value{{int64(3)}, {int64(2)}, {int64(1)}}},
+			want:  []int64{3, 2, 1},
+		},
+		{
+			name:  "missing",
+			query: testQuery{columns: []string{"id"}},
+		},
+		{
+			name:    "queryerr",
+			query:   testQuery{queryErr: errors.New("query")},
+			wantErr: errors.New("query"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{queries: map[string]testQuery{query: tt.query}, query: testQuery{queryErr: errors.New("unexpected query")}}
+			db := newTestDB(t, cfg)
+			repo := NewCategoryRepository(db)
+			got, err := repo.GetCategoryAncestorIDs(context.Background(), 3)
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected ids %v, got %v", tt.want, got)
+			}
+			if got := cfg.getLastQueryArgs(); !reflect.DeepEqual(got, []driver.Value{int64(3)}) {
+				t.Fatalf("expected args [3], got %v", got)
+			}
+		})
+	}
+}
+
+func TestCategoryRepository_GetCategoryTree(t *testing.T) {
+	query := "WITH RECURSIVE tree (id, name, description, parent_id, depth) AS (SELECT id, name, description, parent_id, 0 FROM categories WHERE parent_id IS NULL AND deleted_at IS NULL UNION ALL SELECT categories.id, categories.name, categories.description, categories.parent_id, tree.depth + 1 FROM categories JOIN tree ON categories.parent_id = tree.id WHERE categories.deleted_at IS NULL) SELECT id, name, description, parent_id FROM tree ORDER BY depth, name, id"
+	columns := []string{"id", "name", "description", "parent_id"}
+	tests := []struct {
+		name    string
+		query   testQuery
+		want    []entity.Category
+		wantErr error
+	}{
+		{
+			name: "ok",
+			query: testQuery{columns: columns, rows: [][]driver.Value{
+				{int64(1), "Makanan & Minuman", "", nil},
+				{int64(2), "Minuman", "", int64(1)},
+				{int64(3), "Susu", "", int64(2)},
+			}},
+			want: []entity.Category{
+				{ID: 1, Name: "Makanan & Minuman"},
+				{ID: 2, Name: "Minuman", ParentID: ptrInt64(1)},
+				{ID: 3, Name: "Susu", ParentID: ptrInt64(2)},
+			},
+		},
+		{
+			name:    "queryerr",
+			query:   testQuery{queryErr: errors.New("query")},
+			wantErr: errors.New("query"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{queries: map[string]testQuery{query: tt.query}, query: testQuery{queryErr: errors.New("unexpected query")}}
+			db := newTestDB(t, cfg)
+			repo := NewCategoryRepository(db)
+			got, err := repo.GetCategoryTree(context.Background())
+			if (err == nil) != (tt.wantErr == nil) {
+				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr != nil {
+				if err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected categories %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
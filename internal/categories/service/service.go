@@ -1,70 +1,327 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
 )
 
 type categoryService struct {
 	categoryRepository repository.CategoryRepository
+	ready              func(ctx context.Context) error
 }
 
 type CategoryService interface {
-	CreateCategory(requestCategory *entity.RequestCategory) error
-	UpdateCategory(id int64, requestCategory *entity.RequestCategory) error
-	DeleteCategory(id int64) error
-	GetCategoryByID(id int64) (*entity.ResponseCategory, error)
-	GetAllCategories() ([]entity.ResponseCategory, error)
-	API() entity.HealthCheck
+	CreateCategory(ctx context.Context, actor audit.Actor, requestCategory *entity.RequestCategory) error
+	UpdateCategory(ctx context.Context, actor audit.Actor, id int64, version int64, requestCategory *entity.RequestCategory) error
+	PatchCategory(ctx context.Context, actor audit.Actor, id int64, version int64, patch *entity.PatchCategory) error
+	DeleteCategory(ctx context.Context, actor audit.Actor, id int64, version int64, options entity.DeleteCategoryOptions) error
+	RestoreCategory(ctx context.Context, actor audit.Actor, id int64) error
+	GetCategoryByID(ctx context.Context, id int64, withStats bool) (*entity.ResponseCategory, error)
+	GetAllCategories(ctx context.Context, filter entity.CategoryFilter) ([]entity.ResponseCategory, *pagination.Meta, error)
+	GetCategoryTree(ctx context.Context) ([]entity.ResponseCategoryTree, error)
+	API(ctx context.Context) entity.HealthCheck
 }
 
-func NewCategoryService(categoryRepository repository.CategoryRepository) CategoryService {
-	return &categoryService{categoryRepository: categoryRepository}
+// NewCategoryService returns a CategoryService. ready runs the readiness checks whose result API reports.
+func NewCategoryService(categoryRepository repository.CategoryRepository, ready func(ctx context.Context) error) CategoryService {
+	return &categoryService{categoryRepository: categoryRepository, ready: ready}
 }
 
-func (s *categoryService) API() entity.HealthCheck {
+// API reports the Categories API healthy when the readiness checks of its dependencies pass.
+func (s *categoryService) API(ctx context.Context) entity.HealthCheck {
 	return entity.HealthCheck{
 		Name:      "Categories API",
-		IsHealthy: true,
+		IsHealthy: s.ready(ctx) == nil,
 	}
 }
 
-func (s *categoryService) CreateCategory(requestCategory *entity.RequestCategory) error {
+// CreateCategory creates the category and records actor as its creator in the audit log.
+func (s *categoryService) CreateCategory(ctx context.Context, actor audit.Actor, requestCategory *entity.RequestCategory) error {
+	if err := requestCategory.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.validateParent(ctx, 0, requestCategory.ParentID); err != nil {
+		return err
+	}
+
 	category := &entity.Category{
 		Name:        requestCategory.Name,
 		Description: requestCategory.Description,
+		ParentID:    requestCategory.ParentID,
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionCreate,
+		EntityType: audit.EntityCategory,
+		After:      *requestCategory,
 	}
-	return s.categoryRepository.CreateCategory(category)
+
+	return s.categoryRepository.CreateCategory(ctx, category, entry)
 }
 
-func (s *categoryService) UpdateCategory(id int64, requestCategory *entity.RequestCategory) error {
-	_, err := s.categoryRepository.GetCategoryByID(id)
+// UpdateCategory updates the category and records the change made by actor in the audit log. A version other than zero
+// must be the current version of the category, as sent in If-Match, or nothing is updated. The category cannot be
+// moved under itself or one of its subcategories.
+func (s *categoryService) UpdateCategory(ctx context.Context, actor audit.Actor, id int64, version int64, requestCategory *entity.RequestCategory) error {
+	current, err := s.categoryRepository.GetCategoryByID(ctx, id)
 	if err != nil {
-		return errors.New("category not found")
+		return err
+	}
+
+	if err = requestCategory.Validate(); err != nil {
+		return err
+	}
+
+	// A request without parent_id keeps the current parent, PATCH with a null parent_id makes a category top-level.
+	after := *requestCategory
+	if after.ParentID == nil {
+		after.ParentID = current.ParentID
+	} else if err = s.validateParent(ctx, id, after.ParentID); err != nil {
+		return err
 	}
 
 	category := &entity.Category{
-		Name:        requestCategory.Name,
-		Description: requestCategory.Description,
+		Name:        after.Name,
+		Description: after.Description,
+		ParentID:    after.ParentID,
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityCategory,
+		EntityID:   id,
+		Before:     categorySnapshot(current),
+		After:      after,
+	}
+
+	return s.categoryRepository.UpdateCategory(ctx, id, version, category, entry)
+}
+
+// PatchCategory changes only the members present in patch and records the change made by actor in the audit log.
+func (s *categoryService) PatchCategory(ctx context.Context, actor audit.Actor, id int64, version int64, patch *entity.PatchCategory) error {
+	current, err := s.categoryRepository.GetCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = patch.Validate(); err != nil {
+		return err
+	}
+
+	before := categorySnapshot(current)
+	after := before
+	patch.Apply(&after)
+
+	if err = after.Validate(); err != nil {
+		return err
+	}
+
+	if patch.ParentID.Set {
+		if err = s.validateParent(ctx, id, after.ParentID); err != nil {
+			return err
+		}
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityCategory,
+		EntityID:   id,
+		Before:     before,
+		After:      after,
 	}
-	return s.categoryRepository.UpdateCategory(id, category)
+
+	return s.categoryRepository.PatchCategory(ctx, id, version, patch, entry)
 }
 
-func (s *categoryService) DeleteCategory(id int64) error {
-	_, err := s.categoryRepository.GetCategoryByID(id)
+// DeleteCategory deletes the category and records actor and the deleted state in the audit log. Its products are
+// handled as options tell: they either keep the category from being deleted or are moved to the target category
+// together with the deletion.
+func (s *categoryService) DeleteCategory(ctx context.Context, actor audit.Actor, id int64, version int64, options entity.DeleteCategoryOptions) error {
+	current, err := s.categoryRepository.GetCategoryByID(ctx, id)
 	if err != nil {
-		return errors.New("category not found")
+		return err
+	}
+
+	if err = options.Validate(id); err != nil {
+		return err
 	}
 
-	return s.categoryRepository.DeleteCategory(id)
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionDelete,
+		EntityType: audit.EntityCategory,
+		EntityID:   id,
+		Before:     categorySnapshot(current),
+	}
+
+	return s.categoryRepository.DeleteCategory(ctx, id, version, options.TargetCategoryID, entry)
 }
 
-func (s *categoryService) GetCategoryByID(id int64) (*entity.ResponseCategory, error) {
-	return s.categoryRepository.GetCategoryByID(id)
+// RestoreCategory brings back a deleted category and records actor and the restored state in the audit log. It fails
+// with a conflict when the parent of the category has been deleted.
+func (s *categoryService) RestoreCategory(ctx context.Context, actor audit.Actor, id int64) error {
+	deleted, err := s.categoryRepository.GetDeletedCategoryByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if deleted.ParentID != nil {
+		_, err = s.categoryRepository.GetCategoryByID(ctx, *deleted.ParentID)
+		if errors.Is(err, apperror.ErrNotFound) {
+			return apperror.Conflict("parent category of the category has been deleted")
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionRestore,
+		EntityType: audit.EntityCategory,
+		EntityID:   id,
+		After:      categorySnapshot(deleted),
+	}
+
+	return s.categoryRepository.RestoreCategory(ctx, id, entry)
 }
 
-func (s *categoryService) GetAllCategories() ([]entity.ResponseCategory, error) {
-	return s.categoryRepository.GetAllCategories()
+// GetCategoryByID returns the category, with the stats of its products when withStats is set.
+func (s *categoryService) GetCategoryByID(ctx context.Context, id int64, withStats bool) (*entity.ResponseCategory, error) {
+	category, err := s.categoryRepository.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if withStats {
+		categories := []entity.ResponseCategory{*category}
+		if err = s.addStats(ctx, categories); err != nil {
+			return nil, err
+		}
+		category = &categories[0]
+	}
+
+	return category, nil
+}
+
+func (s *categoryService) GetAllCategories(ctx context.Context, filter entity.CategoryFilter) ([]entity.ResponseCategory, *pagination.Meta, error) {
+	categories, total, err := s.categoryRepository.GetAllCategories(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if filter.WithStats {
+		if err = s.addStats(ctx, categories); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return categories, pagination.NewMeta(filter.Pagination, total), nil
+}
+
+// GetCategoryTree returns the top-level categories with their subcategories nested in them.
+func (s *categoryService) GetCategoryTree(ctx context.Context) ([]entity.ResponseCategoryTree, error) {
+	categories, err := s.categoryRepository.GetCategoryTree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []entity.Category
+	children := make(map[int64][]entity.Category)
+	for _, category := range categories {
+		if category.ParentID == nil {
+			roots = append(roots, category)
+			continue
+		}
+
+		children[*category.ParentID] = append(children[*category.ParentID], category)
+	}
+
+	return categoryTree(roots, children), nil
+}
+
+// categoryTree nests the children of every category in it, keeping the order of categories.
+func categoryTree(categories []entity.Category, children map[int64][]entity.Category) []entity.ResponseCategoryTree {
+	tree := make([]entity.ResponseCategoryTree, 0, len(categories))
+	for _, category := range categories {
+		tree = append(tree, entity.ResponseCategoryTree{
+			ID:          category.ID,
+			Name:        category.Name,
+			Description: category.Description,
+			Children:    categoryTree(children[category.ID], children),
+		})
+	}
+
+	return tree
+}
+
+// validateParent makes sure the parent a category request refers to exists and is neither category id itself nor one
+// of its subcategories, which would make a cycle. id is zero for a new category.
+func (s *categoryService) validateParent(ctx context.Context, id int64, parentID *int64) error {
+	if parentID == nil {
+		return nil
+	}
+
+	_, err := s.categoryRepository.GetCategoryByID(ctx, *parentID)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Invalid(apperror.FieldError{Field: "parent_id", Message: "parent category not found"})
+	}
+
+	if err != nil || id == 0 {
+		return err
+	}
+
+	ancestorIDs, err := s.categoryRepository.GetCategoryAncestorIDs(ctx, *parentID)
+	if err != nil {
+		return err
+	}
+
+	for _, ancestorID := range ancestorIDs {
+		if ancestorID == id {
+			return apperror.Invalid(apperror.FieldError{Field: "parent_id", Message: "parent category must not be the category itself or one of its subcategories"})
+		}
+	}
+
+	return nil
+}
+
+// addStats sets the product count and stock value of every category, zero for a category without products.
+func (s *categoryService) addStats(ctx context.Context, categories []entity.ResponseCategory) error {
+	ids := make([]int64, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+
+	stats, err := s.categoryRepository.GetCategoryStats(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range categories {
+		stat := stats[categories[i].ID]
+		categories[i].ProductCount = &stat.ProductCount
+		categories[i].TotalStockValue = &stat.TotalStockValue
+	}
+
+	return nil
+}
+
+// categorySnapshot returns the editable fields of a category, the state recorded in the audit log.
+func categorySnapshot(category *entity.ResponseCategory) entity.RequestCategory {
+	return entity.RequestCategory{
+		Name:        category.Name,
+		Description: category.Description,
+		ParentID:    category.ParentID,
+	}
 }
@@ -1,56 +1,115 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/categories/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
 )
 
 type mockCategoryRepository struct {
-	createFunc  func(*entity.Category) error
-	updateFunc  func(int64, *entity.Category) error
-	deleteFunc  func(int64) error
-	getByIDFunc func(int64) (*entity.ResponseCategory, error)
-	getAllFunc  func() ([]entity.ResponseCategory, error)
+	createFunc     func(*entity.Category, *audit.Entry) error
+	updateFunc     func(int64, *entity.Category, *audit.Entry) error
+	patchFunc      func(int64, *entity.PatchCategory, *audit.Entry) error
+	deleteFunc     func(int64, int64, *audit.Entry) error
+	restoreFunc    func(int64, *audit.Entry) error
+	getByIDFunc    func(int64) (*entity.ResponseCategory, error)
+	getDeletedFunc func(int64) (*entity.ResponseCategory, error)
+	getAllFunc     func(entity.CategoryFilter) ([]entity.ResponseCategory, int, error)
+	getStatsFunc   func([]int64) (map[int64]entity.CategoryStats, error)
+	ancestorsFunc  func(int64) ([]int64, error)
+	getTreeFunc    func() ([]entity.Category, error)
+
+	version int64
 }
 
-func (m *mockCategoryRepository) CreateCategory(category *entity.Category) error {
+func (m *mockCategoryRepository) CreateCategory(ctx context.Context, category *entity.Category, entry *audit.Entry) error {
 	if m.createFunc == nil {
 		return errors.New("not implemented")
 	}
-	return m.createFunc(category)
+	return m.createFunc(category, entry)
 }
 
-func (m *mockCategoryRepository) UpdateCategory(id int64, category *entity.Category) error {
+func (m *mockCategoryRepository) UpdateCategory(ctx context.Context, id int64, version int64, category *entity.Category, entry *audit.Entry) error {
+	m.version = version
 	if m.updateFunc == nil {
 		return errors.New("not implemented")
 	}
-	return m.updateFunc(id, category)
+	return m.updateFunc(id, category, entry)
 }
 
-func (m *mockCategoryRepository) DeleteCategory(id int64) error {
+func (m *mockCategoryRepository) PatchCategory(ctx context.Context, id int64, version int64, patch *entity.PatchCategory, entry *audit.Entry) error {
+	m.version = version
+	if m.patchFunc == nil {
+		return errors.New("not implemented")
+	}
+	return m.patchFunc(id, patch, entry)
+}
+
+func (m *mockCategoryRepository) DeleteCategory(ctx context.Context, id int64, version int64, targetID int64, entry *audit.Entry) error {
+	m.version = version
 	if m.deleteFunc == nil {
 		return errors.New("not implemented")
 	}
-	return m.deleteFunc(id)
+	return m.deleteFunc(id, targetID, entry)
+}
+
+func (m *mockCategoryRepository) RestoreCategory(ctx context.Context, id int64, entry *audit.Entry) error {
+	if m.restoreFunc == nil {
+		return errors.New("not implemented")
+	}
+	return m.restoreFunc(id, entry)
 }
 
-func (m *mockCategoryRepository) GetCategoryByID(id int64) (*entity.ResponseCategory, error) {
+func (m *mockCategoryRepository) GetDeletedCategoryByID(ctx context.Context, id int64) (*entity.ResponseCategory, error) {
+	if m.getDeletedFunc == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.getDeletedFunc(id)
+}
+
+func (m *mockCategoryRepository) GetCategoryByID(ctx context.Context, id int64) (*entity.ResponseCategory, error) {
 	if m.getByIDFunc == nil {
 		return nil, errors.New("not implemented")
 	}
 	return m.getByIDFunc(id)
 }
 
-func (m *mockCategoryRepository) GetAllCategories() ([]entity.ResponseCategory, error) {
+func (m *mockCategoryRepository) GetAllCategories(ctx context.Context, filter entity.CategoryFilter) ([]entity.ResponseCategory, int, error) {
 	if m.getAllFunc == nil {
+		return nil, 0, errors.New("not implemented")
+	}
+	return m.getAllFunc(filter)
+}
+
+func (m *mockCategoryRepository) GetCategoryStats(ctx context.Context, ids []int64) (map[int64]entity.CategoryStats, error) {
+	if m.getStatsFunc == nil {
 		return nil, errors.New("not implemented")
 	}
-	return m.getAllFunc()
+	return m.getStatsFunc(ids)
+}
+
+func (m *mockCategoryRepository) GetCategoryAncestorIDs(ctx context.Context, id int64) ([]int64, error) {
+	if m.ancestorsFunc == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.ancestorsFunc(id)
+}
+
+func (m *mockCategoryRepository) GetCategoryTree(ctx context.Context) ([]entity.Category, error) {
+	if m.getTreeFunc == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.getTreeFunc()
 }
 
 var _ repository.CategoryRepository = (*mockCategoryRepository)(nil)
@@ -65,7 +124,7 @@ func TestNewCategoryService(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &mockCategoryRepository{}
-			svc := NewCategoryService(repo)
+			svc := NewCategoryService(repo, func(context.Context) error { return nil })
 			if svc == nil {
 				t.Fatal("expected non-nil service")
 			}
@@ -79,20 +138,19 @@ func TestNewCategoryService(t *testing.T) {
 func TestCategoryServiceAPI(t *testing.T) {
 	tests := []struct {
 		name string
-		want entity.HealthCheck
+		err  error
+		want bool
 	}{
-		{
-			name: "ok",
-			want: entity.HealthCheck{Name: "Categories API", IsHealthy: true},
-		},
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := &categoryService{}
-			got := svc.API()
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			svc := &categoryService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Categories API" || got.IsHealthy != tt.want {
+				t.Fatalf("unexpected healthcheck: %+v", got)
 			}
 		})
 	}
@@ -100,34 +158,45 @@ func TestCategoryServiceAPI(t *testing.T) {
 
 func TestCategoryServiceCreateCategory(t *testing.T) {
 	req := &entity.RequestCategory{Name: "Food", Description: "Daily"}
+	actor := audit.Actor{ID: 1, Username: "admin"}
 	repoErr := errors.New("repo error")
 
 	tests := []struct {
 		name    string
+		req     *entity.RequestCategory
 		err     error
 		wantErr string
 	}{
-		{name: "ok"},
-		{name: "err", err: repoErr, wantErr: repoErr.Error()},
+		{name: "ok", req: req},
+		{name: "err", req: req, err: repoErr, wantErr: repoErr.Error()},
+		{name: "blank-name", req: &entity.RequestCategory{Name: " ", Description: "Daily"}, wantErr: "name is required"},
+		{name: "long-name", req: &entity.RequestCategory{Name: strings.Repeat("a", 256)}, wantErr: "name must be at most 255 characters"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var gotCategory *entity.Category
+			var (
+				gotCategory *entity.Category
+				gotEntry    *audit.Entry
+			)
 			called := false
 			repo := &mockCategoryRepository{
-				createFunc: func(category *entity.Category) error {
+				createFunc: func(category *entity.Category, entry *audit.Entry) error {
 					called = true
 					gotCategory = category
+					gotEntry = entry
 					return tt.err
 				},
 			}
 			svc := &categoryService{categoryRepository: repo}
-			err := svc.CreateCategory(req)
+			err := svc.CreateCategory(context.Background(), actor, tt.req)
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
 					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
 				}
+				if tt.err == nil && called {
+					t.Fatal("expected an invalid category not to be created")
+				}
 				return
 			}
 			if err != nil {
@@ -142,23 +211,40 @@ func TestCategoryServiceCreateCategory(t *testing.T) {
 			if gotCategory.Name != req.Name || gotCategory.Description != req.Description {
 				t.Fatalf("expected category %+v, got %+v", *req, *gotCategory)
 			}
+			wantEntry := &audit.Entry{Actor: actor, Action: audit.ActionCreate, EntityType: audit.EntityCategory, After: *req}
+			if !reflect.DeepEqual(gotEntry, wantEntry) {
+				t.Fatalf("expected audit entry %+v, got %+v", wantEntry, gotEntry)
+			}
 		})
 	}
 }
 
 func TestCategoryServiceUpdateCategory(t *testing.T) {
-	req := &entity.RequestCategory{Name: "Books", Description: "Reading"}
-	missingErr := errors.New("missing")
+	actor := audit.Actor{ID: 1, Username: "admin"}
+	missingErr := apperror.NotFound("category not found")
+	parentID, otherParentID := int64(2), int64(4)
 
 	tests := []struct {
-		name       string
-		getErr     error
-		updateErr  error
-		wantErr    string
-		wantUpdate bool
+		name          string
+		req           *entity.RequestCategory
+		currentParent *int64
+		getErr        error
+		updateErr     error
+		wantErr       string
+		wantUpdate    bool
+		wantParent    *int64
 	}{
 		{name: "missing", getErr: missingErr, wantErr: "category not found"},
+		{name: "get-err", getErr: errors.New("db down"), wantErr: "db down"},
 		{name: "ok", wantUpdate: true},
+		{name: "keeps-parent", currentParent: &parentID, wantUpdate: true, wantParent: &parentID},
+		{
+			name:          "moves-parent",
+			req:           &entity.RequestCategory{Name: "Books", Description: "Reading", ParentID: &otherParentID},
+			currentParent: &parentID,
+			wantUpdate:    true,
+			wantParent:    &otherParentID,
+		},
 	}
 
 	for _, tt := range tests {
@@ -168,25 +254,37 @@ func TestCategoryServiceUpdateCategory(t *testing.T) {
 				gotUpdate   bool
 				gotUpdateID int64
 				gotCategory *entity.Category
+				gotEntry    *audit.Entry
 			)
+			req := tt.req
+			if req == nil {
+				req = &entity.RequestCategory{Name: "Books", Description: "Reading"}
+			}
 			repo := &mockCategoryRepository{
 				getByIDFunc: func(id int64) (*entity.ResponseCategory, error) {
+					if id != 7 {
+						return &entity.ResponseCategory{ID: id}, nil
+					}
 					gotGetID = id
 					if tt.getErr != nil {
 						return nil, tt.getErr
 					}
-					return &entity.ResponseCategory{ID: id}, nil
+					return &entity.ResponseCategory{ID: id, Name: "Book", Description: "Reading", ParentID: tt.currentParent}, nil
 				},
-				updateFunc: func(id int64, category *entity.Category) error {
+				ancestorsFunc: func(id int64) ([]int64, error) {
+					return []int64{id}, nil
+				},
+				updateFunc: func(id int64, category *entity.Category, entry *audit.Entry) error {
 					gotUpdate = true
 					gotUpdateID = id
 					gotCategory = category
+					gotEntry = entry
 					return tt.updateErr
 				},
 			}
 
 			svc := &categoryService{categoryRepository: repo}
-			err := svc.UpdateCategory(7, req)
+			err := svc.UpdateCategory(context.Background(), actor, 7, 3, req)
 			if gotGetID != 7 {
 				t.Fatalf("expected GetCategoryByID id 7, got %d", gotGetID)
 			}
@@ -208,28 +306,119 @@ func TestCategoryServiceUpdateCategory(t *testing.T) {
 			if gotUpdateID != 7 {
 				t.Fatalf("expected UpdateCategory id 7, got %d", gotUpdateID)
 			}
+			if repo.version != 3 {
+				t.Fatalf("expected UpdateCategory version 3, got %d", repo.version)
+			}
 			if gotCategory == nil {
 				t.Fatal("expected category to be passed")
 			}
-			if gotCategory.Name != req.Name || gotCategory.Description != req.Description {
-				t.Fatalf("expected category %+v, got %+v", *req, *gotCategory)
+			if gotCategory.Name != req.Name || gotCategory.Description != req.Description || !reflect.DeepEqual(gotCategory.ParentID, tt.wantParent) {
+				t.Fatalf("expected category %+v with parent %v, got %+v", *req, tt.wantParent, *gotCategory)
+			}
+			wantEntry := &audit.Entry{
+				Actor:      actor,
+				Action:     audit.ActionUpdate,
+				EntityType: audit.EntityCategory,
+				EntityID:   7,
+				Before:     entity.RequestCategory{Name: "Book", Description: "Reading", ParentID: tt.currentParent},
+				After:      entity.RequestCategory{Name: req.Name, Description: req.Description, ParentID: tt.wantParent},
+			}
+			if !reflect.DeepEqual(gotEntry, wantEntry) {
+				t.Fatalf("expected audit entry %+v, got %+v", wantEntry, gotEntry)
+			}
+		})
+	}
+}
+
+func TestCategoryServicePatchCategory(t *testing.T) {
+	actor := audit.Actor{ID: 1, Username: "admin"}
+	before := entity.RequestCategory{Name: "Book", Description: "Reading"}
+
+	tests := []struct {
+		name      string
+		patch     *entity.PatchCategory
+		getErr    error
+		patchErr  error
+		wantErr   string
+		wantAfter entity.RequestCategory
+	}{
+		{name: "missing", patch: &entity.PatchCategory{Name: patch.Of("Books")}, getErr: apperror.NotFound("category not found"), wantErr: "category not found"},
+		{name: "null-name", patch: &entity.PatchCategory{Name: patch.Field[string]{Set: true, Null: true}}, wantErr: "name must not be null"},
+		{name: "blank-name", patch: &entity.PatchCategory{Name: patch.Of(" ")}, wantErr: "name is required"},
+		{name: "patch-err", patch: &entity.PatchCategory{Name: patch.Of("Books")}, patchErr: errors.New("db down"), wantErr: "db down"},
+		{name: "name-only", patch: &entity.PatchCategory{Name: patch.Of("Books")}, wantAfter: entity.RequestCategory{Name: "Books", Description: "Reading"}},
+		{name: "clear-description", patch: &entity.PatchCategory{Description: patch.Field[string]{Set: true, Null: true}}, wantAfter: entity.RequestCategory{Name: "Book"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotPatchID int64
+				gotPatch   *entity.PatchCategory
+				gotEntry   *audit.Entry
+			)
+			repo := &mockCategoryRepository{
+				getByIDFunc: func(id int64) (*entity.ResponseCategory, error) {
+					if tt.getErr != nil {
+						return nil, tt.getErr
+					}
+					return &entity.ResponseCategory{ID: id, Name: "Book", Description: "Reading"}, nil
+				},
+				patchFunc: func(id int64, p *entity.PatchCategory, entry *audit.Entry) error {
+					gotPatchID = id
+					gotPatch = p
+					gotEntry = entry
+					return tt.patchErr
+				},
+			}
+
+			svc := &categoryService{categoryRepository: repo}
+			err := svc.PatchCategory(context.Background(), actor, 7, 3, tt.patch)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if tt.patchErr == nil && gotPatch != nil {
+					t.Fatal("did not expect PatchCategory to be called")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotPatchID != 7 || repo.version != 3 || gotPatch != tt.patch {
+				t.Fatalf("expected PatchCategory(7, 3, %+v), got (%d, %d, %+v)", tt.patch, gotPatchID, repo.version, gotPatch)
+			}
+			wantEntry := &audit.Entry{Actor: actor, Action: audit.ActionUpdate, EntityType: audit.EntityCategory, EntityID: 7, Before: before, After: tt.wantAfter}
+			if !reflect.DeepEqual(gotEntry, wantEntry) {
+				t.Fatalf("expected audit entry %+v, got %+v", wantEntry, gotEntry)
 			}
 		})
 	}
 }
 
 func TestCategoryServiceDeleteCategory(t *testing.T) {
-	missingErr := errors.New("missing")
+	missingErr := apperror.NotFound("category not found")
+
+	reject := entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject}
 
 	tests := []struct {
-		name       string
-		getErr     error
-		deleteErr  error
-		wantErr    string
-		wantDelete bool
+		name         string
+		options      entity.DeleteCategoryOptions
+		getErr       error
+		deleteErr    error
+		wantErr      string
+		wantDelete   bool
+		wantTargetID int64
 	}{
-		{name: "missing", getErr: missingErr, wantErr: "category not found"},
-		{name: "ok", wantDelete: true},
+		{name: "missing", options: reject, getErr: missingErr, wantErr: "category not found"},
+		{name: "get-err", options: reject, getErr: errors.New("db down"), wantErr: "db down"},
+		{name: "ok", options: reject, wantDelete: true},
+		{name: "reassign", options: entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReassign, TargetCategoryID: 3}, wantDelete: true, wantTargetID: 3},
+		{name: "unknown-strategy", options: entity.DeleteCategoryOptions{Strategy: "cascade"}, wantErr: "strategy must be reject or reassign"},
+		{name: "reassign-without-target", options: entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReassign}, wantErr: "target_category_id is required to reassign products"},
+		{name: "reassign-to-itself", options: entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReassign, TargetCategoryID: 9}, wantErr: "target_category_id must be another category"},
+		{name: "reject-with-target", options: entity.DeleteCategoryOptions{Strategy: entity.DeleteStrategyReject, TargetCategoryID: 3}, wantErr: "target_category_id is only used to reassign products"},
 	}
 
 	for _, tt := range tests {
@@ -238,6 +427,8 @@ func TestCategoryServiceDeleteCategory(t *testing.T) {
 				gotGetID    int64
 				gotDelete   bool
 				gotDeleteID int64
+				gotTargetID int64
+				gotEntry    *audit.Entry
 			)
 			repo := &mockCategoryRepository{
 				getByIDFunc: func(id int64) (*entity.ResponseCategory, error) {
@@ -245,17 +436,19 @@ func TestCategoryServiceDeleteCategory(t *testing.T) {
 					if tt.getErr != nil {
 						return nil, tt.getErr
 					}
-					return &entity.ResponseCategory{ID: id}, nil
+					return &entity.ResponseCategory{ID: id, Name: "Toys"}, nil
 				},
-				deleteFunc: func(id int64) error {
+				deleteFunc: func(id int64, targetID int64, entry *audit.Entry) error {
 					gotDelete = true
 					gotDeleteID = id
+					gotTargetID = targetID
+					gotEntry = entry
 					return tt.deleteErr
 				},
 			}
 
 			svc := &categoryService{categoryRepository: repo}
-			err := svc.DeleteCategory(9)
+			err := svc.DeleteCategory(context.Background(), audit.System, 9, 2, tt.options)
 			if gotGetID != 9 {
 				t.Fatalf("expected GetCategoryByID id 9, got %d", gotGetID)
 			}
@@ -277,6 +470,264 @@ func TestCategoryServiceDeleteCategory(t *testing.T) {
 			if gotDeleteID != 9 {
 				t.Fatalf("expected DeleteCategory id 9, got %d", gotDeleteID)
 			}
+			if repo.version != 2 {
+				t.Fatalf("expected DeleteCategory version 2, got %d", repo.version)
+			}
+			if gotTargetID != tt.wantTargetID {
+				t.Fatalf("expected DeleteCategory target %d, got %d", tt.wantTargetID, gotTargetID)
+			}
+			wantEntry := &audit.Entry{Actor: audit.System, Action: audit.ActionDelete, EntityType: audit.EntityCategory, EntityID: 9, Before: entity.RequestCategory{Name: "Toys"}}
+			if !reflect.DeepEqual(gotEntry, wantEntry) {
+				t.Fatalf("expected audit entry %+v, got %+v", wantEntry, gotEntry)
+			}
+		})
+	}
+}
+
+func TestCategoryServiceRestoreCategory(t *testing.T) {
+	parentID := int64(2)
+
+	tests := []struct {
+		name        string
+		parentID    *int64
+		getErr      error
+		parentErr   error
+		restoreErr  error
+		wantErr     string
+		wantRestore bool
+	}{
+		{name: "not-deleted", getErr: apperror.NotFound("deleted category not found"), wantErr: "deleted category not found"},
+		{name: "restore-err", restoreErr: errors.New("db down"), wantErr: "db down", wantRestore: true},
+		{name: "ok", wantRestore: true},
+		{name: "parent-deleted", parentID: &parentID, parentErr: apperror.NotFound("category not found"), wantErr: "parent category of the category has been deleted"},
+		{name: "parent-err", parentID: &parentID, parentErr: errors.New("db down"), wantErr: "db down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotRestore bool
+				gotEntry   *audit.Entry
+			)
+			repo := &mockCategoryRepository{
+				getDeletedFunc: func(id int64) (*entity.ResponseCategory, error) {
+					if tt.getErr != nil {
+						return nil, tt.getErr
+					}
+					return &entity.ResponseCategory{ID: id, Name: "Toys", Description: "fun", ParentID: tt.parentID}, nil
+				},
+				getByIDFunc: func(id int64) (*entity.ResponseCategory, error) {
+					if id != parentID {
+						t.Fatalf("expected parent %d to be checked, got %d", parentID, id)
+					}
+					return nil, tt.parentErr
+				},
+				restoreFunc: func(id int64, entry *audit.Entry) error {
+					gotRestore = id == 9
+					gotEntry = entry
+					return tt.restoreErr
+				},
+			}
+
+			svc := &categoryService{categoryRepository: repo}
+			err := svc.RestoreCategory(context.Background(), audit.System, 9)
+			if gotRestore != tt.wantRestore {
+				t.Fatalf("expected restore of category 9 %v, got %v", tt.wantRestore, gotRestore)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			wantEntry := &audit.Entry{Actor: audit.System, Action: audit.ActionRestore, EntityType: audit.EntityCategory, EntityID: 9, After: entity.RequestCategory{Name: "Toys", Description: "fun"}}
+			if !reflect.DeepEqual(gotEntry, wantEntry) {
+				t.Fatalf("expected audit entry %+v, got %+v", wantEntry, gotEntry)
+			}
+		})
+	}
+}
+
+func TestCategoryServiceParent(t *testing.T) {
+	ptr := func(id int64) *int64 { return &id }
+	ancestors := map[int64][]int64{2: {2, 1}, 7: {7, 1}, 9: {9, 8, 7, 1}}
+
+	tests := []struct {
+		name       string
+		call       func(svc *categoryService) error
+		wantErr    string
+		wantParent *int64
+	}{
+		{
+			name: "create",
+			call: func(svc *categoryService) error {
+				return svc.CreateCategory(context.Background(), audit.System, &entity.RequestCategory{Name: "Susu", ParentID: ptr(2)})
+			},
+			wantParent: ptr(2),
+		},
+		{
+			name: "create-missing-parent",
+			call: func(svc *categoryService) error {
+				return svc.CreateCategory(context.Background(), audit.System, &entity.RequestCategory{Name: "Susu", ParentID: ptr(5)})
+			},
+			wantErr: "parent category not found",
+		},
+		{
+			name: "create-bad-parent",
+			call: func(svc *categoryService) error {
+				return svc.CreateCategory(context.Background(), audit.System, &entity.RequestCategory{Name: "Susu", ParentID: ptr(0)})
+			},
+			wantErr: "parent_id must be a positive integer",
+		},
+		{
+			name: "update",
+			call: func(svc *categoryService) error {
+				return svc.UpdateCategory(context.Background(), audit.System, 7, 0, &entity.RequestCategory{Name: "Susu", ParentID: ptr(2)})
+			},
+			wantParent: ptr(2),
+		},
+		{
+			name: "update-under-itself",
+			call: func(svc *categoryService) error {
+				return svc.UpdateCategory(context.Background(), audit.System, 7, 0, &entity.RequestCategory{Name: "Susu", ParentID: ptr(7)})
+			},
+			wantErr: "parent category must not be the category itself or one of its subcategories",
+		},
+		{
+			name: "update-under-descendant",
+			call: func(svc *categoryService) error {
+				return svc.UpdateCategory(context.Background(), audit.System, 7, 0, &entity.RequestCategory{Name: "Susu", ParentID: ptr(9)})
+			},
+			wantErr: "parent category must not be the category itself or one of its subcategories",
+		},
+		{
+			name: "update-missing-parent",
+			call: func(svc *categoryService) error {
+				return svc.UpdateCategory(context.Background(), audit.System, 7, 0, &entity.RequestCategory{Name: "Susu", ParentID: ptr(5)})
+			},
+			wantErr: "parent category not found",
+		},
+		{
+			name: "patch-under-descendant",
+			call: func(svc *categoryService) error {
+				return svc.PatchCategory(context.Background(), audit.System, 7, 0, &entity.PatchCategory{ParentID: patch.Of(ptr(9))})
+			},
+			wantErr: "parent category must not be the category itself or one of its subcategories",
+		},
+		{
+			name: "patch-top-level",
+			call: func(svc *categoryService) error {
+				return svc.PatchCategory(context.Background(), audit.System, 7, 0, &entity.PatchCategory{ParentID: patch.Field[*int64]{Set: true, Null: true}})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				written   bool
+				gotParent *int64
+			)
+			repo := &mockCategoryRepository{
+				getByIDFunc: func(id int64) (*entity.ResponseCategory, error) {
+					if _, ok := ancestors[id]; !ok {
+						return nil, apperror.NotFound("category not found")
+					}
+					return &entity.ResponseCategory{ID: id, Name: "Minuman"}, nil
+				},
+				ancestorsFunc: func(id int64) ([]int64, error) {
+					return ancestors[id], nil
+				},
+				createFunc: func(category *entity.Category, _ *audit.Entry) error {
+					written, gotParent = true, category.ParentID
+					return nil
+				},
+				updateFunc: func(_ int64, category *entity.Category, _ *audit.Entry) error {
+					written, gotParent = true, category.ParentID
+					return nil
+				},
+				patchFunc: func(_ int64, p *entity.PatchCategory, _ *audit.Entry) error {
+					written, gotParent = true, p.ParentID.Value
+					return nil
+				},
+			}
+
+			err := tt.call(&categoryService{categoryRepository: repo})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if written {
+					t.Fatal("did not expect the category to be written")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !written || !reflect.DeepEqual(gotParent, tt.wantParent) {
+				t.Fatalf("expected parent %v to be written, got %v (written %v)", tt.wantParent, gotParent, written)
+			}
+		})
+	}
+}
+
+func TestCategoryServiceGetCategoryTree(t *testing.T) {
+	ptr := func(id int64) *int64 { return &id }
+
+	tests := []struct {
+		name    string
+		rows    []entity.Category
+		err     error
+		want    []entity.ResponseCategoryTree
+		wantErr string
+	}{
+		{
+			name: "nested",
+			rows: []entity.Category{
+				{ID: 1, Name: "Makanan & Minuman"},
+				{ID: 4, Name: "Rumah Tangga"},
+				{ID: 2, Name: "Minuman", ParentID: ptr(1)},
+				{ID: 3, Name: "Susu", ParentID: ptr(2)},
+			},
+			want: []entity.ResponseCategoryTree{
+				{ID: 1, Name: "Makanan & Minuman", Children: []entity.ResponseCategoryTree{
+					{ID: 2, Name: "Minuman", Children: []entity.ResponseCategoryTree{
+						{ID: 3, Name: "Susu", Children: []entity.ResponseCategoryTree{}},
+					}},
+				}},
+				{ID: 4, Name: "Rumah Tangga", Children: []entity.ResponseCategoryTree{}},
+			},
+		},
+		{name: "empty", want: []entity.ResponseCategoryTree{}},
+		{name: "err", err: errors.New("db down"), wantErr: "db down"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockCategoryRepository{
+				getTreeFunc: func() ([]entity.Category, error) {
+					return tt.rows, tt.err
+				},
+			}
+
+			svc := &categoryService{categoryRepository: repo}
+			got, err := svc.GetCategoryTree(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected tree %+v, got %+v", tt.want, got)
+			}
 		})
 	}
 }
@@ -315,7 +766,7 @@ func TestCategoryServiceGetCategoryByID(t *testing.T) {
 			}
 
 			svc := &categoryService{categoryRepository: repo}
-			got, err := svc.GetCategoryByID(3)
+			got, err := svc.GetCategoryByID(context.Background(), 3, false)
 			if gotID != 3 {
 				t.Fatalf("expected GetCategoryByID id 3, got %d", gotID)
 			}
@@ -338,6 +789,66 @@ func TestCategoryServiceGetCategoryByID(t *testing.T) {
 	}
 }
 
+func TestCategoryServiceCategoryStats(t *testing.T) {
+	statsErr := errors.New("stats error")
+	count, value := 2, int64(15000)
+	zeroCount, zeroValue := 0, int64(0)
+
+	tests := []struct {
+		name     string
+		statsErr error
+		wantErr  string
+	}{
+		{name: "ok"},
+		{name: "err", statsErr: statsErr, wantErr: statsErr.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotIDs [][]int64
+			repo := &mockCategoryRepository{
+				getByIDFunc: func(id int64) (*entity.ResponseCategory, error) {
+					return &entity.ResponseCategory{ID: id, Name: "Susu"}, nil
+				},
+				getAllFunc: func(entity.CategoryFilter) ([]entity.ResponseCategory, int, error) {
+					return []entity.ResponseCategory{{ID: 3, Name: "Susu"}, {ID: 4, Name: "Teh"}}, 2, nil
+				},
+				getStatsFunc: func(ids []int64) (map[int64]entity.CategoryStats, error) {
+					gotIDs = append(gotIDs, ids)
+					if tt.statsErr != nil {
+						return nil, tt.statsErr
+					}
+					return map[int64]entity.CategoryStats{3: {ProductCount: count, TotalStockValue: value}}, nil
+				},
+			}
+			svc := &categoryService{categoryRepository: repo}
+
+			category, err := svc.GetCategoryByID(context.Background(), 3, true)
+			list, _, listErr := svc.GetAllCategories(context.Background(), entity.CategoryFilter{WithStats: true, Pagination: pagination.Params{Page: 1, PageSize: 10}})
+			if !reflect.DeepEqual(gotIDs, [][]int64{{3}, {3, 4}}) {
+				t.Fatalf("unexpected stats ids: %v", gotIDs)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr || listErr == nil || listErr.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v and %v", tt.wantErr, err, listErr)
+				}
+				return
+			}
+			if err != nil || listErr != nil {
+				t.Fatalf("unexpected error: %v %v", err, listErr)
+			}
+			want := entity.ResponseCategory{ID: 3, Name: "Susu", ProductCount: &count, TotalStockValue: &value}
+			if !reflect.DeepEqual(*category, want) {
+				t.Fatalf("expected category %+v, got %+v", want, *category)
+			}
+			wantList := []entity.ResponseCategory{want, {ID: 4, Name: "Teh", ProductCount: &zeroCount, TotalStockValue: &zeroValue}}
+			if !reflect.DeepEqual(list, wantList) {
+				t.Fatalf("expected categories %+v, got %+v", wantList, list)
+			}
+		})
+	}
+}
+
 func TestCategoryServiceGetAllCategories(t *testing.T) {
 	resp := []entity.ResponseCategory{
 		{
@@ -370,23 +881,27 @@ func TestCategoryServiceGetAllCategories(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			filter := entity.CategoryFilter{Name: "a", Pagination: pagination.Params{Page: 1, PageSize: 10}}
 			repo := &mockCategoryRepository{
-				getAllFunc: func() ([]entity.ResponseCategory, error) {
+				getAllFunc: func(got entity.CategoryFilter) ([]entity.ResponseCategory, int, error) {
+					if !reflect.DeepEqual(got, filter) {
+						t.Fatalf("expected filter %+v, got %+v", filter, got)
+					}
 					if tt.err != nil {
-						return nil, tt.err
+						return nil, 0, tt.err
 					}
-					return tt.resp, nil
+					return tt.resp, len(tt.resp), nil
 				},
 			}
 
 			svc := &categoryService{categoryRepository: repo}
-			got, err := svc.GetAllCategories()
+			got, meta, err := svc.GetAllCategories(context.Background(), filter)
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
 					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
 				}
-				if got != nil {
-					t.Fatalf("expected nil categories, got %+v", got)
+				if got != nil || meta != nil {
+					t.Fatalf("expected nil categories, got %+v %+v", got, meta)
 				}
 				return
 			}
@@ -396,6 +911,9 @@ func TestCategoryServiceGetAllCategories(t *testing.T) {
 			if !reflect.DeepEqual(got, tt.resp) {
 				t.Fatalf("expected response %+v, got %+v", tt.resp, got)
 			}
+			if !reflect.DeepEqual(meta, pagination.NewMeta(filter.Pagination, len(tt.resp))) {
+				t.Fatalf("unexpected meta: %+v", meta)
+			}
 		})
 	}
 }
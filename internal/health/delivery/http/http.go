@@ -6,6 +6,7 @@ import (
 	"strconv"
 
 	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/service"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
 )
@@ -54,7 +55,7 @@ func (h *HealthHandler) API(w http.ResponseWriter, r *http.Request) {
 // @Router /api/health/db [get]
 func (h *HealthHandler) DB(w http.ResponseWriter, r *http.Request) {
 	var result response.APIResponse
-	svcHealthCheckResult, err := h.service.DB()
+	svcHealthCheckResult, err := h.service.DB(r.Context())
 	if svcHealthCheckResult.IsHealthy && err == nil {
 		result.Code = strconv.Itoa(constants.SuccessCode)
 		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
@@ -67,3 +68,38 @@ func (h *HealthHandler) DB(w http.ResponseWriter, r *http.Request) {
 	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
 	return
 }
+
+// HealthCheckLive godoc
+// @Summary Liveness probe
+// @Description Reports that the process is up. It checks no dependency, so it stays up while the database is down.
+// @Tags healthcheck
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/health/live [get]
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Kasir API is alive", h.service.Live())
+}
+
+// HealthCheckReady godoc
+// @Summary Readiness probe
+// @Description Runs every dependency check (database, migrations, connection pool) and returns the status and latency of each. Responds 503 when any check is down.
+// @Tags healthcheck
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]interface{}
+// @Router /api/health/ready [get]
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	probe := h.service.Ready(r.Context())
+	if probe.Status == entity.StatusUp {
+		response.Success(w, http.StatusOK, constants.SuccessCode, "Kasir API is ready", probe)
+		return
+	}
+
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, response.APIResponse{
+		Code:    strconv.Itoa(constants.ErrorCode),
+		Message: "Kasir API is not ready",
+		Data:    probe,
+	})
+}
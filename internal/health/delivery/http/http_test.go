@@ -1,10 +1,12 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/entity"
@@ -12,19 +14,32 @@ import (
 )
 
 type mockHealthService struct {
-	apiResult entity.HealthCheck
-	dbResult  entity.HealthCheck
-	dbErr     error
+	apiResult   entity.HealthCheck
+	dbResult    entity.HealthCheck
+	dbErr       error
+	readyResult *entity.Probe
 }
 
 func (m mockHealthService) API() entity.HealthCheck {
 	return m.apiResult
 }
 
-func (m mockHealthService) DB() (entity.HealthCheck, error) {
+func (m mockHealthService) DB(ctx context.Context) (entity.HealthCheck, error) {
 	return m.dbResult, m.dbErr
 }
 
+func (m mockHealthService) Live() entity.Probe {
+	return entity.Probe{Status: entity.StatusUp}
+}
+
+func (m mockHealthService) Ready(ctx context.Context) entity.Probe {
+	return *m.readyResult
+}
+
+func (m mockHealthService) Check(ctx context.Context) error {
+	return nil
+}
+
 func TestNewHealthHandler(t *testing.T) {
 	svc := mockHealthService{}
 	h := NewHealthHandler(svc)
@@ -194,6 +209,86 @@ func TestHealthHandlerDB(t *testing.T) {
 	}
 }
 
+func TestHealthHandlerLive(t *testing.T) {
+	h := NewHealthHandler(mockHealthService{})
+	w := httptest.NewRecorder()
+	h.Live(w, httptest.NewRequest(http.MethodGet, "/api/health/live", nil))
+
+	resp := w.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	body := decodeAPIResponse(t, resp)
+	if body.Code != "1000" || body.Message != "Kasir API is alive" {
+		t.Fatalf("unexpected body: %+v", body)
+	}
+	if data, ok := body.Data.(map[string]any); !ok || data["status"] != entity.StatusUp {
+		t.Fatalf("unexpected data: %v", body.Data)
+	}
+}
+
+func TestHealthHandlerReady(t *testing.T) {
+	cases := []struct {
+		name        string
+		probe       entity.Probe
+		wantStatus  int
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name: "ready",
+			probe: entity.Probe{Status: entity.StatusUp, Checks: []entity.CheckResult{
+				{Name: "database", Status: entity.StatusUp, LatencyMs: 1.5},
+			}},
+			wantStatus:  http.StatusOK,
+			wantCode:    "1000",
+			wantMessage: "Kasir API is ready",
+		},
+		{
+			name: "not-ready",
+			probe: entity.Probe{Status: entity.StatusDown, Checks: []entity.CheckResult{
+				{Name: "database", Status: entity.StatusUp, LatencyMs: 1.5},
+				{Name: "migrations", Status: entity.StatusDown, LatencyMs: 2, Error: "pending migrations: 5"},
+			}},
+			wantStatus:  http.StatusServiceUnavailable,
+			wantCode:    "2000",
+			wantMessage: "Kasir API is not ready",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewHealthHandler(mockHealthService{readyResult: &tc.probe})
+			w := httptest.NewRecorder()
+			h.Ready(w, httptest.NewRequest(http.MethodGet, "/api/health/ready", nil))
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.wantStatus)
+			}
+
+			var body struct {
+				Code    string       `json:"code"`
+				Message string       `json:"message"`
+				Data    entity.Probe `json:"data"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if body.Code != tc.wantCode || body.Message != tc.wantMessage {
+				t.Fatalf("code/message = %q/%q, want %q/%q", body.Code, body.Message, tc.wantCode, tc.wantMessage)
+			}
+			if !reflect.DeepEqual(body.Data, tc.probe) {
+				t.Fatalf("data = %+v, want %+v", body.Data, tc.probe)
+			}
+		})
+	}
+}
+
 func decodeAPIResponse(t *testing.T, resp *http.Response) response.APIResponse {
 	t.Helper()
 	var body response.APIResponse
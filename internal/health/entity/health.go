@@ -4,3 +4,23 @@ type HealthCheck struct {
 	Name      string `json:"name"`
 	IsHealthy bool   `json:"is_healthy"`
 }
+
+// Statuses of a probe and of each of its checks.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Probe is the answer of the liveness or readiness probe. Status is up only when every check is up.
+type Probe struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
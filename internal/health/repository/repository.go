@@ -1,6 +1,9 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
 )
 
@@ -9,18 +12,55 @@ type healthRepository struct {
 }
 
 type HealthRepository interface {
-	DB() error
+	DB(ctx context.Context) error
+	AppliedMigrations(ctx context.Context) ([]int64, error)
+	PoolStats() sql.DBStats
 }
 
 func NewHealthRepository(db *database.DB) HealthRepository {
 	return &healthRepository{db: db}
 }
 
-func (h *healthRepository) DB() error {
-	err := h.db.DB.Ping()
+func (h *healthRepository) DB(ctx context.Context) error {
+	err := h.db.DB.PingContext(ctx)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// AppliedMigrations returns the versions recorded in schema_migrations, in ascending order.
+func (h *healthRepository) AppliedMigrations(ctx context.Context) ([]int64, error) {
+	var (
+		query    string
+		versions []int64
+		err      error
+	)
+
+	query = "SELECT version FROM schema_migrations ORDER BY version ASC"
+
+	err = h.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var version int64
+
+			if err := rows.Scan(&version); err != nil {
+				return err
+			}
+
+			versions = append(versions, version)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+// PoolStats returns the statistics of the database connection pool.
+func (h *healthRepository) PoolStats() sql.DBStats {
+	return h.db.DB.Stats()
+}
@@ -6,6 +6,8 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"io"
+	"reflect"
 	"sync/atomic"
 	"testing"
 
@@ -13,21 +15,63 @@ import (
 )
 
 type pingDriver struct {
-	pingErr error
+	pingErr  error
+	versions []int64
+	queryErr error
 }
 
 type pingConn struct {
-	pingErr error
+	pingErr  error
+	versions []int64
+	queryErr error
 }
 
 func (d *pingDriver) Open(name string) (driver.Conn, error) {
-	return &pingConn{pingErr: d.pingErr}, nil
+	return &pingConn{pingErr: d.pingErr, versions: d.versions, queryErr: d.queryErr}, nil
 }
 
 func (c *pingConn) Prepare(query string) (driver.Stmt, error) {
+	if query != "SELECT version FROM schema_migrations ORDER BY version ASC" {
+		return nil, errors.New("not implemented")
+	}
+	return &versionStmt{conn: c}, nil
+}
+
+type versionStmt struct {
+	conn *pingConn
+}
+
+func (s *versionStmt) Close() error  { return nil }
+func (s *versionStmt) NumInput() int { return 0 }
+
+func (s *versionStmt) Exec([]driver.Value) (driver.Result, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (s *versionStmt) Query([]driver.Value) (driver.Rows, error) {
+	if s.conn.queryErr != nil {
+		return nil, s.conn.queryErr
+	}
+	return &versionRows{versions: s.conn.versions}, nil
+}
+
+type versionRows struct {
+	versions []int64
+	idx      int
+}
+
+func (r *versionRows) Columns() []string { return []string{"version"} }
+func (r *versionRows) Close() error      { return nil }
+
+func (r *versionRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.versions) {
+		return io.EOF
+	}
+	dest[0] = r.versions[r.idx]
+	r.idx++
+	return nil
+}
+
 func (c *pingConn) Close() error {
 	return nil
 }
@@ -43,9 +87,14 @@ func (c *pingConn) Ping(ctx context.Context) error {
 var driverCounter uint64
 
 func newTestDB(t *testing.T, pingErr error) *database.DB {
+	t.Helper()
+	return newTestDBWithDriver(t, &pingDriver{pingErr: pingErr})
+}
+
+func newTestDBWithDriver(t *testing.T, d *pingDriver) *database.DB {
 	t.Helper()
 	name := fmt.Sprintf("ping-driver-%d", atomic.AddUint64(&driverCounter, 1))
-	sql.Register(name, &pingDriver{pingErr: pingErr})
+	sql.Register(name, d)
 	sqlDB, err := sql.Open(name, "")
 	if err != nil {
 		t.Fatalf("open db: %v", err)
@@ -95,7 +144,7 @@ func TestHealthRepositoryDB(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			repo := &healthRepository{db: newTestDB(t, tc.pingErr)}
-			err := repo.DB()
+			err := repo.DB(context.Background())
 			if tc.wantErr {
 				if err == nil {
 					t.Fatalf("expected error")
@@ -108,3 +157,45 @@ func TestHealthRepositoryDB(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthRepositoryAppliedMigrations(t *testing.T) {
+	cases := []struct {
+		name    string
+		driver  *pingDriver
+		want    []int64
+		wantErr bool
+	}{
+		{name: "none", driver: &pingDriver{}},
+		{name: "ok", driver: &pingDriver{versions: []int64{1, 2, 5}}, want: []int64{1, 2, 5}},
+		{name: "err", driver: &pingDriver{queryErr: errors.New("relation schema_migrations does not exist")}, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &healthRepository{db: newTestDBWithDriver(t, tc.driver)}
+			got, err := repo.AppliedMigrations(context.Background())
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("versions = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHealthRepositoryPoolStats(t *testing.T) {
+	db := newTestDB(t, nil)
+	db.SetMaxOpenConns(7)
+	repo := &healthRepository{db: db}
+
+	if got := repo.PoolStats().MaxOpenConnections; got != 7 {
+		t.Fatalf("max open connections = %d, want 7", got)
+	}
+}
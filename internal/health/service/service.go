@@ -1,21 +1,46 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/repository"
 )
 
+// DefaultCheckTimeout bounds each readiness check when no timeout is configured.
+const DefaultCheckTimeout = 2 * time.Second
+
+// Checker is one dependency checked by the readiness probe. Check returns nil when the dependency is usable.
+type Checker struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
 type healthService struct {
 	healthRepository repository.HealthRepository
+	checkers         []Checker
+	timeout          time.Duration
 }
 
 type HealthService interface {
 	API() entity.HealthCheck
-	DB() (entity.HealthCheck, error)
+	DB(ctx context.Context) (entity.HealthCheck, error)
+	Live() entity.Probe
+	Ready(ctx context.Context) entity.Probe
+	Check(ctx context.Context) error
 }
 
-func NewHealthService(healthRepo repository.HealthRepository) HealthService {
-	return &healthService{healthRepository: healthRepo}
+// NewHealthService returns a HealthService whose readiness probe runs checkers, each bounded by timeout. A timeout of
+// zero uses DefaultCheckTimeout.
+func NewHealthService(healthRepo repository.HealthRepository, timeout time.Duration, checkers ...Checker) HealthService {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	return &healthService{healthRepository: healthRepo, checkers: checkers, timeout: timeout}
 }
 
 func (h *healthService) API() entity.HealthCheck {
@@ -26,8 +51,8 @@ func (h *healthService) API() entity.HealthCheck {
 	}
 }
 
-func (h *healthService) DB() (entity.HealthCheck, error) {
-	err := h.healthRepository.DB()
+func (h *healthService) DB(ctx context.Context) (entity.HealthCheck, error) {
+	err := h.healthRepository.DB(ctx)
 	if err != nil {
 		return entity.HealthCheck{}, err
 	}
@@ -37,3 +62,132 @@ func (h *healthService) DB() (entity.HealthCheck, error) {
 		IsHealthy: true,
 	}, nil
 }
+
+// Live reports that the process is up and serving requests. It checks no dependency, so a database outage does not
+// get the service restarted.
+func (h *healthService) Live() entity.Probe {
+	return entity.Probe{Status: entity.StatusUp}
+}
+
+// Ready runs every checker concurrently and reports each result with its latency. The service is ready only when every
+// check passes within the timeout.
+func (h *healthService) Ready(ctx context.Context) entity.Probe {
+	results := make([]entity.CheckResult, len(h.checkers))
+	done := make(chan struct{}, len(h.checkers))
+
+	for i, checker := range h.checkers {
+		go func() {
+			results[i] = h.run(ctx, checker)
+			done <- struct{}{}
+		}()
+	}
+
+	for range h.checkers {
+		<-done
+	}
+
+	probe := entity.Probe{Status: entity.StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status != entity.StatusUp {
+			probe.Status = entity.StatusDown
+		}
+	}
+
+	return probe
+}
+
+// Check runs the readiness checks, as Ready does, and returns the error of the first failing one, or nil when all
+// pass. The health endpoints of the other modules report it.
+func (h *healthService) Check(ctx context.Context) error {
+	for _, result := range h.Ready(ctx).Checks {
+		if result.Status != entity.StatusUp {
+			return fmt.Errorf("%s: %s", result.Name, result.Error)
+		}
+	}
+
+	return nil
+}
+
+// run runs one checker and stops waiting for it once the timeout elapses, even when the check ignores its context.
+func (h *healthService) run(ctx context.Context, checker Checker) entity.CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	began := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- checker.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := entity.CheckResult{
+		Name:      checker.Name,
+		Status:    entity.StatusUp,
+		LatencyMs: float64(time.Since(began).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = entity.StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// DatabaseChecker pings the database.
+func DatabaseChecker(healthRepo repository.HealthRepository) Checker {
+	return Checker{Name: "database", Check: healthRepo.DB}
+}
+
+// MigrationsChecker fails while any of versions has not been applied to the database yet.
+func MigrationsChecker(healthRepo repository.HealthRepository, versions []int64) Checker {
+	return Checker{
+		Name: "migrations",
+		Check: func(ctx context.Context) error {
+			var pending []string
+
+			applied, err := healthRepo.AppliedMigrations(ctx)
+			if err != nil {
+				return err
+			}
+
+			done := make(map[int64]bool, len(applied))
+			for _, version := range applied {
+				done[version] = true
+			}
+
+			for _, version := range versions {
+				if !done[version] {
+					pending = append(pending, fmt.Sprint(version))
+				}
+			}
+
+			if len(pending) > 0 {
+				return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+			}
+
+			return nil
+		},
+	}
+}
+
+// PoolChecker fails when every connection of a bounded connection pool is in use, so new queries have to wait for one.
+func PoolChecker(healthRepo repository.HealthRepository) Checker {
+	return Checker{
+		Name: "connection_pool",
+		Check: func(ctx context.Context) error {
+			stats := healthRepo.PoolStats()
+			if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+				return fmt.Errorf("connection pool saturated: %d of %d connections in use, %d waits", stats.InUse, stats.MaxOpenConnections, stats.WaitCount)
+			}
+
+			return nil
+		},
+	}
+}
@@ -1,20 +1,35 @@
 package service
 
 import (
+	"context"
+	"database/sql"
 	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/health/entity"
 )
 
 type stubHealthRepository struct {
-	err error
+	err           error
+	migrations    []int64
+	migrationsErr error
+	stats         sql.DBStats
 }
 
-func (s stubHealthRepository) DB() error {
+func (s stubHealthRepository) DB(ctx context.Context) error {
 	return s.err
 }
 
+func (s stubHealthRepository) AppliedMigrations(ctx context.Context) ([]int64, error) {
+	return s.migrations, s.migrationsErr
+}
+
+func (s stubHealthRepository) PoolStats() sql.DBStats {
+	return s.stats
+}
+
 func TestHealthServiceAPI(t *testing.T) {
 	tests := []struct {
 		name string
@@ -66,7 +81,7 @@ func TestHealthServiceDB(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			svc := &healthService{healthRepository: stubHealthRepository{err: tt.repoErr}}
-			got, err := svc.DB()
+			got, err := svc.DB(context.Background())
 			if !errors.Is(err, tt.wantErr) {
 				t.Fatalf("DB() error = %v, want %v", err, tt.wantErr)
 			}
@@ -79,18 +94,186 @@ func TestHealthServiceDB(t *testing.T) {
 
 func TestNewHealthService(t *testing.T) {
 	tests := []struct {
-		name string
-		repo stubHealthRepository
+		name        string
+		repo        stubHealthRepository
+		timeout     time.Duration
+		wantTimeout time.Duration
 	}{
-		{name: "ok"},
+		{name: "ok", timeout: time.Second, wantTimeout: time.Second},
+		{name: "default-timeout", wantTimeout: DefaultCheckTimeout},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := NewHealthService(tt.repo)
+			svc := NewHealthService(tt.repo, tt.timeout, DatabaseChecker(tt.repo))
 			if svc == nil {
 				t.Fatal("NewHealthService() = nil")
 			}
+			hs := svc.(*healthService)
+			if hs.timeout != tt.wantTimeout || len(hs.checkers) != 1 {
+				t.Fatalf("timeout = %v, checkers = %d", hs.timeout, len(hs.checkers))
+			}
+		})
+	}
+}
+
+func TestHealthServiceLive(t *testing.T) {
+	svc := &healthService{checkers: []Checker{{Name: "down", Check: func(context.Context) error { return errors.New("down") }}}}
+	if got := svc.Live(); !reflect.DeepEqual(got, entity.Probe{Status: entity.StatusUp}) {
+		t.Fatalf("Live() = %+v", got)
+	}
+}
+
+func TestHealthServiceReady(t *testing.T) {
+	up := Checker{Name: "up", Check: func(context.Context) error { return nil }}
+	down := Checker{Name: "down", Check: func(context.Context) error { return errors.New("boom") }}
+	stuck := Checker{Name: "stuck", Check: func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+
+	tests := []struct {
+		name       string
+		checkers   []Checker
+		wantStatus string
+		wantChecks []entity.CheckResult
+	}{
+		{name: "no-checkers", wantStatus: entity.StatusUp, wantChecks: []entity.CheckResult{}},
+		{
+			name:       "all-up",
+			checkers:   []Checker{up, up},
+			wantStatus: entity.StatusUp,
+			wantChecks: []entity.CheckResult{{Name: "up", Status: entity.StatusUp}, {Name: "up", Status: entity.StatusUp}},
+		},
+		{
+			name:       "one-down",
+			checkers:   []Checker{up, down},
+			wantStatus: entity.StatusDown,
+			wantChecks: []entity.CheckResult{{Name: "up", Status: entity.StatusUp}, {Name: "down", Status: entity.StatusDown, Error: "boom"}},
+		},
+		{
+			name:       "timeout",
+			checkers:   []Checker{stuck, up},
+			wantStatus: entity.StatusDown,
+			wantChecks: []entity.CheckResult{{Name: "stuck", Status: entity.StatusDown, Error: context.DeadlineExceeded.Error()}, {Name: "up", Status: entity.StatusUp}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &healthService{checkers: tt.checkers, timeout: 20 * time.Millisecond}
+			began := time.Now()
+			got := svc.Ready(context.Background())
+			if elapsed := time.Since(began); elapsed > 500*time.Millisecond {
+				t.Fatalf("Ready() took %v, want it bounded by the timeout", elapsed)
+			}
+			if got.Status != tt.wantStatus {
+				t.Fatalf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			for i := range got.Checks {
+				if got.Checks[i].LatencyMs < 0 {
+					t.Fatalf("negative latency: %+v", got.Checks[i])
+				}
+				got.Checks[i].LatencyMs = 0
+			}
+			if !reflect.DeepEqual(got.Checks, tt.wantChecks) {
+				t.Fatalf("checks = %+v, want %+v", got.Checks, tt.wantChecks)
+			}
+		})
+	}
+}
+
+func TestHealthServiceCheck(t *testing.T) {
+	up := Checker{Name: "up", Check: func(context.Context) error { return nil }}
+	down := Checker{Name: "database", Check: func(context.Context) error { return errors.New("boom") }}
+
+	tests := []struct {
+		name     string
+		checkers []Checker
+		wantErr  string
+	}{
+		{name: "no-checkers"},
+		{name: "all-up", checkers: []Checker{up, up}},
+		{name: "one-down", checkers: []Checker{up, down}, wantErr: "database: boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &healthService{checkers: tt.checkers, timeout: 20 * time.Millisecond}
+			err := svc.Check(context.Background())
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabaseChecker(t *testing.T) {
+	errBoom := errors.New("boom")
+	checker := DatabaseChecker(stubHealthRepository{err: errBoom})
+	if checker.Name != "database" {
+		t.Fatalf("name = %q", checker.Name)
+	}
+	if err := checker.Check(context.Background()); !errors.Is(err, errBoom) {
+		t.Fatalf("Check() = %v, want %v", err, errBoom)
+	}
+}
+
+func TestMigrationsChecker(t *testing.T) {
+	errBoom := errors.New("relation schema_migrations does not exist")
+
+	tests := []struct {
+		name    string
+		repo    stubHealthRepository
+		wantErr string
+	}{
+		{name: "all-applied", repo: stubHealthRepository{migrations: []int64{1, 2, 3}}},
+		{name: "pending", repo: stubHealthRepository{migrations: []int64{1}}, wantErr: "pending migrations: 2, 3"},
+		{name: "repo-err", repo: stubHealthRepository{migrationsErr: errBoom}, wantErr: errBoom.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := MigrationsChecker(tt.repo, []int64{1, 2, 3}).Check(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Check() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoolChecker(t *testing.T) {
+	tests := []struct {
+		name    string
+		stats   sql.DBStats
+		wantErr string
+	}{
+		{name: "unbounded", stats: sql.DBStats{InUse: 50}},
+		{name: "free", stats: sql.DBStats{MaxOpenConnections: 10, InUse: 9}},
+		{name: "saturated", stats: sql.DBStats{MaxOpenConnections: 10, InUse: 10, WaitCount: 4}, wantErr: "connection pool saturated: 10 of 10 connections in use, 4 waits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := PoolChecker(stubHealthRepository{stats: tt.stats}).Check(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Check() = %v, want %q", err, tt.wantErr)
+			}
 		})
 	}
 }
@@ -1,14 +1,22 @@
 package http
 
 import (
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
 )
 
@@ -31,7 +39,7 @@ func NewProductHandler(service service.ProductService) *ProductHandler {
 // @Router /api/products/health [get]
 func (h *ProductHandler) API(w http.ResponseWriter, r *http.Request) {
 	var result response.APIResponse
-	svcHealthCheckResult := h.service.API()
+	svcHealthCheckResult := h.service.API(r.Context())
 
 	if svcHealthCheckResult.IsHealthy {
 		result.Code = strconv.Itoa(constants.SuccessCode)
@@ -52,36 +60,212 @@ func (h *ProductHandler) API(w http.ResponseWriter, r *http.Request) {
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param product body entity.RequestProduct true "Product Data"
 // @Success 201 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/products [post]
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var requestProduct entity.RequestProduct
 	if err := response.ParseJSON(r, &requestProduct); err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductRequest, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductRequest, err)
 		return
 	}
 
-	if err := h.service.CreateProduct(&requestProduct); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Product created failed", err)
+	if err := h.service.CreateProduct(r.Context(), audit.ActorFromContext(r.Context()), &requestProduct); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product created failed", err)
 		return
 	}
 
 	response.Success(w, http.StatusCreated, constants.SuccessCode, "Product created successfully", nil)
 }
 
+// maxImportBytes limits the size of the CSV file read by ImportProducts.
+const maxImportBytes = 5 << 20
+
+// ImportProducts godoc
+// @Summary Import products from a CSV file
+// @Description Create products from a CSV file sent as the body (text/csv) or as the file field of a form. The header row names the columns name, price, stock, category and sku, in any order; category holds the ID or the name of the category. Every row is validated first and the products are only created, in one transaction, when all rows are valid. With dry_run=true the rows are only validated. The report lists every row with its line number and, for an invalid row, its errors.
+// @Tags products
+// @Accept text/csv
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param dry_run query bool false "Only validate the rows"
+// @Param file formData file false "CSV file, when sent as a form"
+// @Success 200 {object} map[string]interface{}
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/import [post]
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	var (
+		dryRun bool
+		err    error
+	)
+
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductImport, fmt.Errorf("dry_run must be a boolean"))
+			return
+		}
+	}
+
+	rows, err := parseImportRequest(w, r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductImport, err)
+		return
+	}
+
+	report, err := h.service.ImportProducts(r.Context(), audit.ActorFromContext(r.Context()), rows, dryRun)
+	if err != nil && report != nil {
+		// The report tells which rows are invalid, so it is sent with the error.
+		response.WriteJSONResponse(w, apperror.Status(err), response.APIResponse{
+			Code:    strconv.Itoa(apperror.Code(err)),
+			Message: fmt.Sprintf("Products import failed: %s", err),
+			Data:    report,
+		})
+		return
+	}
+
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Products import failed", err)
+		return
+	}
+
+	if dryRun {
+		response.Success(w, http.StatusOK, constants.SuccessCode, "Products validated successfully", report)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Products imported successfully", report)
+}
+
+// parseImportRequest reads the rows of the CSV file of an import request, from the file field of a multipart form or
+// else from the body.
+func parseImportRequest(w http.ResponseWriter, r *http.Request) ([]entity.ImportProductRow, error) {
+	if r.Body == nil {
+		return nil, fmt.Errorf("missing csv file")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return parseImportCSV(r.Body)
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("missing csv file in form field file: %w", err)
+	}
+	defer file.Close()
+
+	return parseImportCSV(file)
+}
+
+// parseImportCSV reads the rows of a product import from a CSV file whose header row names entity.ImportColumns.
+// Errors name the line of the file they were found on.
+func parseImportCSV(file io.Reader) ([]entity.ImportProductRow, error) {
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("csv file is empty")
+	}
+
+	if err != nil {
+		return nil, csvError(err)
+	}
+
+	headerLine, _ := reader.FieldPos(0)
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheets often start a UTF-8 file with a byte order mark.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(entity.ImportColumns, name) {
+			return nil, fmt.Errorf("line %d: unknown column %q, the columns are %s", headerLine, name, strings.Join(entity.ImportColumns, ", "))
+		}
+
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("line %d: duplicate column %q", headerLine, name)
+		}
+
+		columns[name] = i
+	}
+
+	for _, name := range entity.ImportColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("line %d: missing column %q", headerLine, name)
+		}
+	}
+
+	var rows []entity.ImportProductRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		if len(rows) == entity.MaxImportRows {
+			return nil, fmt.Errorf("csv file must have at most %d product rows", entity.MaxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, entity.ImportProductRow{
+			Line:     line,
+			Name:     record[columns["name"]],
+			Price:    record[columns["price"]],
+			Stock:    record[columns["stock"]],
+			Category: record[columns["category"]],
+			SKU:      record[columns["sku"]],
+		})
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("csv file has no product rows")
+	}
+
+	return rows, nil
+}
+
+// csvError describes an error reading a CSV file, with the line of the file for a malformed row.
+func csvError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("line %d: %w", parseErr.Line, parseErr.Err)
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("csv file must be at most %d bytes", maxBytesErr.Limit)
+	}
+
+	return err
+}
+
 // UpdateProduct godoc
 // @Summary Update a product
 // @Description Update a product
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /api/products/{id}, the request fails with 412 when the product has changed since"
 // @Param product body entity.RequestProduct true "Product Data"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
@@ -90,17 +274,70 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/products/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	version, err := response.IfMatch(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidIfMatch, err)
 		return
 	}
 
 	if err := response.ParseJSON(r, &requestProduct); err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductRequest, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductRequest, err)
+		return
+	}
+
+	if err := h.service.UpdateProduct(r.Context(), audit.ActorFromContext(r.Context()), int64(id), version, &requestProduct); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product updated failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Product updated successfully", nil)
+}
+
+// PatchProduct godoc
+// @Summary Partially update a product
+// @Description Update only the fields sent, as a JSON Merge Patch (RFC 7396). A null barcode removes the barcode.
+// @Tags products
+// @Accept json
+// @Accept application/merge-patch+json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /api/products/{id}, the request fails with 412 when the product has changed since"
+// @Param product body entity.RequestProduct true "Product fields to change"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 412 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id} [patch]
+func (h *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	var patchProduct entity.PatchProduct
+
+	idStr := strings.TrimPrefix(r.URL.Path, "/products/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	version, err := response.IfMatch(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidIfMatch, err)
 		return
 	}
 
-	if err := h.service.UpdateProduct(int64(id), &requestProduct); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Product updated failed", err)
+	if err := response.ParseJSON(r, &patchProduct); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductRequest, err)
+		return
+	}
+
+	if err := h.service.PatchProduct(r.Context(), audit.ActorFromContext(r.Context()), int64(id), version, &patchProduct); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product updated failed", err)
 		return
 	}
 
@@ -113,70 +350,330 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Product ID"
+// @Param If-Match header string false "ETag from GET /api/products/{id}, the request fails with 412 when the product has changed since"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 412 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/products/{id} [delete]
 func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/products/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	version, err := response.IfMatch(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidIfMatch, err)
 		return
 	}
 
-	if err := h.service.DeleteProduct(int64(id)); err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Product delete failed", err)
+	if err := h.service.DeleteProduct(r.Context(), audit.ActorFromContext(r.Context()), int64(id), version); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product delete failed", err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, constants.SuccessCode, "Product deleted successfully", nil)
 }
 
+// RestoreProduct godoc
+// @Summary Restore a product
+// @Description Restore a deleted product. Fails with 409 when its SKU or barcode is used by another product or its category has been deleted
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/restore [post]
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/products/"), "/restore")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	if err := h.service.RestoreProduct(r.Context(), audit.ActorFromContext(r.Context()), int64(id)); err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product restore failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Product restored successfully", nil)
+}
+
 // GetProductByID godoc
 // @Summary Get a product by ID
 // @Description Get a product by ID
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "Product ID"
 // @Success 200 {object} map[string]interface{}
+// @Header 200 {string} ETag "Version of the product, send it in If-Match to update or delete it"
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/products/{id} [get]
 func (h *ProductHandler) GetProductByID(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/products/")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		response.Error(w, http.StatusBadRequest, constants.ErrorCode, constants.ErrInvalidProductID, err)
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	product, err := h.service.GetProductByID(r.Context(), int64(id))
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product retrieved failed", err)
+		return
+	}
+
+	response.SetETag(w, product.Version)
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Product retrieved successfully", product)
+}
+
+// GetProductByBarcode godoc
+// @Summary Get a product by barcode or SKU
+// @Description Resolve a scanned EAN-13/UPC-A barcode, or a SKU, to a product
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code path string true "Barcode or SKU"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/by-barcode/{code} [get]
+func (h *ProductHandler) GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := strings.TrimSpace(strings.TrimPrefix(r.URL.Path, "/products/by-barcode/"))
+	if code == "" {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductCode, fmt.Errorf("code is required"))
 		return
 	}
 
-	product, err := h.service.GetProductByID(int64(id))
+	product, err := h.service.GetProductByCode(r.Context(), code)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Product retrieved failed", err)
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Product retrieved failed", err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, constants.SuccessCode, "Product retrieved successfully", product)
 }
 
+// GetLowStockProducts godoc
+// @Summary Get low stock products
+// @Description Get the products whose stock is at or below their reorder level, grouped by category
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]string
+// @Router /api/products/low-stock [get]
+func (h *ProductHandler) GetLowStockProducts(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.GetLowStockProducts(r.Context())
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Low stock products retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Low stock products retrieved successfully", categories)
+}
+
 // GetAllProducts godoc
 // @Summary Get all products
-// @Description Get all products
+// @Description Get a page of products, optionally filtered and sorted
 // @Tags products
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (id, name, sku, price, stock, created_at, updated_at)"
+// @Param category_id query int false "Category ID"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param in_stock query bool false "Only products with stock"
+// @Param include_deleted query bool false "Also list deleted products (admin only)"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /api/products [get]
 func (h *ProductHandler) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.service.GetAllProducts()
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductFilter, err)
+		return
+	}
+
+	if filter.IncludeDeleted && !auth.IsAdmin(r.Context()) {
+		response.Error(w, http.StatusForbidden, constants.ForbiddenErrorCode, constants.ErrForbidden, fmt.Errorf("only admins can list deleted products"))
+		return
+	}
+
+	products, meta, err := h.service.GetAllProducts(r.Context(), filter)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Products retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Products retrieved successfully", products, meta)
+}
+
+// GetProductsByCategory godoc
+// @Summary Get the products of a category
+// @Description Get a page of the products of a category, optionally filtered and sorted
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Category ID"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (id, name, sku, price, stock, created_at, updated_at)"
+// @Param min_price query int false "Minimum price"
+// @Param max_price query int false "Maximum price"
+// @Param in_stock query bool false "Only products with stock"
+// @Param include_deleted query bool false "Also list deleted products (admin only)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/categories/{id}/products [get]
+func (h *ProductHandler) GetProductsByCategory(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/categories/"), "/products")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCategoryID, err)
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductFilter, err)
+		return
+	}
+
+	if filter.IncludeDeleted && !auth.IsAdmin(r.Context()) {
+		response.Error(w, http.StatusForbidden, constants.ForbiddenErrorCode, constants.ErrForbidden, fmt.Errorf("only admins can list deleted products"))
+		return
+	}
+
+	products, meta, err := h.service.GetProductsByCategory(r.Context(), int64(id), filter)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Products retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Products retrieved successfully", products, meta)
+}
+
+func parseProductFilter(r *http.Request) (entity.ProductFilter, error) {
+	var (
+		filter entity.ProductFilter
+		err    error
+	)
+
+	query := r.URL.Query()
+	filter.Pagination, err = pagination.Parse(query, entity.ProductSortFields...)
+	if err != nil {
+		return filter, err
+	}
+
+	if categoryID := query.Get("category_id"); categoryID != "" {
+		filter.CategoryID, err = strconv.Atoi(categoryID)
+		if err != nil || filter.CategoryID < 1 {
+			return filter, fmt.Errorf("category_id must be a positive integer")
+		}
+	}
+
+	if minPrice := query.Get("min_price"); minPrice != "" {
+		value, err := strconv.Atoi(minPrice)
+		if err != nil || value < 0 {
+			return filter, fmt.Errorf("min_price must be a non-negative integer")
+		}
+		filter.MinPrice = &value
+	}
+
+	if maxPrice := query.Get("max_price"); maxPrice != "" {
+		value, err := strconv.Atoi(maxPrice)
+		if err != nil || value < 0 {
+			return filter, fmt.Errorf("max_price must be a non-negative integer")
+		}
+		filter.MaxPrice = &value
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not be greater than max_price")
+	}
+
+	if inStock := query.Get("in_stock"); inStock != "" {
+		filter.InStock, err = strconv.ParseBool(inStock)
+		if err != nil {
+			return filter, fmt.Errorf("in_stock must be a boolean")
+		}
+	}
+
+	if includeDeleted := query.Get("include_deleted"); includeDeleted != "" {
+		filter.IncludeDeleted, err = strconv.ParseBool(includeDeleted)
+		if err != nil {
+			return filter, fmt.Errorf("include_deleted must be a boolean")
+		}
+	}
+
+	return filter, nil
+}
+
+// SearchProducts godoc
+// @Summary Search products
+// @Description Search products by name or category name, best matches first
+// @Tags products
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param q query string true "Keyword"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/search [get]
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	keyword := strings.TrimSpace(query.Get("q"))
+	if keyword == "" {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductSearch, fmt.Errorf("q is required"))
+		return
+	}
+
+	limit := pagination.DefaultPageSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		value, err := strconv.Atoi(limitStr)
+		if err != nil || value < 1 || value > pagination.MaxPageSize {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductSearch, fmt.Errorf("limit must be between 1 and %d", pagination.MaxPageSize))
+			return
+		}
+		limit = value
+	}
+
+	products, err := h.service.SearchProducts(r.Context(), keyword, limit)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, constants.ErrorCode, "Products retrieved failed", err)
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Products searched failed", err)
 		return
 	}
 
-	response.Success(w, http.StatusOK, constants.SuccessCode, "Products retrieved successfully", products)
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Products searched successfully", products)
 }
@@ -1,64 +1,144 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
 
 	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
 )
 
 type mockProductService struct {
-	createFn func(*entity.RequestProduct) error
-	updateFn func(int64, *entity.RequestProduct) error
-	deleteFn func(int64) error
-	getByID  func(int64) (*entity.ResponseProductWithCategories, error)
-	getAllFn func() ([]entity.ResponseProductWithCategories, error)
-	apiFn    func() entity.HealthCheck
+	createFn  func(*entity.RequestProduct) error
+	importFn  func([]entity.ImportProductRow, bool) (*entity.ImportProductReport, error)
+	updateFn  func(int64, *entity.RequestProduct) error
+	patchFn   func(int64, *entity.PatchProduct) error
+	deleteFn  func(int64) error
+	restoreFn func(int64) error
+	getByID   func(int64) (*entity.ResponseProductWithCategories, error)
+	getByCode func(string) (*entity.ResponseProductWithCategories, error)
+	getAllFn  func(entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error)
+	byCatFn   func(int64, entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error)
+	searchFn  func(string, int) ([]entity.ResponseProductWithCategories, error)
+	lowStock  func() ([]entity.ResponseLowStockCategory, error)
+	apiFn     func() entity.HealthCheck
+
+	actor   audit.Actor
+	ctx     context.Context
+	version int64
 }
 
-func (m *mockProductService) CreateProduct(product *entity.RequestProduct) error {
+func (m *mockProductService) CreateProduct(ctx context.Context, actor audit.Actor, product *entity.RequestProduct) error {
+	m.actor = actor
 	if m.createFn == nil {
 		return nil
 	}
 	return m.createFn(product)
 }
 
-func (m *mockProductService) UpdateProduct(id int64, product *entity.RequestProduct) error {
+func (m *mockProductService) ImportProducts(ctx context.Context, actor audit.Actor, rows []entity.ImportProductRow, dryRun bool) (*entity.ImportProductReport, error) {
+	m.actor = actor
+	if m.importFn == nil {
+		return &entity.ImportProductReport{}, nil
+	}
+	return m.importFn(rows, dryRun)
+}
+
+func (m *mockProductService) UpdateProduct(ctx context.Context, actor audit.Actor, id int64, version int64, product *entity.RequestProduct) error {
+	m.actor = actor
+	m.version = version
 	if m.updateFn == nil {
 		return nil
 	}
 	return m.updateFn(id, product)
 }
 
-func (m *mockProductService) DeleteProduct(id int64) error {
+func (m *mockProductService) PatchProduct(ctx context.Context, actor audit.Actor, id int64, version int64, patch *entity.PatchProduct) error {
+	m.actor = actor
+	m.version = version
+	if m.patchFn == nil {
+		return nil
+	}
+	return m.patchFn(id, patch)
+}
+
+func (m *mockProductService) DeleteProduct(ctx context.Context, actor audit.Actor, id int64, version int64) error {
+	m.actor = actor
+	m.version = version
 	if m.deleteFn == nil {
 		return nil
 	}
 	return m.deleteFn(id)
 }
 
-func (m *mockProductService) GetProductByID(id int64) (*entity.ResponseProductWithCategories, error) {
+func (m *mockProductService) RestoreProduct(ctx context.Context, actor audit.Actor, id int64) error {
+	m.actor = actor
+	if m.restoreFn == nil {
+		return nil
+	}
+	return m.restoreFn(id)
+}
+
+func (m *mockProductService) GetProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error) {
+	m.ctx = ctx
 	if m.getByID == nil {
 		return nil, nil
 	}
 	return m.getByID(id)
 }
 
-func (m *mockProductService) GetAllProducts() ([]entity.ResponseProductWithCategories, error) {
+func (m *mockProductService) GetProductByCode(ctx context.Context, code string) (*entity.ResponseProductWithCategories, error) {
+	if m.getByCode == nil {
+		return nil, nil
+	}
+	return m.getByCode(code)
+}
+
+func (m *mockProductService) GetAllProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error) {
 	if m.getAllFn == nil {
+		return nil, nil, nil
+	}
+	return m.getAllFn(filter)
+}
+
+func (m *mockProductService) GetProductsByCategory(ctx context.Context, categoryID int64, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error) {
+	if m.byCatFn == nil {
+		return nil, nil, nil
+	}
+	return m.byCatFn(categoryID, filter)
+}
+
+func (m *mockProductService) SearchProducts(ctx context.Context, keyword string, limit int) ([]entity.ResponseProductWithCategories, error) {
+	if m.searchFn == nil {
+		return nil, nil
+	}
+	return m.searchFn(keyword, limit)
+}
+
+func (m *mockProductService) GetLowStockProducts(ctx context.Context) ([]entity.ResponseLowStockCategory, error) {
+	if m.lowStock == nil {
 		return nil, nil
 	}
-	return m.getAllFn()
+	return m.lowStock()
 }
 
-func (m *mockProductService) API() entity.HealthCheck {
+func (m *mockProductService) API(ctx context.Context) entity.HealthCheck {
 	if m.apiFn == nil {
 		return entity.HealthCheck{}
 	}
@@ -139,11 +219,14 @@ func TestProductHandlerCreateProduct(t *testing.T) {
 		wantCode   string
 		wantMsg    string
 		wantPrefix bool
+		wantFields []apperror.FieldError
 		wantCalled bool
 	}{
-		{name: "bad-json", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true, wantCalled: false},
-		{name: "nil-body", bodyNil: true, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true, wantCalled: false},
+		{name: "bad-json", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true, wantCalled: false},
+		{name: "nil-body", bodyNil: true, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true, wantCalled: false},
+		{name: "unknown-field", body: `{"name":"n","colour":"red"}`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductRequest + `: unknown field "colour"`, wantFields: []apperror.FieldError{{Field: "colour", Message: `unknown field "colour"`}}},
 		{name: "svc-error", body: validBody, svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Product created failed: db", wantCalled: true},
+		{name: "invalid", body: validBody, svcErr: apperror.Invalid(apperror.FieldError{Field: "price", Message: "price must not be negative"}), wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: "Product created failed: price must not be negative", wantFields: []apperror.FieldError{{Field: "price", Message: "price must not be negative"}}, wantCalled: true},
 		{name: "ok", body: validBody, wantStatus: http.StatusCreated, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product created successfully", wantCalled: true},
 	}
 
@@ -167,6 +250,7 @@ func TestProductHandlerCreateProduct(t *testing.T) {
 				req = &http.Request{Body: nil}
 			} else {
 				req = httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(tc.body))
+				req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{UserID: 1, Username: "admin", Role: auth.RoleAdmin}))
 			}
 
 			h.CreateProduct(rec, req)
@@ -195,6 +279,173 @@ func TestProductHandlerCreateProduct(t *testing.T) {
 			if tc.wantStatus == http.StatusCreated && resp.Data != nil {
 				t.Fatalf("data = %v, want nil", resp.Data)
 			}
+			if !reflect.DeepEqual(resp.Errors, tc.wantFields) {
+				t.Fatalf("errors = %+v, want %+v", resp.Errors, tc.wantFields)
+			}
+			if tc.wantCalled && svc.actor != (audit.Actor{ID: 1, Username: "admin"}) {
+				t.Fatalf("actor = %+v, want the authenticated admin", svc.actor)
+			}
+		})
+	}
+}
+
+func TestProductHandlerImportProducts(t *testing.T) {
+	validBody := "name,price,stock,category,sku\nSusu,10,2,Minuman,SKU-1\n"
+	validRows := []entity.ImportProductRow{{Line: 2, Name: "Susu", Price: "10", Stock: "2", Category: "Minuman", SKU: "SKU-1"}}
+	report := &entity.ImportProductReport{Total: 1, Valid: 1, Failed: 0, Rows: []entity.ImportProductResult{{Line: 2, SKU: "SKU-1", Status: entity.ImportStatusValid}}}
+
+	cases := []struct {
+		name       string
+		query      string
+		body       string
+		svcReport  *entity.ImportProductReport
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantDryRun bool
+		wantData   bool
+		wantCalled bool
+	}{
+		{name: "bad-dry-run", query: "?dry_run=maybe", body: validBody, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductImport + ": dry_run must be a boolean"},
+		{name: "bad-csv", body: "name,price\n", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductImport + `: line 1: missing column "stock"`},
+		{name: "svc-error", body: validBody, svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Products import failed: db", wantCalled: true},
+		{name: "invalid-rows", body: validBody, svcReport: report, svcErr: apperror.Validation("1 of 1 rows are invalid, no product was imported"), wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: "Products import failed: 1 of 1 rows are invalid, no product was imported", wantData: true, wantCalled: true},
+		{name: "dry-run", query: "?dry_run=true", body: validBody, svcReport: report, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Products validated successfully", wantDryRun: true, wantData: true, wantCalled: true},
+		{name: "ok", body: validBody, svcReport: report, wantStatus: http.StatusCreated, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Products imported successfully", wantData: true, wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			svc := &mockProductService{
+				importFn: func(rows []entity.ImportProductRow, dryRun bool) (*entity.ImportProductReport, error) {
+					called = true
+					if !reflect.DeepEqual(rows, validRows) {
+						t.Fatalf("rows = %+v, want %+v", rows, validRows)
+					}
+					if dryRun != tc.wantDryRun {
+						t.Fatalf("dry run = %v, want %v", dryRun, tc.wantDryRun)
+					}
+					return tc.svcReport, tc.svcErr
+				},
+			}
+			h := NewProductHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/products/import"+tc.query, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "text/csv")
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{UserID: 1, Username: "admin", Role: auth.RoleAdmin}))
+
+			h.ImportProducts(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			if msg, _ := resp.Message.(string); msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if (resp.Data != nil) != tc.wantData {
+				t.Fatalf("data = %v, want data %v", resp.Data, tc.wantData)
+			}
+			if tc.wantCalled && svc.actor != (audit.Actor{ID: 1, Username: "admin"}) {
+				t.Fatalf("actor = %+v", svc.actor)
+			}
+		})
+	}
+}
+
+func TestProductHandlerImportProductsForm(t *testing.T) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "products.csv")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	_, _ = part.Write([]byte("sku,name,category,stock,price\nSKU-1,Susu,3,2,10\n"))
+	_ = form.Close()
+
+	var got []entity.ImportProductRow
+	svc := &mockProductService{
+		importFn: func(rows []entity.ImportProductRow, dryRun bool) (*entity.ImportProductReport, error) {
+			got = rows
+			return &entity.ImportProductReport{}, nil
+		},
+	}
+	h := NewProductHandler(svc)
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/products/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	h.ImportProducts(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+	want := []entity.ImportProductRow{{Line: 2, Name: "Susu", Price: "10", Stock: "2", Category: "3", SKU: "SKU-1"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %+v, want %+v", got, want)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/products/import", strings.NewReader(""))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+
+	h.ImportProducts(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestParseImportCSV(t *testing.T) {
+	tooMany := "name,price,stock,category,sku\n" + strings.Repeat("a,1,1,1,S\n", entity.MaxImportRows+1)
+
+	cases := []struct {
+		name    string
+		csv     string
+		want    []entity.ImportProductRow
+		wantErr string
+	}{
+		{
+			name: "ok",
+			csv:  "\ufeffName, Price, Stock, Category, SKU\n\"Susu, UHT\",10,2,Minuman,SKU-1\n\nRoti,5,0,4,SKU-2\n",
+			want: []entity.ImportProductRow{
+				{Line: 2, Name: "Susu, UHT", Price: "10", Stock: "2", Category: "Minuman", SKU: "SKU-1"},
+				{Line: 4, Name: "Roti", Price: "5", Stock: "0", Category: "4", SKU: "SKU-2"},
+			},
+		},
+		{name: "empty", csv: "", wantErr: "csv file is empty"},
+		{name: "no-rows", csv: "name,price,stock,category,sku\n", wantErr: "csv file has no product rows"},
+		{name: "unknown-column", csv: "name,price,stock,category,sku,colour\n", wantErr: `line 1: unknown column "colour", the columns are name, price, stock, category, sku`},
+		{name: "duplicate-column", csv: "name,price,stock,category,sku,name\n", wantErr: `line 1: duplicate column "name"`},
+		{name: "missing-column", csv: "name,price,stock,category\n", wantErr: `line 1: missing column "sku"`},
+		{name: "field-count", csv: "name,price,stock,category,sku\nSusu,10,2,Minuman,SKU-1\nRoti,5\n", wantErr: "line 3: wrong number of fields"},
+		{name: "bad-quote", csv: "name,price,stock,category,sku\nSu\"su,10,2,Minuman,SKU-1\n", wantErr: `line 2: bare " in non-quoted-field`},
+		{name: "too-many", csv: tooMany, wantErr: fmt.Sprintf("csv file must have at most %d product rows", entity.MaxImportRows)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseImportCSV(strings.NewReader(tc.csv))
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("rows = %+v, want %+v", got, tc.want)
+			}
 		})
 	}
 }
@@ -207,6 +458,7 @@ func TestProductHandlerUpdateProduct(t *testing.T) {
 		name       string
 		path       string
 		body       string
+		ifMatch    string
 		wantStatus int
 		wantCode   string
 		wantMsg    string
@@ -214,9 +466,12 @@ func TestProductHandlerUpdateProduct(t *testing.T) {
 		svcErr     error
 		wantCalled bool
 		wantID     int64
+		wantVer    int64
 	}{
-		{name: "bad-id", path: "/products/abc", body: validBody, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true, wantCalled: false},
-		{name: "bad-json", path: "/products/12", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true, wantCalled: false, wantID: 12},
+		{name: "bad-id", path: "/products/abc", body: validBody, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true, wantCalled: false},
+		{name: "bad-json", path: "/products/12", body: `{"name":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true, wantCalled: false, wantID: 12},
+		{name: "bad-if-match", path: "/products/12", body: validBody, ifMatch: "12", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidIfMatch, wantPrefix: true, wantCalled: false},
+		{name: "stale-version", path: "/products/12", body: validBody, ifMatch: `"5"`, svcErr: apperror.PreconditionFailed("product has been changed since version 5"), wantStatus: http.StatusPreconditionFailed, wantCode: strconv.Itoa(constants.PreconditionFailedErrorCode), wantMsg: "Product updated failed: product has been changed since version 5", wantCalled: true, wantID: 12, wantVer: 5},
 		{name: "svc-error", path: "/products/12", body: validBody, svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Product updated failed: db", wantCalled: true, wantID: 12},
 		{name: "ok", path: "/products/12", body: validBody, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product updated successfully", wantCalled: true, wantID: 12},
 	}
@@ -238,6 +493,9 @@ func TestProductHandlerUpdateProduct(t *testing.T) {
 			h := NewProductHandler(svc)
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodPut, tc.path, strings.NewReader(tc.body))
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			h.UpdateProduct(rec, req)
 
@@ -247,6 +505,88 @@ func TestProductHandlerUpdateProduct(t *testing.T) {
 			if tc.wantCalled && gotID != tc.wantID {
 				t.Fatalf("id = %d, want %d", gotID, tc.wantID)
 			}
+			if svc.version != tc.wantVer {
+				t.Fatalf("version = %d, want %d", svc.version, tc.wantVer)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, ok := resp.Message.(string)
+			if !ok {
+				t.Fatalf("message type = %T, want string", resp.Message)
+			}
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestProductHandlerPatchProduct(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		body       string
+		ifMatch    string
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		svcErr     error
+		wantCalled bool
+		wantPatch  entity.PatchProduct
+		wantVer    int64
+	}{
+		{name: "bad-id", path: "/products/abc", body: `{"price":10}`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true},
+		{name: "bad-json", path: "/products/12", body: `{"price":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductRequest, wantPrefix: true},
+		{name: "unknown-field", path: "/products/12", body: `{"colour":"red"}`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductRequest + `: unknown field "colour"`},
+		{name: "not-found", path: "/products/12", body: `{"price":10}`, svcErr: apperror.NotFound("product not found"), wantStatus: http.StatusNotFound, wantCode: strconv.Itoa(constants.NotFoundErrorCode), wantMsg: "Product updated failed: product not found", wantCalled: true, wantPatch: entity.PatchProduct{Price: patch.Of(10)}},
+		{name: "stale-version", path: "/products/12", body: `{"price":10}`, ifMatch: `"5"`, svcErr: apperror.PreconditionFailed("product has been changed since version 5"), wantStatus: http.StatusPreconditionFailed, wantCode: strconv.Itoa(constants.PreconditionFailedErrorCode), wantMsg: "Product updated failed: product has been changed since version 5", wantCalled: true, wantPatch: entity.PatchProduct{Price: patch.Of(10)}, wantVer: 5},
+		{name: "price-only", path: "/products/12", body: `{"price":10}`, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product updated successfully", wantCalled: true, wantPatch: entity.PatchProduct{Price: patch.Of(10)}},
+		{name: "remove-barcode", path: "/products/12", body: `{"barcode":null,"stock":0}`, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product updated successfully", wantCalled: true, wantPatch: entity.PatchProduct{Barcode: patch.Field[string]{Set: true, Null: true}, Stock: patch.Of(0)}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			var gotID int64
+			svc := &mockProductService{
+				patchFn: func(id int64, p *entity.PatchProduct) error {
+					called = true
+					gotID = id
+					if *p != tc.wantPatch {
+						t.Fatalf("patch = %+v, want %+v", *p, tc.wantPatch)
+					}
+					return tc.svcErr
+				},
+			}
+			h := NewProductHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPatch, tc.path, strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			h.PatchProduct(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if tc.wantCalled && gotID != 12 {
+				t.Fatalf("id = %d, want 12", gotID)
+			}
+			if svc.version != tc.wantVer {
+				t.Fatalf("version = %d, want %d", svc.version, tc.wantVer)
+			}
 			if rec.Code != tc.wantStatus {
 				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
 			}
@@ -273,6 +613,7 @@ func TestProductHandlerDeleteProduct(t *testing.T) {
 	cases := []struct {
 		name       string
 		path       string
+		ifMatch    string
 		wantStatus int
 		wantCode   string
 		wantMsg    string
@@ -280,8 +621,12 @@ func TestProductHandlerDeleteProduct(t *testing.T) {
 		svcErr     error
 		wantCalled bool
 		wantID     int64
+		wantVer    int64
 	}{
-		{name: "bad-id", path: "/products/abc", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true, wantCalled: false},
+		{name: "bad-id", path: "/products/abc", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true, wantCalled: false},
+		{name: "bad-if-match", path: "/products/9", ifMatch: `"1", "2"`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidIfMatch, wantPrefix: true, wantCalled: false},
+		{name: "stale-version", path: "/products/9", ifMatch: `"5"`, svcErr: apperror.PreconditionFailed("product has been changed since version 5"), wantStatus: http.StatusPreconditionFailed, wantCode: strconv.Itoa(constants.PreconditionFailedErrorCode), wantMsg: "Product delete failed: product has been changed since version 5", wantCalled: true, wantID: 9, wantVer: 5},
+		{name: "any-version", path: "/products/9", ifMatch: "*", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product deleted successfully", wantCalled: true, wantID: 9},
 		{name: "svc-error", path: "/products/9", svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Product delete failed: db", wantCalled: true, wantID: 9},
 		{name: "ok", path: "/products/9", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product deleted successfully", wantCalled: true, wantID: 9},
 	}
@@ -300,6 +645,9 @@ func TestProductHandlerDeleteProduct(t *testing.T) {
 			h := NewProductHandler(svc)
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodDelete, tc.path, nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			h.DeleteProduct(rec, req)
 
@@ -309,6 +657,69 @@ func TestProductHandlerDeleteProduct(t *testing.T) {
 			if tc.wantCalled && gotID != tc.wantID {
 				t.Fatalf("id = %d, want %d", gotID, tc.wantID)
 			}
+			if svc.version != tc.wantVer {
+				t.Fatalf("version = %d, want %d", svc.version, tc.wantVer)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, ok := resp.Message.(string)
+			if !ok {
+				t.Fatalf("message type = %T, want string", resp.Message)
+			}
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestProductHandlerRestoreProduct(t *testing.T) {
+	cases := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		svcErr     error
+		wantCalled bool
+	}{
+		{name: "bad-id", path: "/products/abc/restore", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true},
+		{name: "not-deleted", path: "/products/9/restore", svcErr: apperror.NotFound("deleted product not found"), wantStatus: http.StatusNotFound, wantCode: strconv.Itoa(constants.NotFoundErrorCode), wantMsg: "Product restore failed: deleted product not found", wantCalled: true},
+		{name: "sku-taken", path: "/products/9/restore", svcErr: apperror.Conflict("sku already used by another product"), wantStatus: http.StatusConflict, wantCode: strconv.Itoa(constants.ConflictErrorCode), wantMsg: "Product restore failed: sku already used by another product", wantCalled: true},
+		{name: "ok", path: "/products/9/restore", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product restored successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			svc := &mockProductService{
+				restoreFn: func(id int64) error {
+					called = true
+					if id != 9 {
+						t.Fatalf("id = %d, want 9", id)
+					}
+					return tc.svcErr
+				},
+			}
+			h := NewProductHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+
+			h.RestoreProduct(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
 			if rec.Code != tc.wantStatus {
 				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
 			}
@@ -332,7 +743,7 @@ func TestProductHandlerDeleteProduct(t *testing.T) {
 }
 
 func TestProductHandlerGetProductByID(t *testing.T) {
-	product := &entity.ResponseProductWithCategories{ID: 7, Name: "p1", Price: 10, Stock: 2, CategoryID: 3, CategoryName: "c1"}
+	product := &entity.ResponseProductWithCategories{ID: 7, Name: "p1", Price: 10, Stock: 2, CategoryID: 3, CategoryName: "c1", Version: 4}
 
 	cases := []struct {
 		name       string
@@ -345,8 +756,9 @@ func TestProductHandlerGetProductByID(t *testing.T) {
 		wantCalled bool
 		wantID     int64
 	}{
-		{name: "bad-id", path: "/products/abc", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true, wantCalled: false},
+		{name: "bad-id", path: "/products/abc", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true, wantCalled: false},
 		{name: "svc-error", path: "/products/7", svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Product retrieved failed: db", wantCalled: true, wantID: 7},
+		{name: "not-found", path: "/products/7", svcErr: apperror.NotFound("product not found"), wantStatus: http.StatusNotFound, wantCode: strconv.Itoa(constants.NotFoundErrorCode), wantMsg: "Product retrieved failed: product not found", wantCalled: true, wantID: 7},
 		{name: "ok", path: "/products/7", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product retrieved successfully", wantCalled: true, wantID: 7},
 	}
 
@@ -405,6 +817,101 @@ func TestProductHandlerGetProductByID(t *testing.T) {
 				if data["name"] != product.Name {
 					t.Fatalf("data.name = %v, want %s", data["name"], product.Name)
 				}
+				if got := rec.Header().Get("ETag"); got != `"4"` {
+					t.Fatalf("ETag = %q, want %q", got, `"4"`)
+				}
+			}
+		})
+	}
+}
+
+func TestProductHandlerPassesRequestContext(t *testing.T) {
+	type ctxKey struct{}
+	svc := &mockProductService{
+		getByID: func(int64) (*entity.ResponseProductWithCategories, error) {
+			return &entity.ResponseProductWithCategories{ID: 1}, nil
+		},
+	}
+	h := NewProductHandler(svc)
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	req := httptest.NewRequest(http.MethodGet, "/products/1", nil).WithContext(ctx)
+
+	h.GetProductByID(httptest.NewRecorder(), req)
+	cancel()
+
+	if svc.ctx == nil || svc.ctx.Value(ctxKey{}) != "request" {
+		t.Fatalf("service did not receive the request context")
+	}
+	if svc.ctx.Err() == nil {
+		t.Fatalf("expected cancelling the request to cancel the service context")
+	}
+}
+
+func TestProductHandlerGetProductByBarcode(t *testing.T) {
+	product := &entity.ResponseProductWithCategories{ID: 7, Name: "p1", SKU: "SKU-7", Barcode: "8992761166014", Price: 10, Stock: 2, CategoryID: 3, CategoryName: "c1"}
+
+	cases := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		svcErr     error
+		wantCalled bool
+	}{
+		{name: "empty-code", path: "/products/by-barcode/", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductCode, wantPrefix: true},
+		{name: "svc-error", path: "/products/by-barcode/8992761166014", svcErr: errors.New("product not found"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Product retrieved failed: product not found", wantCalled: true},
+		{name: "ok", path: "/products/by-barcode/8992761166014", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Product retrieved successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			svc := &mockProductService{
+				getByCode: func(code string) (*entity.ResponseProductWithCategories, error) {
+					called = true
+					if code != product.Barcode {
+						t.Fatalf("code = %q, want %q", code, product.Barcode)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return product, nil
+				},
+			}
+			h := NewProductHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+
+			h.GetProductByBarcode(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.(map[string]any)
+				if !ok {
+					t.Fatalf("data type = %T, want map", resp.Data)
+				}
+				if data["sku"] != product.SKU || data["barcode"] != product.Barcode {
+					t.Fatalf("unexpected data: %v", data)
+				}
 			}
 		})
 	}
@@ -415,31 +922,75 @@ func TestProductHandlerGetAllProducts(t *testing.T) {
 		{ID: 1, Name: "p1", Price: 10, Stock: 2, CategoryID: 3, CategoryName: "c1"},
 		{ID: 2, Name: "p2", Price: 11, Stock: 3, CategoryID: 4, CategoryName: "c2"},
 	}
+	minPrice, maxPrice := 10, 20
 
 	cases := []struct {
 		name       string
+		query      string
+		role       string
+		wantFilter *entity.ProductFilter
 		wantStatus int
 		wantCode   string
 		wantMsg    string
+		wantPrefix bool
 		svcErr     error
 	}{
 		{name: "svc-error", wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Products retrieved failed: db", svcErr: errors.New("db")},
 		{name: "ok", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Products retrieved successfully"},
+		{
+			name:  "filters",
+			query: "?page=2&page_size=5&sort=-price,name&category_id=3&min_price=10&max_price=20&in_stock=true",
+			wantFilter: &entity.ProductFilter{
+				CategoryID: 3,
+				MinPrice:   &minPrice,
+				MaxPrice:   &maxPrice,
+				InStock:    true,
+				Pagination: pagination.Params{Page: 2, PageSize: 5, Sort: []pagination.Sort{{Field: "price", Desc: true}, {Field: "name"}}},
+			},
+			wantStatus: http.StatusOK,
+			wantCode:   strconv.Itoa(constants.SuccessCode),
+			wantMsg:    "Products retrieved successfully",
+		},
+		{name: "bad-page", query: "?page=0", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{name: "bad-sort", query: "?sort=secret", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{name: "bad-category", query: "?category_id=x", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{name: "bad-min-price", query: "?min_price=-1", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{name: "bad-max-price", query: "?max_price=x", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{name: "price-range", query: "?min_price=20&max_price=10", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{name: "bad-in-stock", query: "?in_stock=maybe", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
+		{
+			name:       "include-deleted",
+			query:      "?include_deleted=true",
+			role:       auth.RoleAdmin,
+			wantFilter: &entity.ProductFilter{IncludeDeleted: true, Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			wantStatus: http.StatusOK,
+			wantCode:   strconv.Itoa(constants.SuccessCode),
+			wantMsg:    "Products retrieved successfully",
+		},
+		{name: "include-deleted-cashier", query: "?include_deleted=true", role: auth.RoleCashier, wantStatus: http.StatusForbidden, wantCode: strconv.Itoa(constants.ForbiddenErrorCode), wantMsg: constants.ErrForbidden, wantPrefix: true},
+		{name: "include-deleted-anonymous", query: "?include_deleted=true", wantStatus: http.StatusForbidden, wantCode: strconv.Itoa(constants.ForbiddenErrorCode), wantMsg: constants.ErrForbidden, wantPrefix: true},
+		{name: "bad-include-deleted", query: "?include_deleted=maybe", role: auth.RoleAdmin, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter, wantPrefix: true},
 	}
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			svc := &mockProductService{
-				getAllFn: func() ([]entity.ResponseProductWithCategories, error) {
+				getAllFn: func(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error) {
+					if tc.wantFilter != nil && !reflect.DeepEqual(filter, *tc.wantFilter) {
+						t.Fatalf("filter = %+v, want %+v", filter, *tc.wantFilter)
+					}
 					if tc.svcErr != nil {
-						return nil, tc.svcErr
+						return nil, nil, tc.svcErr
 					}
-					return products, nil
+					return products, pagination.NewMeta(filter.Pagination, 12), nil
 				},
 			}
 			h := NewProductHandler(svc)
 			rec := httptest.NewRecorder()
-			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			req := httptest.NewRequest(http.MethodGet, "/products"+tc.query, nil)
+			if tc.role != "" {
+				req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{Role: tc.role}))
+			}
 
 			h.GetAllProducts(rec, req)
 
@@ -454,10 +1005,14 @@ func TestProductHandlerGetAllProducts(t *testing.T) {
 			if !ok {
 				t.Fatalf("message type = %T, want string", resp.Message)
 			}
-			if msg != tc.wantMsg {
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
 				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
 			}
-			if tc.name == "ok" {
+			if tc.wantStatus == http.StatusOK {
 				data, ok := resp.Data.([]any)
 				if !ok {
 					t.Fatalf("data type = %T, want slice", resp.Data)
@@ -465,6 +1020,221 @@ func TestProductHandlerGetAllProducts(t *testing.T) {
 				if len(data) != len(products) {
 					t.Fatalf("data len = %d, want %d", len(data), len(products))
 				}
+				meta, ok := resp.Meta.(map[string]any)
+				if !ok {
+					t.Fatalf("meta type = %T, want map", resp.Meta)
+				}
+				if meta["total_items"] != float64(12) {
+					t.Fatalf("meta = %v", meta)
+				}
+			}
+		})
+	}
+}
+
+func TestProductHandlerGetProductsByCategory(t *testing.T) {
+	products := []entity.ResponseProductWithCategories{{ID: 1, Name: "p1", Price: 10, Stock: 2, CategoryID: 3, CategoryName: "c1"}}
+
+	cases := []struct {
+		name       string
+		path       string
+		role       string
+		wantFilter *entity.ProductFilter
+		wantCalls  int
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		svcErr     error
+	}{
+		{
+			name:       "ok",
+			path:       "/categories/3/products?in_stock=true&sort=-price",
+			wantFilter: &entity.ProductFilter{InStock: true, Pagination: pagination.Params{Page: 1, PageSize: 20, Sort: []pagination.Sort{{Field: "price", Desc: true}}}},
+			wantCalls:  1,
+			wantStatus: http.StatusOK,
+			wantCode:   strconv.Itoa(constants.SuccessCode),
+			wantMsg:    "Products retrieved successfully",
+		},
+		{name: "bad-id", path: "/categories/abc/products", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidCategoryID},
+		{name: "bad-filter", path: "/categories/3/products?in_stock=maybe", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductFilter},
+		{name: "include-deleted-cashier", path: "/categories/3/products?include_deleted=true", role: auth.RoleCashier, wantStatus: http.StatusForbidden, wantCode: strconv.Itoa(constants.ForbiddenErrorCode), wantMsg: constants.ErrForbidden},
+		{
+			name:       "missing-category",
+			path:       "/categories/3/products",
+			svcErr:     apperror.NotFound("category not found"),
+			wantCalls:  1,
+			wantStatus: http.StatusNotFound,
+			wantCode:   strconv.Itoa(constants.NotFoundErrorCode),
+			wantMsg:    "Products retrieved failed: category not found",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			svc := &mockProductService{
+				byCatFn: func(categoryID int64, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error) {
+					calls++
+					if categoryID != 3 {
+						t.Fatalf("category id = %d, want 3", categoryID)
+					}
+					if tc.wantFilter != nil && !reflect.DeepEqual(filter, *tc.wantFilter) {
+						t.Fatalf("filter = %+v, want %+v", filter, *tc.wantFilter)
+					}
+					if tc.svcErr != nil {
+						return nil, nil, tc.svcErr
+					}
+					return products, pagination.NewMeta(filter.Pagination, 1), nil
+				},
+			}
+			h := NewProductHandler(svc)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.role != "" {
+				req = req.WithContext(auth.NewContext(req.Context(), &auth.Claims{Role: tc.role}))
+			}
+
+			h.GetProductsByCategory(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			if calls != tc.wantCalls {
+				t.Fatalf("service calls = %d, want %d", calls, tc.wantCalls)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if !strings.HasPrefix(msg, tc.wantMsg) {
+				t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestProductHandlerSearchProducts(t *testing.T) {
+	products := []entity.ResponseProductWithCategories{{ID: 1, Name: "Bebelac", CategoryName: "Susu"}}
+
+	cases := []struct {
+		name        string
+		query       string
+		svcErr      error
+		wantKeyword string
+		wantLimit   int
+		wantStatus  int
+		wantCode    string
+		wantMsg     string
+		wantPrefix  bool
+		wantCalled  bool
+	}{
+		{name: "missing-q", query: "", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductSearch, wantPrefix: true},
+		{name: "blank-q", query: "?q=++", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductSearch, wantPrefix: true},
+		{name: "bad-limit", query: "?q=bebe&limit=0", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductSearch, wantPrefix: true},
+		{name: "svc-error", query: "?q=bebe", svcErr: errors.New("db"), wantKeyword: "bebe", wantLimit: 20, wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Products searched failed: db", wantCalled: true},
+		{name: "ok", query: "?q=+bebe+sus&limit=5", wantKeyword: "bebe sus", wantLimit: 5, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Products searched successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewProductHandler(&mockProductService{
+				searchFn: func(keyword string, limit int) ([]entity.ResponseProductWithCategories, error) {
+					called = true
+					if keyword != tc.wantKeyword || limit != tc.wantLimit {
+						t.Fatalf("args = %q %d, want %q %d", keyword, limit, tc.wantKeyword, tc.wantLimit)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return products, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/products/search"+tc.query, nil)
+
+			h.SearchProducts(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.([]any)
+				if !ok || len(data) != 1 {
+					t.Fatalf("unexpected data: %v", resp.Data)
+				}
+			}
+		})
+	}
+}
+
+func TestProductHandlerGetLowStockProducts(t *testing.T) {
+	categories := []entity.ResponseLowStockCategory{
+		{CategoryID: 2, CategoryName: "Susu", Products: []entity.ResponseProductWithCategories{{ID: 1, Name: "Bebelac", Stock: 1, ReorderLevel: 3}}},
+	}
+
+	cases := []struct {
+		name       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "svc-error", svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Low stock products retrieved failed: db"},
+		{name: "ok", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Low stock products retrieved successfully"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewProductHandler(&mockProductService{
+				lowStock: func() ([]entity.ResponseLowStockCategory, error) {
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return categories, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/products/low-stock", nil)
+
+			h.GetLowStockProducts(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			if msg, _ := resp.Message.(string); msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.([]any)
+				if !ok || len(data) != 1 {
+					t.Fatalf("unexpected data: %v", resp.Data)
+				}
+				group, _ := data[0].(map[string]any)
+				products, _ := group["products"].([]any)
+				if group["category_name"] != "Susu" || len(products) != 1 {
+					t.Fatalf("unexpected group: %v", group)
+				}
 			}
 		})
 	}
@@ -1,22 +1,87 @@
 package entity
 
-import "time"
+import (
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/validation"
+)
 
 type Product struct {
-	ID         int    `json:"id"`
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryID int    `json:"category_id"`
-	CreatedAt  string `json:"created_at", omitempty`
-	UpdatedAt  string `json:"updated_at", omitempty`
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	SKU          string `json:"sku"`
+	Barcode      string `json:"barcode"`
+	Price        int    `json:"price"`
+	Stock        int    `json:"stock"`
+	ReorderLevel int    `json:"reorder_level"`
+	CategoryID   int    `json:"category_id"`
+	CreatedAt    string `json:"created_at,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
 }
 
 type RequestProduct struct {
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Stock      int    `json:"stock"`
-	CategoryID int    `json:"category_id"`
+	Name         string `json:"name"`
+	SKU          string `json:"sku"`
+	Barcode      string `json:"barcode"`
+	Price        int    `json:"price"`
+	Stock        int    `json:"stock"`
+	ReorderLevel int    `json:"reorder_level"`
+	CategoryID   int    `json:"category_id"`
+}
+
+// maxNameLength is the length of the name column.
+const maxNameLength = 255
+
+// Validate checks the fields of a product request that need no lookup. The SKU and barcode are checked by the
+// service, which normalises them first.
+func (r *RequestProduct) Validate() error {
+	v := validation.New()
+	v.Required("name", r.Name)
+	v.MaxLength("name", r.Name, maxNameLength)
+	v.NonNegative("price", r.Price)
+	v.NonNegative("stock", r.Stock)
+	v.NonNegative("reorder_level", r.ReorderLevel)
+	v.Positive("category_id", r.CategoryID)
+	return v.Err()
+}
+
+// PatchProduct is a JSON Merge Patch of a product: only the members it contains are changed. A null barcode removes
+// the barcode, the other members cannot be removed.
+type PatchProduct struct {
+	Name         patch.Field[string] `json:"name"`
+	SKU          patch.Field[string] `json:"sku"`
+	Barcode      patch.Field[string] `json:"barcode"`
+	Price        patch.Field[int]    `json:"price"`
+	Stock        patch.Field[int]    `json:"stock"`
+	ReorderLevel patch.Field[int]    `json:"reorder_level"`
+	CategoryID   patch.Field[int]    `json:"category_id"`
+}
+
+// Validate rejects null members that cannot be removed. The patched values are checked on the merged product, see
+// Apply.
+func (p *PatchProduct) Validate() error {
+	v := validation.New()
+	v.Check(!p.Name.Null, "name", "name must not be null")
+	v.Check(!p.SKU.Null, "sku", "sku must not be null")
+	v.Check(!p.Price.Null, "price", "price must not be null")
+	v.Check(!p.Stock.Null, "stock", "stock must not be null")
+	v.Check(!p.ReorderLevel.Null, "reorder_level", "reorder_level must not be null")
+	v.Check(!p.CategoryID.Null, "category_id", "category_id must not be null")
+	return v.Err()
+}
+
+// Apply merges the patch into product.
+func (p *PatchProduct) Apply(product *RequestProduct) {
+	p.Name.Apply(&product.Name)
+	p.SKU.Apply(&product.SKU)
+	p.Barcode.Apply(&product.Barcode)
+	p.Price.Apply(&product.Price)
+	p.Stock.Apply(&product.Stock)
+	p.ReorderLevel.Apply(&product.ReorderLevel)
+	p.CategoryID.Apply(&product.CategoryID)
 }
 
 type HealthCheck struct {
@@ -25,28 +90,106 @@ type HealthCheck struct {
 }
 
 type ProductWithCategories struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Price        int    `json:"price"`
-	Stock        int    `json:"stock"`
-	CategoryID   int    `json:"category_id,omitempty"`
-	CategoryName string `json:"category_name"`
-	CreatedAt    string `json:"created_at", omitempty`
-	UpdatedAt    string `json:"updated_at", omitempty`
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	SKU          string  `json:"sku"`
+	Barcode      string  `json:"barcode"`
+	Price        int     `json:"price"`
+	Stock        int     `json:"stock"`
+	ReorderLevel int     `json:"reorder_level"`
+	CategoryID   int     `json:"category_id,omitempty"`
+	CategoryName string  `json:"category_name"`
+	CreatedAt    string  `json:"created_at,omitempty"`
+	UpdatedAt    string  `json:"updated_at,omitempty"`
+	DeletedAt    *string `json:"deleted_at,omitempty"`
+	Version      int64   `json:"-"`
 }
 
 type ResponseProductWithCategories struct {
 	ID           int       `json:"id"`
 	Name         string    `json:"name"`
+	SKU          string    `json:"sku"`
+	Barcode      string    `json:"barcode,omitempty"`
 	Price        int       `json:"price"`
 	Stock        int       `json:"stock"`
+	ReorderLevel int       `json:"reorder_level"`
 	CategoryID   int       `json:"category_id,omitempty"`
 	CategoryName string    `json:"category_name"`
-	CreatedAt    time.Time `json:"created_at", omitempty`
-	UpdatedAt    time.Time `json:"updated_at", omitempty`
+	CreatedAt    time.Time `json:"created_at,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+	// DeletedAt is set for a deleted product, which is only listed with ProductFilter.IncludeDeleted.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Version counts the changes to the product. It is only read by GetProductByID and sent as the ETag header.
+	Version int64 `json:"-"`
+}
+
+// ResponseLowStockCategory groups the products of one category whose stock is at or below their reorder level.
+type ResponseLowStockCategory struct {
+	CategoryID   int                             `json:"category_id"`
+	CategoryName string                          `json:"category_name"`
+	Products     []ResponseProductWithCategories `json:"products"`
 }
 
 type Category struct {
 	ID   int    `json:"id"`
 	Name string `json:"name"`
 }
+
+// ProductSortFields lists the fields the product list can be sorted by.
+var ProductSortFields = []string{"id", "name", "sku", "price", "stock", "created_at", "updated_at"}
+
+// ProductFilter narrows and pages the product list. Nil prices and a zero CategoryID mean no filter; a CategoryID also
+// matches the products of its subcategories. Deleted products are left out unless IncludeDeleted is set.
+type ProductFilter struct {
+	CategoryID     int
+	MinPrice       *int
+	MaxPrice       *int
+	InStock        bool
+	IncludeDeleted bool
+	Pagination     pagination.Params
+}
+
+// ImportColumns are the columns of a product CSV import, named in its header row in any order. category holds the ID
+// or the name of the category.
+var ImportColumns = []string{"name", "price", "stock", "category", "sku"}
+
+// MaxImportRows limits the number of products one import can create.
+const MaxImportRows = 1000
+
+// ImportProductRow is one row of a product CSV import with its values as read from the file. Line is the line of the
+// file the row starts on, so the import report can point at it.
+type ImportProductRow struct {
+	Line     int
+	Name     string
+	Price    string
+	Stock    string
+	Category string
+	SKU      string
+}
+
+// Statuses of a row in an import report.
+const (
+	ImportStatusValid   = "valid"
+	ImportStatusCreated = "created"
+	ImportStatusFailed  = "failed"
+)
+
+// ImportProductResult is the outcome of one row of a product import. Errors name the CSV column of each invalid value.
+type ImportProductResult struct {
+	Line   int                   `json:"line"`
+	SKU    string                `json:"sku"`
+	Status string                `json:"status"`
+	ID     int                   `json:"id,omitempty"`
+	Errors []apperror.FieldError `json:"errors,omitempty"`
+}
+
+// ImportProductReport sums up a product import. Products are only created when every row is valid and DryRun is
+// false, so Created is either zero or Total.
+type ImportProductReport struct {
+	DryRun  bool                  `json:"dry_run"`
+	Total   int                   `json:"total"`
+	Valid   int                   `json:"valid"`
+	Failed  int                   `json:"failed"`
+	Created int                   `json:"created"`
+	Rows    []ImportProductResult `json:"rows"`
+}
@@ -1,20 +1,45 @@
 package repository
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
 )
 
+// productSortColumns maps entity.ProductSortFields to their columns.
+var productSortColumns = map[string]string{
+	"id":         "products.id",
+	"name":       "products.name",
+	"sku":        "products.sku",
+	"price":      "products.price",
+	"stock":      "products.stock",
+	"created_at": "products.created_at",
+	"updated_at": "products.updated_at",
+}
+
 type ProductRepository interface {
-	CreateProduct(product *entity.Product) error
-	UpdateProduct(id int64, product *entity.Product) error
-	DeleteProduct(id int64) error
-	GetProductByID(id int64) (*entity.ResponseProductWithCategories, error)
-	GetAllProducts() ([]entity.ResponseProductWithCategories, error)
-	GetCategoryByID(id int64) (*entity.Category, error)
+	CreateProduct(ctx context.Context, product *entity.Product, entry *audit.Entry) error
+	CreateProducts(ctx context.Context, products []*entity.Product, entries []*audit.Entry) error
+	UpdateProduct(ctx context.Context, id int64, version int64, product *entity.Product, entry *audit.Entry) (*alert.LowStock, error)
+	PatchProduct(ctx context.Context, id int64, version int64, patch *entity.PatchProduct, entry *audit.Entry) (*alert.LowStock, error)
+	DeleteProduct(ctx context.Context, id int64, version int64, entry *audit.Entry) error
+	RestoreProduct(ctx context.Context, id int64, entry *audit.Entry) error
+	GetProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error)
+	GetDeletedProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error)
+	GetProductByCode(ctx context.Context, code string) (*entity.ResponseProductWithCategories, error)
+	GetAllProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error)
+	GetCategoryByID(ctx context.Context, id int64) (*entity.Category, error)
+	GetCategoriesByName(ctx context.Context, name string) ([]entity.Category, error)
+	SearchProducts(ctx context.Context, keyword string, limit int) ([]entity.ResponseProductWithCategories, error)
+	GetLowStockProducts(ctx context.Context) ([]entity.ResponseProductWithCategories, error)
 }
 
 type productRepository struct {
@@ -25,17 +50,131 @@ func NewProductRepository(db *database.DB) ProductRepository {
 	return &productRepository{db: db}
 }
 
-func (r *productRepository) CreateProduct(product *entity.Product) error {
+// CreateProduct inserts the product and, when it starts with stock, records that stock as its first restock in the
+// stock ledger. entry is written to the audit log with the new product ID in the same transaction.
+func (r *productRepository) CreateProduct(ctx context.Context, product *entity.Product, entry *audit.Entry) error {
+	return r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		return insertProduct(ctx, tx, product, entry)
+	})
+}
+
+// CreateProducts inserts the products in one transaction, the same as CreateProduct does for each, so either all of
+// them are created or none is. entries[i] is the audit log entry of products[i].
+func (r *productRepository) CreateProducts(ctx context.Context, products []*entity.Product, entries []*audit.Entry) error {
+	if len(products) != len(entries) {
+		return fmt.Errorf("got %d audit entries for %d products", len(entries), len(products))
+	}
+
+	return r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		for i, product := range products {
+			if err := insertProduct(ctx, tx, product, entries[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// insertProduct inserts the product within tx and sets its ID, see CreateProduct.
+func insertProduct(ctx context.Context, tx *database.Tx, product *entity.Product, entry *audit.Entry) error {
 	var (
-		query string
-		err   error
+		query         string
+		movementQuery string
+		err           error
+	)
+
+	query = "INSERT INTO products (name, sku, barcode, price, stock, reorder_level, category_id, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9) RETURNING id"
+	movementQuery = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+
+	if err = lockCategory(ctx, tx, product.CategoryID); err != nil {
+		return err
+	}
+
+	err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&product.ID)
+		}, product.Name, product.SKU, product.Barcode, product.Price, product.Stock, product.ReorderLevel, product.CategoryID, "now()", "now()")
+	})
+
+	if err != nil {
+		return codeConflict(err)
+	}
+
+	if entry != nil {
+		entry.EntityID = int64(product.ID)
+	}
+
+	if err = audit.Write(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	if product.Stock == 0 {
+		return nil
+	}
+
+	return tx.WithStmtContext(ctx, movementQuery, func(stmt *database.Stmt) error {
+		_, err = stmt.ExecContext(ctx, product.ID, "restock", product.Stock, product.Stock, "initial stock", "now()")
+		return err
+	})
+}
+
+// UpdateProduct updates the product and records any change to its stock as an adjustment in the stock ledger. entry
+// is written to the audit log in the same transaction. A version other than zero is the version the update is based
+// on: when the product has been changed since, nothing is updated and a precondition failed error is returned. A low
+// stock event is returned when the update takes the stock to or below the reorder level.
+func (r *productRepository) UpdateProduct(ctx context.Context, id int64, version int64, product *entity.Product, entry *audit.Entry) (*alert.LowStock, error) {
+	var (
+		lockQuery     string
+		query         string
+		movementQuery string
+		args          []interface{}
+		currentID     int64
+		currentStock  int
+		err           error
 	)
 
-	query = "INSERT INTO products (name, price, stock, category_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	lockQuery = "SELECT id, stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	query, args = whereVersion("UPDATE products SET name = $1, sku = $2, barcode = NULLIF($3, ''), price = $4, stock = $5, reorder_level = $6, category_id = $7, updated_at = $8, version = version + 1 WHERE id = $9", []interface{}{product.Name, product.SKU, product.Barcode, product.Price, product.Stock, product.ReorderLevel, product.CategoryID, "now()", id}, version)
+	movementQuery = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
 
-	err = r.db.WithTx(func(tx *database.Tx) error {
-		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(product.Name, product.Price, product.Stock, product.CategoryID, "now()", "now()")
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		if err = lockCategory(ctx, tx, product.CategoryID); err != nil {
+			return err
+		}
+
+		err = tx.WithStmtContext(ctx, lockQuery, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&currentID, &currentStock)
+			}, id)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if currentID == 0 {
+			return apperror.NotFound("product not found")
+		}
+
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return execVersioned(ctx, stmt, version, args...)
+		})
+
+		if err != nil {
+			return codeConflict(err)
+		}
+
+		if err = audit.Write(ctx, tx, entry); err != nil {
+			return err
+		}
+
+		if product.Stock == currentStock {
+			return nil
+		}
+
+		err = tx.WithStmtContext(ctx, movementQuery, func(stmt *database.Stmt) error {
+			_, err = stmt.ExecContext(ctx, id, "adjustment", product.Stock-currentStock, product.Stock, "product update", "now()")
 			return err
 		})
 
@@ -46,84 +185,345 @@ func (r *productRepository) CreateProduct(product *entity.Product) error {
 		return nil
 	})
 
-	return err
+	if err != nil {
+		return nil, err
+	}
+
+	return lowStock(id, product.Name, currentStock, product.Stock, product.ReorderLevel), nil
+}
+
+// PatchProduct updates only the columns of the members present in patch. A stock change is recorded as an adjustment
+// in the stock ledger and version is checked, as by UpdateProduct, and entry is written to the audit log in the same
+// transaction. A low stock event is returned as by UpdateProduct.
+func (r *productRepository) PatchProduct(ctx context.Context, id int64, version int64, patch *entity.PatchProduct, entry *audit.Entry) (*alert.LowStock, error) {
+	var (
+		lockQuery     string
+		query         string
+		movementQuery string
+		currentID     int64
+		currentName   string
+		currentStock  int
+		reorderLevel  int
+		err           error
+	)
+
+	set, args := productPatchClause(patch)
+	if set == "" {
+		return nil, nil
+	}
+
+	args = append(args, "now()", id)
+	lockQuery = "SELECT id, name, stock, reorder_level FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	query = fmt.Sprintf("UPDATE products SET %s, updated_at = $%d, version = version + 1 WHERE id = $%d", set, len(args)-1, len(args))
+	query, args = whereVersion(query, args, version)
+	movementQuery = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		if patch.CategoryID.Set {
+			if err = lockCategory(ctx, tx, patch.CategoryID.Value); err != nil {
+				return err
+			}
+		}
+
+		err = tx.WithStmtContext(ctx, lockQuery, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&currentID, &currentName, &currentStock, &reorderLevel)
+			}, id)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if currentID == 0 {
+			return apperror.NotFound("product not found")
+		}
+
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return execVersioned(ctx, stmt, version, args...)
+		})
+
+		if err != nil {
+			return codeConflict(err)
+		}
+
+		if err = audit.Write(ctx, tx, entry); err != nil {
+			return err
+		}
+
+		if !patch.Stock.Set || patch.Stock.Value == currentStock {
+			return nil
+		}
+
+		return tx.WithStmtContext(ctx, movementQuery, func(stmt *database.Stmt) error {
+			_, err := stmt.ExecContext(ctx, id, "adjustment", patch.Stock.Value-currentStock, patch.Stock.Value, "product update", "now()")
+			return err
+		})
+	})
+
+	if err != nil || !patch.Stock.Set {
+		return nil, err
+	}
+
+	patch.Name.Apply(&currentName)
+	patch.ReorderLevel.Apply(&reorderLevel)
+
+	return lowStock(id, currentName, currentStock, patch.Stock.Value, reorderLevel), nil
+}
+
+// lowStock returns the low stock event of a product update changing the stock from before to after, or nil when the
+// update does not take the stock to or below reorderLevel.
+func lowStock(id int64, name string, before, after, reorderLevel int) *alert.LowStock {
+	if !alert.Crossed(before, after, reorderLevel) {
+		return nil
+	}
+
+	return &alert.LowStock{
+		ProductID:    id,
+		ProductName:  name,
+		Stock:        after,
+		ReorderLevel: reorderLevel,
+		Source:       alert.SourceProductUpdate,
+	}
+}
+
+// productPatchClause builds the SET list of the columns patched by patch, without updated_at. It returns an empty
+// list for a patch without members.
+func productPatchClause(patch *entity.PatchProduct) (string, []interface{}) {
+	var (
+		columns []string
+		args    []interface{}
+	)
+
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		columns = append(columns, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if patch.Name.Set {
+		set("name", patch.Name.Value)
+	}
+
+	if patch.SKU.Set {
+		set("sku", patch.SKU.Value)
+	}
+
+	if patch.Barcode.Set {
+		args = append(args, patch.Barcode.Value)
+		columns = append(columns, fmt.Sprintf("barcode = NULLIF($%d, '')", len(args)))
+	}
+
+	if patch.Price.Set {
+		set("price", patch.Price.Value)
+	}
+
+	if patch.Stock.Set {
+		set("stock", patch.Stock.Value)
+	}
+
+	if patch.ReorderLevel.Set {
+		set("reorder_level", patch.ReorderLevel.Value)
+	}
 
+	if patch.CategoryID.Set {
+		set("category_id", patch.CategoryID.Value)
+	}
+
+	return strings.Join(columns, ", "), args
 }
 
-func (r *productRepository) UpdateProduct(id int64, product *entity.Product) error {
+// DeleteProduct marks the product as deleted, which keeps the row for the transactions and stock movements that refer
+// to it, and writes entry to the audit log in the same transaction. version is checked as by UpdateProduct.
+func (r *productRepository) DeleteProduct(ctx context.Context, id int64, version int64, entry *audit.Entry) error {
 	var (
 		query string
+		args  []interface{}
 		err   error
 	)
 
-	query = "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4, updated_at = $5 WHERE id = $6"
+	query, args = whereVersion("UPDATE products SET deleted_at = $1, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL", []interface{}{"now()", id}, version)
 
-	err = r.db.WithTx(func(tx *database.Tx) error {
-		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err := stmt.Exec(product.Name, product.Price, product.Stock, product.CategoryID, "now()", id)
-			return err
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return execVersioned(ctx, stmt, version, args...)
 		})
 
 		if err != nil {
 			return err
 		}
 
+		if err = audit.Write(ctx, tx, entry); err != nil {
+			return err
+		}
+
 		return nil
 	})
 
 	return err
 }
 
-func (r *productRepository) DeleteProduct(id int64) error {
+// RestoreProduct clears the deletion mark of a deleted product and writes entry to the audit log in the same
+// transaction. It returns a not found error when the product is not deleted, and a conflict error when its SKU or
+// barcode has been taken by another product since.
+func (r *productRepository) RestoreProduct(ctx context.Context, id int64, entry *audit.Entry) error {
 	var (
 		query string
 		err   error
 	)
 
-	query = "DELETE FROM products WHERE id = $1"
+	query = "UPDATE products SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL"
 
-	err = r.db.WithTx(func(tx *database.Tx) error {
-		err = tx.WithStmt(query, func(stmt *database.Stmt) error {
-			_, err = stmt.Exec(id)
-			return err
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			result, err := stmt.ExecContext(ctx, "now()", id)
+			if err != nil {
+				return codeConflict(err)
+			}
+
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if affected == 0 {
+				return apperror.NotFound("deleted product not found")
+			}
+
+			return nil
 		})
 
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return audit.Write(ctx, tx, entry)
 	})
 
 	return err
 }
 
-func (r *productRepository) GetAllProducts() ([]entity.ResponseProductWithCategories, error) {
+// lockCategory locks category id against deletion until tx ends, so a product is never written to a category that is
+// deleted in the meantime: a category delete that has to reject the products of the category waits for tx and then sees
+// the product. It returns a conflict error when the category has been deleted already.
+func lockCategory(ctx context.Context, tx *database.Tx, id int) error {
+	var (
+		query  string
+		locked int
+		err    error
+	)
+
+	query = "SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE"
+
+	err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&locked)
+		}, id)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if locked == 0 {
+		return apperror.Conflict("category has been deleted")
+	}
+
+	return nil
+}
+
+// codeConflict turns a unique violation of the SKU or barcode into the conflict error the service returns for a code
+// already used by another product. Any other error is returned unchanged.
+func codeConflict(err error) error {
+	constraint, ok := database.UniqueViolation(err)
+	if !ok {
+		return err
+	}
+
+	for _, field := range []string{"sku", "barcode"} {
+		if strings.Contains(constraint, field) {
+			return apperror.Conflict("%s already used by another product", field)
+		}
+	}
+
+	return err
+}
+
+// whereVersion restricts the statement query with args to the given version of the row, unless version is zero.
+func whereVersion(query string, args []interface{}, version int64) (string, []interface{}) {
+	if version == 0 {
+		return query, args
+	}
+
+	args = append(args, version)
+	return fmt.Sprintf("%s AND version = $%d", query, len(args)), args
+}
+
+// execVersioned runs a statement restricted by whereVersion. When it affects no row the product has been changed
+// since version, or removed, and a precondition failed error is returned.
+func execVersioned(ctx context.Context, stmt *database.Stmt, version int64, args ...interface{}) error {
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return nil
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return apperror.PreconditionFailed("product has been changed since version %d", version)
+	}
+
+	return nil
+}
+
+func (r *productRepository) GetAllProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error) {
 	var (
 		query             string
+		countQuery        string
+		where             string
+		args              []interface{}
+		total             int
 		products          []entity.ProductWithCategories
 		productCategories []entity.ResponseProductWithCategories
 		err               error
 	)
 
-	query = "SELECT products.id, products.name, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id"
+	where, args = productFilterClause(filter)
+	countQuery = "SELECT COUNT(*) FROM products" + where
+	query = fmt.Sprintf("SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.deleted_at FROM products JOIN categories ON products.category_id = categories.id%s %s LIMIT $%d OFFSET $%d", where, filter.Pagination.OrderBy(productSortColumns, "products.id"), len(args)+1, len(args)+2)
+
+	err = r.db.WithStmtContext(ctx, countQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&total)
+		}, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
 
-	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
-		err = stmt.Query(func(rows *database.Rows) error {
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
 			var product entity.ProductWithCategories
-			if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName); err != nil {
+			if err := rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcode, &product.Price, &product.Stock, &product.ReorderLevel, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName, &product.DeletedAt); err != nil {
 				return err
 			}
 
 			products = append(products, product)
 			return nil
-		})
+		}, append(args, filter.Pagination.Limit(), filter.Pagination.Offset())...)
 
 		return err
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	for _, product := range products {
@@ -133,19 +533,62 @@ func (r *productRepository) GetAllProducts() ([]entity.ResponseProductWithCatego
 		productCategories = append(productCategories, entity.ResponseProductWithCategories{
 			ID:           product.ID,
 			Name:         product.Name,
+			SKU:          product.SKU,
+			Barcode:      product.Barcode,
 			Price:        product.Price,
 			Stock:        product.Stock,
+			ReorderLevel: product.ReorderLevel,
 			CategoryName: product.CategoryName,
 			CategoryID:   product.CategoryID,
 			CreatedAt:    createdAt,
 			UpdatedAt:    updatedAt,
+			DeletedAt:    datetime.ParseNullTime(product.DeletedAt),
 		})
 	}
 
-	return productCategories, nil
+	return productCategories, total, nil
 }
 
-func (r *productRepository) GetProductByID(id int64) (*entity.ResponseProductWithCategories, error) {
+// productFilterClause builds the WHERE clause shared by the product list and count queries. A category filter also
+// matches the products of its subcategories, at any depth. The descendants are collected with UNION, which drops a category
+// already collected, so the walk ends even if the parents of the categories form a cycle.
+func productFilterClause(filter entity.ProductFilter) (string, []interface{}) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "products.deleted_at IS NULL")
+	}
+
+	if filter.CategoryID != 0 {
+		args = append(args, filter.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("products.category_id IN (WITH RECURSIVE descendants (id) AS (SELECT id FROM categories WHERE id = $%d UNION SELECT categories.id FROM categories JOIN descendants ON categories.parent_id = descendants.id WHERE categories.deleted_at IS NULL) SELECT id FROM descendants)", len(args)))
+	}
+
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("products.price >= $%d", len(args)))
+	}
+
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("products.price <= $%d", len(args)))
+	}
+
+	if filter.InStock {
+		conditions = append(conditions, "products.stock > 0")
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *productRepository) GetProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error) {
 	var (
 		product         entity.ProductWithCategories
 		productCategory entity.ResponseProductWithCategories
@@ -153,11 +596,11 @@ func (r *productRepository) GetProductByID(id int64) (*entity.ResponseProductWit
 		query           string
 	)
 
-	query = "SELECT products.id, products.name, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1"
+	query = "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.version FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1 AND products.deleted_at IS NULL"
 
-	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
-		err = stmt.Query(func(rows *database.Rows) error {
-			if err := rows.Scan(&product.ID, &product.Name, &product.Price, &product.Stock, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName); err != nil {
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			if err := rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcode, &product.Price, &product.Stock, &product.ReorderLevel, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName, &product.Version); err != nil {
 				return err
 			}
 
@@ -172,7 +615,7 @@ func (r *productRepository) GetProductByID(id int64) (*entity.ResponseProductWit
 	}
 
 	if product.ID == 0 {
-		return nil, errors.New("product not found")
+		return nil, apperror.NotFound("product not found")
 	}
 
 	createdAt, _ := datetime.ParseTime(product.CreatedAt)
@@ -181,28 +624,130 @@ func (r *productRepository) GetProductByID(id int64) (*entity.ResponseProductWit
 	productCategory = entity.ResponseProductWithCategories{
 		ID:           product.ID,
 		Name:         product.Name,
+		SKU:          product.SKU,
+		Barcode:      product.Barcode,
 		Price:        product.Price,
 		Stock:        product.Stock,
+		ReorderLevel: product.ReorderLevel,
 		CategoryID:   product.CategoryID,
 		CategoryName: product.CategoryName,
 		CreatedAt:    createdAt,
 		UpdatedAt:    updatedAt,
+		Version:      product.Version,
 	}
 
 	return &productCategory, nil
 }
 
-func (r *productRepository) GetCategoryByID(id int64) (*entity.Category, error) {
+// GetDeletedProductByID returns a deleted product, the one RestoreProduct would bring back.
+func (r *productRepository) GetDeletedProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error) {
+	var (
+		product         entity.ProductWithCategories
+		productCategory entity.ResponseProductWithCategories
+		err             error
+		query           string
+	)
+
+	query = "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.deleted_at, products.version FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1 AND products.deleted_at IS NOT NULL"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcode, &product.Price, &product.Stock, &product.ReorderLevel, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName, &product.DeletedAt, &product.Version)
+		}, id)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if product.ID == 0 {
+		return nil, apperror.NotFound("deleted product not found")
+	}
+
+	createdAt, _ := datetime.ParseTime(product.CreatedAt)
+	updatedAt, _ := datetime.ParseTime(product.UpdatedAt)
+
+	productCategory = entity.ResponseProductWithCategories{
+		ID:           product.ID,
+		Name:         product.Name,
+		SKU:          product.SKU,
+		Barcode:      product.Barcode,
+		Price:        product.Price,
+		Stock:        product.Stock,
+		ReorderLevel: product.ReorderLevel,
+		CategoryID:   product.CategoryID,
+		CategoryName: product.CategoryName,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+		DeletedAt:    datetime.ParseNullTime(product.DeletedAt),
+		Version:      product.Version,
+	}
+
+	return &productCategory, nil
+}
+
+// GetProductByCode finds the product whose barcode or SKU equals code, so a scanner can resolve either.
+func (r *productRepository) GetProductByCode(ctx context.Context, code string) (*entity.ResponseProductWithCategories, error) {
+	var (
+		product         entity.ProductWithCategories
+		productCategory entity.ResponseProductWithCategories
+		err             error
+		query           string
+	)
+
+	query = "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND (products.barcode = $1 OR products.sku = $1) ORDER BY COALESCE(products.barcode = $1, false) DESC LIMIT 1"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			if err := rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcode, &product.Price, &product.Stock, &product.ReorderLevel, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName); err != nil {
+				return err
+			}
+
+			return nil
+		}, code)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if product.ID == 0 {
+		return nil, apperror.NotFound("product not found")
+	}
+
+	createdAt, _ := datetime.ParseTime(product.CreatedAt)
+	updatedAt, _ := datetime.ParseTime(product.UpdatedAt)
+
+	productCategory = entity.ResponseProductWithCategories{
+		ID:           product.ID,
+		Name:         product.Name,
+		SKU:          product.SKU,
+		Barcode:      product.Barcode,
+		Price:        product.Price,
+		Stock:        product.Stock,
+		ReorderLevel: product.ReorderLevel,
+		CategoryID:   product.CategoryID,
+		CategoryName: product.CategoryName,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}
+
+	return &productCategory, nil
+}
+
+func (r *productRepository) GetCategoryByID(ctx context.Context, id int64) (*entity.Category, error) {
 	var (
 		category entity.Category
 		err      error
 		query    string
 	)
 
-	query = "SELECT id, name FROM categories WHERE id = $1"
+	query = "SELECT id, name FROM categories WHERE id = $1 AND deleted_at IS NULL"
 
-	err = r.db.WithStmt(query, func(stmt *database.Stmt) error {
-		err = stmt.Query(func(rows *database.Rows) error {
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
 			if err := rows.Scan(&category.ID, &category.Name); err != nil {
 				return err
 			}
@@ -218,8 +763,159 @@ func (r *productRepository) GetCategoryByID(id int64) (*entity.Category, error)
 	}
 
 	if category.ID == 0 {
-		return nil, errors.New("category not found")
+		return nil, apperror.NotFound("category not found")
 	}
 
 	return &category, nil
 }
+
+// GetCategoriesByName returns the categories named name, ignoring case, in the order they were created. Category names
+// are not unique, so there may be more than one.
+func (r *productRepository) GetCategoriesByName(ctx context.Context, name string) ([]entity.Category, error) {
+	var (
+		categories []entity.Category
+		err        error
+		query      string
+	)
+
+	query = "SELECT id, name FROM categories WHERE lower(name) = lower($1) AND deleted_at IS NULL ORDER BY id"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var category entity.Category
+			if err := rows.Scan(&category.ID, &category.Name); err != nil {
+				return err
+			}
+
+			categories = append(categories, category)
+			return nil
+		}, name)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// SearchProducts ranks products by how well their name, and to a lesser degree their category name, match the
+// keyword. Every word of the keyword is matched as a prefix so "bebe sus" finds "Bebelac" in "Susu"; a substring
+// match on the product name is kept as a fallback for fragments taken from the middle of a word; there "%" and "_" in
+// the keyword match themselves rather than any text.
+func (r *productRepository) SearchProducts(ctx context.Context, keyword string, limit int) ([]entity.ResponseProductWithCategories, error) {
+	var (
+		query             string
+		products          []entity.ProductWithCategories
+		productCategories []entity.ResponseProductWithCategories
+		err               error
+	)
+
+	query = "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND ((setweight(to_tsvector('simple', products.name), 'A') || setweight(to_tsvector('simple', categories.name), 'B')) @@ to_tsquery('simple', $1) OR products.name ILIKE '%' || $2 || '%' ESCAPE '\\') ORDER BY ts_rank(setweight(to_tsvector('simple', products.name), 'A') || setweight(to_tsvector('simple', categories.name), 'B'), to_tsquery('simple', $1)) DESC, products.name ASC, products.id ASC LIMIT $3"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var product entity.ProductWithCategories
+			if err := rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcode, &product.Price, &product.Stock, &product.ReorderLevel, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName); err != nil {
+				return err
+			}
+
+			products = append(products, product)
+			return nil
+		}, toPrefixTsQuery(keyword), database.EscapeLike(keyword), limit)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		createdAt, _ := datetime.ParseTime(product.CreatedAt)
+		updatedAt, _ := datetime.ParseTime(product.UpdatedAt)
+
+		productCategories = append(productCategories, entity.ResponseProductWithCategories{
+			ID:           product.ID,
+			Name:         product.Name,
+			SKU:          product.SKU,
+			Barcode:      product.Barcode,
+			Price:        product.Price,
+			Stock:        product.Stock,
+			ReorderLevel: product.ReorderLevel,
+			CategoryName: product.CategoryName,
+			CategoryID:   product.CategoryID,
+			CreatedAt:    createdAt,
+			UpdatedAt:    updatedAt,
+		})
+	}
+
+	return productCategories, nil
+}
+
+// GetLowStockProducts lists the products whose stock is at or below their reorder level, ordered by category so
+// they can be grouped, and within a category by the lowest stock first.
+func (r *productRepository) GetLowStockProducts(ctx context.Context) ([]entity.ResponseProductWithCategories, error) {
+	var (
+		query             string
+		products          []entity.ProductWithCategories
+		productCategories []entity.ResponseProductWithCategories
+		err               error
+	)
+
+	query = "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND products.stock <= products.reorder_level ORDER BY categories.name ASC, categories.id ASC, products.stock ASC, products.id ASC"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var product entity.ProductWithCategories
+			if err := rows.Scan(&product.ID, &product.Name, &product.SKU, &product.Barcode, &product.Price, &product.Stock, &product.ReorderLevel, &product.CreatedAt, &product.UpdatedAt, &product.CategoryID, &product.CategoryName); err != nil {
+				return err
+			}
+
+			products = append(products, product)
+			return nil
+		})
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		createdAt, _ := datetime.ParseTime(product.CreatedAt)
+		updatedAt, _ := datetime.ParseTime(product.UpdatedAt)
+
+		productCategories = append(productCategories, entity.ResponseProductWithCategories{
+			ID:           product.ID,
+			Name:         product.Name,
+			SKU:          product.SKU,
+			Barcode:      product.Barcode,
+			Price:        product.Price,
+			Stock:        product.Stock,
+			ReorderLevel: product.ReorderLevel,
+			CategoryName: product.CategoryName,
+			CategoryID:   product.CategoryID,
+			CreatedAt:    createdAt,
+			UpdatedAt:    updatedAt,
+		})
+	}
+
+	return productCategories, nil
+}
+
+// toPrefixTsQuery turns free text into a tsquery that requires every word as a prefix, e.g. "Bebe sus" becomes
+// "bebe:* & sus:*". Anything other than letters and digits is dropped so user input can never break the tsquery
+// syntax.
+func toPrefixTsQuery(keyword string) string {
+	words := strings.FieldsFunc(strings.ToLower(keyword), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	return strings.Join(words, " & ")
+}
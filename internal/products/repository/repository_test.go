@@ -1,17 +1,25 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
 )
 
 type testQuery struct {
@@ -27,6 +35,10 @@ type testConfig struct {
 	beginErr    error
 	commitErr   error
 	rollbackErr error
+	queryArgs   map[string][]driver.Value
+	execArgs    map[string][]driver.Value
+	// rowsAffected overrides the rows a statement affects, one when unset.
+	rowsAffected map[string]int64
 }
 
 func (c *testConfig) getPrepareErr(query string) error {
@@ -90,10 +102,21 @@ func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
 	if err := s.cfg.getExecErr(s.query); err != nil {
 		return nil, err
 	}
+	if s.cfg.execArgs == nil {
+		s.cfg.execArgs = make(map[string][]driver.Value)
+	}
+	s.cfg.execArgs[s.query] = append([]driver.Value(nil), args...)
+	if n, ok := s.cfg.rowsAffected[s.query]; ok {
+		return driver.RowsAffected(n), nil
+	}
 	return driver.RowsAffected(1), nil
 }
 
 func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.cfg.queryArgs == nil {
+		s.cfg.queryArgs = make(map[string][]driver.Value)
+	}
+	s.cfg.queryArgs[s.query] = append([]driver.Value(nil), args...)
 	q := s.cfg.getQuery(s.query)
 	if q.queryErr != nil {
 		return nil, q.queryErr
@@ -146,6 +169,19 @@ func (r *testRows) Next(dest []driver.Value) error {
 
 var driverCounter int64
 
+const auditQuery = "INSERT INTO audit_logs (actor_id, actor_username, action, entity_type, entity_id, before, after, created_at) VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)"
+
+const categoryLockQuery = "SELECT 1 FROM categories WHERE id = $1 AND deleted_at IS NULL FOR SHARE"
+
+// categoryLocked adds to queries the lock of lockCategory finding the category.
+func categoryLocked(queries map[string]testQuery) map[string]testQuery {
+	locked := map[string]testQuery{categoryLockQuery: {columns: []string{"?column?"}, rows: [][]driver.Value{{int64(1)}}}}
+	for query, result := range queries {
+		locked[query] = result
+	}
+	return locked
+}
+
 func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
 	t.Helper()
 	name := fmt.Sprintf("repo_test_driver_%d", atomic.AddInt64(&driverCounter, 1))
@@ -176,34 +212,376 @@ func TestNewProductRepository(t *testing.T) {
 }
 
 func TestProductRepositoryCreateProduct(t *testing.T) {
-	query := "INSERT INTO products (name, price, stock, category_id, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)"
-	product := &entity.Product{Name: "p1", Price: 10, Stock: 2, CategoryID: 3}
+	query := "INSERT INTO products (name, sku, barcode, price, stock, reorder_level, category_id, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9) RETURNING id"
+	movementQuery := "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	inserted := categoryLocked(map[string]testQuery{query: {columns: []string{"id"}, rows: [][]driver.Value{{int64(5)}}}})
 	errPrepare := errors.New("prepare")
+	errQuery := errors.New("query")
 	errExec := errors.New("exec")
 	errBegin := errors.New("begin")
 	errCommit := errors.New("commit")
 
+	tests := []struct {
+		name         string
+		stock        int
+		cfg          *testConfig
+		wantErr      error
+		wantMovement []driver.Value
+	}{
+		{name: "ok", stock: 2, cfg: &testConfig{query: inserted}, wantMovement: []driver.Value{int64(5), "restock", int64(2), int64(2), "initial stock", "now()"}},
+		{name: "no-stock", cfg: &testConfig{query: inserted}},
+		{name: "prepare", stock: 2, cfg: &testConfig{query: categoryLocked(nil), prepareErr: map[string]error{query: errPrepare}}, wantErr: errPrepare},
+		{name: "query", stock: 2, cfg: &testConfig{query: categoryLocked(map[string]testQuery{query: {queryErr: errQuery}})}, wantErr: errQuery},
+		{name: "sku-taken", stock: 2, cfg: &testConfig{query: categoryLocked(map[string]testQuery{query: {queryErr: &pq.Error{Code: "23505", Constraint: "products_sku_active_idx"}}})}, wantErr: apperror.ErrConflict},
+		{name: "category-deleted", stock: 2, cfg: &testConfig{}, wantErr: apperror.ErrConflict},
+		{name: "movement", stock: 2, cfg: &testConfig{query: inserted, execErr: map[string]error{movementQuery: errExec}}, wantErr: errExec},
+		{name: "audit", stock: 2, cfg: &testConfig{query: inserted, execErr: map[string]error{auditQuery: errExec}}, wantErr: errExec},
+		{name: "begin", stock: 2, cfg: &testConfig{beginErr: errBegin}, wantErr: errBegin},
+		{name: "commit", stock: 2, cfg: &testConfig{query: inserted, commitErr: errCommit}, wantErr: errCommit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			product := &entity.Product{Name: "p1", SKU: "SKU-1", Barcode: "8992761166014", Price: 10, Stock: tt.stock, ReorderLevel: 4, CategoryID: 3}
+			entry := &audit.Entry{Actor: audit.Actor{ID: 1, Username: "admin"}, Action: audit.ActionCreate, EntityType: audit.EntityProduct, After: entity.RequestProduct{Name: "p1"}}
+			err := repo.CreateProduct(context.Background(), product, entry)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected nil error, got %v", err)
+				}
+				if product.ID != 5 {
+					t.Fatalf("unexpected product id: %d", product.ID)
+				}
+				wantArgs := []driver.Value{"p1", "SKU-1", "8992761166014", int64(10), int64(tt.stock), int64(4), int64(3), "now()", "now()"}
+				if got := tt.cfg.queryArgs[query]; !reflect.DeepEqual(got, wantArgs) {
+					t.Fatalf("expected insert args %v, got %v", wantArgs, got)
+				}
+				if got := tt.cfg.queryArgs[categoryLockQuery]; !reflect.DeepEqual(got, []driver.Value{int64(3)}) {
+					t.Fatalf("expected category lock args [3], got %v", got)
+				}
+				if got := tt.cfg.execArgs[movementQuery]; !reflect.DeepEqual(got, tt.wantMovement) {
+					t.Fatalf("expected movement %v, got %v", tt.wantMovement, got)
+				}
+				wantAudit := []driver.Value{int64(1), "admin", "create", "product", int64(5), nil, `{"name":"p1","sku":"","barcode":"","price":0,"stock":0,"reorder_level":0,"category_id":0}`, "now()"}
+				if got := tt.cfg.execArgs[auditQuery]; !reflect.DeepEqual(got, wantAudit) {
+					t.Fatalf("expected audit %v, got %v", wantAudit, got)
+				}
+				return
+			}
+			if err == nil || !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestProductRepositoryCreateProducts(t *testing.T) {
+	query := "INSERT INTO products (name, sku, barcode, price, stock, reorder_level, category_id, created_at, updated_at) VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7, $8, $9) RETURNING id"
+	movementQuery := "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	inserted := categoryLocked(map[string]testQuery{query: {columns: []string{"id"}, rows: [][]driver.Value{{int64(5)}}}})
+	errQuery := errors.New("query")
+	errExec := errors.New("exec")
+	errCommit := errors.New("commit")
+
 	tests := []struct {
 		name    string
+		entries int
 		cfg     *testConfig
-		wantErr error
+		wantErr string
+	}{
+		{name: "ok", entries: 2, cfg: &testConfig{query: inserted}},
+		{name: "entries", entries: 1, cfg: &testConfig{query: inserted}, wantErr: "got 1 audit entries for 2 products"},
+		{name: "query", entries: 2, cfg: &testConfig{query: categoryLocked(map[string]testQuery{query: {queryErr: errQuery}})}, wantErr: errQuery.Error()},
+		{name: "movement", entries: 2, cfg: &testConfig{query: inserted, execErr: map[string]error{movementQuery: errExec}}, wantErr: errExec.Error()},
+		{name: "commit", entries: 2, cfg: &testConfig{query: inserted, commitErr: errCommit}, wantErr: errCommit.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			products := []*entity.Product{
+				{Name: "p1", SKU: "SKU-1", Price: 10, Stock: 0, CategoryID: 3},
+				{Name: "p2", SKU: "SKU-2", Price: 20, Stock: 4, CategoryID: 3},
+			}
+			entries := make([]*audit.Entry, tt.entries)
+			for i := range entries {
+				entries[i] = &audit.Entry{Actor: audit.Actor{ID: 1, Username: "admin"}, Action: audit.ActionCreate, EntityType: audit.EntityProduct, After: entity.RequestProduct{Name: "p"}}
+			}
+			err := repo.CreateProducts(context.Background(), products, entries)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			wantArgs := []driver.Value{"p2", "SKU-2", "", int64(20), int64(4), int64(0), int64(3), "now()", "now()"}
+			if got := tt.cfg.queryArgs[query]; !reflect.DeepEqual(got, wantArgs) {
+				t.Fatalf("expected insert args %v, got %v", wantArgs, got)
+			}
+			wantMovement := []driver.Value{int64(5), "restock", int64(4), int64(4), "initial stock", "now()"}
+			if got := tt.cfg.execArgs[movementQuery]; !reflect.DeepEqual(got, wantMovement) {
+				t.Fatalf("expected movement %v, got %v", wantMovement, got)
+			}
+			for i, entry := range entries {
+				if products[i].ID != 5 || entry.EntityID != 5 {
+					t.Fatalf("unexpected product %d id %d, audit entity id %d", i, products[i].ID, entry.EntityID)
+				}
+			}
+		})
+	}
+}
+
+func TestProductRepositoryUpdateProduct(t *testing.T) {
+	lockQuery := "SELECT id, stock FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	query := "UPDATE products SET name = $1, sku = $2, barcode = NULLIF($3, ''), price = $4, stock = $5, reorder_level = $6, category_id = $7, updated_at = $8, version = version + 1 WHERE id = $9"
+	versionedQuery := query + " AND version = $10"
+	movementQuery := "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	product := &entity.Product{Name: "p2", SKU: "SKU-2", Price: 20, Stock: 5, ReorderLevel: 2, CategoryID: 4}
+	locked := func(stock int64) map[string]testQuery {
+		return categoryLocked(map[string]testQuery{lockQuery: {columns: []string{"id", "stock"}, rows: [][]driver.Value{{int64(9), stock}}}})
+	}
+	errExec := errors.New("exec")
+	errCommit := errors.New("commit")
+
+	tests := []struct {
+		name         string
+		version      int64
+		stock        int
+		cfg          *testConfig
+		wantErr      string
+		wantMovement []driver.Value
+		wantLowStock *alert.LowStock
+	}{
+		{name: "ok", cfg: &testConfig{query: locked(3)}, wantMovement: []driver.Value{int64(9), "adjustment", int64(2), int64(5), "product update", "now()"}},
+		{
+			name:         "low-stock",
+			stock:        2,
+			cfg:          &testConfig{query: locked(3)},
+			wantMovement: []driver.Value{int64(9), "adjustment", int64(-1), int64(2), "product update", "now()"},
+			wantLowStock: &alert.LowStock{ProductID: 9, ProductName: "p2", Stock: 2, ReorderLevel: 2, Source: alert.SourceProductUpdate},
+		},
+		{name: "version", version: 3, cfg: &testConfig{query: locked(5)}},
+		{name: "stale-version", version: 3, cfg: &testConfig{query: locked(5), rowsAffected: map[string]int64{versionedQuery: 0}}, wantErr: "product has been changed since version 3"},
+		{name: "same-stock", cfg: &testConfig{query: locked(5)}},
+		{name: "missing", cfg: &testConfig{query: categoryLocked(nil)}, wantErr: "product not found"},
+		{name: "category-deleted", cfg: &testConfig{}, wantErr: "category has been deleted"},
+		{name: "exec", cfg: &testConfig{query: locked(3), execErr: map[string]error{query: errExec}}, wantErr: errExec.Error()},
+		{name: "barcode-taken", cfg: &testConfig{query: locked(3), execErr: map[string]error{query: &pq.Error{Code: "23505", Constraint: "products_barcode_active_idx"}}}, wantErr: "barcode already used by another product"},
+		{name: "movement", cfg: &testConfig{query: locked(3), execErr: map[string]error{movementQuery: errExec}}, wantErr: errExec.Error()},
+		{name: "audit", cfg: &testConfig{query: locked(3), execErr: map[string]error{auditQuery: errExec}}, wantErr: errExec.Error()},
+		{name: "commit", cfg: &testConfig{query: locked(3), commitErr: errCommit}, wantErr: errCommit.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			entry := &audit.Entry{Actor: audit.Actor{ID: 1, Username: "admin"}, Action: audit.ActionUpdate, EntityType: audit.EntityProduct, EntityID: 9, Before: map[string]int{"price": 10}, After: map[string]int{"price": 20}}
+			product := *product
+			if tt.stock != 0 {
+				product.Stock = tt.stock
+			}
+			lowStock, err := repo.UpdateProduct(context.Background(), 9, tt.version, &product, entry)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected nil error, got %v", err)
+				}
+				if !reflect.DeepEqual(lowStock, tt.wantLowStock) {
+					t.Fatalf("expected low stock %+v, got %+v", tt.wantLowStock, lowStock)
+				}
+				wantQuery, wantArgs := query, []driver.Value{"p2", "SKU-2", "", int64(20), int64(product.Stock), int64(2), int64(4), "now()", int64(9)}
+				if tt.version != 0 {
+					wantQuery, wantArgs = versionedQuery, append(wantArgs, tt.version)
+				}
+				if got := tt.cfg.execArgs[wantQuery]; !reflect.DeepEqual(got, wantArgs) {
+					t.Fatalf("expected update args %v, got %v", wantArgs, got)
+				}
+				if got := tt.cfg.queryArgs[categoryLockQuery]; !reflect.DeepEqual(got, []driver.Value{int64(4)}) {
+					t.Fatalf("expected category lock args [4], got %v", got)
+				}
+				if got := tt.cfg.execArgs[movementQuery]; !reflect.DeepEqual(got, tt.wantMovement) {
+					t.Fatalf("expected movement %v, got %v", tt.wantMovement, got)
+				}
+				wantAudit := []driver.Value{int64(1), "admin", "update", "product", int64(9), `{"price":10}`, `{"price":20}`, "now()"}
+				if got := tt.cfg.execArgs[auditQuery]; !reflect.DeepEqual(got, wantAudit) {
+					t.Fatalf("expected audit %v, got %v", wantAudit, got)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+			if _, ok := tt.cfg.execArgs[auditQuery]; ok && tt.version != 0 {
+				t.Fatalf("expected no audit entry for a stale version")
+			}
+		})
+	}
+}
+
+func TestProductRepositoryPatchProduct(t *testing.T) {
+	lockQuery := "SELECT id, name, stock, reorder_level FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	movementQuery := "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6)"
+	priceQuery := "UPDATE products SET price = $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	versionedPriceQuery := priceQuery + " AND version = $4"
+	fullQuery := "UPDATE products SET name = $1, sku = $2, barcode = NULLIF($3, ''), price = $4, stock = $5, reorder_level = $6, category_id = $7, updated_at = $8, version = version + 1 WHERE id = $9"
+	stockQuery := "UPDATE products SET stock = $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	locked := func(stock int64) map[string]testQuery {
+		return map[string]testQuery{lockQuery: {columns: []string{"id", "name", "stock", "reorder_level"}, rows: [][]driver.Value{{int64(9), "p1", stock, int64(2)}}}}
+	}
+	errExec := errors.New("exec")
+
+	tests := []struct {
+		name         string
+		version      int64
+		patch        *entity.PatchProduct
+		cfg          *testConfig
+		wantErr      string
+		wantQuery    string
+		wantArgs     []driver.Value
+		wantMovement []driver.Value
+		wantLowStock *alert.LowStock
+	}{
+		{
+			name:      "price-only",
+			patch:     &entity.PatchProduct{Price: patch.Of(20)},
+			cfg:       &testConfig{query: locked(3)},
+			wantQuery: priceQuery,
+			wantArgs:  []driver.Value{int64(20), "now()", int64(9)},
+		},
+		{
+			name: "every-member",
+			patch: &entity.PatchProduct{
+				Name:         patch.Of("p2"),
+				SKU:          patch.Of("SKU-2"),
+				Barcode:      patch.Field[string]{Set: true, Null: true},
+				Price:        patch.Of(20),
+				Stock:        patch.Of(5),
+				ReorderLevel: patch.Of(2),
+				CategoryID:   patch.Of(4),
+			},
+			cfg:          &testConfig{query: categoryLocked(locked(3))},
+			wantQuery:    fullQuery,
+			wantArgs:     []driver.Value{"p2", "SKU-2", "", int64(20), int64(5), int64(2), int64(4), "now()", int64(9)},
+			wantMovement: []driver.Value{int64(9), "adjustment", int64(2), int64(5), "product update", "now()"},
+		},
+		{
+			name:         "low-stock",
+			patch:        &entity.PatchProduct{Stock: patch.Of(1)},
+			cfg:          &testConfig{query: locked(3)},
+			wantQuery:    stockQuery,
+			wantArgs:     []driver.Value{int64(1), "now()", int64(9)},
+			wantMovement: []driver.Value{int64(9), "adjustment", int64(-2), int64(1), "product update", "now()"},
+			wantLowStock: &alert.LowStock{ProductID: 9, ProductName: "p1", Stock: 1, ReorderLevel: 2, Source: alert.SourceProductUpdate},
+		},
+		{
+			name:         "already-low",
+			patch:        &entity.PatchProduct{Stock: patch.Of(1)},
+			cfg:          &testConfig{query: locked(2)},
+			wantQuery:    stockQuery,
+			wantArgs:     []driver.Value{int64(1), "now()", int64(9)},
+			wantMovement: []driver.Value{int64(9), "adjustment", int64(-1), int64(1), "product update", "now()"},
+		},
+		{
+			name:      "version",
+			version:   3,
+			patch:     &entity.PatchProduct{Price: patch.Of(20)},
+			cfg:       &testConfig{query: locked(3)},
+			wantQuery: versionedPriceQuery,
+			wantArgs:  []driver.Value{int64(20), "now()", int64(9), int64(3)},
+		},
+		{name: "stale-version", version: 3, patch: &entity.PatchProduct{Price: patch.Of(20)}, cfg: &testConfig{query: locked(3), rowsAffected: map[string]int64{versionedPriceQuery: 0}}, wantErr: "product has been changed since version 3"},
+		{name: "empty", patch: &entity.PatchProduct{}, cfg: &testConfig{}},
+		{name: "missing", patch: &entity.PatchProduct{Price: patch.Of(20)}, cfg: &testConfig{}, wantErr: "product not found"},
+		{name: "category-deleted", patch: &entity.PatchProduct{CategoryID: patch.Of(4)}, cfg: &testConfig{query: locked(3)}, wantErr: "category has been deleted"},
+		{name: "exec", patch: &entity.PatchProduct{Price: patch.Of(20)}, cfg: &testConfig{query: locked(3), execErr: map[string]error{priceQuery: errExec}}, wantErr: errExec.Error()},
+		{name: "audit", patch: &entity.PatchProduct{Price: patch.Of(20)}, cfg: &testConfig{query: locked(3), execErr: map[string]error{auditQuery: errExec}}, wantErr: errExec.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			entry := &audit.Entry{Actor: audit.Actor{ID: 1, Username: "admin"}, Action: audit.ActionUpdate, EntityType: audit.EntityProduct, EntityID: 9, Before: map[string]int{"price": 10}, After: map[string]int{"price": 20}}
+			lowStock, err := repo.PatchProduct(context.Background(), 9, tt.version, tt.patch, entry)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if !reflect.DeepEqual(lowStock, tt.wantLowStock) {
+				t.Fatalf("expected low stock %+v, got %+v", tt.wantLowStock, lowStock)
+			}
+			if tt.wantQuery == "" {
+				if len(tt.cfg.execArgs) != 0 || len(tt.cfg.queryArgs) != 0 {
+					t.Fatalf("expected no statements, got exec %v query %v", tt.cfg.execArgs, tt.cfg.queryArgs)
+				}
+				return
+			}
+			if got := tt.cfg.execArgs[tt.wantQuery]; !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Fatalf("expected update args %v, got %v", tt.wantArgs, got)
+			}
+			if got := tt.cfg.execArgs[movementQuery]; !reflect.DeepEqual(got, tt.wantMovement) {
+				t.Fatalf("expected movement %v, got %v", tt.wantMovement, got)
+			}
+			wantAudit := []driver.Value{int64(1), "admin", "update", "product", int64(9), `{"price":10}`, `{"price":20}`, "now()"}
+			if got := tt.cfg.execArgs[auditQuery]; !reflect.DeepEqual(got, wantAudit) {
+				t.Fatalf("expected audit %v, got %v", wantAudit, got)
+			}
+		})
+	}
+}
+
+func TestProductRepositoryDeleteProduct(t *testing.T) {
+	query := "UPDATE products SET deleted_at = $1, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL"
+	versionedQuery := "UPDATE products SET deleted_at = $1, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NULL AND version = $3"
+	errPrepare := errors.New("prepare")
+	errBegin := errors.New("begin")
+	errExec := errors.New("exec")
+
+	tests := []struct {
+		name     string
+		version  int64
+		cfg      *testConfig
+		wantErr  error
+		wantArgs []driver.Value
 	}{
 		{name: "ok", cfg: &testConfig{}},
+		{name: "version", version: 3, cfg: &testConfig{}, wantArgs: []driver.Value{"now()", int64(1), int64(3)}},
+		{name: "stale-version", version: 3, cfg: &testConfig{rowsAffected: map[string]int64{versionedQuery: 0}}, wantErr: apperror.ErrPrecondition},
 		{name: "prepare", cfg: &testConfig{prepareErr: map[string]error{query: errPrepare}}, wantErr: errPrepare},
-		{name: "exec", cfg: &testConfig{execErr: map[string]error{query: errExec}}, wantErr: errExec},
+		{name: "audit", cfg: &testConfig{execErr: map[string]error{auditQuery: errExec}}, wantErr: errExec},
 		{name: "begin", cfg: &testConfig{beginErr: errBegin}, wantErr: errBegin},
-		{name: "commit", cfg: &testConfig{commitErr: errCommit}, wantErr: errCommit},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := newTestDB(t, tt.cfg)
 			repo := NewProductRepository(db)
-			err := repo.CreateProduct(product)
+			entry := &audit.Entry{Actor: audit.System, Action: audit.ActionDelete, EntityType: audit.EntityProduct, EntityID: 1, Before: map[string]string{"name": "p1"}}
+			err := repo.DeleteProduct(context.Background(), 1, tt.version, entry)
 			if tt.wantErr == nil {
 				if err != nil {
 					t.Fatalf("expected nil error, got %v", err)
 				}
+				if tt.wantArgs != nil {
+					if got := tt.cfg.execArgs[versionedQuery]; !reflect.DeepEqual(got, tt.wantArgs) {
+						t.Fatalf("expected delete args %v, got %v", tt.wantArgs, got)
+					}
+				}
+				wantAudit := []driver.Value{int64(0), "system", "delete", "product", int64(1), `{"name":"p1"}`, nil, "now()"}
+				if got := tt.cfg.execArgs[auditQuery]; !reflect.DeepEqual(got, wantAudit) {
+					t.Fatalf("expected audit %v, got %v", wantAudit, got)
+				}
 				return
 			}
 			if err == nil || !errors.Is(err, tt.wantErr) {
@@ -213,11 +591,9 @@ func TestProductRepositoryCreateProduct(t *testing.T) {
 	}
 }
 
-func TestProductRepositoryUpdateProduct(t *testing.T) {
-	query := "UPDATE products SET name = $1, price = $2, stock = $3, category_id = $4, updated_at = $5 WHERE id = $6"
-	product := &entity.Product{Name: "p2", Price: 20, Stock: 5, CategoryID: 4}
+func TestProductRepositoryRestoreProduct(t *testing.T) {
+	query := "UPDATE products SET deleted_at = NULL, updated_at = $1, version = version + 1 WHERE id = $2 AND deleted_at IS NOT NULL"
 	errExec := errors.New("exec")
-	errCommit := errors.New("commit")
 
 	tests := []struct {
 		name    string
@@ -225,19 +601,29 @@ func TestProductRepositoryUpdateProduct(t *testing.T) {
 		wantErr error
 	}{
 		{name: "ok", cfg: &testConfig{}},
+		{name: "not-deleted", cfg: &testConfig{rowsAffected: map[string]int64{query: 0}}, wantErr: apperror.ErrNotFound},
 		{name: "exec", cfg: &testConfig{execErr: map[string]error{query: errExec}}, wantErr: errExec},
-		{name: "commit", cfg: &testConfig{commitErr: errCommit}, wantErr: errCommit},
+		{name: "sku-taken", cfg: &testConfig{execErr: map[string]error{query: &pq.Error{Code: "23505", Constraint: "products_sku_active_idx"}}}, wantErr: apperror.ErrConflict},
+		{name: "audit", cfg: &testConfig{execErr: map[string]error{auditQuery: errExec}}, wantErr: errExec},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := newTestDB(t, tt.cfg)
 			repo := NewProductRepository(db)
-			err := repo.UpdateProduct(9, product)
+			entry := &audit.Entry{Actor: audit.System, Action: audit.ActionRestore, EntityType: audit.EntityProduct, EntityID: 1, After: map[string]string{"name": "p1"}}
+			err := repo.RestoreProduct(context.Background(), 1, entry)
 			if tt.wantErr == nil {
 				if err != nil {
 					t.Fatalf("expected nil error, got %v", err)
 				}
+				if got, want := tt.cfg.execArgs[query], []driver.Value{"now()", int64(1)}; !reflect.DeepEqual(got, want) {
+					t.Fatalf("expected restore args %v, got %v", want, got)
+				}
+				wantAudit := []driver.Value{int64(0), "system", "restore", "product", int64(1), nil, `{"name":"p1"}`, "now()"}
+				if got := tt.cfg.execArgs[auditQuery]; !reflect.DeepEqual(got, wantAudit) {
+					t.Fatalf("expected audit %v, got %v", wantAudit, got)
+				}
 				return
 			}
 			if err == nil || !errors.Is(err, tt.wantErr) {
@@ -247,67 +633,112 @@ func TestProductRepositoryUpdateProduct(t *testing.T) {
 	}
 }
 
-func TestProductRepositoryDeleteProduct(t *testing.T) {
-	query := "DELETE FROM products WHERE id = $1"
-	errPrepare := errors.New("prepare")
-	errBegin := errors.New("begin")
+func TestProductRepositoryGetDeletedProductByID(t *testing.T) {
+	query := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.deleted_at, products.version FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1 AND products.deleted_at IS NOT NULL"
+	columns := []string{"id", "name", "sku", "barcode", "price", "stock", "reorder_level", "created_at", "updated_at", "category_id", "category_name", "deleted_at", "version"}
+	errQuery := errors.New("query")
+	time1 := "2023-01-02T03:04:05Z"
+	time2 := "2023-02-02T03:04:05Z"
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	parsed2, _ := time.Parse(time.RFC3339, time2)
 
 	tests := []struct {
 		name    string
 		cfg     *testConfig
-		wantErr error
+		wantErr string
 	}{
-		{name: "ok", cfg: &testConfig{}},
-		{name: "prepare", cfg: &testConfig{prepareErr: map[string]error{query: errPrepare}}, wantErr: errPrepare},
-		{name: "begin", cfg: &testConfig{beginErr: errBegin}, wantErr: errBegin},
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{
+				query: {columns: columns, rows: [][]driver.Value{{int64(1), "p1", "SKU-1", "", int64(10), int64(2), int64(0), time1, time2, int64(7), "c1", time2, int64(5)}}},
+			}},
+		},
+		{
+			name:    "missing",
+			cfg:     &testConfig{query: map[string]testQuery{query: {columns: columns}}},
+			wantErr: "deleted product not found",
+		},
+		{
+			name:    "query",
+			cfg:     &testConfig{query: map[string]testQuery{query: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			db := newTestDB(t, tt.cfg)
 			repo := NewProductRepository(db)
-			err := repo.DeleteProduct(1)
-			if tt.wantErr == nil {
+			got, err := repo.GetDeletedProductByID(context.Background(), 1)
+			if tt.wantErr == "" {
 				if err != nil {
 					t.Fatalf("expected nil error, got %v", err)
 				}
+				if got.ID != 1 || got.SKU != "SKU-1" || got.CategoryID != 7 || got.Version != 5 {
+					t.Fatalf("unexpected product: %+v", got)
+				}
+				if got.DeletedAt == nil || !got.DeletedAt.Equal(parsed2.In(loc)) {
+					t.Fatalf("unexpected deleted at: %v", got.DeletedAt)
+				}
 				return
 			}
-			if err == nil || !errors.Is(err, tt.wantErr) {
-				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
 			}
 		})
 	}
 }
 
 func TestProductRepositoryGetAllProducts(t *testing.T) {
-	query := "SELECT products.id, products.name, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id"
+	query := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.deleted_at FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL ORDER BY products.id ASC LIMIT $1 OFFSET $2"
+	countQuery := "SELECT COUNT(*) FROM products WHERE products.deleted_at IS NULL"
+	filteredQuery := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.deleted_at FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND products.category_id IN (WITH RECURSIVE descendants (id) AS (SELECT id FROM categories WHERE id = $1 UNION SELECT categories.id FROM categories JOIN descendants ON categories.parent_id = descendants.id WHERE categories.deleted_at IS NULL) SELECT id FROM descendants) AND products.price >= $2 AND products.price <= $3 AND products.stock > 0 ORDER BY products.price DESC, products.name ASC, products.id ASC LIMIT $4 OFFSET $5"
+	filteredCountQuery := "SELECT COUNT(*) FROM products WHERE products.deleted_at IS NULL AND products.category_id IN (WITH RECURSIVE descendants (id) AS (SELECT id FROM categories WHERE id = $1 UNION SELECT categories.id FROM categories JOIN descendants ON categories.parent_id = descendants.id WHERE categories.deleted_at IS NULL) SELECT id FROM descendants) AND products.price >= $2 AND products.price <= $3 AND products.stock > 0"
+	allQuery := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.deleted_at FROM products JOIN categories ON products.category_id = categories.id ORDER BY products.id ASC LIMIT $1 OFFSET $2"
+	allCountQuery := "SELECT COUNT(*) FROM products"
 	errQuery := errors.New("query")
 	time1 := "2023-01-02T03:04:05Z"
 	time2 := "2023-02-02T03:04:05Z"
 	loc, _ := time.LoadLocation("Asia/Jakarta")
 	parsed1, _ := time.Parse(time.RFC3339, time1)
 	parsed2, _ := time.Parse(time.RFC3339, time2)
+	deletedAt := parsed2.In(loc)
+	minPrice, maxPrice := 10, 50
+	columns := []string{"id", "name", "sku", "barcode", "price", "stock", "reorder_level", "created_at", "updated_at", "category_id", "category_name", "deleted_at"}
+	rows := [][]driver.Value{
+		{int64(1), "p1", "SKU-1", "", int64(10), int64(2), int64(0), time1, time2, int64(7), "c1"},
+		{int64(2), "p2", "SKU-2", "", int64(20), int64(3), int64(0), time2, time1, int64(8), "c2"},
+	}
+	defaultFilter := entity.ProductFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}}
+	fullFilter := entity.ProductFilter{
+		CategoryID: 7,
+		MinPrice:   &minPrice,
+		MaxPrice:   &maxPrice,
+		InStock:    true,
+		Pagination: pagination.Params{Page: 3, PageSize: 10, Sort: []pagination.Sort{{Field: "price", Desc: true}, {Field: "name"}}},
+	}
 
 	tests := []struct {
-		name      string
-		cfg       *testConfig
-		wantErr   error
-		wantCount int
-		wantFirst *entity.ResponseProductWithCategories
+		name          string
+		filter        entity.ProductFilter
+		cfg           *testConfig
+		wantErr       error
+		wantCount     int
+		wantTotal     int
+		wantFirst     *entity.ResponseProductWithCategories
+		wantQuery     string
+		wantQueryArgs []driver.Value
+		wantCountArgs []driver.Value
 	}{
 		{
-			name: "ok",
+			name:   "ok",
+			filter: defaultFilter,
 			cfg: &testConfig{query: map[string]testQuery{
-				query: {
-					columns: []string{"id", "name", "price", "stock", "created_at", "updated_at", "category_id", "category_name"},
-					rows: [][]driver.Value{
-						{int64(1), "p1", int64(10), int64(2), time1, time2, int64(7), "c1"},
-						{int64(2), "p2", int64(20), int64(3), time2, time1, int64(8), "c2"},
-					},
-				},
+				countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(2)}}},
+				query:      {columns: columns, rows: rows},
 			}},
 			wantCount: 2,
+			wantTotal: 2,
 			wantFirst: &entity.ResponseProductWithCategories{
 				ID:           1,
 				Name:         "p1",
@@ -318,15 +749,67 @@ func TestProductRepositoryGetAllProducts(t *testing.T) {
 				CreatedAt:    parsed1.In(loc),
 				UpdatedAt:    parsed2.In(loc),
 			},
+			wantQuery:     query,
+			wantQueryArgs: []driver.Value{int64(20), int64(0)},
+			wantCountArgs: []driver.Value{},
+		},
+		{
+			name:   "filtered",
+			filter: fullFilter,
+			cfg: &testConfig{query: map[string]testQuery{
+				filteredCountQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(21)}}},
+				filteredQuery:      {columns: columns, rows: rows[:1]},
+			}},
+			wantCount:     1,
+			wantTotal:     21,
+			wantQuery:     filteredQuery,
+			wantQueryArgs: []driver.Value{int64(7), int64(10), int64(50), int64(10), int64(20)},
+			wantCountArgs: []driver.Value{int64(7), int64(10), int64(50)},
+		},
+		{
+			name:   "include-deleted",
+			filter: entity.ProductFilter{IncludeDeleted: true, Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			cfg: &testConfig{query: map[string]testQuery{
+				allCountQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(1)}}},
+				allQuery:      {columns: columns, rows: [][]driver.Value{{int64(3), "p3", "SKU-3", "", int64(30), int64(0), int64(0), time1, time1, int64(7), "c1", time2}}},
+			}},
+			wantCount: 1,
+			wantTotal: 1,
+			wantFirst: &entity.ResponseProductWithCategories{
+				ID:           3,
+				Name:         "p3",
+				Price:        30,
+				CategoryID:   7,
+				CategoryName: "c1",
+				CreatedAt:    parsed1.In(loc),
+				UpdatedAt:    parsed1.In(loc),
+				DeletedAt:    &deletedAt,
+			},
+			wantQuery:     allQuery,
+			wantQueryArgs: []driver.Value{int64(20), int64(0)},
 		},
 		{
-			name:      "empty",
-			cfg:       &testConfig{query: map[string]testQuery{query: {columns: []string{"id"}}}},
+			name:   "empty",
+			filter: defaultFilter,
+			cfg: &testConfig{query: map[string]testQuery{
+				countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(0)}}},
+				query:      {columns: []string{"id"}},
+			}},
 			wantCount: 0,
 		},
 		{
-			name:    "query",
-			cfg:     &testConfig{query: map[string]testQuery{query: {queryErr: errQuery}}},
+			name:    "count",
+			filter:  defaultFilter,
+			cfg:     &testConfig{query: map[string]testQuery{countQuery: {queryErr: errQuery}}},
+			wantErr: errQuery,
+		},
+		{
+			name:   "query",
+			filter: defaultFilter,
+			cfg: &testConfig{query: map[string]testQuery{
+				countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(2)}}},
+				query:      {queryErr: errQuery},
+			}},
 			wantErr: errQuery,
 		},
 	}
@@ -335,7 +818,7 @@ func TestProductRepositoryGetAllProducts(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db := newTestDB(t, tt.cfg)
 			repo := NewProductRepository(db)
-			got, err := repo.GetAllProducts()
+			got, total, err := repo.GetAllProducts(context.Background(), tt.filter)
 			if tt.wantErr == nil {
 				if err != nil {
 					t.Fatalf("expected nil error, got %v", err)
@@ -343,6 +826,9 @@ func TestProductRepositoryGetAllProducts(t *testing.T) {
 				if len(got) != tt.wantCount {
 					t.Fatalf("expected %d products, got %d", tt.wantCount, len(got))
 				}
+				if total != tt.wantTotal {
+					t.Fatalf("expected total %d, got %d", tt.wantTotal, total)
+				}
 				if tt.wantFirst != nil && len(got) > 0 {
 					if got[0].ID != tt.wantFirst.ID || got[0].Name != tt.wantFirst.Name || got[0].Price != tt.wantFirst.Price || got[0].Stock != tt.wantFirst.Stock || got[0].CategoryID != tt.wantFirst.CategoryID || got[0].CategoryName != tt.wantFirst.CategoryName {
 						t.Fatalf("unexpected first product: %+v", got[0])
@@ -353,6 +839,23 @@ func TestProductRepositoryGetAllProducts(t *testing.T) {
 					if got[0].CreatedAt.Location().String() != loc.String() || got[0].UpdatedAt.Location().String() != loc.String() {
 						t.Fatalf("unexpected location: %s %s", got[0].CreatedAt.Location(), got[0].UpdatedAt.Location())
 					}
+					if (got[0].DeletedAt == nil) != (tt.wantFirst.DeletedAt == nil) || got[0].DeletedAt != nil && !got[0].DeletedAt.Equal(*tt.wantFirst.DeletedAt) {
+						t.Fatalf("unexpected deleted at: %v", got[0].DeletedAt)
+					}
+				}
+				if tt.wantQuery != "" {
+					if args := tt.cfg.queryArgs[tt.wantQuery]; !reflect.DeepEqual(args, tt.wantQueryArgs) {
+						t.Fatalf("unexpected query args: %v", args)
+					}
+				}
+				if tt.wantCountArgs != nil {
+					countKey := countQuery
+					if tt.wantQuery == filteredQuery {
+						countKey = filteredCountQuery
+					}
+					if args := tt.cfg.queryArgs[countKey]; len(args) != len(tt.wantCountArgs) || (len(args) > 0 && !reflect.DeepEqual(args, tt.wantCountArgs)) {
+						t.Fatalf("unexpected count args: %v", args)
+					}
 				}
 				return
 			}
@@ -364,7 +867,7 @@ func TestProductRepositoryGetAllProducts(t *testing.T) {
 }
 
 func TestProductRepositoryGetProductByID(t *testing.T) {
-	query := "SELECT products.id, products.name, products.price, products.stock, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1"
+	query := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name, products.version FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1 AND products.deleted_at IS NULL"
 	errQuery := errors.New("query")
 	time1 := "2023-01-02T03:04:05Z"
 	time2 := "2023-02-02T03:04:05Z"
@@ -382,8 +885,8 @@ func TestProductRepositoryGetProductByID(t *testing.T) {
 			name: "ok",
 			cfg: &testConfig{query: map[string]testQuery{
 				query: {
-					columns: []string{"id", "name", "price", "stock", "created_at", "updated_at", "category_id", "category_name"},
-					rows:    [][]driver.Value{{int64(1), "p1", int64(10), int64(2), time1, time2, int64(7), "c1"}},
+					columns: []string{"id", "name", "sku", "barcode", "price", "stock", "reorder_level", "created_at", "updated_at", "category_id", "category_name", "version"},
+					rows:    [][]driver.Value{{int64(1), "p1", "SKU-1", "", int64(10), int64(2), int64(0), time1, time2, int64(7), "c1", int64(4)}},
 				},
 			}},
 			want: &entity.ResponseProductWithCategories{
@@ -395,6 +898,7 @@ func TestProductRepositoryGetProductByID(t *testing.T) {
 				CategoryName: "c1",
 				CreatedAt:    parsed1.In(loc),
 				UpdatedAt:    parsed2.In(loc),
+				Version:      4,
 			},
 		},
 		{
@@ -413,7 +917,7 @@ func TestProductRepositoryGetProductByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db := newTestDB(t, tt.cfg)
 			repo := NewProductRepository(db)
-			got, err := repo.GetProductByID(1)
+			got, err := repo.GetProductByID(context.Background(), 1)
 			if tt.wantErr == "" {
 				if err != nil {
 					t.Fatalf("expected nil error, got %v", err)
@@ -421,7 +925,7 @@ func TestProductRepositoryGetProductByID(t *testing.T) {
 				if got == nil {
 					t.Fatalf("expected product")
 				}
-				if got.ID != tt.want.ID || got.Name != tt.want.Name || got.Price != tt.want.Price || got.Stock != tt.want.Stock || got.CategoryID != tt.want.CategoryID || got.CategoryName != tt.want.CategoryName {
+				if got.ID != tt.want.ID || got.Name != tt.want.Name || got.Price != tt.want.Price || got.Stock != tt.want.Stock || got.CategoryID != tt.want.CategoryID || got.CategoryName != tt.want.CategoryName || got.Version != tt.want.Version {
 					t.Fatalf("unexpected product: %+v", got)
 				}
 				if !got.CreatedAt.Equal(tt.want.CreatedAt) || !got.UpdatedAt.Equal(tt.want.UpdatedAt) {
@@ -439,8 +943,66 @@ func TestProductRepositoryGetProductByID(t *testing.T) {
 	}
 }
 
+func TestProductRepositoryGetProductByCode(t *testing.T) {
+	query := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND (products.barcode = $1 OR products.sku = $1) ORDER BY COALESCE(products.barcode = $1, false) DESC LIMIT 1"
+	columns := []string{"id", "name", "sku", "barcode", "price", "stock", "reorder_level", "created_at", "updated_at", "category_id", "category_name"}
+	errQuery := errors.New("query")
+	time1 := "2023-01-02T03:04:05Z"
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr string
+		want    *entity.ResponseProductWithCategories
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{
+				query: {
+					columns: columns,
+					rows:    [][]driver.Value{{int64(1), "p1", "SKU-1", "8992761166014", int64(10), int64(2), int64(0), time1, time1, int64(7), "c1"}},
+				},
+			}},
+			want: &entity.ResponseProductWithCategories{ID: 1, Name: "p1", SKU: "SKU-1", Barcode: "8992761166014", CategoryID: 7, CategoryName: "c1"},
+		},
+		{
+			name:    "missing",
+			cfg:     &testConfig{query: map[string]testQuery{query: {columns: columns}}},
+			wantErr: "product not found",
+		},
+		{
+			name:    "query",
+			cfg:     &testConfig{query: map[string]testQuery{query: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			got, err := repo.GetProductByCode(context.Background(), "8992761166014")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("expected nil error, got %v", err)
+				}
+				if got == nil || got.ID != tt.want.ID || got.SKU != tt.want.SKU || got.Barcode != tt.want.Barcode || got.CategoryName != tt.want.CategoryName {
+					t.Fatalf("unexpected product: %+v", got)
+				}
+				if args := tt.cfg.queryArgs[query]; len(args) != 1 || args[0] != "8992761166014" {
+					t.Fatalf("unexpected args: %v", args)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestProductRepositoryGetCategoryByID(t *testing.T) {
-	query := "SELECT id, name FROM categories WHERE id = $1"
+	query := "SELECT id, name FROM categories WHERE id = $1 AND deleted_at IS NULL"
 	errQuery := errors.New("query")
 
 	tests := []struct {
@@ -475,7 +1037,7 @@ func TestProductRepositoryGetCategoryByID(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			db := newTestDB(t, tt.cfg)
 			repo := NewProductRepository(db)
-			got, err := repo.GetCategoryByID(1)
+			got, err := repo.GetCategoryByID(context.Background(), 1)
 			if tt.wantErr == "" {
 				if err != nil {
 					t.Fatalf("expected nil error, got %v", err)
@@ -495,3 +1057,205 @@ func TestProductRepositoryGetCategoryByID(t *testing.T) {
 	}
 }
 
+func TestProductRepositoryGetCategoriesByName(t *testing.T) {
+	query := "SELECT id, name FROM categories WHERE lower(name) = lower($1) AND deleted_at IS NULL ORDER BY id"
+	errQuery := errors.New("query")
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr string
+		want    []entity.Category
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{
+				query: {
+					columns: []string{"id", "name"},
+					rows:    [][]driver.Value{{int64(1), "Susu"}, {int64(4), "susu"}},
+				},
+			}},
+			want: []entity.Category{{ID: 1, Name: "Susu"}, {ID: 4, Name: "susu"}},
+		},
+		{
+			name: "missing",
+			cfg:  &testConfig{query: map[string]testQuery{query: {columns: []string{"id", "name"}}}},
+		},
+		{
+			name:    "query",
+			cfg:     &testConfig{query: map[string]testQuery{query: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			got, err := repo.GetCategoriesByName(context.Background(), "SUSU")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("categories = %+v, want %+v", got, tt.want)
+			}
+			if args := tt.cfg.queryArgs[query]; !reflect.DeepEqual(args, []driver.Value{"SUSU"}) {
+				t.Fatalf("unexpected args: %v", args)
+			}
+		})
+	}
+}
+
+func TestProductRepositorySearchProducts(t *testing.T) {
+	query := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND ((setweight(to_tsvector('simple', products.name), 'A') || setweight(to_tsvector('simple', categories.name), 'B')) @@ to_tsquery('simple', $1) OR products.name ILIKE '%' || $2 || '%' ESCAPE '\\') ORDER BY ts_rank(setweight(to_tsvector('simple', products.name), 'A') || setweight(to_tsvector('simple', categories.name), 'B'), to_tsquery('simple', $1)) DESC, products.name ASC, products.id ASC LIMIT $3"
+	errQuery := errors.New("query")
+	time1 := "2023-01-02T03:04:05Z"
+
+	tests := []struct {
+		name      string
+		cfg       *testConfig
+		wantErr   error
+		wantNames []string
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{
+				query: {
+					columns: []string{"id", "name", "sku", "barcode", "price", "stock", "reorder_level", "created_at", "updated_at", "category_id", "category_name"},
+					rows: [][]driver.Value{
+						{int64(1), "Bebelac", "SKU-1", "", int64(10), int64(2), int64(0), time1, time1, int64(7), "Susu"},
+						{int64(2), "Bebe Biscuit", "SKU-2", "", int64(20), int64(3), int64(0), time1, time1, int64(8), "Snack"},
+					},
+				},
+			}},
+			wantNames: []string{"Bebelac", "Bebe Biscuit"},
+		},
+		{
+			name:    "query",
+			cfg:     &testConfig{query: map[string]testQuery{query: {queryErr: errQuery}}},
+			wantErr: errQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			got, err := repo.SearchProducts(context.Background(), "Bebe, sus_50%!", 10)
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			var names []string
+			for _, product := range got {
+				names = append(names, product.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Fatalf("unexpected products: %v", names)
+			}
+			wantArgs := []driver.Value{"bebe:* & sus:* & 50:*", `Bebe, sus\_50\%!`, int64(10)}
+			if args := tt.cfg.queryArgs[query]; !reflect.DeepEqual(args, wantArgs) {
+				t.Fatalf("unexpected args: %v", args)
+			}
+		})
+	}
+}
+
+func TestProductRepositoryGetLowStockProducts(t *testing.T) {
+	query := "SELECT products.id, products.name, products.sku, COALESCE(products.barcode, '') as barcode, products.price, products.stock, products.reorder_level, products.created_at, products.updated_at, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.deleted_at IS NULL AND products.stock <= products.reorder_level ORDER BY categories.name ASC, categories.id ASC, products.stock ASC, products.id ASC"
+	errQuery := errors.New("query")
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	time1 := "2023-01-02T03:04:05Z"
+	createdAt, _ := time.Parse(time.RFC3339, time1)
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr error
+		want    []entity.ResponseProductWithCategories
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{
+				query: {
+					columns: []string{"id", "name", "sku", "barcode", "price", "stock", "reorder_level", "created_at", "updated_at", "category_id", "category_name"},
+					rows: [][]driver.Value{
+						{int64(2), "Bebe Biscuit", "SKU-2", "", int64(20), int64(0), int64(5), time1, time1, int64(8), "Snack"},
+						{int64(1), "Bebelac", "SKU-1", "", int64(10), int64(2), int64(2), time1, time1, int64(7), "Susu"},
+					},
+				},
+			}},
+			want: []entity.ResponseProductWithCategories{
+				{ID: 2, Name: "Bebe Biscuit", SKU: "SKU-2", Price: 20, Stock: 0, ReorderLevel: 5, CategoryID: 8, CategoryName: "Snack", CreatedAt: createdAt.In(loc), UpdatedAt: createdAt.In(loc)},
+				{ID: 1, Name: "Bebelac", SKU: "SKU-1", Price: 10, Stock: 2, ReorderLevel: 2, CategoryID: 7, CategoryName: "Susu", CreatedAt: createdAt.In(loc), UpdatedAt: createdAt.In(loc)},
+			},
+		},
+		{name: "empty", cfg: &testConfig{}},
+		{
+			name:    "query",
+			cfg:     &testConfig{query: map[string]testQuery{query: {queryErr: errQuery}}},
+			wantErr: errQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewProductRepository(db)
+			got, err := repo.GetLowStockProducts(context.Background())
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d products, got %d", len(tt.want), len(got))
+			}
+			for i := range got {
+				if !got[i].CreatedAt.Equal(tt.want[i].CreatedAt) || !got[i].UpdatedAt.Equal(tt.want[i].UpdatedAt) {
+					t.Fatalf("unexpected timestamps: %+v", got[i])
+				}
+				got[i].CreatedAt, got[i].UpdatedAt = tt.want[i].CreatedAt, tt.want[i].UpdatedAt
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected products: %+v", got)
+			}
+		})
+	}
+}
+
+func TestToPrefixTsQuery(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "bebe", want: "bebe:*"},
+		{input: "  Bebe   SUSU ", want: "bebe:* & susu:*"},
+		{input: "kopi's & (teh) | !", want: "kopi:* & s:* & teh:*"},
+		{input: "indomie 2", want: "indomie:* & 2:*"},
+		{input: "!!!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := toPrefixTsQuery(tt.input); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+)
+
+const maxSKULength = 64
+
+// validateSKU checks that a SKU is present and short enough to print on a shelf label.
+func validateSKU(sku string) error {
+	if sku == "" {
+		return apperror.Invalid(apperror.FieldError{Field: "sku", Message: "sku is required"})
+	}
+
+	if len(sku) > maxSKULength || strings.ContainsAny(sku, " \t\r\n") {
+		return apperror.Invalid(apperror.FieldError{Field: "sku", Message: "invalid sku: must be at most 64 characters without spaces"})
+	}
+
+	return nil
+}
+
+// validateBarcode accepts an empty barcode or a 12-digit UPC-A / 13-digit EAN-13 code whose last digit is a valid
+// check digit.
+func validateBarcode(barcode string) error {
+	if barcode == "" {
+		return nil
+	}
+
+	if len(barcode) != 12 && len(barcode) != 13 {
+		return apperror.Invalid(apperror.FieldError{Field: "barcode", Message: "invalid barcode: must be a 12-digit UPC-A or 13-digit EAN-13 code"})
+	}
+
+	for _, r := range barcode {
+		if r < '0' || r > '9' {
+			return apperror.Invalid(apperror.FieldError{Field: "barcode", Message: "invalid barcode: must be a 12-digit UPC-A or 13-digit EAN-13 code"})
+		}
+	}
+
+	// A UPC-A code is an EAN-13 code with a leading zero, so both share the same check digit calculation.
+	code := barcode
+	if len(code) == 12 {
+		code = "0" + code
+	}
+
+	sum := 0
+	for i := 0; i < 12; i++ {
+		digit := int(code[i] - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	if (10-sum%10)%10 != int(code[12]-'0') {
+		return apperror.Invalid(apperror.FieldError{Field: "barcode", Message: "invalid barcode check digit"})
+	}
+
+	return nil
+}
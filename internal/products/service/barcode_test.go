@@ -0,0 +1,63 @@
+package service
+
+import "testing"
+
+func TestValidateSKU(t *testing.T) {
+	tests := []struct {
+		name    string
+		sku     string
+		wantErr string
+	}{
+		{name: "ok", sku: "SUSU-BBL-400"},
+		{name: "empty", sku: "", wantErr: "sku is required"},
+		{name: "space", sku: "SUSU BBL", wantErr: "invalid sku: must be at most 64 characters without spaces"},
+		{name: "long", sku: "ABCDEFGHIJKLMNOPQRSTUVWXYZABCDEFGHIJKLMNOPQRSTUVWXYZABCDEFGHIJKLM", wantErr: "invalid sku: must be at most 64 characters without spaces"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSKU(tt.sku)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateBarcode(t *testing.T) {
+	tests := []struct {
+		name    string
+		barcode string
+		wantErr string
+	}{
+		{name: "empty", barcode: ""},
+		{name: "ean13", barcode: "8992761166014"},
+		{name: "ean13-zero-check", barcode: "4006381333931"},
+		{name: "upca", barcode: "036000291452"},
+		{name: "ean13-bad-check", barcode: "8992761166015", wantErr: "invalid barcode check digit"},
+		{name: "upca-bad-check", barcode: "036000291453", wantErr: "invalid barcode check digit"},
+		{name: "short", barcode: "12345", wantErr: "invalid barcode: must be a 12-digit UPC-A or 13-digit EAN-13 code"},
+		{name: "letters", barcode: "89927611660AB", wantErr: "invalid barcode: must be a 12-digit UPC-A or 13-digit EAN-13 code"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateBarcode(tt.barcode)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
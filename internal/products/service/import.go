@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+)
+
+// importCategory is a category value of an import resolved to a category ID, or the reason it could not be.
+type importCategory struct {
+	id      int
+	message string
+}
+
+// ImportProducts validates every row of a product import and, unless dryRun is set, creates the products in one
+// transaction with actor recorded as their creator. Nothing is created when a row is invalid: the report is returned
+// together with a validation error, so the caller can show which rows to fix.
+func (s *productService) ImportProducts(ctx context.Context, actor audit.Actor, rows []entity.ImportProductRow, dryRun bool) (*entity.ImportProductReport, error) {
+	if len(rows) == 0 {
+		return nil, apperror.Validation("import has no product rows")
+	}
+
+	if len(rows) > entity.MaxImportRows {
+		return nil, apperror.Validation("import must have at most %d product rows", entity.MaxImportRows)
+	}
+
+	report := &entity.ImportProductReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Rows:   make([]entity.ImportProductResult, len(rows)),
+	}
+	products := make([]*entity.Product, 0, len(rows))
+	entries := make([]*audit.Entry, 0, len(rows))
+	categories := make(map[string]importCategory)
+	skuLines := make(map[string]int)
+
+	for i, row := range rows {
+		requestProduct, fields, err := s.validateImportRow(ctx, row, categories, skuLines)
+		if err != nil {
+			return nil, err
+		}
+
+		report.Rows[i] = entity.ImportProductResult{Line: row.Line, SKU: requestProduct.SKU, Status: entity.ImportStatusValid, Errors: fields}
+		if len(fields) > 0 {
+			report.Rows[i].Status = entity.ImportStatusFailed
+			report.Failed++
+			continue
+		}
+
+		report.Valid++
+		products = append(products, &entity.Product{
+			Name:       requestProduct.Name,
+			SKU:        requestProduct.SKU,
+			Price:      requestProduct.Price,
+			Stock:      requestProduct.Stock,
+			CategoryID: requestProduct.CategoryID,
+		})
+		entries = append(entries, &audit.Entry{
+			Actor:      actor,
+			Action:     audit.ActionCreate,
+			EntityType: audit.EntityProduct,
+			After:      *requestProduct,
+		})
+	}
+
+	if report.Failed > 0 {
+		return report, apperror.Validation("%d of %d rows are invalid, no product was imported", report.Failed, report.Total)
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	if err := s.productRepository.CreateProducts(ctx, products, entries); err != nil {
+		return nil, err
+	}
+
+	for i, product := range products {
+		report.Rows[i].Status = entity.ImportStatusCreated
+		report.Rows[i].ID = product.ID
+	}
+	report.Created = len(products)
+
+	return report, nil
+}
+
+// validateImportRow turns a row of an import into a product request and returns the errors of its invalid values,
+// named after the CSV columns. categories caches the categories resolved so far and skuLines the line of every SKU seen
+// so far, so a SKU used twice in the file is reported on its second row. The error is only set when a lookup fails.
+func (s *productService) validateImportRow(ctx context.Context, row entity.ImportProductRow, categories map[string]importCategory, skuLines map[string]int) (*entity.RequestProduct, []apperror.FieldError, error) {
+	var fields []apperror.FieldError
+	add := func(field, message string) {
+		for _, f := range fields {
+			if f.Field == field {
+				return
+			}
+		}
+		fields = append(fields, apperror.FieldError{Field: field, Message: message})
+	}
+
+	requestProduct := &entity.RequestProduct{
+		Name: strings.TrimSpace(row.Name),
+		SKU:  strings.TrimSpace(row.SKU),
+	}
+
+	var err error
+	if requestProduct.Price, err = strconv.Atoi(strings.TrimSpace(row.Price)); err != nil {
+		add("price", "price must be a whole number")
+	}
+
+	if requestProduct.Stock, err = strconv.Atoi(strings.TrimSpace(row.Stock)); err != nil {
+		add("stock", "stock must be a whole number")
+	}
+
+	category, err := s.resolveImportCategory(ctx, strings.TrimSpace(row.Category), categories)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if category.message != "" {
+		add("category", category.message)
+	}
+	requestProduct.CategoryID = category.id
+
+	// A missing category is already reported above, under the name of its column.
+	for _, field := range apperror.Fields(requestProduct.Validate()) {
+		if field.Field != "category_id" {
+			add(field.Field, field.Message)
+		}
+	}
+
+	if err = validateSKU(requestProduct.SKU); err != nil {
+		add("sku", err.Error())
+		return requestProduct, fields, nil
+	}
+
+	if line, ok := skuLines[requestProduct.SKU]; ok {
+		add("sku", fmt.Sprintf("sku already used on line %d", line))
+		return requestProduct, fields, nil
+	}
+	skuLines[requestProduct.SKU] = row.Line
+
+	err = s.ensureCodeUnused(ctx, 0, requestProduct.SKU, "sku")
+	if errors.Is(err, apperror.ErrConflict) {
+		add("sku", err.Error())
+		return requestProduct, fields, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return requestProduct, fields, nil
+}
+
+// resolveImportCategory finds the category a row of an import refers to by its ID, when value is a number, or else by
+// its name. Every value is only looked up once per import.
+func (s *productService) resolveImportCategory(ctx context.Context, value string, categories map[string]importCategory) (importCategory, error) {
+	if value == "" {
+		return importCategory{message: "category is required"}, nil
+	}
+
+	key := strings.ToLower(value)
+	if category, ok := categories[key]; ok {
+		return category, nil
+	}
+
+	var category importCategory
+	if id, err := strconv.ParseInt(value, 10, 64); err == nil {
+		_, err = s.productRepository.GetCategoryByID(ctx, id)
+		switch {
+		case errors.Is(err, apperror.ErrNotFound):
+			category.message = "category not found"
+		case err != nil:
+			return category, err
+		default:
+			category.id = int(id)
+		}
+	} else {
+		found, err := s.productRepository.GetCategoriesByName(ctx, value)
+		if err != nil {
+			return category, err
+		}
+
+		switch len(found) {
+		case 0:
+			category.message = "category not found"
+		case 1:
+			category.id = found[0].ID
+		default:
+			category.message = fmt.Sprintf("category name matches %d categories, use the category id", len(found))
+		}
+	}
+
+	categories[key] = category
+	return category, nil
+}
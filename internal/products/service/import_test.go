@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+)
+
+func TestProductServiceImportProducts(t *testing.T) {
+	actor := audit.Actor{ID: 1, Username: "admin"}
+	row := func(line int, sku, category string) entity.ImportProductRow {
+		return entity.ImportProductRow{Line: line, Name: " Susu ", Price: "10", Stock: "2", Category: category, SKU: sku}
+	}
+	categories := func(name string) ([]entity.Category, error) {
+		switch name {
+		case "Minuman":
+			return []entity.Category{{ID: 3, Name: "Minuman"}}, nil
+		case "Roti":
+			return []entity.Category{{ID: 4, Name: "Roti"}, {ID: 7, Name: "roti"}}, nil
+		}
+		return nil, nil
+	}
+	categoryByID := func(id int64) (*entity.Category, error) {
+		if id == 5 {
+			return &entity.Category{ID: 5}, nil
+		}
+		return nil, apperror.NotFound("category not found")
+	}
+	existing := func(code string) (*entity.ResponseProductWithCategories, error) {
+		if code == "USED" {
+			return &entity.ResponseProductWithCategories{ID: 9}, nil
+		}
+		return nil, apperror.NotFound("product not found")
+	}
+	errDB := errors.New("db")
+
+	tests := []struct {
+		name          string
+		rows          []entity.ImportProductRow
+		dryRun        bool
+		repo          *mockProductRepository
+		wantErr       string
+		wantReport    *entity.ImportProductReport
+		wantCreated   []*entity.Product
+		wantNoCreate  bool
+		wantCategoryN int
+	}{
+		{name: "no-rows", wantErr: "import has no product rows", wantNoCreate: true},
+		{name: "too-many", rows: make([]entity.ImportProductRow, entity.MaxImportRows+1), wantErr: "import must have at most 1000 product rows", wantNoCreate: true},
+		{
+			name: "ok",
+			rows: []entity.ImportProductRow{row(2, "SKU-1", "minuman"), row(3, " SKU-2 ", "5")},
+			repo: &mockProductRepository{
+				getCategoriesFn:   func(name string) ([]entity.Category, error) { return categories("Minuman") },
+				getCategoryByIDFn: categoryByID,
+				createProductsFn: func(products []*entity.Product) error {
+					for i, product := range products {
+						product.ID = 20 + i
+					}
+					return nil
+				},
+			},
+			wantReport: &entity.ImportProductReport{Total: 2, Valid: 2, Created: 2, Rows: []entity.ImportProductResult{
+				{Line: 2, SKU: "SKU-1", Status: entity.ImportStatusCreated, ID: 20},
+				{Line: 3, SKU: "SKU-2", Status: entity.ImportStatusCreated, ID: 21},
+			}},
+			wantCreated: []*entity.Product{
+				{ID: 20, Name: "Susu", SKU: "SKU-1", Price: 10, Stock: 2, CategoryID: 3},
+				{ID: 21, Name: "Susu", SKU: "SKU-2", Price: 10, Stock: 2, CategoryID: 5},
+			},
+		},
+		{
+			name:   "dry-run",
+			rows:   []entity.ImportProductRow{row(2, "SKU-1", "5")},
+			dryRun: true,
+			repo:   &mockProductRepository{getCategoryByIDFn: categoryByID},
+			wantReport: &entity.ImportProductReport{DryRun: true, Total: 1, Valid: 1, Rows: []entity.ImportProductResult{
+				{Line: 2, SKU: "SKU-1", Status: entity.ImportStatusValid},
+			}},
+			wantNoCreate: true,
+		},
+		{
+			name: "invalid-rows",
+			rows: []entity.ImportProductRow{
+				row(2, "SKU-1", "Minuman"),
+				{Line: 3, Name: "", Price: "1.5", Stock: "-1", Category: "Roti", SKU: "SKU-2"},
+				row(4, "SKU-1", "8"),
+				row(5, "USED", "Kue"),
+				row(6, "", "Minuman"),
+				row(7, "SKU-3", "Minuman"),
+			},
+			repo:    &mockProductRepository{getCategoriesFn: categories, getCategoryByIDFn: categoryByID, getProductByCodeFn: existing},
+			wantErr: "4 of 6 rows are invalid, no product was imported",
+			wantReport: &entity.ImportProductReport{Total: 6, Valid: 2, Failed: 4, Rows: []entity.ImportProductResult{
+				{Line: 2, SKU: "SKU-1", Status: entity.ImportStatusValid},
+				{Line: 3, SKU: "SKU-2", Status: entity.ImportStatusFailed, Errors: []apperror.FieldError{
+					{Field: "price", Message: "price must be a whole number"},
+					{Field: "category", Message: "category name matches 2 categories, use the category id"},
+					{Field: "name", Message: "name is required"},
+					{Field: "stock", Message: "stock must not be negative"},
+				}},
+				{Line: 4, SKU: "SKU-1", Status: entity.ImportStatusFailed, Errors: []apperror.FieldError{
+					{Field: "category", Message: "category not found"},
+					{Field: "sku", Message: "sku already used on line 2"},
+				}},
+				{Line: 5, SKU: "USED", Status: entity.ImportStatusFailed, Errors: []apperror.FieldError{
+					{Field: "category", Message: "category not found"},
+					{Field: "sku", Message: "sku already used by another product"},
+				}},
+				{Line: 6, Status: entity.ImportStatusFailed, Errors: []apperror.FieldError{{Field: "sku", Message: "sku is required"}}},
+				{Line: 7, SKU: "SKU-3", Status: entity.ImportStatusValid},
+			}},
+			wantNoCreate:  true,
+			wantCategoryN: 3,
+		},
+		{
+			name:         "category-error",
+			rows:         []entity.ImportProductRow{row(2, "SKU-1", "Minuman")},
+			repo:         &mockProductRepository{getCategoriesFn: func(string) ([]entity.Category, error) { return nil, errDB }},
+			wantErr:      "db",
+			wantNoCreate: true,
+		},
+		{
+			name:         "code-error",
+			rows:         []entity.ImportProductRow{row(2, "SKU-1", "5")},
+			repo:         &mockProductRepository{getCategoryByIDFn: categoryByID, getProductByCodeFn: func(string) (*entity.ResponseProductWithCategories, error) { return nil, errDB }},
+			wantErr:      "db",
+			wantNoCreate: true,
+		},
+		{
+			name:    "create-error",
+			rows:    []entity.ImportProductRow{row(2, "SKU-1", "5")},
+			repo:    &mockProductRepository{getCategoryByIDFn: categoryByID, createProductsFn: func([]*entity.Product) error { return errDB }},
+			wantErr: "db",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := tt.repo
+			if repo == nil {
+				repo = &mockProductRepository{}
+			}
+			lookups := 0
+			if getCategories := repo.getCategoriesFn; getCategories != nil {
+				repo.getCategoriesFn = func(name string) ([]entity.Category, error) {
+					lookups++
+					return getCategories(name)
+				}
+			}
+			svc := &productService{productRepository: repo}
+
+			report, err := svc.ImportProducts(context.Background(), actor, tt.rows, tt.dryRun)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(report, tt.wantReport) {
+				t.Fatalf("report = %+v, want %+v", report, tt.wantReport)
+			}
+			if tt.wantNoCreate && repo.createProductsArg != nil {
+				t.Fatalf("expected no products to be created, got %+v", repo.createProductsArg)
+			}
+			if tt.wantCreated != nil {
+				if !reflect.DeepEqual(repo.createProductsArg, tt.wantCreated) {
+					t.Fatalf("created = %+v, want %+v", repo.createProductsArg, tt.wantCreated)
+				}
+				if len(repo.entriesArg) != len(tt.wantCreated) {
+					t.Fatalf("entries = %d, want %d", len(repo.entriesArg), len(tt.wantCreated))
+				}
+				entry := repo.entriesArg[0]
+				wantAfter := entity.RequestProduct{Name: "Susu", SKU: "SKU-1", Price: 10, Stock: 2, CategoryID: 3}
+				if entry.Actor != actor || entry.Action != audit.ActionCreate || entry.EntityType != audit.EntityProduct || entry.After != wantAfter {
+					t.Fatalf("unexpected audit entry: %+v", entry)
+				}
+			}
+			if tt.wantCategoryN != 0 && lookups != tt.wantCategoryN {
+				t.Fatalf("category name lookups = %d, want %d", lookups, tt.wantCategoryN)
+			}
+		})
+	}
+}
@@ -1,87 +1,383 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strings"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
 )
 
 type productService struct {
 	productRepository repository.ProductRepository
+	notifier          alert.Notifier
+	ready             func(ctx context.Context) error
 }
 
 type ProductService interface {
-	CreateProduct(product *entity.RequestProduct) error
-	UpdateProduct(id int64, product *entity.RequestProduct) error
-	DeleteProduct(id int64) error
-	GetProductByID(id int64) (*entity.ResponseProductWithCategories, error)
-	GetAllProducts() ([]entity.ResponseProductWithCategories, error)
-	API() entity.HealthCheck
+	CreateProduct(ctx context.Context, actor audit.Actor, product *entity.RequestProduct) error
+	ImportProducts(ctx context.Context, actor audit.Actor, rows []entity.ImportProductRow, dryRun bool) (*entity.ImportProductReport, error)
+	UpdateProduct(ctx context.Context, actor audit.Actor, id int64, version int64, product *entity.RequestProduct) error
+	PatchProduct(ctx context.Context, actor audit.Actor, id int64, version int64, patch *entity.PatchProduct) error
+	DeleteProduct(ctx context.Context, actor audit.Actor, id int64, version int64) error
+	RestoreProduct(ctx context.Context, actor audit.Actor, id int64) error
+	GetProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error)
+	GetProductByCode(ctx context.Context, code string) (*entity.ResponseProductWithCategories, error)
+	GetAllProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error)
+	GetProductsByCategory(ctx context.Context, categoryID int64, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error)
+	SearchProducts(ctx context.Context, keyword string, limit int) ([]entity.ResponseProductWithCategories, error)
+	GetLowStockProducts(ctx context.Context) ([]entity.ResponseLowStockCategory, error)
+	API(ctx context.Context) entity.HealthCheck
 }
 
-func NewProductService(productRepository repository.ProductRepository) *productService {
-	return &productService{productRepository: productRepository}
+// NewProductService returns a ProductService. ready runs the readiness checks whose result API reports.
+func NewProductService(productRepository repository.ProductRepository, notifier alert.Notifier, ready func(ctx context.Context) error) *productService {
+	return &productService{productRepository: productRepository, notifier: notifier, ready: ready}
 }
 
-func (s *productService) API() entity.HealthCheck {
+// API reports the Products API healthy when the readiness checks of its dependencies pass.
+func (s *productService) API(ctx context.Context) entity.HealthCheck {
 	return entity.HealthCheck{
 		Name:      "Products API",
-		IsHealthy: true,
+		IsHealthy: s.ready(ctx) == nil,
 	}
 }
 
-func (s *productService) CreateProduct(requestProduct *entity.RequestProduct) error {
-	_, err := s.productRepository.GetCategoryByID(int64(requestProduct.CategoryID))
+// CreateProduct creates the product and records actor as its creator in the audit log.
+func (s *productService) CreateProduct(ctx context.Context, actor audit.Actor, requestProduct *entity.RequestProduct) error {
+	if err := requestProduct.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.validateCodes(ctx, 0, requestProduct); err != nil {
+		return err
+	}
+
+	if err := s.validateCategory(ctx, requestProduct.CategoryID); err != nil {
+		return err
+	}
+
+	product := &entity.Product{
+		Name:         requestProduct.Name,
+		SKU:          requestProduct.SKU,
+		Barcode:      requestProduct.Barcode,
+		Price:        requestProduct.Price,
+		Stock:        requestProduct.Stock,
+		ReorderLevel: requestProduct.ReorderLevel,
+		CategoryID:   requestProduct.CategoryID,
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionCreate,
+		EntityType: audit.EntityProduct,
+		After:      *requestProduct,
+	}
+
+	return s.productRepository.CreateProduct(ctx, product, entry)
+}
+
+// UpdateProduct updates the product and records the change made by actor in the audit log. A version other than zero
+// must be the current version of the product, as sent in If-Match, or nothing is updated. A stock change taking the
+// product to or below its reorder level is notified, as by a checkout.
+func (s *productService) UpdateProduct(ctx context.Context, actor audit.Actor, id int64, version int64, requestProduct *entity.RequestProduct) error {
+	current, err := s.productRepository.GetProductByID(ctx, id)
 	if err != nil {
-		return errors.New("category not found")
+		return err
+	}
+
+	if err := requestProduct.Validate(); err != nil {
+		return err
+	}
+
+	if err = s.validateCodes(ctx, id, requestProduct); err != nil {
+		return err
+	}
+
+	if err = s.validateCategory(ctx, requestProduct.CategoryID); err != nil {
+		return err
 	}
 
 	product := &entity.Product{
-		Name:       requestProduct.Name,
-		Price:      requestProduct.Price,
-		Stock:      requestProduct.Stock,
-		CategoryID: requestProduct.CategoryID,
+		Name:         requestProduct.Name,
+		SKU:          requestProduct.SKU,
+		Barcode:      requestProduct.Barcode,
+		Price:        requestProduct.Price,
+		Stock:        requestProduct.Stock,
+		ReorderLevel: requestProduct.ReorderLevel,
+		CategoryID:   requestProduct.CategoryID,
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityProduct,
+		EntityID:   id,
+		Before:     productSnapshot(current),
+		After:      *requestProduct,
 	}
 
-	return s.productRepository.CreateProduct(product)
+	lowStock, err := s.productRepository.UpdateProduct(ctx, id, version, product, entry)
+	if err != nil {
+		return err
+	}
+
+	if lowStock != nil {
+		s.notifier.NotifyLowStock(ctx, *lowStock)
+	}
+
+	return nil
 }
 
-func (s *productService) UpdateProduct(id int64, requestProduct *entity.RequestProduct) error {
-	_, err := s.productRepository.GetProductByID(id)
+// PatchProduct changes only the members present in patch and records the change made by actor in the audit log. The
+// patched product is validated as a whole and a low stock is notified, the same as by UpdateProduct.
+func (s *productService) PatchProduct(ctx context.Context, actor audit.Actor, id int64, version int64, patch *entity.PatchProduct) error {
+	current, err := s.productRepository.GetProductByID(ctx, id)
 	if err != nil {
-		return errors.New("product not found")
+		return err
+	}
+
+	if err = patch.Validate(); err != nil {
+		return err
+	}
+
+	before := productSnapshot(current)
+	after := before
+	patch.Apply(&after)
+
+	if err = after.Validate(); err != nil {
+		return err
+	}
+
+	if patch.SKU.Set || patch.Barcode.Set {
+		if err = s.validateCodes(ctx, id, &after); err != nil {
+			return err
+		}
+
+		// validateCodes trims the codes, so the normalised values are the ones stored.
+		patch.SKU.Value = after.SKU
+		patch.Barcode.Value = after.Barcode
 	}
 
-	_, err = s.productRepository.GetCategoryByID(int64(requestProduct.CategoryID))
+	if patch.CategoryID.Set {
+		if err = s.validateCategory(ctx, after.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionUpdate,
+		EntityType: audit.EntityProduct,
+		EntityID:   id,
+		Before:     before,
+		After:      after,
+	}
+
+	lowStock, err := s.productRepository.PatchProduct(ctx, id, version, patch, entry)
 	if err != nil {
-		return errors.New("category not found")
+		return err
 	}
 
-	product := &entity.Product{
-		Name:       requestProduct.Name,
-		Price:      requestProduct.Price,
-		Stock:      requestProduct.Stock,
-		CategoryID: requestProduct.CategoryID,
+	if lowStock != nil {
+		s.notifier.NotifyLowStock(ctx, *lowStock)
 	}
 
-	return s.productRepository.UpdateProduct(id, product)
+	return nil
 }
 
-func (s *productService) DeleteProduct(id int64) error {
-	_, err := s.productRepository.GetProductByID(id)
+// DeleteProduct deletes the product and records actor and the deleted state in the audit log.
+func (s *productService) DeleteProduct(ctx context.Context, actor audit.Actor, id int64, version int64) error {
+	current, err := s.productRepository.GetProductByID(ctx, id)
 	if err != nil {
-		return errors.New("product not found")
+		return err
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionDelete,
+		EntityType: audit.EntityProduct,
+		EntityID:   id,
+		Before:     productSnapshot(current),
 	}
 
-	return s.productRepository.DeleteProduct(id)
+	return s.productRepository.DeleteProduct(ctx, id, version, entry)
 }
 
-func (s *productService) GetProductByID(id int64) (*entity.ResponseProductWithCategories, error) {
-	result, err := s.productRepository.GetProductByID(id)
+// RestoreProduct brings back a deleted product and records actor and the restored state in the audit log. It fails
+// with a conflict when another product has taken its SKU or barcode, or when its category has been deleted.
+func (s *productService) RestoreProduct(ctx context.Context, actor audit.Actor, id int64) error {
+	deleted, err := s.productRepository.GetDeletedProductByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = s.ensureCodeUnused(ctx, id, deleted.SKU, "sku"); err != nil {
+		return err
+	}
+
+	if deleted.Barcode != "" {
+		if err = s.ensureCodeUnused(ctx, id, deleted.Barcode, "barcode"); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.productRepository.GetCategoryByID(ctx, int64(deleted.CategoryID))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Conflict("category of the product has been deleted")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	entry := &audit.Entry{
+		Actor:      actor,
+		Action:     audit.ActionRestore,
+		EntityType: audit.EntityProduct,
+		EntityID:   id,
+		After:      productSnapshot(deleted),
+	}
+
+	return s.productRepository.RestoreProduct(ctx, id, entry)
+}
+
+func (s *productService) GetProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error) {
+	result, err := s.productRepository.GetProductByID(ctx, id)
 	return result, err
 }
 
-func (s *productService) GetAllProducts() ([]entity.ResponseProductWithCategories, error) {
-	return s.productRepository.GetAllProducts()
+func (s *productService) GetProductByCode(ctx context.Context, code string) (*entity.ResponseProductWithCategories, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, apperror.Validation("product code is required")
+	}
+
+	return s.productRepository.GetProductByCode(ctx, code)
+}
+
+func (s *productService) GetAllProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error) {
+	products, total, err := s.productRepository.GetAllProducts(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return products, pagination.NewMeta(filter.Pagination, total), nil
+}
+
+// GetProductsByCategory returns a page of the products of a category, narrowed by the rest of filter. It returns a not
+// found error when the category does not exist, rather than an empty page.
+func (s *productService) GetProductsByCategory(ctx context.Context, categoryID int64, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, *pagination.Meta, error) {
+	if _, err := s.productRepository.GetCategoryByID(ctx, categoryID); err != nil {
+		return nil, nil, err
+	}
+
+	filter.CategoryID = int(categoryID)
+	return s.GetAllProducts(ctx, filter)
+}
+
+func (s *productService) SearchProducts(ctx context.Context, keyword string, limit int) ([]entity.ResponseProductWithCategories, error) {
+	keyword = strings.TrimSpace(keyword)
+	if keyword == "" {
+		return nil, apperror.Validation("search keyword is required")
+	}
+
+	return s.productRepository.SearchProducts(ctx, keyword, limit)
+}
+
+// GetLowStockProducts groups the products at or below their reorder level by category.
+func (s *productService) GetLowStockProducts(ctx context.Context) ([]entity.ResponseLowStockCategory, error) {
+	products, err := s.productRepository.GetLowStockProducts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	categories := []entity.ResponseLowStockCategory{}
+	for _, product := range products {
+		if n := len(categories); n == 0 || categories[n-1].CategoryID != product.CategoryID {
+			categories = append(categories, entity.ResponseLowStockCategory{
+				CategoryID:   product.CategoryID,
+				CategoryName: product.CategoryName,
+			})
+		}
+
+		last := &categories[len(categories)-1]
+		last.Products = append(last.Products, product)
+	}
+
+	return categories, nil
+}
+
+// validateCodes normalises and validates the SKU and barcode of a product request and makes sure neither is already
+// used, as a SKU or a barcode, by a product other than id. Pass an id of 0 for a new product.
+func (s *productService) validateCodes(ctx context.Context, id int64, requestProduct *entity.RequestProduct) error {
+	requestProduct.SKU = strings.TrimSpace(requestProduct.SKU)
+	requestProduct.Barcode = strings.TrimSpace(requestProduct.Barcode)
+
+	if err := validateSKU(requestProduct.SKU); err != nil {
+		return err
+	}
+
+	if err := validateBarcode(requestProduct.Barcode); err != nil {
+		return err
+	}
+
+	if err := s.ensureCodeUnused(ctx, id, requestProduct.SKU, "sku"); err != nil {
+		return err
+	}
+
+	if requestProduct.Barcode != "" {
+		if err := s.ensureCodeUnused(ctx, id, requestProduct.Barcode, "barcode"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// productSnapshot returns the editable fields of a product, the state recorded in the audit log.
+func productSnapshot(product *entity.ResponseProductWithCategories) entity.RequestProduct {
+	return entity.RequestProduct{
+		Name:         product.Name,
+		SKU:          product.SKU,
+		Barcode:      product.Barcode,
+		Price:        product.Price,
+		Stock:        product.Stock,
+		ReorderLevel: product.ReorderLevel,
+		CategoryID:   product.CategoryID,
+	}
+}
+
+// ensureCodeUnused returns a conflict error when code is the SKU or barcode of a product other than id. field names
+// the code in the error.
+func (s *productService) ensureCodeUnused(ctx context.Context, id int64, code, field string) error {
+	existing, err := s.productRepository.GetProductByCode(ctx, code)
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if int64(existing.ID) != id {
+		return apperror.Conflict("%s already used by another product", field)
+	}
+
+	return nil
+}
+
+// validateCategory makes sure the category a product request refers to exists.
+func (s *productService) validateCategory(ctx context.Context, categoryID int) error {
+	_, err := s.productRepository.GetCategoryByID(ctx, int64(categoryID))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return apperror.Invalid(apperror.FieldError{Field: "category_id", Message: "category not found"})
+	}
+
+	return err
 }
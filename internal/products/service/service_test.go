@@ -1,54 +1,124 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"reflect"
 	"testing"
 
 	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/products/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/audit"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/patch"
 )
 
 type mockProductRepository struct {
-	createProductFn  func(product *entity.Product) error
-	updateProductFn  func(id int64, product *entity.Product) error
-	deleteProductFn  func(id int64) error
-	getProductByIDFn func(id int64) (*entity.ResponseProductWithCategories, error)
-	getAllProductsFn func() ([]entity.ResponseProductWithCategories, error)
-	getCategoryByIDFn func(id int64) (*entity.Category, error)
-
-	createProductArg *entity.Product
-	updateProductArg *entity.Product
-	updateProductID  int64
-	deleteProductID  int64
-	getCategoryIDArg int64
-	getProductIDArg  int64
+	createProductFn    func(product *entity.Product) error
+	createProductsFn   func(products []*entity.Product) error
+	updateProductFn    func(id int64, product *entity.Product) error
+	patchProductFn     func(id int64, patch *entity.PatchProduct) error
+	deleteProductFn    func(id int64) error
+	restoreProductFn   func(id int64) error
+	getDeletedFn       func(id int64) (*entity.ResponseProductWithCategories, error)
+	getProductByIDFn   func(id int64) (*entity.ResponseProductWithCategories, error)
+	getProductByCodeFn func(code string) (*entity.ResponseProductWithCategories, error)
+	getAllProductsFn   func(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error)
+	getCategoryByIDFn  func(id int64) (*entity.Category, error)
+	getCategoriesFn    func(name string) ([]entity.Category, error)
+	searchProductsFn   func(keyword string, limit int) ([]entity.ResponseProductWithCategories, error)
+	getLowStockFn      func() ([]entity.ResponseProductWithCategories, error)
+	lowStock           *alert.LowStock
+
+	createProductArg  *entity.Product
+	createProductsArg []*entity.Product
+	entriesArg        []*audit.Entry
+	updateProductArg  *entity.Product
+	patchProductArg   *entity.PatchProduct
+	entryArg          *audit.Entry
+	updateProductID   int64
+	patchProductID    int64
+	deleteProductID   int64
+	restoreProductID  int64
+	versionArg        int64
+	getCategoryIDArg  int64
+	getProductIDArg   int64
 }
 
-func (m *mockProductRepository) CreateProduct(product *entity.Product) error {
+func (m *mockProductRepository) CreateProduct(ctx context.Context, product *entity.Product, entry *audit.Entry) error {
 	m.createProductArg = product
+	m.entryArg = entry
 	if m.createProductFn == nil {
 		return nil
 	}
 	return m.createProductFn(product)
 }
 
-func (m *mockProductRepository) UpdateProduct(id int64, product *entity.Product) error {
+func (m *mockProductRepository) CreateProducts(ctx context.Context, products []*entity.Product, entries []*audit.Entry) error {
+	m.createProductsArg = products
+	m.entriesArg = entries
+	if m.createProductsFn == nil {
+		return nil
+	}
+	return m.createProductsFn(products)
+}
+
+func (m *mockProductRepository) UpdateProduct(ctx context.Context, id int64, version int64, product *entity.Product, entry *audit.Entry) (*alert.LowStock, error) {
 	m.updateProductID = id
+	m.versionArg = version
 	m.updateProductArg = product
+	m.entryArg = entry
 	if m.updateProductFn == nil {
-		return nil
+		return m.lowStock, nil
 	}
-	return m.updateProductFn(id, product)
+	if err := m.updateProductFn(id, product); err != nil {
+		return nil, err
+	}
+	return m.lowStock, nil
+}
+
+func (m *mockProductRepository) PatchProduct(ctx context.Context, id int64, version int64, patch *entity.PatchProduct, entry *audit.Entry) (*alert.LowStock, error) {
+	m.patchProductID = id
+	m.versionArg = version
+	m.patchProductArg = patch
+	m.entryArg = entry
+	if m.patchProductFn == nil {
+		return m.lowStock, nil
+	}
+	if err := m.patchProductFn(id, patch); err != nil {
+		return nil, err
+	}
+	return m.lowStock, nil
 }
 
-func (m *mockProductRepository) DeleteProduct(id int64) error {
+func (m *mockProductRepository) DeleteProduct(ctx context.Context, id int64, version int64, entry *audit.Entry) error {
 	m.deleteProductID = id
+	m.versionArg = version
+	m.entryArg = entry
 	if m.deleteProductFn == nil {
 		return nil
 	}
 	return m.deleteProductFn(id)
 }
 
-func (m *mockProductRepository) GetProductByID(id int64) (*entity.ResponseProductWithCategories, error) {
+func (m *mockProductRepository) RestoreProduct(ctx context.Context, id int64, entry *audit.Entry) error {
+	m.restoreProductID = id
+	m.entryArg = entry
+	if m.restoreProductFn == nil {
+		return nil
+	}
+	return m.restoreProductFn(id)
+}
+
+func (m *mockProductRepository) GetDeletedProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error) {
+	if m.getDeletedFn == nil {
+		return nil, apperror.NotFound("deleted product not found")
+	}
+	return m.getDeletedFn(id)
+}
+
+func (m *mockProductRepository) GetProductByID(ctx context.Context, id int64) (*entity.ResponseProductWithCategories, error) {
 	m.getProductIDArg = id
 	if m.getProductByIDFn == nil {
 		return nil, nil
@@ -56,14 +126,21 @@ func (m *mockProductRepository) GetProductByID(id int64) (*entity.ResponseProduc
 	return m.getProductByIDFn(id)
 }
 
-func (m *mockProductRepository) GetAllProducts() ([]entity.ResponseProductWithCategories, error) {
+func (m *mockProductRepository) GetProductByCode(ctx context.Context, code string) (*entity.ResponseProductWithCategories, error) {
+	if m.getProductByCodeFn == nil {
+		return nil, apperror.NotFound("product not found")
+	}
+	return m.getProductByCodeFn(code)
+}
+
+func (m *mockProductRepository) GetAllProducts(ctx context.Context, filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error) {
 	if m.getAllProductsFn == nil {
-		return nil, nil
+		return nil, 0, nil
 	}
-	return m.getAllProductsFn()
+	return m.getAllProductsFn(filter)
 }
 
-func (m *mockProductRepository) GetCategoryByID(id int64) (*entity.Category, error) {
+func (m *mockProductRepository) GetCategoryByID(ctx context.Context, id int64) (*entity.Category, error) {
 	m.getCategoryIDArg = id
 	if m.getCategoryByIDFn == nil {
 		return nil, nil
@@ -71,6 +148,37 @@ func (m *mockProductRepository) GetCategoryByID(id int64) (*entity.Category, err
 	return m.getCategoryByIDFn(id)
 }
 
+func (m *mockProductRepository) GetCategoriesByName(ctx context.Context, name string) ([]entity.Category, error) {
+	if m.getCategoriesFn == nil {
+		return nil, nil
+	}
+	return m.getCategoriesFn(name)
+}
+
+func (m *mockProductRepository) SearchProducts(ctx context.Context, keyword string, limit int) ([]entity.ResponseProductWithCategories, error) {
+	if m.searchProductsFn == nil {
+		return nil, nil
+	}
+	return m.searchProductsFn(keyword, limit)
+}
+
+func (m *mockProductRepository) GetLowStockProducts(ctx context.Context) ([]entity.ResponseProductWithCategories, error) {
+	if m.getLowStockFn == nil {
+		return nil, nil
+	}
+	return m.getLowStockFn()
+}
+
+type mockNotifier struct {
+	events []alert.LowStock
+}
+
+func (m *mockNotifier) NotifyLowStock(ctx context.Context, event alert.LowStock) {
+	m.events = append(m.events, event)
+}
+
+func (m *mockNotifier) Close(context.Context) error { return nil }
+
 func TestNewProductService(t *testing.T) {
 	tests := []struct {
 		name string
@@ -81,13 +189,20 @@ func TestNewProductService(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &mockProductRepository{}
-			service := NewProductService(repo)
+			notifier := &mockNotifier{}
+			service := NewProductService(repo, notifier, func(context.Context) error { return nil })
 			if service == nil {
 				t.Fatal("expected service")
 			}
 			if service.productRepository != repo {
 				t.Fatal("repository not set")
 			}
+			if service.notifier != notifier {
+				t.Fatal("notifier not set")
+			}
+			if service.ready == nil {
+				t.Fatal("ready not set")
+			}
 		})
 	}
 }
@@ -95,15 +210,18 @@ func TestNewProductService(t *testing.T) {
 func TestProductService_API(t *testing.T) {
 	tests := []struct {
 		name string
+		err  error
+		want bool
 	}{
-		{name: "ok"},
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			svc := &productService{productRepository: &mockProductRepository{}}
-			got := svc.API()
-			if got.Name != "Products API" || got.IsHealthy != true {
+			svc := &productService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Products API" || got.IsHealthy != tt.want {
 				t.Fatalf("unexpected healthcheck: %+v", got)
 			}
 		})
@@ -111,6 +229,8 @@ func TestProductService_API(t *testing.T) {
 }
 
 func TestProductService_CreateProduct(t *testing.T) {
+	actor := audit.Actor{ID: 1, Username: "admin"}
+
 	tests := []struct {
 		name        string
 		req         *entity.RequestProduct
@@ -119,19 +239,72 @@ func TestProductService_CreateProduct(t *testing.T) {
 		wantProduct *entity.Product
 		wantCatID   int64
 	}{
+		{
+			name:    "reorder-negative",
+			req:     &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, ReorderLevel: -1, CategoryID: 2},
+			wantErr: "reorder_level must not be negative",
+		},
+		{
+			name:    "fields-invalid",
+			req:     &entity.RequestProduct{Name: " ", SKU: "SKU-1", Price: -10, Stock: -1},
+			wantErr: "name is required; price must not be negative; stock must not be negative; category_id is required",
+		},
+		{
+			name:    "sku-missing",
+			req:     &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			wantErr: "sku is required",
+		},
+		{
+			name:    "barcode-invalid",
+			req:     &entity.RequestProduct{Name: "n", SKU: "SKU-1", Barcode: "8992761166015", Price: 10, Stock: 1, CategoryID: 2},
+			wantErr: "invalid barcode check digit",
+		},
+		{
+			name: "sku-taken",
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: 3, SKU: code}, nil
+				}
+			},
+			wantErr: "sku already used by another product",
+		},
+		{
+			name: "sku-lookup-err",
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					return nil, errors.New("db down")
+				}
+			},
+			wantErr: "db down",
+		},
+		{
+			name: "barcode-taken",
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Barcode: "8992761166014", Price: 10, Stock: 1, CategoryID: 2},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					if code == "SKU-1" {
+						return nil, apperror.NotFound("product not found")
+					}
+					return &entity.ResponseProductWithCategories{ID: 3, Barcode: code}, nil
+				}
+			},
+			wantErr: "barcode already used by another product",
+		},
 		{
 			name: "category-miss",
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
-					return nil, errors.New("nope")
+					return nil, apperror.NotFound("category not found")
 				}
 			},
 			wantErr: "category not found",
 		},
 		{
 			name: "create-err",
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
 					return &entity.Category{ID: int(id)}, nil
@@ -144,13 +317,13 @@ func TestProductService_CreateProduct(t *testing.T) {
 		},
 		{
 			name: "ok",
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, ReorderLevel: 3, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
 					return &entity.Category{ID: int(id)}, nil
 				}
 			},
-			wantProduct: &entity.Product{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			wantProduct: &entity.Product{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, ReorderLevel: 3, CategoryID: 2},
 			wantCatID:   2,
 		},
 	}
@@ -162,7 +335,7 @@ func TestProductService_CreateProduct(t *testing.T) {
 				tt.setupMock(repo)
 			}
 			svc := &productService{productRepository: repo}
-			err := svc.CreateProduct(tt.req)
+			err := svc.CreateProduct(context.Background(), actor, tt.req)
 
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
@@ -184,12 +357,18 @@ func TestProductService_CreateProduct(t *testing.T) {
 				if repo.getCategoryIDArg != tt.wantCatID {
 					t.Fatalf("unexpected category id: %d", repo.getCategoryIDArg)
 				}
+				wantEntry := &audit.Entry{Actor: actor, Action: audit.ActionCreate, EntityType: audit.EntityProduct, After: *tt.req}
+				if !reflect.DeepEqual(repo.entryArg, wantEntry) {
+					t.Fatalf("unexpected audit entry: %+v", repo.entryArg)
+				}
 			}
 		})
 	}
 }
 
 func TestProductService_UpdateProduct(t *testing.T) {
+	actor := audit.Actor{ID: 1, Username: "admin"}
+
 	tests := []struct {
 		name        string
 		id          int64
@@ -203,24 +382,35 @@ func TestProductService_UpdateProduct(t *testing.T) {
 		{
 			name: "product-miss",
 			id:   10,
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
-					return nil, errors.New("no product")
+					return nil, apperror.NotFound("product not found")
 				}
 			},
 			wantErr: "product not found",
 		},
+		{
+			name: "reorder-negative",
+			id:   10,
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, ReorderLevel: -2, CategoryID: 2},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: int(id)}, nil
+				}
+			},
+			wantErr: "reorder_level must not be negative",
+		},
 		{
 			name: "category-miss",
 			id:   10,
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
 					return &entity.ResponseProductWithCategories{ID: int(id)}, nil
 				}
 				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
-					return nil, errors.New("no category")
+					return nil, apperror.NotFound("category not found")
 				}
 			},
 			wantErr: "category not found",
@@ -228,7 +418,7 @@ func TestProductService_UpdateProduct(t *testing.T) {
 		{
 			name: "update-err",
 			id:   10,
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
 					return &entity.ResponseProductWithCategories{ID: int(id)}, nil
@@ -243,18 +433,35 @@ func TestProductService_UpdateProduct(t *testing.T) {
 			wantErr: "update fail",
 		},
 		{
-			name: "ok",
+			name: "sku-taken",
 			id:   10,
-			req:  &entity.RequestProduct{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			req:  &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, CategoryID: 2},
 			setupMock: func(m *mockProductRepository) {
 				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
 					return &entity.ResponseProductWithCategories{ID: int(id)}, nil
 				}
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: 11, SKU: code}, nil
+				}
+			},
+			wantErr: "sku already used by another product",
+		},
+		{
+			name: "ok",
+			id:   10,
+			req:  &entity.RequestProduct{Name: "n", SKU: " SKU-1 ", Barcode: "8992761166014", Price: 10, Stock: 1, CategoryID: 2},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: int(id), Name: "old", SKU: "SKU-1", Price: 8, Stock: 1, CategoryID: 2, CategoryName: "c"}, nil
+				}
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: 10, SKU: "SKU-1", Barcode: "8992761166014"}, nil
+				}
 				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
 					return &entity.Category{ID: int(id)}, nil
 				}
 			},
-			wantProduct: &entity.Product{Name: "n", Price: 10, Stock: 1, CategoryID: 2},
+			wantProduct: &entity.Product{Name: "n", SKU: "SKU-1", Barcode: "8992761166014", Price: 10, Stock: 1, CategoryID: 2},
 			wantCatID:   2,
 			wantID:      10,
 		},
@@ -267,7 +474,7 @@ func TestProductService_UpdateProduct(t *testing.T) {
 				tt.setupMock(repo)
 			}
 			svc := &productService{productRepository: repo}
-			err := svc.UpdateProduct(tt.id, tt.req)
+			err := svc.UpdateProduct(context.Background(), actor, tt.id, 4, tt.req)
 
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
@@ -289,9 +496,234 @@ func TestProductService_UpdateProduct(t *testing.T) {
 				if repo.getCategoryIDArg != tt.wantCatID {
 					t.Fatalf("unexpected category id: %d", repo.getCategoryIDArg)
 				}
-				if repo.updateProductID != tt.wantID {
-					t.Fatalf("unexpected update id: %d", repo.updateProductID)
+				if repo.updateProductID != tt.wantID || repo.versionArg != 4 {
+					t.Fatalf("unexpected update id/version: %d/%d", repo.updateProductID, repo.versionArg)
+				}
+				wantEntry := &audit.Entry{
+					Actor:      actor,
+					Action:     audit.ActionUpdate,
+					EntityType: audit.EntityProduct,
+					EntityID:   tt.wantID,
+					Before:     entity.RequestProduct{Name: "old", SKU: "SKU-1", Price: 8, Stock: 1, CategoryID: 2},
+					After:      entity.RequestProduct{Name: "n", SKU: "SKU-1", Barcode: "8992761166014", Price: 10, Stock: 1, CategoryID: 2},
+				}
+				if !reflect.DeepEqual(repo.entryArg, wantEntry) {
+					t.Fatalf("unexpected audit entry: %+v", repo.entryArg)
+				}
+			}
+		})
+	}
+}
+
+func TestProductService_PatchProduct(t *testing.T) {
+	actor := audit.Actor{ID: 1, Username: "admin"}
+	current := &entity.ResponseProductWithCategories{ID: 10, Name: "old", SKU: "SKU-1", Barcode: "8992761166014", Price: 8, Stock: 1, CategoryID: 2}
+	before := entity.RequestProduct{Name: "old", SKU: "SKU-1", Barcode: "8992761166014", Price: 8, Stock: 1, CategoryID: 2}
+
+	tests := []struct {
+		name         string
+		patch        *entity.PatchProduct
+		setupMock    func(m *mockProductRepository)
+		wantErr      string
+		wantPatch    *entity.PatchProduct
+		wantAfter    entity.RequestProduct
+		wantCategory bool
+	}{
+		{
+			name:  "product-miss",
+			patch: &entity.PatchProduct{Price: patch.Of(10)},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
+					return nil, apperror.NotFound("product not found")
+				}
+			},
+			wantErr: "product not found",
+		},
+		{
+			name:    "null-name",
+			patch:   &entity.PatchProduct{Name: patch.Field[string]{Set: true, Null: true}, Stock: patch.Field[int]{Set: true, Null: true}},
+			wantErr: "name must not be null; stock must not be null",
+		},
+		{
+			name:    "invalid-price",
+			patch:   &entity.PatchProduct{Price: patch.Of(-1)},
+			wantErr: "price must not be negative",
+		},
+		{
+			name:  "sku-taken",
+			patch: &entity.PatchProduct{SKU: patch.Of("SKU-2")},
+			setupMock: func(m *mockProductRepository) {
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: 3, SKU: code}, nil
+				}
+			},
+			wantErr: "sku already used by another product",
+		},
+		{
+			name:  "category-miss",
+			patch: &entity.PatchProduct{CategoryID: patch.Of(5)},
+			setupMock: func(m *mockProductRepository) {
+				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
+					return nil, apperror.NotFound("category not found")
 				}
+			},
+			wantErr: "category not found",
+		},
+		{
+			name:      "price-only",
+			patch:     &entity.PatchProduct{Price: patch.Of(12)},
+			wantPatch: &entity.PatchProduct{Price: patch.Of(12)},
+			wantAfter: entity.RequestProduct{Name: "old", SKU: "SKU-1", Barcode: "8992761166014", Price: 12, Stock: 1, CategoryID: 2},
+		},
+		{
+			name:      "remove-barcode",
+			patch:     &entity.PatchProduct{SKU: patch.Of(" SKU-9 "), Barcode: patch.Field[string]{Set: true, Null: true}},
+			wantPatch: &entity.PatchProduct{SKU: patch.Of("SKU-9"), Barcode: patch.Field[string]{Set: true, Null: true}},
+			wantAfter: entity.RequestProduct{Name: "old", SKU: "SKU-9", Price: 8, Stock: 1, CategoryID: 2},
+		},
+		{
+			name:  "move-category",
+			patch: &entity.PatchProduct{CategoryID: patch.Of(4)},
+			setupMock: func(m *mockProductRepository) {
+				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
+					return &entity.Category{ID: int(id)}, nil
+				}
+			},
+			wantPatch:    &entity.PatchProduct{CategoryID: patch.Of(4)},
+			wantAfter:    entity.RequestProduct{Name: "old", SKU: "SKU-1", Barcode: "8992761166014", Price: 8, Stock: 1, CategoryID: 4},
+			wantCategory: true,
+		},
+		{
+			name:  "patch-err",
+			patch: &entity.PatchProduct{Price: patch.Of(12)},
+			setupMock: func(m *mockProductRepository) {
+				m.patchProductFn = func(id int64, p *entity.PatchProduct) error {
+					return errors.New("db down")
+				}
+			},
+			wantErr: "db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductRepository{
+				getProductByIDFn: func(id int64) (*entity.ResponseProductWithCategories, error) {
+					copied := *current
+					return &copied, nil
+				},
+			}
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
+			svc := &productService{productRepository: repo}
+			err := svc.PatchProduct(context.Background(), actor, 10, 4, tt.patch)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if repo.patchProductFn == nil && repo.patchProductArg != nil {
+					t.Fatal("expected an invalid patch not to be stored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if repo.patchProductID != 10 || repo.versionArg != 4 || !reflect.DeepEqual(repo.patchProductArg, tt.wantPatch) {
+				t.Fatalf("patch = %d %d %+v, want 10 4 %+v", repo.patchProductID, repo.versionArg, repo.patchProductArg, tt.wantPatch)
+			}
+			if (repo.getCategoryIDArg != 0) != tt.wantCategory {
+				t.Fatalf("category looked up = %v, want %v", repo.getCategoryIDArg != 0, tt.wantCategory)
+			}
+			wantEntry := &audit.Entry{
+				Actor:      actor,
+				Action:     audit.ActionUpdate,
+				EntityType: audit.EntityProduct,
+				EntityID:   10,
+				Before:     before,
+				After:      tt.wantAfter,
+			}
+			if !reflect.DeepEqual(repo.entryArg, wantEntry) {
+				t.Fatalf("unexpected audit entry: %+v", repo.entryArg)
+			}
+		})
+	}
+}
+
+func TestProductService_NotifyLowStock(t *testing.T) {
+	actor := audit.Actor{ID: 1, Username: "admin"}
+	event := &alert.LowStock{ProductID: 10, ProductName: "n", Stock: 1, ReorderLevel: 2, Source: alert.SourceProductUpdate}
+
+	tests := []struct {
+		name     string
+		lowStock *alert.LowStock
+		err      error
+		update   func(svc *productService) error
+		wantErr  string
+		want     []alert.LowStock
+	}{
+		{
+			name:     "update",
+			lowStock: event,
+			update: func(svc *productService) error {
+				return svc.UpdateProduct(context.Background(), actor, 10, 0, &entity.RequestProduct{Name: "n", SKU: "SKU-1", Price: 10, Stock: 1, ReorderLevel: 2, CategoryID: 2})
+			},
+			want: []alert.LowStock{*event},
+		},
+		{
+			name:     "patch",
+			lowStock: event,
+			update: func(svc *productService) error {
+				return svc.PatchProduct(context.Background(), actor, 10, 0, &entity.PatchProduct{Stock: patch.Of(1)})
+			},
+			want: []alert.LowStock{*event},
+		},
+		{
+			name: "not-low",
+			update: func(svc *productService) error {
+				return svc.PatchProduct(context.Background(), actor, 10, 0, &entity.PatchProduct{Stock: patch.Of(5)})
+			},
+		},
+		{
+			name:     "failed",
+			lowStock: event,
+			err:      errors.New("db down"),
+			update: func(svc *productService) error {
+				return svc.PatchProduct(context.Background(), actor, 10, 0, &entity.PatchProduct{Stock: patch.Of(1)})
+			},
+			wantErr: "db down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductRepository{
+				lowStock: tt.lowStock,
+				getProductByIDFn: func(id int64) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: int(id), Name: "n", SKU: "SKU-1", Price: 10, Stock: 3, ReorderLevel: 2, CategoryID: 2}, nil
+				},
+				getCategoryByIDFn: func(id int64) (*entity.Category, error) {
+					return &entity.Category{ID: int(id)}, nil
+				},
+				getProductByCodeFn: func(code string) (*entity.ResponseProductWithCategories, error) {
+					return &entity.ResponseProductWithCategories{ID: 10, SKU: code}, nil
+				},
+				patchProductFn: func(int64, *entity.PatchProduct) error { return tt.err },
+			}
+			notifier := &mockNotifier{}
+			svc := NewProductService(repo, notifier, nil)
+
+			err := tt.update(svc)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+			}
+			if !reflect.DeepEqual(notifier.events, tt.want) {
+				t.Fatalf("events = %+v, want %+v", notifier.events, tt.want)
 			}
 		})
 	}
@@ -310,7 +742,7 @@ func TestProductService_DeleteProduct(t *testing.T) {
 			id:   10,
 			setupMock: func(m *mockProductRepository) {
 				m.getProductByIDFn = func(id int64) (*entity.ResponseProductWithCategories, error) {
-					return nil, errors.New("no product")
+					return nil, apperror.NotFound("product not found")
 				}
 			},
 			wantErr: "product not found",
@@ -347,7 +779,7 @@ func TestProductService_DeleteProduct(t *testing.T) {
 				tt.setupMock(repo)
 			}
 			svc := &productService{productRepository: repo}
-			err := svc.DeleteProduct(tt.id)
+			err := svc.DeleteProduct(context.Background(), audit.System, tt.id, 4)
 
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
@@ -358,8 +790,114 @@ func TestProductService_DeleteProduct(t *testing.T) {
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-			if repo.deleteProductID != tt.wantID {
-				t.Fatalf("unexpected delete id: %d", repo.deleteProductID)
+			if repo.deleteProductID != tt.wantID || repo.versionArg != 4 {
+				t.Fatalf("unexpected delete id/version: %d/%d", repo.deleteProductID, repo.versionArg)
+			}
+			wantEntry := &audit.Entry{Actor: audit.System, Action: audit.ActionDelete, EntityType: audit.EntityProduct, EntityID: tt.wantID, Before: entity.RequestProduct{}}
+			if !reflect.DeepEqual(repo.entryArg, wantEntry) {
+				t.Fatalf("unexpected audit entry: %+v", repo.entryArg)
+			}
+		})
+	}
+}
+
+func TestProductService_RestoreProduct(t *testing.T) {
+	deleted := func(id int64) (*entity.ResponseProductWithCategories, error) {
+		return &entity.ResponseProductWithCategories{ID: int(id), Name: "Teh", SKU: "TEH-1", Barcode: "8992761166014", Price: 5000, CategoryID: 3}, nil
+	}
+	category := func(id int64) (*entity.Category, error) {
+		return &entity.Category{ID: int(id), Name: "Minuman"}, nil
+	}
+
+	tests := []struct {
+		name      string
+		setupMock func(m *mockProductRepository)
+		wantErr   error
+	}{
+		{
+			name:    "not-deleted",
+			wantErr: apperror.ErrNotFound,
+		},
+		{
+			name: "sku-taken",
+			setupMock: func(m *mockProductRepository) {
+				m.getDeletedFn = deleted
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					if code == "TEH-1" {
+						return &entity.ResponseProductWithCategories{ID: 11}, nil
+					}
+					return nil, apperror.NotFound("product not found")
+				}
+			},
+			wantErr: apperror.ErrConflict,
+		},
+		{
+			name: "barcode-taken",
+			setupMock: func(m *mockProductRepository) {
+				m.getDeletedFn = deleted
+				m.getProductByCodeFn = func(code string) (*entity.ResponseProductWithCategories, error) {
+					if code == "8992761166014" {
+						return &entity.ResponseProductWithCategories{ID: 11}, nil
+					}
+					return nil, apperror.NotFound("product not found")
+				}
+			},
+			wantErr: apperror.ErrConflict,
+		},
+		{
+			name: "category-deleted",
+			setupMock: func(m *mockProductRepository) {
+				m.getDeletedFn = deleted
+				m.getCategoryByIDFn = func(id int64) (*entity.Category, error) {
+					return nil, apperror.NotFound("category not found")
+				}
+			},
+			wantErr: apperror.ErrConflict,
+		},
+		{
+			name: "restore-err",
+			setupMock: func(m *mockProductRepository) {
+				m.getDeletedFn = deleted
+				m.getCategoryByIDFn = category
+				m.restoreProductFn = func(id int64) error {
+					return apperror.NotFound("deleted product not found")
+				}
+			},
+			wantErr: apperror.ErrNotFound,
+		},
+		{
+			name: "ok",
+			setupMock: func(m *mockProductRepository) {
+				m.getDeletedFn = deleted
+				m.getCategoryByIDFn = category
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductRepository{}
+			if tt.setupMock != nil {
+				tt.setupMock(repo)
+			}
+			svc := &productService{productRepository: repo}
+			err := svc.RestoreProduct(context.Background(), audit.System, 10)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if repo.restoreProductID != 10 || repo.getCategoryIDArg != 3 {
+				t.Fatalf("unexpected restore id/category: %d/%d", repo.restoreProductID, repo.getCategoryIDArg)
+			}
+			wantEntry := &audit.Entry{Actor: audit.System, Action: audit.ActionRestore, EntityType: audit.EntityProduct, EntityID: 10, After: entity.RequestProduct{Name: "Teh", SKU: "TEH-1", Barcode: "8992761166014", Price: 5000, CategoryID: 3}}
+			if !reflect.DeepEqual(repo.entryArg, wantEntry) {
+				t.Fatalf("unexpected audit entry: %+v", repo.entryArg)
 			}
 		})
 	}
@@ -402,7 +940,7 @@ func TestProductService_GetProductByID(t *testing.T) {
 				tt.setupMock(repo)
 			}
 			svc := &productService{productRepository: repo}
-			got, err := svc.GetProductByID(tt.id)
+			got, err := svc.GetProductByID(context.Background(), tt.id)
 
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
@@ -423,27 +961,85 @@ func TestProductService_GetProductByID(t *testing.T) {
 	}
 }
 
+func TestProductService_GetProductByCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		code       string
+		repoErr    error
+		wantCode   string
+		wantErr    string
+		wantCalled bool
+	}{
+		{name: "empty", code: "  ", wantErr: "product code is required"},
+		{name: "ok", code: " 8992761166014 ", wantCode: "8992761166014", wantCalled: true},
+		{name: "err", code: "SKU-1", repoErr: errors.New("product not found"), wantCode: "SKU-1", wantErr: "product not found", wantCalled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			repo := &mockProductRepository{
+				getProductByCodeFn: func(code string) (*entity.ResponseProductWithCategories, error) {
+					called = true
+					if code != tt.wantCode {
+						t.Fatalf("unexpected code: %q", code)
+					}
+					if tt.repoErr != nil {
+						return nil, tt.repoErr
+					}
+					return &entity.ResponseProductWithCategories{ID: 1, Barcode: code}, nil
+				},
+			}
+			svc := &productService{productRepository: repo}
+			got, err := svc.GetProductByCode(context.Background(), tt.code)
+
+			if called != tt.wantCalled {
+				t.Fatalf("repository called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil || got.ID != 1 {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+		})
+	}
+}
+
 func TestProductService_GetAllProducts(t *testing.T) {
+	filter := entity.ProductFilter{CategoryID: 3, Pagination: pagination.Params{Page: 1, PageSize: 2}}
+
 	tests := []struct {
 		name      string
 		setupMock func(m *mockProductRepository)
 		want      []entity.ResponseProductWithCategories
+		wantTotal int
 		wantErr   string
 	}{
 		{
 			name: "ok",
 			setupMock: func(m *mockProductRepository) {
-				m.getAllProductsFn = func() ([]entity.ResponseProductWithCategories, error) {
-					return []entity.ResponseProductWithCategories{{ID: 1}, {ID: 2}}, nil
+				m.getAllProductsFn = func(got entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error) {
+					if got.CategoryID != filter.CategoryID || got.Pagination.PageSize != filter.Pagination.PageSize {
+						t.Fatalf("unexpected filter: %+v", got)
+					}
+					return []entity.ResponseProductWithCategories{{ID: 1}, {ID: 2}}, 5, nil
 				}
 			},
-			want: []entity.ResponseProductWithCategories{{ID: 1}, {ID: 2}},
+			want:      []entity.ResponseProductWithCategories{{ID: 1}, {ID: 2}},
+			wantTotal: 5,
 		},
 		{
 			name: "err",
 			setupMock: func(m *mockProductRepository) {
-				m.getAllProductsFn = func() ([]entity.ResponseProductWithCategories, error) {
-					return nil, errors.New("boom")
+				m.getAllProductsFn = func(entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error) {
+					return nil, 0, errors.New("boom")
 				}
 			},
 			wantErr: "boom",
@@ -457,14 +1053,14 @@ func TestProductService_GetAllProducts(t *testing.T) {
 				tt.setupMock(repo)
 			}
 			svc := &productService{productRepository: repo}
-			got, err := svc.GetAllProducts()
+			got, meta, err := svc.GetAllProducts(context.Background(), filter)
 
 			if tt.wantErr != "" {
 				if err == nil || err.Error() != tt.wantErr {
 					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
 				}
-				if got != nil {
-					t.Fatalf("expected nil result, got %+v", got)
+				if got != nil || meta != nil {
+					t.Fatalf("expected nil result, got %+v %+v", got, meta)
 				}
 				return
 			}
@@ -479,6 +1075,176 @@ func TestProductService_GetAllProducts(t *testing.T) {
 					t.Fatalf("unexpected result: %+v", got)
 				}
 			}
+			if meta == nil || meta.TotalItems != tt.wantTotal || meta.TotalPages != 3 || meta.NextPage == nil || *meta.NextPage != 2 {
+				t.Fatalf("unexpected meta: %+v", meta)
+			}
+		})
+	}
+}
+
+func TestProductService_GetProductsByCategory(t *testing.T) {
+	tests := []struct {
+		name        string
+		categoryErr error
+		wantErr     string
+		wantList    bool
+	}{
+		{name: "ok", wantList: true},
+		{name: "missing-category", categoryErr: apperror.NotFound("category not found"), wantErr: "category not found"},
+		{name: "category-err", categoryErr: errors.New("db"), wantErr: "db"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotCategoryID int64
+				gotFilter     *entity.ProductFilter
+			)
+			repo := &mockProductRepository{
+				getCategoryByIDFn: func(id int64) (*entity.Category, error) {
+					gotCategoryID = id
+					if tt.categoryErr != nil {
+						return nil, tt.categoryErr
+					}
+					return &entity.Category{ID: int(id), Name: "Susu"}, nil
+				},
+				getAllProductsFn: func(filter entity.ProductFilter) ([]entity.ResponseProductWithCategories, int, error) {
+					gotFilter = &filter
+					return []entity.ResponseProductWithCategories{{ID: 1, CategoryID: 4}}, 1, nil
+				},
+			}
+			svc := &productService{productRepository: repo}
+			filter := entity.ProductFilter{CategoryID: 9, InStock: true, Pagination: pagination.Params{Page: 1, PageSize: 20}}
+			got, meta, err := svc.GetProductsByCategory(context.Background(), 4, filter)
+
+			if gotCategoryID != 4 {
+				t.Fatalf("expected category 4 to be checked, got %d", gotCategoryID)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if gotFilter != nil {
+					t.Fatalf("did not expect products to be listed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			want := entity.ProductFilter{CategoryID: 4, InStock: true, Pagination: pagination.Params{Page: 1, PageSize: 20}}
+			if gotFilter == nil || !reflect.DeepEqual(*gotFilter, want) {
+				t.Fatalf("expected filter %+v, got %+v", want, gotFilter)
+			}
+			if len(got) != 1 || meta == nil || meta.TotalItems != 1 {
+				t.Fatalf("unexpected result: %+v %+v", got, meta)
+			}
+		})
+	}
+}
+
+func TestProductService_SearchProducts(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyword     string
+		repoErr     error
+		wantKeyword string
+		wantErr     string
+		wantCalled  bool
+	}{
+		{name: "empty", keyword: "   ", wantErr: "search keyword is required"},
+		{name: "ok", keyword: "  bebe ", wantKeyword: "bebe", wantCalled: true},
+		{name: "err", keyword: "bebe", repoErr: errors.New("boom"), wantKeyword: "bebe", wantErr: "boom", wantCalled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			repo := &mockProductRepository{
+				searchProductsFn: func(keyword string, limit int) ([]entity.ResponseProductWithCategories, error) {
+					called = true
+					if keyword != tt.wantKeyword || limit != 5 {
+						t.Fatalf("unexpected args: %q %d", keyword, limit)
+					}
+					if tt.repoErr != nil {
+						return nil, tt.repoErr
+					}
+					return []entity.ResponseProductWithCategories{{ID: 1, Name: "Bebelac"}}, nil
+				},
+			}
+			svc := &productService{productRepository: repo}
+			got, err := svc.SearchProducts(context.Background(), tt.keyword, 5)
+
+			if called != tt.wantCalled {
+				t.Fatalf("repository called = %v, want %v", called, tt.wantCalled)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != 1 || got[0].Name != "Bebelac" {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+		})
+	}
+}
+
+func TestProductService_GetLowStockProducts(t *testing.T) {
+	tests := []struct {
+		name     string
+		products []entity.ResponseProductWithCategories
+		repoErr  error
+		want     []entity.ResponseLowStockCategory
+		wantErr  string
+	}{
+		{
+			name: "grouped",
+			products: []entity.ResponseProductWithCategories{
+				{ID: 2, Name: "Bebe Biscuit", Stock: 0, ReorderLevel: 5, CategoryID: 8, CategoryName: "Snack"},
+				{ID: 3, Name: "Chitato", Stock: 1, ReorderLevel: 4, CategoryID: 8, CategoryName: "Snack"},
+				{ID: 1, Name: "Bebelac", Stock: 2, ReorderLevel: 2, CategoryID: 7, CategoryName: "Susu"},
+			},
+			want: []entity.ResponseLowStockCategory{
+				{CategoryID: 8, CategoryName: "Snack", Products: []entity.ResponseProductWithCategories{
+					{ID: 2, Name: "Bebe Biscuit", Stock: 0, ReorderLevel: 5, CategoryID: 8, CategoryName: "Snack"},
+					{ID: 3, Name: "Chitato", Stock: 1, ReorderLevel: 4, CategoryID: 8, CategoryName: "Snack"},
+				}},
+				{CategoryID: 7, CategoryName: "Susu", Products: []entity.ResponseProductWithCategories{
+					{ID: 1, Name: "Bebelac", Stock: 2, ReorderLevel: 2, CategoryID: 7, CategoryName: "Susu"},
+				}},
+			},
+		},
+		{name: "empty", want: []entity.ResponseLowStockCategory{}},
+		{name: "err", repoErr: errors.New("boom"), wantErr: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockProductRepository{
+				getLowStockFn: func() ([]entity.ResponseProductWithCategories, error) {
+					return tt.products, tt.repoErr
+				},
+			}
+			svc := &productService{productRepository: repo}
+			got, err := svc.GetLowStockProducts(context.Background())
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected result: %+v", got)
+			}
 		})
 	}
 }
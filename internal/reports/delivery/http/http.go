@@ -0,0 +1,97 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type ReportHandler struct {
+	service service.ReportService
+}
+
+func NewReportHandler(service service.ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of reports API
+// @Description Get health status of reports API
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/reports/health [get]
+func (h *ReportHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API(r.Context())
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// GetSalesReport godoc
+// @Summary Get sales report
+// @Description Get total revenue, items sold and a per-category breakdown between two business days (Asia/Jakarta, inclusive). Both dates default to today; to defaults to from.
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/reports/sales [get]
+func (h *ReportHandler) GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	var (
+		from time.Time
+		to   time.Time
+		err  error
+	)
+
+	query := r.URL.Query()
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err = datetime.ParseDate(fromStr)
+	} else {
+		from, err = datetime.Today()
+	}
+
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidReportDate, err)
+		return
+	}
+
+	to = from
+	if toStr := query.Get("to"); toStr != "" {
+		to, err = datetime.ParseDate(toStr)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidReportDate, err)
+			return
+		}
+	}
+
+	report, err := h.service.GetSalesReport(r.Context(), from, to)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Sales report retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Sales report retrieved successfully", report)
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type mockReportService struct {
+	getSalesReportFn func(from, to time.Time) (*entity.ResponseSalesReport, error)
+	apiFn            func() entity.HealthCheck
+}
+
+func (m *mockReportService) GetSalesReport(ctx context.Context, from, to time.Time) (*entity.ResponseSalesReport, error) {
+	if m.getSalesReportFn == nil {
+		return nil, nil
+	}
+	return m.getSalesReportFn(from, to)
+}
+
+func (m *mockReportService) API(ctx context.Context) entity.HealthCheck {
+	if m.apiFn == nil {
+		return entity.HealthCheck{}
+	}
+	return m.apiFn()
+}
+
+func decodeAPIResponse(t *testing.T, rec *httptest.ResponseRecorder) response.APIResponse {
+	t.Helper()
+	var resp response.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestNewReportHandler(t *testing.T) {
+	svc := &mockReportService{}
+	h := NewReportHandler(svc)
+	if h == nil {
+		t.Fatalf("handler is nil")
+	}
+	if h.service != svc {
+		t.Fatalf("service mismatch")
+	}
+}
+
+func TestReportHandlerAPI(t *testing.T) {
+	cases := []struct {
+		name       string
+		health     entity.HealthCheck
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "healthy", health: entity.HealthCheck{Name: "reports", IsHealthy: true}, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "reports is healthy"},
+		{name: "unhealthy", health: entity.HealthCheck{Name: "reports"}, wantStatus: http.StatusServiceUnavailable, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "reports is not healthy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewReportHandler(&mockReportService{
+				apiFn: func() entity.HealthCheck { return tc.health },
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/reports/health", nil)
+			h.API(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			if resp.Message != tc.wantMsg {
+				t.Fatalf("message = %v, want %q", resp.Message, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestReportHandlerGetSalesReport(t *testing.T) {
+	today, err := datetime.Today()
+	if err != nil {
+		t.Fatalf("today: %v", err)
+	}
+	jan1, _ := datetime.ParseDate("2024-01-01")
+	jan31, _ := datetime.ParseDate("2024-01-31")
+
+	cases := []struct {
+		name       string
+		query      string
+		svcErr     error
+		wantFrom   time.Time
+		wantTo     time.Time
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+	}{
+		{name: "bad-from", query: "?from=01-01-2024", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidReportDate, wantPrefix: true},
+		{name: "bad-to", query: "?from=2024-01-01&to=tomorrow", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidReportDate, wantPrefix: true},
+		{name: "svc-error", query: "?from=2024-01-01&to=2024-01-31", svcErr: errors.New("db"), wantFrom: jan1, wantTo: jan31, wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Sales report retrieved failed: db", wantCalled: true},
+		{name: "range", query: "?from=2024-01-01&to=2024-01-31", wantFrom: jan1, wantTo: jan31, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Sales report retrieved successfully", wantCalled: true},
+		{name: "single-day", query: "?from=2024-01-01", wantFrom: jan1, wantTo: jan1, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Sales report retrieved successfully", wantCalled: true},
+		{name: "today", wantFrom: today, wantTo: today, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Sales report retrieved successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewReportHandler(&mockReportService{
+				getSalesReportFn: func(from, to time.Time) (*entity.ResponseSalesReport, error) {
+					called = true
+					if !from.Equal(tc.wantFrom) || !to.Equal(tc.wantTo) {
+						t.Fatalf("range = %v - %v, want %v - %v", from, to, tc.wantFrom, tc.wantTo)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return &entity.ResponseSalesReport{TotalRevenue: 1000}, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/reports/sales"+tc.query, nil)
+
+			h.GetSalesReport(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+		})
+	}
+}
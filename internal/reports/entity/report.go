@@ -0,0 +1,40 @@
+package entity
+
+type SalesSummary struct {
+	TotalRevenue      int
+	ItemsSold         int
+	TotalTransactions int
+}
+
+type CategorySales struct {
+	CategoryID   int64
+	CategoryName string
+	Revenue      int
+	ItemsSold    int
+}
+
+type SalesReport struct {
+	Summary    SalesSummary
+	Categories []CategorySales
+}
+
+type ResponseCategorySales struct {
+	CategoryID   int64  `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Revenue      int    `json:"revenue"`
+	ItemsSold    int    `json:"items_sold"`
+}
+
+type ResponseSalesReport struct {
+	From              string                  `json:"from"`
+	To                string                  `json:"to"`
+	TotalRevenue      int                     `json:"total_revenue"`
+	ItemsSold         int                     `json:"items_sold"`
+	TotalTransactions int                     `json:"total_transactions"`
+	Categories        []ResponseCategorySales `json:"categories"`
+}
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+)
+
+type ReportRepository interface {
+	GetSalesReport(ctx context.Context, start, end time.Time) (*entity.SalesReport, error)
+}
+
+type reportRepository struct {
+	db *database.DB
+}
+
+func NewReportRepository(db *database.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// GetSalesReport aggregates the transaction lines created in [start, end). The boundaries are passed as zoned
+// timestamps so the caller decides which business day they belong to.
+func (r *reportRepository) GetSalesReport(ctx context.Context, start, end time.Time) (*entity.SalesReport, error) {
+	var (
+		summaryQuery  string
+		categoryQuery string
+		report        entity.SalesReport
+		err           error
+	)
+
+	summaryQuery = "SELECT COALESCE(SUM(transaction_details.subtotal), 0) as total_revenue, COALESCE(SUM(transaction_details.quantity), 0) as items_sold, COUNT(DISTINCT transactions.id) as total_transactions FROM transactions JOIN transaction_details ON transaction_details.transaction_id = transactions.id WHERE transactions.created_at >= $1 AND transactions.created_at < $2"
+	categoryQuery = "SELECT categories.id as category_id, categories.name as category_name, SUM(transaction_details.subtotal) as revenue, SUM(transaction_details.quantity) as items_sold FROM transactions JOIN transaction_details ON transaction_details.transaction_id = transactions.id JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transactions.created_at >= $1 AND transactions.created_at < $2 GROUP BY categories.id, categories.name ORDER BY revenue DESC, categories.id"
+
+	err = r.db.WithStmtContext(ctx, summaryQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&report.Summary.TotalRevenue, &report.Summary.ItemsSold, &report.Summary.TotalTransactions)
+		}, start, end)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.WithStmtContext(ctx, categoryQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var category entity.CategorySales
+			if err := rows.Scan(&category.CategoryID, &category.CategoryName, &category.Revenue, &category.ItemsSold); err != nil {
+				return err
+			}
+
+			report.Categories = append(report.Categories, category)
+			return nil
+		}, start, end)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+)
+
+type testQuery struct {
+	columns  []string
+	rows     [][]driver.Value
+	queryErr error
+}
+
+type testConfig struct {
+	prepareErr map[string]error
+	query      map[string]testQuery
+
+	mu        sync.Mutex
+	queryArgs map[string][]driver.Value
+}
+
+func (c *testConfig) getPrepareErr(query string) error {
+	if c.prepareErr == nil {
+		return nil
+	}
+	return c.prepareErr[query]
+}
+
+func (c *testConfig) getQuery(query string) testQuery {
+	if c.query == nil {
+		return testQuery{}
+	}
+	return c.query[query]
+}
+
+func (c *testConfig) recordQuery(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queryArgs == nil {
+		c.queryArgs = make(map[string][]driver.Value)
+	}
+	c.queryArgs[query] = append([]driver.Value(nil), args...)
+}
+
+func (c *testConfig) getQueryArgs(query string) []driver.Value {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.queryArgs[query]
+}
+
+type testDriver struct {
+	cfg *testConfig
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{cfg: d.cfg}, nil
+}
+
+type testConn struct {
+	cfg *testConfig
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	if err := c.cfg.getPrepareErr(query); err != nil {
+		return nil, err
+	}
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+
+func (c *testConn) Close() error { return nil }
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(0), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.cfg.recordQuery(s.query, args)
+	q := s.cfg.getQuery(s.query)
+	if q.queryErr != nil {
+		return nil, q.queryErr
+	}
+	return &testRows{columns: q.columns, values: q.rows}, nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+	idx     int
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	row := r.values[r.idx]
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = row[i]
+		}
+	}
+	r.idx++
+	return nil
+}
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("report_repo_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+const (
+	summaryQuery  = "SELECT COALESCE(SUM(transaction_details.subtotal), 0) as total_revenue, COALESCE(SUM(transaction_details.quantity), 0) as items_sold, COUNT(DISTINCT transactions.id) as total_transactions FROM transactions JOIN transaction_details ON transaction_details.transaction_id = transactions.id WHERE transactions.created_at >= $1 AND transactions.created_at < $2"
+	categoryQuery = "SELECT categories.id as category_id, categories.name as category_name, SUM(transaction_details.subtotal) as revenue, SUM(transaction_details.quantity) as items_sold FROM transactions JOIN transaction_details ON transaction_details.transaction_id = transactions.id JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transactions.created_at >= $1 AND transactions.created_at < $2 GROUP BY categories.id, categories.name ORDER BY revenue DESC, categories.id"
+)
+
+func TestNewReportRepository(t *testing.T) {
+	db := newTestDB(t, &testConfig{})
+	repo := NewReportRepository(db)
+	if repo == nil {
+		t.Fatalf("expected repository")
+	}
+	r, ok := repo.(*reportRepository)
+	if !ok {
+		t.Fatalf("expected reportRepository")
+	}
+	if r.db != db {
+		t.Fatalf("expected db to match")
+	}
+}
+
+func TestReportRepositoryGetSalesReport(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	end := time.Date(2024, 1, 2, 0, 0, 0, 0, loc)
+	errQuery := errors.New("query")
+
+	okQueries := map[string]testQuery{
+		summaryQuery: {
+			columns: []string{"total_revenue", "items_sold", "total_transactions"},
+			rows:    [][]driver.Value{{int64(75000), int64(9), int64(3)}},
+		},
+		categoryQuery: {
+			columns: []string{"category_id", "category_name", "revenue", "items_sold"},
+			rows: [][]driver.Value{
+				{int64(2), "Susu", int64(50000), int64(5)},
+				{int64(1), "Snack", int64(25000), int64(4)},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		want    *entity.SalesReport
+		wantErr string
+	}{
+		{
+			name: "ok",
+			cfg:  &testConfig{query: okQueries},
+			want: &entity.SalesReport{
+				Summary: entity.SalesSummary{TotalRevenue: 75000, ItemsSold: 9, TotalTransactions: 3},
+				Categories: []entity.CategorySales{
+					{CategoryID: 2, CategoryName: "Susu", Revenue: 50000, ItemsSold: 5},
+					{CategoryID: 1, CategoryName: "Snack", Revenue: 25000, ItemsSold: 4},
+				},
+			},
+		},
+		{
+			name: "empty",
+			cfg: &testConfig{query: map[string]testQuery{
+				summaryQuery: {
+					columns: []string{"total_revenue", "items_sold", "total_transactions"},
+					rows:    [][]driver.Value{{int64(0), int64(0), int64(0)}},
+				},
+			}},
+			want: &entity.SalesReport{},
+		},
+		{
+			name:    "summary-error",
+			cfg:     &testConfig{query: map[string]testQuery{summaryQuery: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
+		{
+			name: "category-error",
+			cfg: &testConfig{query: map[string]testQuery{
+				summaryQuery:  okQueries[summaryQuery],
+				categoryQuery: {queryErr: errQuery},
+			}},
+			wantErr: errQuery.Error(),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewReportRepository(newTestDB(t, tc.cfg))
+			got, err := repo.GetSalesReport(context.Background(), start, end)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("unexpected report: %+v", got)
+			}
+
+			for _, query := range []string{summaryQuery, categoryQuery} {
+				args := tc.cfg.getQueryArgs(query)
+				if len(args) != 2 {
+					t.Fatalf("expected 2 args, got %v", args)
+				}
+				gotStart, _ := args[0].(time.Time)
+				gotEnd, _ := args[1].(time.Time)
+				if !gotStart.Equal(start) || !gotEnd.Equal(end) {
+					t.Fatalf("unexpected boundaries: %v - %v", gotStart, gotEnd)
+				}
+			}
+		})
+	}
+}
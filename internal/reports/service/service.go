@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+)
+
+type reportService struct {
+	reportRepository repository.ReportRepository
+	ready            func(ctx context.Context) error
+}
+
+type ReportService interface {
+	GetSalesReport(ctx context.Context, from, to time.Time) (*entity.ResponseSalesReport, error)
+	API(ctx context.Context) entity.HealthCheck
+}
+
+// NewReportService returns a ReportService. ready runs the readiness checks whose result API reports.
+func NewReportService(reportRepository repository.ReportRepository, ready func(ctx context.Context) error) ReportService {
+	return &reportService{reportRepository: reportRepository, ready: ready}
+}
+
+// API reports the Reports API healthy when the readiness checks of its dependencies pass.
+func (s *reportService) API(ctx context.Context) entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Reports API",
+		IsHealthy: s.ready(ctx) == nil,
+	}
+}
+
+// GetSalesReport reports the sales from the start of the from day up to the end of the to day, both inclusive.
+func (s *reportService) GetSalesReport(ctx context.Context, from, to time.Time) (*entity.ResponseSalesReport, error) {
+	if to.Before(from) {
+		return nil, apperror.Validation("from date must not be after to date")
+	}
+
+	report, err := s.reportRepository.GetSalesReport(ctx, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+
+	respCategories := make([]entity.ResponseCategorySales, 0, len(report.Categories))
+	for _, category := range report.Categories {
+		respCategories = append(respCategories, entity.ResponseCategorySales{
+			CategoryID:   category.CategoryID,
+			CategoryName: category.CategoryName,
+			Revenue:      category.Revenue,
+			ItemsSold:    category.ItemsSold,
+		})
+	}
+
+	return &entity.ResponseSalesReport{
+		From:              from.Format(datetime.DateLayout),
+		To:                to.Format(datetime.DateLayout),
+		TotalRevenue:      report.Summary.TotalRevenue,
+		ItemsSold:         report.Summary.ItemsSold,
+		TotalTransactions: report.Summary.TotalTransactions,
+		Categories:        respCategories,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/reports/repository"
+)
+
+type mockReportRepository struct {
+	getSalesReportFn func(start, end time.Time) (*entity.SalesReport, error)
+
+	start time.Time
+	end   time.Time
+	calls int
+}
+
+func (m *mockReportRepository) GetSalesReport(ctx context.Context, start, end time.Time) (*entity.SalesReport, error) {
+	m.calls++
+	m.start = start
+	m.end = end
+	if m.getSalesReportFn == nil {
+		return &entity.SalesReport{}, nil
+	}
+	return m.getSalesReportFn(start, end)
+}
+
+var _ repository.ReportRepository = (*mockReportRepository)(nil)
+
+func TestNewReportService(t *testing.T) {
+	repo := &mockReportRepository{}
+	svc := NewReportService(repo, func(context.Context) error { return nil })
+	rs, ok := svc.(*reportService)
+	if !ok {
+		t.Fatalf("expected *reportService, got %T", svc)
+	}
+	if rs.reportRepository != repo || rs.ready == nil {
+		t.Fatal("repository not set")
+	}
+}
+
+func TestReportService_API(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &reportService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Reports API" || got.IsHealthy != tt.want {
+				t.Fatalf("unexpected healthcheck: %+v", got)
+			}
+		})
+	}
+}
+
+func TestReportService_GetSalesReport(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, loc)
+	jan3 := time.Date(2024, 1, 3, 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name      string
+		from      time.Time
+		to        time.Time
+		report    *entity.SalesReport
+		repoErr   error
+		want      *entity.ResponseSalesReport
+		wantErr   string
+		wantEnd   time.Time
+		wantCalls int
+	}{
+		{
+			name:    "reversed",
+			from:    jan3,
+			to:      jan1,
+			wantErr: "from date must not be after to date",
+		},
+		{
+			name:      "repo-err",
+			from:      jan1,
+			to:        jan1,
+			repoErr:   errors.New("boom"),
+			wantErr:   "boom",
+			wantEnd:   time.Date(2024, 1, 2, 0, 0, 0, 0, loc),
+			wantCalls: 1,
+		},
+		{
+			name: "ok",
+			from: jan1,
+			to:   jan3,
+			report: &entity.SalesReport{
+				Summary:    entity.SalesSummary{TotalRevenue: 30000, ItemsSold: 3, TotalTransactions: 2},
+				Categories: []entity.CategorySales{{CategoryID: 1, CategoryName: "Susu", Revenue: 30000, ItemsSold: 3}},
+			},
+			want: &entity.ResponseSalesReport{
+				From:              "2024-01-01",
+				To:                "2024-01-03",
+				TotalRevenue:      30000,
+				ItemsSold:         3,
+				TotalTransactions: 2,
+				Categories:        []entity.ResponseCategorySales{{CategoryID: 1, CategoryName: "Susu", Revenue: 30000, ItemsSold: 3}},
+			},
+			wantEnd:   time.Date(2024, 1, 4, 0, 0, 0, 0, loc),
+			wantCalls: 1,
+		},
+		{
+			name:   "no-sales",
+			from:   jan1,
+			to:     jan1,
+			report: &entity.SalesReport{},
+			want: &entity.ResponseSalesReport{
+				From:       "2024-01-01",
+				To:         "2024-01-01",
+				Categories: []entity.ResponseCategorySales{},
+			},
+			wantEnd:   time.Date(2024, 1, 2, 0, 0, 0, 0, loc),
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockReportRepository{
+				getSalesReportFn: func(start, end time.Time) (*entity.SalesReport, error) {
+					return tt.report, tt.repoErr
+				},
+			}
+			svc := &reportService{reportRepository: repo}
+			got, err := svc.GetSalesReport(context.Background(), tt.from, tt.to)
+
+			if repo.calls != tt.wantCalls {
+				t.Fatalf("repository calls = %d, want %d", repo.calls, tt.wantCalls)
+			}
+			if tt.wantCalls > 0 && (!repo.start.Equal(tt.from) || !repo.end.Equal(tt.wantEnd)) {
+				t.Fatalf("range = [%v, %v), want [%v, %v)", repo.start, repo.end, tt.from, tt.wantEnd)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+		})
+	}
+}
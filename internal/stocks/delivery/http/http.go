@@ -0,0 +1,136 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type StockHandler struct {
+	service service.StockService
+}
+
+func NewStockHandler(service service.StockService) *StockHandler {
+	return &StockHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of stocks API
+// @Description Get health status of stocks API
+// @Tags stocks
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/stocks/health [get]
+func (h *StockHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API(r.Context())
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// AdjustStock godoc
+// @Summary Adjust the stock of a product
+// @Description Record a restock, adjustment, return or write-off and apply its signed quantity to the product stock
+// @Tags stocks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param adjustment body entity.RequestStockAdjustment true "Stock Adjustment Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/stock-adjustments [post]
+func (h *StockHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var requestAdjustment entity.RequestStockAdjustment
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/products/"), "/stock-adjustments")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	if err := response.ParseJSON(r, &requestAdjustment); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidStockAdjustmentRequest, err)
+		return
+	}
+
+	movement, err := h.service.AdjustStock(r.Context(), int64(id), &requestAdjustment)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Stock adjusted failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Stock adjusted successfully", movement)
+}
+
+// GetStockMovements godoc
+// @Summary Get the stock movements of a product
+// @Description Get a page of the stock ledger of a product, newest first
+// @Tags stocks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param type query string false "Movement type (sale, restock, adjustment, return, write_off)"
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/products/{id}/stock-movements [get]
+func (h *StockHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	var filter entity.StockMovementFilter
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/products/"), "/stock-movements")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidProductID, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter.Type = strings.TrimSpace(query.Get("type"))
+	if filter.Type != "" && !slices.Contains(entity.MovementTypes, filter.Type) {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidStockMovementFilter, fmt.Errorf("invalid movement type: %s", filter.Type))
+		return
+	}
+
+	filter.Pagination, err = pagination.Parse(query)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidStockMovementFilter, err)
+		return
+	}
+
+	movements, meta, err := h.service.GetStockMovements(r.Context(), int64(id), filter)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Stock movements retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Stock movements retrieved successfully", movements, meta)
+}
@@ -0,0 +1,246 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type mockStockService struct {
+	adjustFn       func(int64, *entity.RequestStockAdjustment) (*entity.ResponseStockMovement, error)
+	getMovementsFn func(int64, entity.StockMovementFilter) ([]entity.ResponseStockMovement, *pagination.Meta, error)
+	apiFn          func() entity.HealthCheck
+}
+
+func (m *mockStockService) AdjustStock(ctx context.Context, productID int64, request *entity.RequestStockAdjustment) (*entity.ResponseStockMovement, error) {
+	if m.adjustFn == nil {
+		return nil, nil
+	}
+	return m.adjustFn(productID, request)
+}
+
+func (m *mockStockService) GetStockMovements(ctx context.Context, productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, *pagination.Meta, error) {
+	if m.getMovementsFn == nil {
+		return nil, nil, nil
+	}
+	return m.getMovementsFn(productID, filter)
+}
+
+func (m *mockStockService) API(ctx context.Context) entity.HealthCheck {
+	if m.apiFn == nil {
+		return entity.HealthCheck{}
+	}
+	return m.apiFn()
+}
+
+func decodeAPIResponse(t *testing.T, rec *httptest.ResponseRecorder) response.APIResponse {
+	t.Helper()
+	var resp response.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestNewStockHandler(t *testing.T) {
+	svc := &mockStockService{}
+	h := NewStockHandler(svc)
+	if h == nil {
+		t.Fatalf("handler is nil")
+	}
+	if h.service != svc {
+		t.Fatalf("service mismatch")
+	}
+}
+
+func TestStockHandlerAPI(t *testing.T) {
+	cases := []struct {
+		name       string
+		health     entity.HealthCheck
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "healthy", health: entity.HealthCheck{Name: "stocks", IsHealthy: true}, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "stocks is healthy"},
+		{name: "unhealthy", health: entity.HealthCheck{Name: "stocks"}, wantStatus: http.StatusServiceUnavailable, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "stocks is not healthy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewStockHandler(&mockStockService{
+				apiFn: func() entity.HealthCheck { return tc.health },
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/stocks/health", nil)
+			h.API(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			if resp.Message != tc.wantMsg {
+				t.Fatalf("message = %v, want %q", resp.Message, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestStockHandlerAdjustStock(t *testing.T) {
+	validBody := `{"type":"restock","quantity":12,"reason":"supplier delivery"}`
+	validReq := entity.RequestStockAdjustment{Type: "restock", Quantity: 12, Reason: "supplier delivery"}
+	movement := &entity.ResponseStockMovement{ID: 3, ProductID: 7, Type: "restock", Quantity: 12, StockAfter: 20}
+
+	cases := []struct {
+		name       string
+		path       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+	}{
+		{name: "bad-id", path: "/products/abc/stock-adjustments", body: validBody, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true},
+		{name: "bad-json", path: "/products/7/stock-adjustments", body: `{"type":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidStockAdjustmentRequest, wantPrefix: true},
+		{name: "svc-error", path: "/products/7/stock-adjustments", body: validBody, svcErr: errors.New("product not found"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Stock adjusted failed: product not found", wantCalled: true},
+		{name: "ok", path: "/products/7/stock-adjustments", body: validBody, wantStatus: http.StatusCreated, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Stock adjusted successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewStockHandler(&mockStockService{
+				adjustFn: func(productID int64, req *entity.RequestStockAdjustment) (*entity.ResponseStockMovement, error) {
+					called = true
+					if productID != 7 || !reflect.DeepEqual(*req, validReq) {
+						t.Fatalf("args = %d %+v, want 7 %+v", productID, *req, validReq)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return movement, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+
+			h.AdjustStock(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.(map[string]any)
+				if !ok {
+					t.Fatalf("data type = %T, want map", resp.Data)
+				}
+				if data["stock_after"] != float64(movement.StockAfter) || data["type"] != movement.Type {
+					t.Fatalf("unexpected data: %v", data)
+				}
+			}
+		})
+	}
+}
+
+func TestStockHandlerGetStockMovements(t *testing.T) {
+	movements := []entity.ResponseStockMovement{{ID: 2, ProductID: 7, Type: "sale", Quantity: -2, StockAfter: 8, ReferenceID: 5}}
+	meta := &pagination.Meta{Page: 1, PageSize: 20, TotalItems: 1, TotalPages: 1}
+
+	cases := []struct {
+		name       string
+		path       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+		wantFilter entity.StockMovementFilter
+	}{
+		{name: "bad-id", path: "/products/abc/stock-movements", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidProductID, wantPrefix: true},
+		{name: "bad-type", path: "/products/7/stock-movements?type=gift", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidStockMovementFilter + ": invalid movement type: gift"},
+		{name: "bad-page", path: "/products/7/stock-movements?page=0", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidStockMovementFilter, wantPrefix: true},
+		{name: "svc-error", path: "/products/7/stock-movements", svcErr: errors.New("product not found"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Stock movements retrieved failed: product not found", wantCalled: true, wantFilter: entity.StockMovementFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}}},
+		{name: "ok", path: "/products/7/stock-movements?type=sale&page_size=5", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Stock movements retrieved successfully", wantCalled: true, wantFilter: entity.StockMovementFilter{Type: "sale", Pagination: pagination.Params{Page: 1, PageSize: 5}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewStockHandler(&mockStockService{
+				getMovementsFn: func(productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, *pagination.Meta, error) {
+					called = true
+					if productID != 7 || !reflect.DeepEqual(filter, tc.wantFilter) {
+						t.Fatalf("args = %d %+v, want 7 %+v", productID, filter, tc.wantFilter)
+					}
+					if tc.svcErr != nil {
+						return nil, nil, tc.svcErr
+					}
+					return movements, meta, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+
+			h.GetStockMovements(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.([]any)
+				if !ok || len(data) != len(movements) {
+					t.Fatalf("unexpected data: %v", resp.Data)
+				}
+				if resp.Meta == nil {
+					t.Fatalf("expected meta")
+				}
+			}
+		})
+	}
+}
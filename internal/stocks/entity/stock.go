@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+// Movement types recorded in the stock ledger. Sales are only written by checkout, the others come from manual
+// stock adjustments.
+const (
+	MovementSale       = "sale"
+	MovementRestock    = "restock"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementWriteOff   = "write_off"
+)
+
+// MovementTypes lists every movement type, used to validate the type filter of the movement list.
+var MovementTypes = []string{MovementSale, MovementRestock, MovementAdjustment, MovementReturn, MovementWriteOff}
+
+type StockMovement struct {
+	ID          int64  `json:"id"`
+	ProductID   int64  `json:"product_id"`
+	Type        string `json:"type"`
+	Quantity    int    `json:"quantity"`
+	StockAfter  int    `json:"stock_after"`
+	Reason      string `json:"reason"`
+	ReferenceID int64  `json:"reference_id"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// RequestStockAdjustment is a manual stock change. Quantity is the signed change: positive for restocks and
+// returns, negative for write-offs and either for adjustments.
+type RequestStockAdjustment struct {
+	Type     string `json:"type"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+type ResponseStockMovement struct {
+	ID          int64     `json:"id"`
+	ProductID   int64     `json:"product_id"`
+	Type        string    `json:"type"`
+	Quantity    int       `json:"quantity"`
+	StockAfter  int       `json:"stock_after"`
+	Reason      string    `json:"reason"`
+	ReferenceID int64     `json:"reference_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StockMovementFilter narrows and pages the movements of a product. An empty Type means every type.
+type StockMovementFilter struct {
+	Type       string
+	Pagination pagination.Params
+}
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+)
+
+type StockRepository interface {
+	AdjustStock(ctx context.Context, movement *entity.StockMovement) (*entity.ResponseStockMovement, *alert.LowStock, error)
+	GetStockMovements(ctx context.Context, productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, int, error)
+	GetProductStock(ctx context.Context, productID int64) (int, error)
+}
+
+type stockRepository struct {
+	db *database.DB
+}
+
+func NewStockRepository(db *database.DB) StockRepository {
+	return &stockRepository{db: db}
+}
+
+// AdjustStock locks the product, applies the signed quantity of the movement to its stock and records the movement
+// with the resulting balance. Both writes share one database transaction, so products.stock is always the
+// stock_after of the product's latest movement. A low stock event is returned when the movement takes the product to
+// or below its reorder level.
+func (r *stockRepository) AdjustStock(ctx context.Context, movement *entity.StockMovement) (*entity.ResponseStockMovement, *alert.LowStock, error) {
+	var (
+		lockQuery    string
+		updateQuery  string
+		insertQuery  string
+		productID    int64
+		name         string
+		stock        int
+		reorderLevel int
+		lowStock     *alert.LowStock
+		err          error
+	)
+
+	lockQuery = "SELECT id, name, stock, reorder_level FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	updateQuery = "UPDATE products SET stock = $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	insertQuery = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, lockQuery, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&productID, &name, &stock, &reorderLevel)
+			}, movement.ProductID)
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if productID == 0 {
+			return apperror.NotFound("product not found")
+		}
+
+		if stock+movement.Quantity < 0 {
+			return apperror.Conflict("insufficient stock for product %d: change %d, available %d", productID, movement.Quantity, stock)
+		}
+
+		movement.StockAfter = stock + movement.Quantity
+
+		err = tx.WithStmtContext(ctx, updateQuery, func(stmt *database.Stmt) error {
+			_, err = stmt.ExecContext(ctx, movement.StockAfter, "now()", productID)
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithStmtContext(ctx, insertQuery, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&movement.ID, &movement.CreatedAt)
+			}, movement.ProductID, movement.Type, movement.Quantity, movement.StockAfter, movement.Reason, "now()")
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if alert.Crossed(stock, movement.StockAfter, reorderLevel) {
+		lowStock = &alert.LowStock{
+			ProductID:    productID,
+			ProductName:  name,
+			Stock:        movement.StockAfter,
+			ReorderLevel: reorderLevel,
+			Source:       alert.SourceAdjustment,
+		}
+	}
+
+	return toResponseStockMovement(*movement), lowStock, nil
+}
+
+func (r *stockRepository) GetStockMovements(ctx context.Context, productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, int, error) {
+	var (
+		query      string
+		countQuery string
+		where      string
+		args       []interface{}
+		total      int
+		movements  []entity.ResponseStockMovement
+		err        error
+	)
+
+	where = " WHERE product_id = $1"
+	args = append(args, productID)
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		where += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+
+	countQuery = "SELECT COUNT(*) FROM stock_movements" + where
+	query = fmt.Sprintf("SELECT id, product_id, type, quantity, stock_after, reason, COALESCE(reference_id, 0) as reference_id, created_at FROM stock_movements%s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", where, len(args)+1, len(args)+2)
+
+	err = r.db.WithStmtContext(ctx, countQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&total)
+		}, args...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var movement entity.StockMovement
+			if err := rows.Scan(&movement.ID, &movement.ProductID, &movement.Type, &movement.Quantity, &movement.StockAfter, &movement.Reason, &movement.ReferenceID, &movement.CreatedAt); err != nil {
+				return err
+			}
+
+			movements = append(movements, *toResponseStockMovement(movement))
+			return nil
+		}, append(args, filter.Pagination.Limit(), filter.Pagination.Offset())...)
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return movements, total, nil
+}
+
+func (r *stockRepository) GetProductStock(ctx context.Context, productID int64) (int, error) {
+	var (
+		query string
+		id    int64
+		stock int
+		err   error
+	)
+
+	query = "SELECT id, stock FROM products WHERE id = $1"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&id, &stock)
+		}, productID)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	if id == 0 {
+		return 0, apperror.NotFound("product not found")
+	}
+
+	return stock, nil
+}
+
+func toResponseStockMovement(movement entity.StockMovement) *entity.ResponseStockMovement {
+	createdAt, _ := datetime.ParseTime(movement.CreatedAt)
+
+	return &entity.ResponseStockMovement{
+		ID:          movement.ID,
+		ProductID:   movement.ProductID,
+		Type:        movement.Type,
+		Quantity:    movement.Quantity,
+		StockAfter:  movement.StockAfter,
+		Reason:      movement.Reason,
+		ReferenceID: movement.ReferenceID,
+		CreatedAt:   createdAt,
+	}
+}
@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type testQuery struct {
+	columns  []string
+	rows     [][]driver.Value
+	queryErr error
+}
+
+type testConfig struct {
+	execErr   map[string]error
+	query     map[string]testQuery
+	commitErr error
+
+	mu        sync.Mutex
+	queryArgs map[string][]driver.Value
+	execArgs  map[string][]driver.Value
+	rolled    bool
+}
+
+func (c *testConfig) getExecErr(query string) error {
+	if c.execErr == nil {
+		return nil
+	}
+	return c.execErr[query]
+}
+
+func (c *testConfig) getQuery(query string) testQuery {
+	if c.query == nil {
+		return testQuery{}
+	}
+	return c.query[query]
+}
+
+func (c *testConfig) recordQuery(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queryArgs == nil {
+		c.queryArgs = make(map[string][]driver.Value)
+	}
+	c.queryArgs[query] = append([]driver.Value(nil), args...)
+}
+
+func (c *testConfig) recordExec(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.execArgs == nil {
+		c.execArgs = make(map[string][]driver.Value)
+	}
+	c.execArgs[query] = append([]driver.Value(nil), args...)
+}
+
+type testDriver struct {
+	cfg *testConfig
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{cfg: d.cfg}, nil
+}
+
+type testConn struct {
+	cfg *testConfig
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+
+func (c *testConn) Close() error { return nil }
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return &testTx{cfg: c.cfg}, nil
+}
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.cfg.getExecErr(s.query); err != nil {
+		return nil, err
+	}
+	s.cfg.recordExec(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.cfg.recordQuery(s.query, args)
+	q := s.cfg.getQuery(s.query)
+	if q.queryErr != nil {
+		return nil, q.queryErr
+	}
+	return &testRows{columns: q.columns, values: q.rows}, nil
+}
+
+type testTx struct {
+	cfg *testConfig
+}
+
+func (t *testTx) Commit() error {
+	return t.cfg.commitErr
+}
+
+func (t *testTx) Rollback() error {
+	t.cfg.mu.Lock()
+	defer t.cfg.mu.Unlock()
+	t.cfg.rolled = true
+	return nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+	idx     int
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	row := r.values[r.idx]
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = row[i]
+		}
+	}
+	r.idx++
+	return nil
+}
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("stock_repo_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+const (
+	lockQuery      = "SELECT id, name, stock, reorder_level FROM products WHERE id = $1 AND deleted_at IS NULL FOR UPDATE"
+	updateQuery    = "UPDATE products SET stock = $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	insertQuery    = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at"
+	stockQuery     = "SELECT id, stock FROM products WHERE id = $1"
+	countQuery     = "SELECT COUNT(*) FROM stock_movements WHERE product_id = $1"
+	listQuery      = "SELECT id, product_id, type, quantity, stock_after, reason, COALESCE(reference_id, 0) as reference_id, created_at FROM stock_movements WHERE product_id = $1 ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3"
+	typeCountQuery = "SELECT COUNT(*) FROM stock_movements WHERE product_id = $1 AND type = $2"
+	typeListQuery  = "SELECT id, product_id, type, quantity, stock_after, reason, COALESCE(reference_id, 0) as reference_id, created_at FROM stock_movements WHERE product_id = $1 AND type = $2 ORDER BY created_at DESC, id DESC LIMIT $3 OFFSET $4"
+)
+
+var movementColumns = []string{"id", "product_id", "type", "quantity", "stock_after", "reason", "reference_id", "created_at"}
+
+func TestNewStockRepository(t *testing.T) {
+	db := newTestDB(t, &testConfig{})
+	repo := NewStockRepository(db)
+	if repo == nil {
+		t.Fatalf("expected repository")
+	}
+	r, ok := repo.(*stockRepository)
+	if !ok {
+		t.Fatalf("expected stockRepository")
+	}
+	if r.db != db {
+		t.Fatalf("expected db to match")
+	}
+}
+
+func TestStockRepositoryAdjustStock(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	createdAt, _ := time.Parse(time.RFC3339, "2023-01-02T03:04:05Z")
+	errExec := errors.New("exec")
+	errCommit := errors.New("commit")
+
+	adjustQueries := func(stock, reorderLevel int64) map[string]testQuery {
+		return map[string]testQuery{
+			lockQuery:   {columns: []string{"id", "name", "stock", "reorder_level"}, rows: [][]driver.Value{{int64(7), "Milk", stock, reorderLevel}}},
+			insertQuery: {columns: []string{"id", "created_at"}, rows: [][]driver.Value{{int64(12), "2023-01-02T03:04:05Z"}}},
+		}
+	}
+
+	tests := []struct {
+		name         string
+		quantity     int
+		cfg          *testConfig
+		wantErr      string
+		wantRollback bool
+		wantLowStock *alert.LowStock
+	}{
+		{name: "ok", quantity: -3, cfg: &testConfig{query: adjustQueries(10, 5)}},
+		{name: "low-stock", quantity: -3, cfg: &testConfig{query: adjustQueries(10, 7)}, wantLowStock: &alert.LowStock{ProductID: 7, ProductName: "Milk", Stock: 7, ReorderLevel: 7, Source: alert.SourceAdjustment}},
+		{name: "already-low", quantity: -3, cfg: &testConfig{query: adjustQueries(10, 10)}},
+		{name: "missing", quantity: 5, cfg: &testConfig{}, wantErr: "product not found", wantRollback: true},
+		{name: "insufficient", quantity: -11, cfg: &testConfig{query: adjustQueries(10, 5)}, wantErr: "insufficient stock for product 7: change -11, available 10", wantRollback: true},
+		{name: "update", quantity: -3, cfg: &testConfig{query: adjustQueries(10, 5), execErr: map[string]error{updateQuery: errExec}}, wantErr: errExec.Error(), wantRollback: true},
+		{name: "commit", quantity: -3, cfg: &testConfig{query: adjustQueries(10, 5), commitErr: errCommit}, wantErr: errCommit.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewStockRepository(db)
+			got, lowStock, err := repo.AdjustStock(context.Background(), &entity.StockMovement{ProductID: 7, Type: entity.MovementWriteOff, Quantity: tt.quantity, Reason: "expired"})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if lowStock != nil {
+					t.Fatalf("expected no low stock event, got %+v", lowStock)
+				}
+				if tt.wantRollback && !tt.cfg.rolled {
+					t.Fatalf("expected rollback")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			want := &entity.ResponseStockMovement{ID: 12, ProductID: 7, Type: entity.MovementWriteOff, Quantity: -3, StockAfter: 7, Reason: "expired", CreatedAt: createdAt.In(loc)}
+			if !got.CreatedAt.Equal(want.CreatedAt) || got.CreatedAt.Location().String() != loc.String() {
+				t.Fatalf("unexpected created at: %v", got.CreatedAt)
+			}
+			got.CreatedAt = want.CreatedAt
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("unexpected movement: %+v", got)
+			}
+			if !reflect.DeepEqual(lowStock, tt.wantLowStock) {
+				t.Fatalf("low stock = %+v, want %+v", lowStock, tt.wantLowStock)
+			}
+			if args := tt.cfg.execArgs[updateQuery]; !reflect.DeepEqual(args, []driver.Value{int64(7), "now()", int64(7)}) {
+				t.Fatalf("unexpected update args: %v", args)
+			}
+			if args := tt.cfg.queryArgs[insertQuery]; !reflect.DeepEqual(args, []driver.Value{int64(7), entity.MovementWriteOff, int64(-3), int64(7), "expired", "now()"}) {
+				t.Fatalf("unexpected insert args: %v", args)
+			}
+		})
+	}
+}
+
+func TestStockRepositoryGetStockMovements(t *testing.T) {
+	errQuery := errors.New("query")
+	rows := [][]driver.Value{
+		{int64(2), int64(7), "sale", int64(-2), int64(8), "checkout", int64(5), "2023-01-03T03:04:05Z"},
+		{int64(1), int64(7), "restock", int64(10), int64(10), "initial stock", int64(0), "2023-01-02T03:04:05Z"},
+	}
+
+	tests := []struct {
+		name          string
+		filter        entity.StockMovementFilter
+		cfg           *testConfig
+		wantErr       error
+		wantTotal     int
+		wantTypes     []string
+		wantQuery     string
+		wantQueryArgs []driver.Value
+	}{
+		{
+			name:   "ok",
+			filter: entity.StockMovementFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			cfg: &testConfig{query: map[string]testQuery{
+				countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(2)}}},
+				listQuery:  {columns: movementColumns, rows: rows},
+			}},
+			wantTotal:     2,
+			wantTypes:     []string{"sale", "restock"},
+			wantQuery:     listQuery,
+			wantQueryArgs: []driver.Value{int64(7), int64(20), int64(0)},
+		},
+		{
+			name:   "by-type",
+			filter: entity.StockMovementFilter{Type: "sale", Pagination: pagination.Params{Page: 2, PageSize: 1}},
+			cfg: &testConfig{query: map[string]testQuery{
+				typeCountQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(3)}}},
+				typeListQuery:  {columns: movementColumns, rows: rows[:1]},
+			}},
+			wantTotal:     3,
+			wantTypes:     []string{"sale"},
+			wantQuery:     typeListQuery,
+			wantQueryArgs: []driver.Value{int64(7), "sale", int64(1), int64(1)},
+		},
+		{
+			name:    "count",
+			filter:  entity.StockMovementFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			cfg:     &testConfig{query: map[string]testQuery{countQuery: {queryErr: errQuery}}},
+			wantErr: errQuery,
+		},
+		{
+			name:   "list",
+			filter: entity.StockMovementFilter{Pagination: pagination.Params{Page: 1, PageSize: 20}},
+			cfg: &testConfig{query: map[string]testQuery{
+				countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(2)}}},
+				listQuery:  {queryErr: errQuery},
+			}},
+			wantErr: errQuery,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewStockRepository(db)
+			got, total, err := repo.GetStockMovements(context.Background(), 7, tt.filter)
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Fatalf("unexpected total: %d", total)
+			}
+			var types []string
+			for _, movement := range got {
+				types = append(types, movement.Type)
+			}
+			if !reflect.DeepEqual(types, tt.wantTypes) {
+				t.Fatalf("unexpected movements: %+v", got)
+			}
+			if got[0].ReferenceID != 5 || got[0].StockAfter != 8 {
+				t.Fatalf("unexpected first movement: %+v", got[0])
+			}
+			if args := tt.cfg.queryArgs[tt.wantQuery]; !reflect.DeepEqual(args, tt.wantQueryArgs) {
+				t.Fatalf("unexpected query args: %v", args)
+			}
+		})
+	}
+}
+
+func TestStockRepositoryGetProductStock(t *testing.T) {
+	errQuery := errors.New("query")
+
+	tests := []struct {
+		name      string
+		cfg       *testConfig
+		wantStock int
+		wantErr   string
+	}{
+		{
+			name:      "ok",
+			cfg:       &testConfig{query: map[string]testQuery{stockQuery: {columns: []string{"id", "stock"}, rows: [][]driver.Value{{int64(7), int64(4)}}}}},
+			wantStock: 4,
+		},
+		{name: "missing", cfg: &testConfig{}, wantErr: "product not found"},
+		{name: "query", cfg: &testConfig{query: map[string]testQuery{stockQuery: {queryErr: errQuery}}}, wantErr: errQuery.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewStockRepository(db)
+			got, err := repo.GetProductStock(context.Background(), 7)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got != tt.wantStock {
+				t.Fatalf("unexpected result: %d, %v", got, err)
+			}
+		})
+	}
+}
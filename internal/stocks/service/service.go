@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type stockService struct {
+	stockRepository repository.StockRepository
+	notifier        alert.Notifier
+	ready           func(ctx context.Context) error
+}
+
+type StockService interface {
+	AdjustStock(ctx context.Context, productID int64, request *entity.RequestStockAdjustment) (*entity.ResponseStockMovement, error)
+	GetStockMovements(ctx context.Context, productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, *pagination.Meta, error)
+	API(ctx context.Context) entity.HealthCheck
+}
+
+// NewStockService returns a StockService. ready runs the readiness checks whose result API reports.
+func NewStockService(stockRepository repository.StockRepository, notifier alert.Notifier, ready func(ctx context.Context) error) StockService {
+	return &stockService{stockRepository: stockRepository, notifier: notifier, ready: ready}
+}
+
+// API reports the Stocks API healthy when the readiness checks of its dependencies pass.
+func (s *stockService) API(ctx context.Context) entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Stocks API",
+		IsHealthy: s.ready(ctx) == nil,
+	}
+}
+
+// AdjustStock records a manual stock change. Sales cannot be recorded here, they are written by checkout.
+func (s *stockService) AdjustStock(ctx context.Context, productID int64, request *entity.RequestStockAdjustment) (*entity.ResponseStockMovement, error) {
+	movementType := strings.TrimSpace(request.Type)
+	reason := strings.TrimSpace(request.Reason)
+
+	switch movementType {
+	case entity.MovementRestock, entity.MovementReturn:
+		if request.Quantity <= 0 {
+			return nil, apperror.Validation("quantity must be positive for %s", movementType)
+		}
+	case entity.MovementWriteOff:
+		if request.Quantity >= 0 {
+			return nil, apperror.Validation("quantity must be negative for write_off")
+		}
+	case entity.MovementAdjustment:
+		if request.Quantity == 0 {
+			return nil, apperror.Validation("quantity must not be zero")
+		}
+	default:
+		return nil, apperror.Validation("invalid movement type: %q, expected restock, adjustment, return or write_off", movementType)
+	}
+
+	if reason == "" && (movementType == entity.MovementAdjustment || movementType == entity.MovementWriteOff) {
+		return nil, apperror.Validation("reason is required for %s", movementType)
+	}
+
+	movement, lowStock, err := s.stockRepository.AdjustStock(ctx, &entity.StockMovement{
+		ProductID: productID,
+		Type:      movementType,
+		Quantity:  request.Quantity,
+		Reason:    reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if lowStock != nil {
+		s.notifier.NotifyLowStock(ctx, *lowStock)
+	}
+
+	return movement, nil
+}
+
+func (s *stockService) GetStockMovements(ctx context.Context, productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, *pagination.Meta, error) {
+	_, err := s.stockRepository.GetProductStock(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	movements, total, err := s.stockRepository.GetStockMovements(ctx, productID, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if movements == nil {
+		movements = []entity.ResponseStockMovement{}
+	}
+
+	return movements, pagination.NewMeta(filter.Pagination, total), nil
+}
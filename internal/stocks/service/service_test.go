@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/stocks/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type mockStockRepository struct {
+	adjustStockFn       func(movement *entity.StockMovement) (*entity.ResponseStockMovement, *alert.LowStock, error)
+	getStockMovementsFn func(productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, int, error)
+	getProductStockFn   func(productID int64) (int, error)
+
+	adjustStockArg *entity.StockMovement
+	adjustCalls    int
+}
+
+func (m *mockStockRepository) AdjustStock(ctx context.Context, movement *entity.StockMovement) (*entity.ResponseStockMovement, *alert.LowStock, error) {
+	m.adjustCalls++
+	m.adjustStockArg = movement
+	if m.adjustStockFn == nil {
+		return nil, nil, nil
+	}
+	return m.adjustStockFn(movement)
+}
+
+func (m *mockStockRepository) GetStockMovements(ctx context.Context, productID int64, filter entity.StockMovementFilter) ([]entity.ResponseStockMovement, int, error) {
+	if m.getStockMovementsFn == nil {
+		return nil, 0, nil
+	}
+	return m.getStockMovementsFn(productID, filter)
+}
+
+func (m *mockStockRepository) GetProductStock(ctx context.Context, productID int64) (int, error) {
+	if m.getProductStockFn == nil {
+		return 0, nil
+	}
+	return m.getProductStockFn(productID)
+}
+
+var _ repository.StockRepository = (*mockStockRepository)(nil)
+
+type mockNotifier struct {
+	events []alert.LowStock
+}
+
+func (m *mockNotifier) NotifyLowStock(ctx context.Context, event alert.LowStock) {
+	m.events = append(m.events, event)
+}
+
+func (m *mockNotifier) Close(context.Context) error { return nil }
+
+func TestNewStockService(t *testing.T) {
+	repo := &mockStockRepository{}
+	notifier := &mockNotifier{}
+	svc := NewStockService(repo, notifier, func(context.Context) error { return nil })
+	ss, ok := svc.(*stockService)
+	if !ok {
+		t.Fatalf("expected *stockService, got %T", svc)
+	}
+	if ss.stockRepository != repo {
+		t.Fatal("repository not set")
+	}
+	if ss.notifier != notifier {
+		t.Fatal("notifier not set")
+	}
+	if ss.ready == nil {
+		t.Fatal("ready not set")
+	}
+}
+
+func TestStockService_API(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &stockService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Stocks API" || got.IsHealthy != tt.want {
+				t.Fatalf("unexpected healthcheck: %+v", got)
+			}
+		})
+	}
+}
+
+func TestStockService_AdjustStock(t *testing.T) {
+	tests := []struct {
+		name         string
+		req          *entity.RequestStockAdjustment
+		repoErr      error
+		lowStock     *alert.LowStock
+		wantErr      string
+		wantMovement *entity.StockMovement
+	}{
+		{name: "bad-type", req: &entity.RequestStockAdjustment{Type: "gift", Quantity: 1}, wantErr: `invalid movement type: "gift", expected restock, adjustment, return or write_off`},
+		{name: "sale", req: &entity.RequestStockAdjustment{Type: "sale", Quantity: -1}, wantErr: `invalid movement type: "sale", expected restock, adjustment, return or write_off`},
+		{name: "restock-negative", req: &entity.RequestStockAdjustment{Type: "restock", Quantity: -1}, wantErr: "quantity must be positive for restock"},
+		{name: "return-zero", req: &entity.RequestStockAdjustment{Type: "return"}, wantErr: "quantity must be positive for return"},
+		{name: "write-off-positive", req: &entity.RequestStockAdjustment{Type: "write_off", Quantity: 2, Reason: "expired"}, wantErr: "quantity must be negative for write_off"},
+		{name: "adjustment-zero", req: &entity.RequestStockAdjustment{Type: "adjustment", Reason: "count"}, wantErr: "quantity must not be zero"},
+		{name: "adjustment-no-reason", req: &entity.RequestStockAdjustment{Type: "adjustment", Quantity: -1, Reason: "  "}, wantErr: "reason is required for adjustment"},
+		{name: "write-off-no-reason", req: &entity.RequestStockAdjustment{Type: "write_off", Quantity: -1}, wantErr: "reason is required for write_off"},
+		{
+			name:         "repo-err",
+			req:          &entity.RequestStockAdjustment{Type: "write_off", Quantity: -20, Reason: "expired"},
+			repoErr:      errors.New("insufficient stock for product 4: change -20, available 3"),
+			wantErr:      "insufficient stock for product 4: change -20, available 3",
+			wantMovement: &entity.StockMovement{ProductID: 4, Type: "write_off", Quantity: -20, Reason: "expired"},
+		},
+		{
+			name:         "restock",
+			req:          &entity.RequestStockAdjustment{Type: " restock ", Quantity: 12},
+			wantMovement: &entity.StockMovement{ProductID: 4, Type: "restock", Quantity: 12},
+		},
+		{
+			name:         "adjustment",
+			req:          &entity.RequestStockAdjustment{Type: "adjustment", Quantity: -2, Reason: " stock opname "},
+			wantMovement: &entity.StockMovement{ProductID: 4, Type: "adjustment", Quantity: -2, Reason: "stock opname"},
+		},
+		{
+			name:         "low-stock",
+			req:          &entity.RequestStockAdjustment{Type: "write_off", Quantity: -5, Reason: "damaged"},
+			lowStock:     &alert.LowStock{ProductID: 4, ProductName: "Milk", Stock: 2, ReorderLevel: 3, Source: alert.SourceAdjustment},
+			wantMovement: &entity.StockMovement{ProductID: 4, Type: "write_off", Quantity: -5, Reason: "damaged"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &entity.ResponseStockMovement{ID: 1}
+			repo := &mockStockRepository{
+				adjustStockFn: func(movement *entity.StockMovement) (*entity.ResponseStockMovement, *alert.LowStock, error) {
+					if tt.repoErr != nil {
+						return nil, nil, tt.repoErr
+					}
+					return want, tt.lowStock, nil
+				},
+			}
+			notifier := &mockNotifier{}
+			svc := &stockService{stockRepository: repo, notifier: notifier}
+			got, err := svc.AdjustStock(context.Background(), 4, tt.req)
+
+			if tt.wantMovement != nil && !reflect.DeepEqual(repo.adjustStockArg, tt.wantMovement) {
+				t.Fatalf("movement = %+v, want %+v", repo.adjustStockArg, tt.wantMovement)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if tt.wantMovement == nil && repo.adjustCalls != 0 {
+					t.Fatal("repository should not be called")
+				}
+				if len(notifier.events) != 0 {
+					t.Fatalf("unexpected notifications: %+v", notifier.events)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != want {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+			var wantEvents []alert.LowStock
+			if tt.lowStock != nil {
+				wantEvents = []alert.LowStock{*tt.lowStock}
+			}
+			if !reflect.DeepEqual(notifier.events, wantEvents) {
+				t.Fatalf("notifications = %+v, want %+v", notifier.events, wantEvents)
+			}
+		})
+	}
+}
+
+func TestStockService_GetStockMovements(t *testing.T) {
+	filter := entity.StockMovementFilter{Type: "sale", Pagination: pagination.Params{Page: 1, PageSize: 1}}
+
+	tests := []struct {
+		name     string
+		stockErr error
+		listErr  error
+		list     []entity.ResponseStockMovement
+		want     []entity.ResponseStockMovement
+		wantErr  string
+	}{
+		{name: "ok", list: []entity.ResponseStockMovement{{ID: 2}}, want: []entity.ResponseStockMovement{{ID: 2}}},
+		{name: "empty", want: []entity.ResponseStockMovement{}},
+		{name: "product-miss", stockErr: errors.New("product not found"), wantErr: "product not found"},
+		{name: "list-err", listErr: errors.New("boom"), wantErr: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockStockRepository{
+				getProductStockFn: func(productID int64) (int, error) {
+					return 5, tt.stockErr
+				},
+				getStockMovementsFn: func(productID int64, got entity.StockMovementFilter) ([]entity.ResponseStockMovement, int, error) {
+					if productID != 4 || !reflect.DeepEqual(got, filter) {
+						t.Fatalf("unexpected args: %d %+v", productID, got)
+					}
+					return tt.list, 3, tt.listErr
+				},
+			}
+			svc := &stockService{stockRepository: repo}
+			got, meta, err := svc.GetStockMovements(context.Background(), 4, filter)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if got != nil || meta != nil {
+					t.Fatalf("expected nil result, got %+v %+v", got, meta)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+			if meta == nil || meta.TotalItems != 3 || meta.TotalPages != 3 {
+				t.Fatalf("unexpected meta: %+v", meta)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type TransactionHandler struct {
+	service service.TransactionService
+}
+
+func NewTransactionHandler(service service.TransactionService) *TransactionHandler {
+	return &TransactionHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of transactions API
+// @Description Get health status of transactions API
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/transactions/health [get]
+func (h *TransactionHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API(r.Context())
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+	return
+}
+
+// Checkout godoc
+// @Summary Checkout a cart
+// @Description Sell the products in the cart, decrementing their stock and recording a transaction
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param checkout body entity.RequestCheckout true "Checkout Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/checkout [post]
+func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
+	var requestCheckout entity.RequestCheckout
+	if err := response.ParseJSON(r, &requestCheckout); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidCheckoutRequest, err)
+		return
+	}
+
+	transaction, err := h.service.Checkout(r.Context(), &requestCheckout)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Checkout failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "Checkout successfully", transaction)
+}
+
+// GetTransactionByID godoc
+// @Summary Get a transaction by ID
+// @Description Get a transaction receipt with its line items by ID
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions/{id} [get]
+func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/transactions/")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidTransactionID, err)
+		return
+	}
+
+	transaction, err := h.service.GetTransactionByID(r.Context(), int64(id))
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Transaction retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Transaction retrieved successfully", transaction)
+}
+
+// GetAllTransactions godoc
+// @Summary Get all transactions
+// @Description Get a page of transactions with their line items, newest first unless sorted otherwise
+// @Tags transactions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default 1)"
+// @Param page_size query int false "Page size (default 20, max 100)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending (id, total_amount, created_at)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/transactions [get]
+func (h *TransactionHandler) GetAllTransactions(w http.ResponseWriter, r *http.Request) {
+	params, err := pagination.Parse(r.URL.Query(), entity.TransactionSortFields...)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidTransactionFilter, err)
+		return
+	}
+
+	transactions, meta, err := h.service.GetAllTransactions(r.Context(), params)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Transactions retrieved failed", err)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, constants.SuccessCode, "Transactions retrieved successfully", transactions, meta)
+}
@@ -0,0 +1,319 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type mockTransactionService struct {
+	checkoutFn func(*entity.RequestCheckout) (*entity.ResponseTransaction, error)
+	getByIDFn  func(int64) (*entity.ResponseTransaction, error)
+	getAllFn   func(pagination.Params) ([]entity.ResponseTransaction, *pagination.Meta, error)
+	apiFn      func() entity.HealthCheck
+}
+
+func (m *mockTransactionService) Checkout(ctx context.Context, requestCheckout *entity.RequestCheckout) (*entity.ResponseTransaction, error) {
+	if m.checkoutFn == nil {
+		return nil, nil
+	}
+	return m.checkoutFn(requestCheckout)
+}
+
+func (m *mockTransactionService) GetTransactionByID(ctx context.Context, id int64) (*entity.ResponseTransaction, error) {
+	if m.getByIDFn == nil {
+		return nil, nil
+	}
+	return m.getByIDFn(id)
+}
+
+func (m *mockTransactionService) GetAllTransactions(ctx context.Context, params pagination.Params) ([]entity.ResponseTransaction, *pagination.Meta, error) {
+	if m.getAllFn == nil {
+		return nil, nil, nil
+	}
+	return m.getAllFn(params)
+}
+
+func (m *mockTransactionService) API(ctx context.Context) entity.HealthCheck {
+	if m.apiFn == nil {
+		return entity.HealthCheck{}
+	}
+	return m.apiFn()
+}
+
+func decodeAPIResponse(t *testing.T, rec *httptest.ResponseRecorder) response.APIResponse {
+	t.Helper()
+	var resp response.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestNewTransactionHandler(t *testing.T) {
+	svc := &mockTransactionService{}
+	h := NewTransactionHandler(svc)
+	if h == nil {
+		t.Fatalf("handler is nil")
+	}
+	if h.service != svc {
+		t.Fatalf("service mismatch")
+	}
+}
+
+func TestTransactionHandlerAPI(t *testing.T) {
+	cases := []struct {
+		name       string
+		health     entity.HealthCheck
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "healthy", health: entity.HealthCheck{Name: "transactions", IsHealthy: true}, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "transactions is healthy"},
+		{name: "unhealthy", health: entity.HealthCheck{Name: "transactions"}, wantStatus: http.StatusServiceUnavailable, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "transactions is not healthy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewTransactionHandler(&mockTransactionService{
+				apiFn: func() entity.HealthCheck { return tc.health },
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/transactions/health", nil)
+			h.API(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			if resp.Message != tc.wantMsg {
+				t.Fatalf("message = %v, want %q", resp.Message, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestTransactionHandlerCheckout(t *testing.T) {
+	validBody := `{"items":[{"product_id":1,"quantity":2}]}`
+	validReq := entity.RequestCheckout{Items: []entity.CheckoutItem{{ProductID: 1, Quantity: 2}}}
+	transaction := &entity.ResponseTransaction{ID: 5, TotalAmount: 20000}
+
+	cases := []struct {
+		name       string
+		body       string
+		bodyNil    bool
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+	}{
+		{name: "bad-json", body: `{"items":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidCheckoutRequest, wantPrefix: true},
+		{name: "nil-body", bodyNil: true, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidCheckoutRequest, wantPrefix: true},
+		{name: "svc-error", body: validBody, svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Checkout failed: db", wantCalled: true},
+		{name: "ok", body: validBody, wantStatus: http.StatusCreated, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Checkout successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewTransactionHandler(&mockTransactionService{
+				checkoutFn: func(req *entity.RequestCheckout) (*entity.ResponseTransaction, error) {
+					called = true
+					if !reflect.DeepEqual(*req, validReq) {
+						t.Fatalf("request = %+v, want %+v", *req, validReq)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return transaction, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+
+			var req *http.Request
+			if tc.bodyNil {
+				req = &http.Request{Body: nil}
+			} else {
+				req = httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(tc.body))
+			}
+
+			h.Checkout(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, ok := resp.Message.(string)
+			if !ok {
+				t.Fatalf("message type = %T, want string", resp.Message)
+			}
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.(map[string]any)
+				if !ok {
+					t.Fatalf("data type = %T, want map", resp.Data)
+				}
+				if data["id"] != float64(transaction.ID) || data["total_amount"] != float64(transaction.TotalAmount) {
+					t.Fatalf("unexpected data: %v", data)
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionHandlerGetTransactionByID(t *testing.T) {
+	transaction := &entity.ResponseTransaction{ID: 7, TotalAmount: 20000, Details: []entity.ResponseTransactionDetail{{ID: 1, ProductName: "Bebelac", CategoryName: "Susu"}}}
+
+	cases := []struct {
+		name       string
+		path       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+	}{
+		{name: "bad-id", path: "/transactions/abc", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidTransactionID, wantPrefix: true},
+		{name: "svc-error", path: "/transactions/7", svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Transaction retrieved failed: db", wantCalled: true},
+		{name: "ok", path: "/transactions/7", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Transaction retrieved successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewTransactionHandler(&mockTransactionService{
+				getByIDFn: func(id int64) (*entity.ResponseTransaction, error) {
+					called = true
+					if id != 7 {
+						t.Fatalf("id = %d, want 7", id)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return transaction, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+
+			h.GetTransactionByID(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			msg, _ := resp.Message.(string)
+			if tc.wantPrefix {
+				if !strings.HasPrefix(msg, tc.wantMsg) {
+					t.Fatalf("message = %q, want prefix %q", msg, tc.wantMsg)
+				}
+			} else if msg != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", msg, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.(map[string]any)
+				if !ok {
+					t.Fatalf("data type = %T, want map", resp.Data)
+				}
+				details, ok := data["details"].([]any)
+				if !ok || len(details) != 1 {
+					t.Fatalf("unexpected details: %v", data["details"])
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionHandlerGetAllTransactions(t *testing.T) {
+	transactions := []entity.ResponseTransaction{{ID: 1}, {ID: 2}}
+
+	wantParams := pagination.Params{Page: 2, PageSize: 5, Sort: []pagination.Sort{{Field: "total_amount", Desc: true}}}
+
+	cases := []struct {
+		name       string
+		query      string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "bad-sort", query: "?sort=product", wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidTransactionFilter + ": invalid sort field: product"},
+		{name: "svc-error", query: "?page=2&page_size=5&sort=-total_amount", svcErr: errors.New("db"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Transactions retrieved failed: db"},
+		{name: "ok", query: "?page=2&page_size=5&sort=-total_amount", wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Transactions retrieved successfully"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewTransactionHandler(&mockTransactionService{
+				getAllFn: func(params pagination.Params) ([]entity.ResponseTransaction, *pagination.Meta, error) {
+					if !reflect.DeepEqual(params, wantParams) {
+						t.Fatalf("params = %+v, want %+v", params, wantParams)
+					}
+					if tc.svcErr != nil {
+						return nil, nil, tc.svcErr
+					}
+					return transactions, pagination.NewMeta(params, 12), nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/transactions"+tc.query, nil)
+
+			h.GetAllTransactions(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			if resp.Message != tc.wantMsg {
+				t.Fatalf("message = %v, want %q", resp.Message, tc.wantMsg)
+			}
+			if tc.name == "ok" {
+				data, ok := resp.Data.([]any)
+				if !ok || len(data) != len(transactions) {
+					t.Fatalf("unexpected data: %v", resp.Data)
+				}
+				if resp.Meta == nil {
+					t.Fatalf("expected page meta")
+				}
+			}
+		})
+	}
+}
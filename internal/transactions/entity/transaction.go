@@ -0,0 +1,58 @@
+package entity
+
+import "time"
+
+type Transaction struct {
+	ID          int64
+	TotalAmount int
+	Details     []TransactionDetail
+	CreatedAt   string
+}
+
+type TransactionDetail struct {
+	ID            int64
+	TransactionID int64
+	ProductID     int64
+	ProductName   string
+	CategoryID    int64
+	CategoryName  string
+	Quantity      int
+	Price         int
+	Subtotal      int
+}
+
+// TransactionSortFields lists the fields the transaction list can be sorted by.
+var TransactionSortFields = []string{"id", "total_amount", "created_at"}
+
+type CheckoutItem struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+type RequestCheckout struct {
+	Items []CheckoutItem `json:"items"`
+}
+
+type ResponseTransactionDetail struct {
+	ID           int64  `json:"id"`
+	ProductID    int64  `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	CategoryID   int64  `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Quantity     int    `json:"quantity"`
+	Price        int    `json:"price"`
+	Subtotal     int    `json:"subtotal"`
+}
+
+type ResponseTransaction struct {
+	ID            int64                       `json:"id"`
+	TotalAmount   int                         `json:"total_amount"`
+	TotalQuantity int                         `json:"total_quantity"`
+	Details       []ResponseTransactionDetail `json:"details"`
+	CreatedAt     time.Time                   `json:"created_at"`
+}
+
+type HealthCheck struct {
+	Name      string `json:"name"`
+	IsHealthy bool   `json:"is_healthy"`
+}
@@ -0,0 +1,353 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type TransactionRepository interface {
+	CreateTransaction(ctx context.Context, items []entity.CheckoutItem) (*entity.ResponseTransaction, []alert.LowStock, error)
+	GetAllTransactions(ctx context.Context, params pagination.Params) ([]entity.ResponseTransaction, int, error)
+	GetTransactionByID(ctx context.Context, id int64) (*entity.ResponseTransaction, error)
+}
+
+// transactionSortColumns maps entity.TransactionSortFields to their columns.
+var transactionSortColumns = map[string]string{
+	"id":           "id",
+	"total_amount": "total_amount",
+	"created_at":   "created_at",
+}
+
+type transactionRepository struct {
+	db *database.DB
+}
+
+func NewTransactionRepository(db *database.DB) TransactionRepository {
+	return &transactionRepository{db: db}
+}
+
+// CreateTransaction locks every product in the cart, checks and decrements its stock, then writes the transaction
+// header, its line items and a sale movement per product in the stock ledger. Everything happens inside one database
+// transaction so two cashiers selling the last unit of a product can never both succeed. The products the sale took
+// to or below their reorder level are returned alongside the transaction.
+func (r *transactionRepository) CreateTransaction(ctx context.Context, items []entity.CheckoutItem) (*entity.ResponseTransaction, []alert.LowStock, error) {
+	var (
+		lockQuery        string
+		updateStockQuery string
+		insertQuery      string
+		insertItemQuery  string
+		movementQuery    string
+		transaction      entity.Transaction
+		stockAfter       map[int64]int
+		lowStock         []alert.LowStock
+		err              error
+	)
+
+	lockQuery = "SELECT products.id, products.name, products.price, products.stock, products.reorder_level, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1 AND products.deleted_at IS NULL FOR UPDATE OF products"
+	updateStockQuery = "UPDATE products SET stock = stock - $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	insertQuery = "INSERT INTO transactions (total_amount, created_at) VALUES ($1, $2) RETURNING id, created_at"
+	insertItemQuery = "INSERT INTO transaction_details (transaction_id, product_id, quantity, price, subtotal) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	movementQuery = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, reference_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	stockAfter = make(map[int64]int, len(items))
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, lockQuery, func(stmt *database.Stmt) error {
+			for _, item := range items {
+				var (
+					productID    int64
+					name         string
+					price        int
+					stock        int
+					reorderLevel int
+					categoryID   int64
+					categoryName string
+				)
+
+				err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+					return rows.Scan(&productID, &name, &price, &stock, &reorderLevel, &categoryID, &categoryName)
+				}, item.ProductID)
+				if err != nil {
+					return err
+				}
+
+				if productID == 0 {
+					return apperror.Validation("product %d not found", item.ProductID)
+				}
+
+				if stock < item.Quantity {
+					return apperror.Conflict("insufficient stock for product %s: requested %d, available %d", name, item.Quantity, stock)
+				}
+
+				stockAfter[productID] = stock - item.Quantity
+				if alert.Crossed(stock, stockAfter[productID], reorderLevel) {
+					lowStock = append(lowStock, alert.LowStock{
+						ProductID:    productID,
+						ProductName:  name,
+						Stock:        stockAfter[productID],
+						ReorderLevel: reorderLevel,
+						Source:       alert.SourceCheckout,
+					})
+				}
+
+				subtotal := price * item.Quantity
+				transaction.TotalAmount += subtotal
+				transaction.Details = append(transaction.Details, entity.TransactionDetail{
+					ProductID:    productID,
+					ProductName:  name,
+					CategoryID:   categoryID,
+					CategoryName: categoryName,
+					Quantity:     item.Quantity,
+					Price:        price,
+					Subtotal:     subtotal,
+				})
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithStmtContext(ctx, updateStockQuery, func(stmt *database.Stmt) error {
+			for _, detail := range transaction.Details {
+				if _, err = stmt.ExecContext(ctx, detail.Quantity, "now()", detail.ProductID); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithStmtContext(ctx, insertQuery, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&transaction.ID, &transaction.CreatedAt)
+			}, transaction.TotalAmount, "now()")
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithStmtContext(ctx, insertItemQuery, func(stmt *database.Stmt) error {
+			for i := range transaction.Details {
+				detail := &transaction.Details[i]
+				detail.TransactionID = transaction.ID
+
+				err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+					return rows.Scan(&detail.ID)
+				}, detail.TransactionID, detail.ProductID, detail.Quantity, detail.Price, detail.Subtotal)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		err = tx.WithStmtContext(ctx, movementQuery, func(stmt *database.Stmt) error {
+			for _, detail := range transaction.Details {
+				if _, err = stmt.ExecContext(ctx, detail.ProductID, "sale", -detail.Quantity, stockAfter[detail.ProductID], "checkout", transaction.ID, "now()"); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return toResponseTransaction(transaction), lowStock, nil
+}
+
+// GetAllTransactions returns a page of transactions with their line items, newest first unless params sorts them
+// otherwise, and the number of transactions on all pages. Only the line items of the transactions on the page are read.
+func (r *transactionRepository) GetAllTransactions(ctx context.Context, params pagination.Params) ([]entity.ResponseTransaction, int, error) {
+	var (
+		query        string
+		countQuery   string
+		detailQuery  string
+		orderBy      string
+		placeholders []string
+		ids          []interface{}
+		total        int
+		transactions []entity.Transaction
+		details      map[int64][]entity.TransactionDetail
+		err          error
+	)
+
+	orderBy = params.OrderBy(transactionSortColumns, "id")
+	if len(params.Sort) == 0 {
+		orderBy = "ORDER BY created_at DESC, id DESC"
+	}
+
+	countQuery = "SELECT COUNT(*) FROM transactions"
+	query = fmt.Sprintf("SELECT id, total_amount, created_at FROM transactions %s LIMIT $1 OFFSET $2", orderBy)
+
+	err = r.db.WithStmtContext(ctx, countQuery, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&total)
+		})
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var transaction entity.Transaction
+			if err := rows.Scan(&transaction.ID, &transaction.TotalAmount, &transaction.CreatedAt); err != nil {
+				return err
+			}
+
+			transactions = append(transactions, transaction)
+			return nil
+		}, params.Limit(), params.Offset())
+
+		return err
+	})
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(transactions) == 0 {
+		return nil, total, nil
+	}
+
+	for _, transaction := range transactions {
+		ids = append(ids, transaction.ID)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(ids)))
+	}
+
+	detailQuery = fmt.Sprintf("SELECT transaction_details.id, transaction_details.transaction_id, transaction_details.product_id, products.name as product_name, categories.id as category_id, categories.name as category_name, transaction_details.quantity, transaction_details.price, transaction_details.subtotal FROM transaction_details JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transaction_details.transaction_id IN (%s) ORDER BY transaction_details.id", strings.Join(placeholders, ", "))
+
+	details, err = r.getTransactionDetails(ctx, detailQuery, ids...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var respTransactions []entity.ResponseTransaction
+	for _, transaction := range transactions {
+		transaction.Details = details[transaction.ID]
+		respTransactions = append(respTransactions, *toResponseTransaction(transaction))
+	}
+
+	return respTransactions, total, nil
+}
+
+func (r *transactionRepository) GetTransactionByID(ctx context.Context, id int64) (*entity.ResponseTransaction, error) {
+	var (
+		query       string
+		detailQuery string
+		transaction entity.Transaction
+		details     map[int64][]entity.TransactionDetail
+		err         error
+	)
+
+	query = "SELECT id, total_amount, created_at FROM transactions WHERE id = $1"
+	detailQuery = "SELECT transaction_details.id, transaction_details.transaction_id, transaction_details.product_id, products.name as product_name, categories.id as category_id, categories.name as category_name, transaction_details.quantity, transaction_details.price, transaction_details.subtotal FROM transaction_details JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transaction_details.transaction_id = $1 ORDER BY transaction_details.id"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		err = stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&transaction.ID, &transaction.TotalAmount, &transaction.CreatedAt)
+		}, id)
+
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if transaction.ID == 0 {
+		return nil, apperror.NotFound("transaction not found")
+	}
+
+	details, err = r.getTransactionDetails(ctx, detailQuery, id)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.Details = details[transaction.ID]
+
+	return toResponseTransaction(transaction), nil
+}
+
+// getTransactionDetails runs a line item query and groups the rows by transaction ID.
+func (r *transactionRepository) getTransactionDetails(ctx context.Context, query string, args ...interface{}) (map[int64][]entity.TransactionDetail, error) {
+	details := make(map[int64][]entity.TransactionDetail)
+
+	err := r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var detail entity.TransactionDetail
+			if err := rows.Scan(&detail.ID, &detail.TransactionID, &detail.ProductID, &detail.ProductName, &detail.CategoryID, &detail.CategoryName, &detail.Quantity, &detail.Price, &detail.Subtotal); err != nil {
+				return err
+			}
+
+			details[detail.TransactionID] = append(details[detail.TransactionID], detail)
+			return nil
+		}, args...)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+func toResponseTransaction(transaction entity.Transaction) *entity.ResponseTransaction {
+	var totalQuantity int
+
+	createdAt, _ := datetime.ParseTime(transaction.CreatedAt)
+
+	details := make([]entity.ResponseTransactionDetail, 0, len(transaction.Details))
+	for _, detail := range transaction.Details {
+		totalQuantity += detail.Quantity
+		details = append(details, entity.ResponseTransactionDetail{
+			ID:           detail.ID,
+			ProductID:    detail.ProductID,
+			ProductName:  detail.ProductName,
+			CategoryID:   detail.CategoryID,
+			CategoryName: detail.CategoryName,
+			Quantity:     detail.Quantity,
+			Price:        detail.Price,
+			Subtotal:     detail.Subtotal,
+		})
+	}
+
+	return &entity.ResponseTransaction{
+		ID:            transaction.ID,
+		TotalAmount:   transaction.TotalAmount,
+		TotalQuantity: totalQuantity,
+		Details:       details,
+		CreatedAt:     createdAt,
+	}
+}
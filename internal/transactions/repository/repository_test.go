@@ -0,0 +1,527 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type testQuery struct {
+	columns  []string
+	rows     [][]driver.Value
+	queryErr error
+}
+
+type testConfig struct {
+	prepareErr map[string]error
+	execErr    map[string]error
+	query      map[string]testQuery
+	beginErr   error
+	commitErr  error
+
+	mu        sync.Mutex
+	execArgs  map[string][][]driver.Value
+	queryArgs map[string][]driver.Value
+	rolled    bool
+}
+
+func (c *testConfig) getPrepareErr(query string) error {
+	if c.prepareErr == nil {
+		return nil
+	}
+	return c.prepareErr[query]
+}
+
+func (c *testConfig) getExecErr(query string) error {
+	if c.execErr == nil {
+		return nil
+	}
+	return c.execErr[query]
+}
+
+func (c *testConfig) getQuery(query string) testQuery {
+	if c.query == nil {
+		return testQuery{}
+	}
+	return c.query[query]
+}
+
+func (c *testConfig) recordExec(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.execArgs == nil {
+		c.execArgs = make(map[string][][]driver.Value)
+	}
+	c.execArgs[query] = append(c.execArgs[query], append([]driver.Value(nil), args...))
+}
+
+func (c *testConfig) getExecArgs(query string) [][]driver.Value {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.execArgs[query]
+}
+
+type testDriver struct {
+	cfg *testConfig
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{cfg: d.cfg}, nil
+}
+
+type testConn struct {
+	cfg *testConfig
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	if err := c.cfg.getPrepareErr(query); err != nil {
+		return nil, err
+	}
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+
+func (c *testConn) Close() error { return nil }
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	if c.cfg.beginErr != nil {
+		return nil, c.cfg.beginErr
+	}
+	return &testTx{cfg: c.cfg}, nil
+}
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.cfg.getExecErr(s.query); err != nil {
+		return nil, err
+	}
+	s.cfg.recordExec(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.cfg.mu.Lock()
+	if s.cfg.queryArgs == nil {
+		s.cfg.queryArgs = make(map[string][]driver.Value)
+	}
+	s.cfg.queryArgs[s.query] = append([]driver.Value(nil), args...)
+	s.cfg.mu.Unlock()
+	q := s.cfg.getQuery(s.query)
+	if q.queryErr != nil {
+		return nil, q.queryErr
+	}
+	return &testRows{columns: q.columns, values: q.rows}, nil
+}
+
+type testTx struct {
+	cfg *testConfig
+}
+
+func (t *testTx) Commit() error {
+	return t.cfg.commitErr
+}
+
+func (t *testTx) Rollback() error {
+	t.cfg.mu.Lock()
+	defer t.cfg.mu.Unlock()
+	t.cfg.rolled = true
+	return nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+	idx     int
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	row := r.values[r.idx]
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = row[i]
+		}
+	}
+	r.idx++
+	return nil
+}
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("transaction_repo_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+const (
+	lockQuery        = "SELECT products.id, products.name, products.price, products.stock, products.reorder_level, categories.id as category_id, categories.name as category_name FROM products JOIN categories ON products.category_id = categories.id WHERE products.id = $1 AND products.deleted_at IS NULL FOR UPDATE OF products"
+	updateStockQuery = "UPDATE products SET stock = stock - $1, updated_at = $2, version = version + 1 WHERE id = $3"
+	insertQuery      = "INSERT INTO transactions (total_amount, created_at) VALUES ($1, $2) RETURNING id, created_at"
+	insertItemQuery  = "INSERT INTO transaction_details (transaction_id, product_id, quantity, price, subtotal) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	movementQuery    = "INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, reference_id, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	headerQuery      = "SELECT id, total_amount, created_at FROM transactions WHERE id = $1"
+	headersQuery     = "SELECT id, total_amount, created_at FROM transactions ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2"
+	countQuery       = "SELECT COUNT(*) FROM transactions"
+	detailQuery      = "SELECT transaction_details.id, transaction_details.transaction_id, transaction_details.product_id, products.name as product_name, categories.id as category_id, categories.name as category_name, transaction_details.quantity, transaction_details.price, transaction_details.subtotal FROM transaction_details JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transaction_details.transaction_id = $1 ORDER BY transaction_details.id"
+	detailsQuery     = "SELECT transaction_details.id, transaction_details.transaction_id, transaction_details.product_id, products.name as product_name, categories.id as category_id, categories.name as category_name, transaction_details.quantity, transaction_details.price, transaction_details.subtotal FROM transaction_details JOIN products ON transaction_details.product_id = products.id JOIN categories ON products.category_id = categories.id WHERE transaction_details.transaction_id IN ($1, $2) ORDER BY transaction_details.id"
+)
+
+var (
+	headerColumns = []string{"id", "total_amount", "created_at"}
+	detailColumns = []string{"id", "transaction_id", "product_id", "product_name", "category_id", "category_name", "quantity", "price", "subtotal"}
+)
+
+func checkoutQueries(stock, reorderLevel int64) map[string]testQuery {
+	return map[string]testQuery{
+		lockQuery: {
+			columns: []string{"id", "name", "price", "stock", "reorder_level", "category_id", "category_name"},
+			rows:    [][]driver.Value{{int64(7), "Bebelac", int64(10000), stock, reorderLevel, int64(2), "Susu"}},
+		},
+		insertQuery: {
+			columns: []string{"id", "created_at"},
+			rows:    [][]driver.Value{{int64(3), "2023-01-02T03:04:05Z"}},
+		},
+		insertItemQuery: {
+			columns: []string{"id"},
+			rows:    [][]driver.Value{{int64(11)}},
+		},
+	}
+}
+
+func TestNewTransactionRepository(t *testing.T) {
+	db := newTestDB(t, &testConfig{})
+	repo := NewTransactionRepository(db)
+	if repo == nil {
+		t.Fatalf("expected repository")
+	}
+	r, ok := repo.(*transactionRepository)
+	if !ok {
+		t.Fatalf("expected transactionRepository")
+	}
+	if r.db != db {
+		t.Fatalf("expected db to match")
+	}
+}
+
+func TestTransactionRepositoryCreateTransaction(t *testing.T) {
+	items := []entity.CheckoutItem{{ProductID: 7, Quantity: 2}}
+	errBegin := errors.New("begin")
+	errExec := errors.New("exec")
+	errCommit := errors.New("commit")
+	errQuery := errors.New("query")
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	createdAt, _ := time.Parse(time.RFC3339, "2023-01-02T03:04:05Z")
+
+	tests := []struct {
+		name         string
+		cfg          *testConfig
+		wantErr      string
+		wantRollback bool
+		wantLowStock []alert.LowStock
+	}{
+		{name: "ok", cfg: &testConfig{query: checkoutQueries(5, 1)}},
+		{
+			name:         "low-stock",
+			cfg:          &testConfig{query: checkoutQueries(5, 3)},
+			wantLowStock: []alert.LowStock{{ProductID: 7, ProductName: "Bebelac", Stock: 3, ReorderLevel: 3, Source: alert.SourceCheckout}},
+		},
+		{name: "already-low", cfg: &testConfig{query: checkoutQueries(5, 5)}},
+		{name: "begin", cfg: &testConfig{beginErr: errBegin}, wantErr: errBegin.Error()},
+		{
+			name:         "missing",
+			cfg:          &testConfig{query: map[string]testQuery{lockQuery: {columns: []string{"id", "name", "price", "stock", "reorder_level", "category_id", "category_name"}}}},
+			wantErr:      "product 7 not found",
+			wantRollback: true,
+		},
+		{
+			name:         "insufficient",
+			cfg:          &testConfig{query: checkoutQueries(1, 1)},
+			wantErr:      "insufficient stock for product Bebelac: requested 2, available 1",
+			wantRollback: true,
+		},
+		{
+			name: "lock-query",
+			cfg: &testConfig{query: map[string]testQuery{
+				lockQuery: {queryErr: errQuery},
+			}},
+			wantErr:      errQuery.Error(),
+			wantRollback: true,
+		},
+		{
+			name:         "update-stock",
+			cfg:          &testConfig{query: checkoutQueries(5, 1), execErr: map[string]error{updateStockQuery: errExec}},
+			wantErr:      errExec.Error(),
+			wantRollback: true,
+		},
+		{
+			name:         "stock-movement",
+			cfg:          &testConfig{query: checkoutQueries(5, 1), execErr: map[string]error{movementQuery: errExec}},
+			wantErr:      errExec.Error(),
+			wantRollback: true,
+		},
+		{
+			name:    "commit",
+			cfg:     &testConfig{query: checkoutQueries(5, 1), commitErr: errCommit},
+			wantErr: errCommit.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewTransactionRepository(db)
+			got, lowStock, err := repo.CreateTransaction(context.Background(), items)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if tt.wantRollback && !tt.cfg.rolled {
+					t.Fatalf("expected rollback")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if got.ID != 3 || got.TotalAmount != 20000 {
+				t.Fatalf("unexpected transaction: %+v", got)
+			}
+			if !got.CreatedAt.Equal(createdAt) || got.CreatedAt.Location().String() != loc.String() {
+				t.Fatalf("unexpected created at: %v", got.CreatedAt)
+			}
+			if got.TotalQuantity != 2 {
+				t.Fatalf("unexpected total quantity: %d", got.TotalQuantity)
+			}
+			wantDetails := []entity.ResponseTransactionDetail{{ID: 11, ProductID: 7, ProductName: "Bebelac", CategoryID: 2, CategoryName: "Susu", Quantity: 2, Price: 10000, Subtotal: 20000}}
+			if !reflect.DeepEqual(got.Details, wantDetails) {
+				t.Fatalf("unexpected details: %+v", got.Details)
+			}
+			if !reflect.DeepEqual(lowStock, tt.wantLowStock) {
+				t.Fatalf("low stock = %+v, want %+v", lowStock, tt.wantLowStock)
+			}
+			wantExec := [][]driver.Value{{int64(2), "now()", int64(7)}}
+			if gotExec := tt.cfg.getExecArgs(updateStockQuery); !reflect.DeepEqual(gotExec, wantExec) {
+				t.Fatalf("expected stock update %v, got %v", wantExec, gotExec)
+			}
+			wantMovements := [][]driver.Value{{int64(7), "sale", int64(-2), int64(3), "checkout", int64(3), "now()"}}
+			if gotMovements := tt.cfg.getExecArgs(movementQuery); !reflect.DeepEqual(gotMovements, wantMovements) {
+				t.Fatalf("expected stock movements %v, got %v", wantMovements, gotMovements)
+			}
+		})
+	}
+}
+
+func TestTransactionRepositoryGetTransactionByID(t *testing.T) {
+	errQuery := errors.New("query")
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	createdAt, _ := time.Parse(time.RFC3339, "2023-01-02T03:04:05Z")
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr string
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{
+				headerQuery: {columns: headerColumns, rows: [][]driver.Value{{int64(3), int64(25000), "2023-01-02T03:04:05Z"}}},
+				detailQuery: {columns: detailColumns, rows: [][]driver.Value{
+					{int64(1), int64(3), int64(7), "Bebelac", int64(2), "Susu", int64(2), int64(10000), int64(20000)},
+					{int64(2), int64(3), int64(8), "Teh", int64(4), "Minuman", int64(1), int64(5000), int64(5000)},
+				}},
+			}},
+		},
+		{
+			name:    "missing",
+			cfg:     &testConfig{query: map[string]testQuery{headerQuery: {columns: headerColumns}}},
+			wantErr: "transaction not found",
+		},
+		{
+			name:    "header-query",
+			cfg:     &testConfig{query: map[string]testQuery{headerQuery: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
+		{
+			name: "detail-query",
+			cfg: &testConfig{query: map[string]testQuery{
+				headerQuery: {columns: headerColumns, rows: [][]driver.Value{{int64(3), int64(25000), "2023-01-02T03:04:05Z"}}},
+				detailQuery: {queryErr: errQuery},
+			}},
+			wantErr: errQuery.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewTransactionRepository(db)
+			got, err := repo.GetTransactionByID(context.Background(), 3)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if got.ID != 3 || got.TotalAmount != 25000 || got.TotalQuantity != 3 {
+				t.Fatalf("unexpected transaction: %+v", got)
+			}
+			if !got.CreatedAt.Equal(createdAt) || got.CreatedAt.Location().String() != loc.String() {
+				t.Fatalf("unexpected created at: %v", got.CreatedAt)
+			}
+			if len(got.Details) != 2 || got.Details[1].ProductName != "Teh" || got.Details[1].CategoryName != "Minuman" {
+				t.Fatalf("unexpected details: %+v", got.Details)
+			}
+		})
+	}
+}
+
+func TestTransactionRepositoryGetAllTransactions(t *testing.T) {
+	errQuery := errors.New("query")
+	counted := testQuery{columns: []string{"count"}, rows: [][]driver.Value{{int64(12)}}}
+	sortedQuery := "SELECT id, total_amount, created_at FROM transactions ORDER BY total_amount DESC, id ASC LIMIT $1 OFFSET $2"
+
+	tests := []struct {
+		name        string
+		params      pagination.Params
+		cfg         *testConfig
+		header      string
+		wantErr     string
+		wantCount   int
+		wantTotal   int
+		wantDetails []string
+	}{
+		{
+			name:   "ok",
+			params: pagination.Params{Page: 2, PageSize: 10},
+			header: headersQuery,
+			cfg: &testConfig{query: map[string]testQuery{
+				countQuery: counted,
+				headersQuery: {columns: headerColumns, rows: [][]driver.Value{
+					{int64(4), int64(5000), "2023-01-03T03:04:05Z"},
+					{int64(3), int64(20000), "2023-01-02T03:04:05Z"},
+				}},
+				detailsQuery: {columns: detailColumns, rows: [][]driver.Value{
+					{int64(1), int64(3), int64(7), "Bebelac", int64(2), "Susu", int64(2), int64(10000), int64(20000)},
+					{int64(2), int64(4), int64(8), "Teh", int64(4), "Minuman", int64(1), int64(5000), int64(5000)},
+				}},
+			}},
+			wantCount:   2,
+			wantTotal:   12,
+			wantDetails: []string{"Teh", "Bebelac"},
+		},
+		{
+			name:      "sorted",
+			params:    pagination.Params{Page: 1, PageSize: 10, Sort: []pagination.Sort{{Field: "total_amount", Desc: true}}},
+			header:    sortedQuery,
+			cfg:       &testConfig{query: map[string]testQuery{countQuery: counted, sortedQuery: {columns: headerColumns}}},
+			wantTotal: 12,
+		},
+		{
+			name:   "empty",
+			params: pagination.Params{Page: 1, PageSize: 10},
+			header: headersQuery,
+			cfg:    &testConfig{query: map[string]testQuery{countQuery: counted, headersQuery: {columns: headerColumns}}},
+			// No line items are read for an empty page.
+			wantTotal: 12,
+		},
+		{
+			name:    "count",
+			params:  pagination.Params{Page: 1, PageSize: 10},
+			cfg:     &testConfig{query: map[string]testQuery{countQuery: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
+		{
+			name:    "query",
+			params:  pagination.Params{Page: 1, PageSize: 10},
+			cfg:     &testConfig{query: map[string]testQuery{countQuery: counted, headersQuery: {queryErr: errQuery}}},
+			wantErr: errQuery.Error(),
+		},
+		{
+			name:   "detail-query",
+			params: pagination.Params{Page: 1, PageSize: 10},
+			cfg: &testConfig{query: map[string]testQuery{
+				countQuery:   counted,
+				headersQuery: {columns: headerColumns, rows: [][]driver.Value{{int64(4), int64(5000), "2023-01-03T03:04:05Z"}, {int64(3), int64(20000), "2023-01-02T03:04:05Z"}}},
+				detailsQuery: {queryErr: errQuery},
+			}},
+			wantErr: errQuery.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewTransactionRepository(db)
+			got, total, err := repo.GetAllTransactions(context.Background(), tt.params)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Fatalf("expected total %d, got %d", tt.wantTotal, total)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("expected %d transactions, got %d", tt.wantCount, len(got))
+			}
+			wantArgs := []driver.Value{int64(tt.params.Limit()), int64(tt.params.Offset())}
+			if args := tt.cfg.queryArgs[tt.header]; !reflect.DeepEqual(args, wantArgs) {
+				t.Fatalf("expected header args %v, got %v", wantArgs, args)
+			}
+			if tt.wantCount == 0 {
+				if _, ok := tt.cfg.queryArgs[detailsQuery]; ok {
+					t.Fatalf("expected no line item query for an empty page")
+				}
+				return
+			}
+			if args := tt.cfg.queryArgs[detailsQuery]; !reflect.DeepEqual(args, []driver.Value{int64(4), int64(3)}) {
+				t.Fatalf("expected line items of the page, got args %v", args)
+			}
+			for i, want := range tt.wantDetails {
+				if len(got[i].Details) != 1 || got[i].Details[0].ProductName != want {
+					t.Fatalf("transaction %d: unexpected details %+v", got[i].ID, got[i].Details)
+				}
+			}
+		})
+	}
+}
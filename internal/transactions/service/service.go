@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/metrics"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+)
+
+type transactionService struct {
+	transactionRepository repository.TransactionRepository
+	notifier              alert.Notifier
+	ready                 func(ctx context.Context) error
+}
+
+type TransactionService interface {
+	Checkout(ctx context.Context, requestCheckout *entity.RequestCheckout) (*entity.ResponseTransaction, error)
+	GetTransactionByID(ctx context.Context, id int64) (*entity.ResponseTransaction, error)
+	GetAllTransactions(ctx context.Context, params pagination.Params) ([]entity.ResponseTransaction, *pagination.Meta, error)
+	API(ctx context.Context) entity.HealthCheck
+}
+
+// NewTransactionService returns a TransactionService. ready runs the readiness checks whose result API reports.
+func NewTransactionService(transactionRepository repository.TransactionRepository, notifier alert.Notifier, ready func(ctx context.Context) error) TransactionService {
+	return &transactionService{transactionRepository: transactionRepository, notifier: notifier, ready: ready}
+}
+
+// API reports the Transactions API healthy when the readiness checks of its dependencies pass.
+func (s *transactionService) API(ctx context.Context) entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Transactions API",
+		IsHealthy: s.ready(ctx) == nil,
+	}
+}
+
+func (s *transactionService) Checkout(ctx context.Context, requestCheckout *entity.RequestCheckout) (*entity.ResponseTransaction, error) {
+	items, err := normalizeItems(requestCheckout.Items)
+	if err != nil {
+		metrics.Checkouts.WithLabelValues(metrics.OutcomeFailed).Inc()
+		return nil, err
+	}
+
+	transaction, lowStock, err := s.transactionRepository.CreateTransaction(ctx, items)
+	if err != nil {
+		metrics.Checkouts.WithLabelValues(metrics.OutcomeFailed).Inc()
+		return nil, err
+	}
+
+	metrics.Checkouts.WithLabelValues(metrics.OutcomeSuccess).Inc()
+	metrics.Revenue.Add(float64(transaction.TotalAmount))
+	metrics.ItemsSold.Add(float64(transaction.TotalQuantity))
+
+	for _, event := range lowStock {
+		s.notifier.NotifyLowStock(ctx, event)
+	}
+
+	return transaction, nil
+}
+
+func (s *transactionService) GetTransactionByID(ctx context.Context, id int64) (*entity.ResponseTransaction, error) {
+	return s.transactionRepository.GetTransactionByID(ctx, id)
+}
+
+func (s *transactionService) GetAllTransactions(ctx context.Context, params pagination.Params) ([]entity.ResponseTransaction, *pagination.Meta, error) {
+	transactions, total, err := s.transactionRepository.GetAllTransactions(ctx, params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return transactions, pagination.NewMeta(params, total), nil
+}
+
+// normalizeItems validates the cart, merges repeated products into a single line and orders the lines by product ID
+// so concurrent checkouts always lock product rows in the same order.
+func normalizeItems(items []entity.CheckoutItem) ([]entity.CheckoutItem, error) {
+	if len(items) == 0 {
+		return nil, apperror.Validation("checkout items are required")
+	}
+
+	quantities := make(map[int64]int)
+	for _, item := range items {
+		if item.ProductID <= 0 {
+			return nil, apperror.Validation("invalid product id")
+		}
+
+		if item.Quantity <= 0 {
+			return nil, apperror.Validation("quantity must be greater than zero")
+		}
+
+		quantities[item.ProductID] += item.Quantity
+	}
+
+	merged := make([]entity.CheckoutItem, 0, len(quantities))
+	for productID, quantity := range quantities {
+		merged = append(merged, entity.CheckoutItem{ProductID: productID, Quantity: quantity})
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].ProductID < merged[j].ProductID
+	})
+
+	return merged, nil
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/transactions/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/alert"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/metrics"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/pagination"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type mockTransactionRepository struct {
+	createTransactionFn  func(items []entity.CheckoutItem) (*entity.ResponseTransaction, []alert.LowStock, error)
+	getTransactionByIDFn func(id int64) (*entity.ResponseTransaction, error)
+	getAllTransactionsFn func(params pagination.Params) ([]entity.ResponseTransaction, int, error)
+
+	createTransactionArg []entity.CheckoutItem
+	createCalls          int
+}
+
+func (m *mockTransactionRepository) CreateTransaction(ctx context.Context, items []entity.CheckoutItem) (*entity.ResponseTransaction, []alert.LowStock, error) {
+	m.createCalls++
+	m.createTransactionArg = items
+	if m.createTransactionFn == nil {
+		return nil, nil, nil
+	}
+	return m.createTransactionFn(items)
+}
+
+func (m *mockTransactionRepository) GetTransactionByID(ctx context.Context, id int64) (*entity.ResponseTransaction, error) {
+	if m.getTransactionByIDFn == nil {
+		return nil, nil
+	}
+	return m.getTransactionByIDFn(id)
+}
+
+func (m *mockTransactionRepository) GetAllTransactions(ctx context.Context, params pagination.Params) ([]entity.ResponseTransaction, int, error) {
+	if m.getAllTransactionsFn == nil {
+		return nil, 0, nil
+	}
+	return m.getAllTransactionsFn(params)
+}
+
+var _ repository.TransactionRepository = (*mockTransactionRepository)(nil)
+
+type mockNotifier struct {
+	events []alert.LowStock
+}
+
+func (m *mockNotifier) NotifyLowStock(ctx context.Context, event alert.LowStock) {
+	m.events = append(m.events, event)
+}
+
+func (m *mockNotifier) Close(context.Context) error { return nil }
+
+func TestNewTransactionService(t *testing.T) {
+	repo := &mockTransactionRepository{}
+	notifier := &mockNotifier{}
+	svc := NewTransactionService(repo, notifier, func(context.Context) error { return nil })
+	ts, ok := svc.(*transactionService)
+	if !ok {
+		t.Fatalf("expected *transactionService, got %T", svc)
+	}
+	if ts.transactionRepository != repo {
+		t.Fatal("repository not set")
+	}
+	if ts.notifier != notifier {
+		t.Fatal("notifier not set")
+	}
+	if ts.ready == nil {
+		t.Fatal("ready not set")
+	}
+}
+
+func TestTransactionService_API(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &transactionService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Transactions API" || got.IsHealthy != tt.want {
+				t.Fatalf("unexpected healthcheck: %+v", got)
+			}
+		})
+	}
+}
+
+func TestTransactionService_Checkout(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *entity.RequestCheckout
+		repoErr   error
+		lowStock  []alert.LowStock
+		wantErr   string
+		wantItems []entity.CheckoutItem
+	}{
+		{
+			name:    "empty",
+			req:     &entity.RequestCheckout{},
+			wantErr: "checkout items are required",
+		},
+		{
+			name:    "bad-product",
+			req:     &entity.RequestCheckout{Items: []entity.CheckoutItem{{ProductID: 0, Quantity: 1}}},
+			wantErr: "invalid product id",
+		},
+		{
+			name:    "bad-quantity",
+			req:     &entity.RequestCheckout{Items: []entity.CheckoutItem{{ProductID: 1, Quantity: 0}}},
+			wantErr: "quantity must be greater than zero",
+		},
+		{
+			name:      "repo-err",
+			req:       &entity.RequestCheckout{Items: []entity.CheckoutItem{{ProductID: 1, Quantity: 1}}},
+			repoErr:   errors.New("insufficient stock"),
+			wantErr:   "insufficient stock",
+			wantItems: []entity.CheckoutItem{{ProductID: 1, Quantity: 1}},
+		},
+		{
+			name: "merged-sorted",
+			req: &entity.RequestCheckout{Items: []entity.CheckoutItem{
+				{ProductID: 9, Quantity: 1},
+				{ProductID: 2, Quantity: 3},
+				{ProductID: 9, Quantity: 4},
+			}},
+			wantItems: []entity.CheckoutItem{{ProductID: 2, Quantity: 3}, {ProductID: 9, Quantity: 5}},
+		},
+		{
+			name: "low-stock",
+			req:  &entity.RequestCheckout{Items: []entity.CheckoutItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}}},
+			lowStock: []alert.LowStock{
+				{ProductID: 1, ProductName: "Bebelac", Stock: 2, ReorderLevel: 2, Source: alert.SourceCheckout},
+				{ProductID: 2, ProductName: "Indomie", Stock: 0, ReorderLevel: 5, Source: alert.SourceCheckout},
+			},
+			wantItems: []entity.CheckoutItem{{ProductID: 1, Quantity: 1}, {ProductID: 2, Quantity: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &entity.ResponseTransaction{ID: 1, TotalAmount: 25000, TotalQuantity: 3}
+			failedBefore := testutil.ToFloat64(metrics.Checkouts.WithLabelValues(metrics.OutcomeFailed))
+			successBefore := testutil.ToFloat64(metrics.Checkouts.WithLabelValues(metrics.OutcomeSuccess))
+			revenueBefore := testutil.ToFloat64(metrics.Revenue)
+			itemsBefore := testutil.ToFloat64(metrics.ItemsSold)
+			repo := &mockTransactionRepository{
+				createTransactionFn: func(items []entity.CheckoutItem) (*entity.ResponseTransaction, []alert.LowStock, error) {
+					if tt.repoErr != nil {
+						return nil, nil, tt.repoErr
+					}
+					return want, tt.lowStock, nil
+				},
+			}
+			notifier := &mockNotifier{}
+			svc := &transactionService{transactionRepository: repo, notifier: notifier}
+			got, err := svc.Checkout(context.Background(), tt.req)
+
+			if tt.wantItems != nil && !reflect.DeepEqual(repo.createTransactionArg, tt.wantItems) {
+				t.Fatalf("items = %+v, want %+v", repo.createTransactionArg, tt.wantItems)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				if tt.wantItems == nil && repo.createCalls != 0 {
+					t.Fatal("repository should not be called")
+				}
+				if got := testutil.ToFloat64(metrics.Checkouts.WithLabelValues(metrics.OutcomeFailed)); got != failedBefore+1 {
+					t.Fatalf("failed checkouts = %v, want %v", got, failedBefore+1)
+				}
+				if got := testutil.ToFloat64(metrics.Revenue); got != revenueBefore {
+					t.Fatalf("revenue = %v, want %v", got, revenueBefore)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != want {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+			if !reflect.DeepEqual(notifier.events, tt.lowStock) {
+				t.Fatalf("notifications = %+v, want %+v", notifier.events, tt.lowStock)
+			}
+			if got := testutil.ToFloat64(metrics.Checkouts.WithLabelValues(metrics.OutcomeSuccess)); got != successBefore+1 {
+				t.Fatalf("successful checkouts = %v, want %v", got, successBefore+1)
+			}
+			if got := testutil.ToFloat64(metrics.Revenue); got != revenueBefore+25000 {
+				t.Fatalf("revenue = %v, want %v", got, revenueBefore+25000)
+			}
+			if got := testutil.ToFloat64(metrics.ItemsSold); got != itemsBefore+3 {
+				t.Fatalf("items sold = %v, want %v", got, itemsBefore+3)
+			}
+		})
+	}
+}
+
+func TestTransactionService_GetTransactionByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		wantErr string
+	}{
+		{name: "ok"},
+		{name: "err", repoErr: errors.New("transaction not found"), wantErr: "transaction not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotID int64
+			repo := &mockTransactionRepository{
+				getTransactionByIDFn: func(id int64) (*entity.ResponseTransaction, error) {
+					gotID = id
+					if tt.repoErr != nil {
+						return nil, tt.repoErr
+					}
+					return &entity.ResponseTransaction{ID: id}, nil
+				},
+			}
+			svc := &transactionService{transactionRepository: repo}
+			got, err := svc.GetTransactionByID(context.Background(), 8)
+			if gotID != 8 {
+				t.Fatalf("unexpected id: %d", gotID)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || got == nil || got.ID != 8 {
+				t.Fatalf("unexpected result: %+v, %v", got, err)
+			}
+		})
+	}
+}
+
+func TestTransactionService_GetAllTransactions(t *testing.T) {
+	tests := []struct {
+		name    string
+		repoErr error
+		want    []entity.ResponseTransaction
+		wantErr string
+	}{
+		{name: "ok", want: []entity.ResponseTransaction{{ID: 1}, {ID: 2}}},
+		{name: "err", repoErr: errors.New("boom"), wantErr: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockTransactionRepository{
+				getAllTransactionsFn: func(params pagination.Params) ([]entity.ResponseTransaction, int, error) {
+					return tt.want, 21, tt.repoErr
+				},
+			}
+			svc := &transactionService{transactionRepository: repo}
+			params := pagination.Params{Page: 2, PageSize: 10}
+			got, meta, err := svc.GetAllTransactions(context.Background(), params)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected result: %+v", got)
+			}
+			if !reflect.DeepEqual(meta, pagination.NewMeta(params, 21)) {
+				t.Fatalf("unexpected meta: %+v", meta)
+			}
+		})
+	}
+}
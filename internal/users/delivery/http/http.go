@@ -0,0 +1,126 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type UserHandler struct {
+	service service.UserService
+}
+
+func NewUserHandler(service service.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// API godoc
+// @Summary Get health status of users API
+// @Description Get health status of users API
+// @Tags users
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 503 {object} map[string]string
+// @Router /api/users/health [get]
+func (h *UserHandler) API(w http.ResponseWriter, r *http.Request) {
+	var result response.APIResponse
+	svcHealthCheckResult := h.service.API(r.Context())
+	if svcHealthCheckResult.IsHealthy {
+		result.Code = strconv.Itoa(constants.SuccessCode)
+		result.Message = fmt.Sprintf("%s is healthy", svcHealthCheckResult.Name)
+		response.WriteJSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	result.Code = strconv.Itoa(constants.ErrorCode)
+	result.Message = fmt.Sprintf("%s is not healthy", svcHealthCheckResult.Name)
+	response.WriteJSONResponse(w, http.StatusServiceUnavailable, result)
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange a username and password for a bearer token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body entity.RequestLogin true "Credentials"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/auth/login [post]
+func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var requestLogin entity.RequestLogin
+	if err := response.ParseJSON(r, &requestLogin); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidLoginRequest, err)
+		return
+	}
+
+	login, err := h.service.Login(r.Context(), &requestLogin)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Login failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Login successfully", login)
+}
+
+// CreateUser godoc
+// @Summary Create a new user
+// @Description Create a new admin or cashier user (admin only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user body entity.RequestUser true "User Data"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users [post]
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var requestUser entity.RequestUser
+	if err := response.ParseJSON(r, &requestUser); err != nil {
+		response.Error(w, http.StatusBadRequest, constants.ValidationErrorCode, constants.ErrInvalidUserRequest, err)
+		return
+	}
+
+	user, err := h.service.CreateUser(r.Context(), &requestUser)
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "User created failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, constants.SuccessCode, "User created successfully", user)
+}
+
+// GetAllUsers godoc
+// @Summary Get all users
+// @Description Get all users (admin only)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /api/users [get]
+func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.service.GetAllUsers(r.Context())
+	if err != nil {
+		response.Error(w, apperror.Status(err), apperror.Code(err), "Users retrieved failed", err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, constants.SuccessCode, "Users retrieved successfully", users)
+}
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/service"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/response"
+)
+
+type mockUserService struct {
+	loginFn       func(*entity.RequestLogin) (*entity.ResponseLogin, error)
+	createUserFn  func(*entity.RequestUser) (*entity.ResponseUser, error)
+	getAllUsersFn func() ([]entity.ResponseUser, error)
+	ensureAdminFn func(string, string) (bool, error)
+	apiFn         func() entity.HealthCheck
+}
+
+func (m *mockUserService) Login(ctx context.Context, request *entity.RequestLogin) (*entity.ResponseLogin, error) {
+	if m.loginFn == nil {
+		return nil, nil
+	}
+	return m.loginFn(request)
+}
+
+func (m *mockUserService) CreateUser(ctx context.Context, request *entity.RequestUser) (*entity.ResponseUser, error) {
+	if m.createUserFn == nil {
+		return nil, nil
+	}
+	return m.createUserFn(request)
+}
+
+func (m *mockUserService) GetAllUsers(ctx context.Context) ([]entity.ResponseUser, error) {
+	if m.getAllUsersFn == nil {
+		return nil, nil
+	}
+	return m.getAllUsersFn()
+}
+
+func (m *mockUserService) EnsureAdmin(ctx context.Context, username, password string) (bool, error) {
+	if m.ensureAdminFn == nil {
+		return false, nil
+	}
+	return m.ensureAdminFn(username, password)
+}
+
+func (m *mockUserService) API(ctx context.Context) entity.HealthCheck {
+	if m.apiFn == nil {
+		return entity.HealthCheck{}
+	}
+	return m.apiFn()
+}
+
+func decodeAPIResponse(t *testing.T, rec *httptest.ResponseRecorder) response.APIResponse {
+	t.Helper()
+	var resp response.APIResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func assertMessage(t *testing.T, resp response.APIResponse, want string, prefix bool) {
+	t.Helper()
+	msg, _ := resp.Message.(string)
+	if prefix {
+		if !strings.HasPrefix(msg, want) {
+			t.Fatalf("message = %q, want prefix %q", msg, want)
+		}
+		return
+	}
+	if msg != want {
+		t.Fatalf("message = %q, want %q", msg, want)
+	}
+}
+
+func TestNewUserHandler(t *testing.T) {
+	svc := &mockUserService{}
+	h := NewUserHandler(svc)
+	if h == nil {
+		t.Fatalf("handler is nil")
+	}
+	if h.service != svc {
+		t.Fatalf("service mismatch")
+	}
+}
+
+func TestUserHandlerAPI(t *testing.T) {
+	cases := []struct {
+		name       string
+		health     entity.HealthCheck
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{name: "healthy", health: entity.HealthCheck{Name: "users", IsHealthy: true}, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "users is healthy"},
+		{name: "unhealthy", health: entity.HealthCheck{Name: "users"}, wantStatus: http.StatusServiceUnavailable, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "users is not healthy"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewUserHandler(&mockUserService{
+				apiFn: func() entity.HealthCheck { return tc.health },
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users/health", nil)
+			h.API(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			assertMessage(t, resp, tc.wantMsg, false)
+		})
+	}
+}
+
+func TestUserHandlerLogin(t *testing.T) {
+	validBody := `{"username":"siti","password":"rahasia123"}`
+	validReq := entity.RequestLogin{Username: "siti", Password: "rahasia123"}
+	login := &entity.ResponseLogin{AccessToken: "token", TokenType: "Bearer", User: entity.ResponseUser{ID: 3, Username: "siti", Role: "cashier"}}
+
+	cases := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+	}{
+		{name: "bad-json", body: `{"username":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidLoginRequest, wantPrefix: true},
+		{name: "bad-credentials", body: validBody, svcErr: service.ErrInvalidCredentials, wantStatus: http.StatusUnauthorized, wantCode: strconv.Itoa(constants.UnauthorizedErrorCode), wantMsg: "Login failed: invalid username or password", wantCalled: true},
+		{name: "svc-error", body: validBody, svcErr: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Login failed: db down", wantCalled: true},
+		{name: "ok", body: validBody, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Login successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewUserHandler(&mockUserService{
+				loginFn: func(req *entity.RequestLogin) (*entity.ResponseLogin, error) {
+					called = true
+					if !reflect.DeepEqual(*req, validReq) {
+						t.Fatalf("request = %+v, want %+v", *req, validReq)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return login, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(tc.body))
+
+			h.Login(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			assertMessage(t, resp, tc.wantMsg, tc.wantPrefix)
+			if tc.name == "ok" {
+				data, ok := resp.Data.(map[string]any)
+				if !ok {
+					t.Fatalf("data type = %T, want map", resp.Data)
+				}
+				if data["access_token"] != "token" || data["token_type"] != "Bearer" {
+					t.Fatalf("unexpected data: %v", data)
+				}
+			}
+		})
+	}
+}
+
+func TestUserHandlerCreateUser(t *testing.T) {
+	validBody := `{"username":"siti","password":"rahasia123","role":"cashier"}`
+	validReq := entity.RequestUser{Username: "siti", Password: "rahasia123", Role: "cashier"}
+	user := &entity.ResponseUser{ID: 5, Username: "siti", Role: "cashier"}
+
+	cases := []struct {
+		name       string
+		body       string
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantPrefix bool
+		wantCalled bool
+	}{
+		{name: "bad-json", body: `{"username":`, wantStatus: http.StatusBadRequest, wantCode: strconv.Itoa(constants.ValidationErrorCode), wantMsg: constants.ErrInvalidUserRequest, wantPrefix: true},
+		{name: "svc-error", body: validBody, svcErr: errors.New("username already taken"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "User created failed: username already taken", wantCalled: true},
+		{name: "ok", body: validBody, wantStatus: http.StatusCreated, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "User created successfully", wantCalled: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			called := false
+			h := NewUserHandler(&mockUserService{
+				createUserFn: func(req *entity.RequestUser) (*entity.ResponseUser, error) {
+					called = true
+					if !reflect.DeepEqual(*req, validReq) {
+						t.Fatalf("request = %+v, want %+v", *req, validReq)
+					}
+					if tc.svcErr != nil {
+						return nil, tc.svcErr
+					}
+					return user, nil
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.body))
+
+			h.CreateUser(rec, req)
+
+			if called != tc.wantCalled {
+				t.Fatalf("service called = %v, want %v", called, tc.wantCalled)
+			}
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			assertMessage(t, resp, tc.wantMsg, tc.wantPrefix)
+			if tc.name == "ok" {
+				data, ok := resp.Data.(map[string]any)
+				if !ok {
+					t.Fatalf("data type = %T, want map", resp.Data)
+				}
+				if data["username"] != "siti" || data["role"] != "cashier" {
+					t.Fatalf("unexpected data: %v", data)
+				}
+				if _, leaked := data["password"]; leaked {
+					t.Fatalf("password leaked in response: %v", data)
+				}
+			}
+		})
+	}
+}
+
+func TestUserHandlerGetAllUsers(t *testing.T) {
+	cases := []struct {
+		name       string
+		users      []entity.ResponseUser
+		svcErr     error
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantLen    int
+	}{
+		{name: "svc-error", svcErr: fmt.Errorf("boom"), wantStatus: http.StatusInternalServerError, wantCode: strconv.Itoa(constants.ErrorCode), wantMsg: "Users retrieved failed: boom"},
+		{name: "ok", users: []entity.ResponseUser{{ID: 1, Username: "admin", Role: "admin"}, {ID: 2, Username: "siti", Role: "cashier"}}, wantStatus: http.StatusOK, wantCode: strconv.Itoa(constants.SuccessCode), wantMsg: "Users retrieved successfully", wantLen: 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := NewUserHandler(&mockUserService{
+				getAllUsersFn: func() ([]entity.ResponseUser, error) {
+					return tc.users, tc.svcErr
+				},
+			})
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users", nil)
+
+			h.GetAllUsers(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tc.wantStatus)
+			}
+			resp := decodeAPIResponse(t, rec)
+			if resp.Code != tc.wantCode {
+				t.Fatalf("code = %q, want %q", resp.Code, tc.wantCode)
+			}
+			assertMessage(t, resp, tc.wantMsg, false)
+			if tc.wantLen > 0 {
+				data, ok := resp.Data.([]any)
+				if !ok || len(data) != tc.wantLen {
+					t.Fatalf("unexpected data: %v", resp.Data)
+				}
+			}
+		})
+	}
+}
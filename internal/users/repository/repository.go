@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+)
+
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *entity.User) error
+	GetUserByUsername(ctx context.Context, username string) (*entity.User, error)
+	GetAllUsers(ctx context.Context) ([]entity.ResponseUser, error)
+	CountUsers(ctx context.Context) (int, error)
+}
+
+type userRepository struct {
+	db *database.DB
+}
+
+func NewUserRepository(db *database.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+// CreateUser inserts the user and sets its ID and timestamps. It returns a conflict error when the username is taken.
+func (r *userRepository) CreateUser(ctx context.Context, user *entity.User) error {
+	var (
+		query string
+		err   error
+	)
+
+	query = "INSERT INTO users (username, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at"
+
+	err = r.db.WithTxContext(ctx, func(tx *database.Tx) error {
+		err = tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+			return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+				return rows.Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+			}, user.Username, user.PasswordHash, user.Role, "now()", "now()")
+		})
+
+		if _, ok := database.UniqueViolation(err); ok {
+			return apperror.Conflict("username already taken")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+// GetUserByUsername returns the user including its password hash, for checking credentials.
+func (r *userRepository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	var (
+		query string
+		user  entity.User
+		err   error
+	)
+
+	query = "SELECT id, username, password_hash, role, created_at, updated_at FROM users WHERE username = $1"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+		}, username)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if user.ID == 0 {
+		return nil, apperror.NotFound("user not found")
+	}
+
+	return &user, nil
+}
+
+func (r *userRepository) GetAllUsers(ctx context.Context) ([]entity.ResponseUser, error) {
+	var (
+		query string
+		users []entity.ResponseUser
+		err   error
+	)
+
+	query = "SELECT id, username, role, created_at, updated_at FROM users ORDER BY id ASC"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			var user entity.User
+			if err := rows.Scan(&user.ID, &user.Username, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+				return err
+			}
+
+			users = append(users, ToResponseUser(user))
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *userRepository) CountUsers(ctx context.Context) (int, error) {
+	var (
+		query string
+		total int
+		err   error
+	)
+
+	query = "SELECT COUNT(*) FROM users"
+
+	err = r.db.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		return stmt.QueryContext(ctx, func(rows *database.Rows) error {
+			return rows.Scan(&total)
+		})
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// ToResponseUser converts a stored user to its API representation, leaving out the password hash.
+func ToResponseUser(user entity.User) entity.ResponseUser {
+	createdAt, _ := datetime.ParseTime(user.CreatedAt)
+	updatedAt, _ := datetime.ParseTime(user.UpdatedAt)
+
+	return entity.ResponseUser{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+)
+
+type testQuery struct {
+	columns  []string
+	rows     [][]driver.Value
+	queryErr error
+}
+
+type testConfig struct {
+	execErr   map[string]error
+	query     map[string]testQuery
+	commitErr error
+
+	mu        sync.Mutex
+	queryArgs map[string][]driver.Value
+	execArgs  map[string][]driver.Value
+	rolled    bool
+}
+
+func (c *testConfig) getExecErr(query string) error {
+	if c.execErr == nil {
+		return nil
+	}
+	return c.execErr[query]
+}
+
+func (c *testConfig) getQuery(query string) testQuery {
+	if c.query == nil {
+		return testQuery{}
+	}
+	return c.query[query]
+}
+
+func (c *testConfig) recordQuery(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.queryArgs == nil {
+		c.queryArgs = make(map[string][]driver.Value)
+	}
+	c.queryArgs[query] = append([]driver.Value(nil), args...)
+}
+
+func (c *testConfig) recordExec(query string, args []driver.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.execArgs == nil {
+		c.execArgs = make(map[string][]driver.Value)
+	}
+	c.execArgs[query] = append([]driver.Value(nil), args...)
+}
+
+type testDriver struct {
+	cfg *testConfig
+}
+
+func (d *testDriver) Open(name string) (driver.Conn, error) {
+	return &testConn{cfg: d.cfg}, nil
+}
+
+type testConn struct {
+	cfg *testConfig
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+
+func (c *testConn) Close() error { return nil }
+
+func (c *testConn) Begin() (driver.Tx, error) {
+	return &testTx{cfg: c.cfg}, nil
+}
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.cfg.getExecErr(s.query); err != nil {
+		return nil, err
+	}
+	s.cfg.recordExec(s.query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.cfg.recordQuery(s.query, args)
+	q := s.cfg.getQuery(s.query)
+	if q.queryErr != nil {
+		return nil, q.queryErr
+	}
+	return &testRows{columns: q.columns, values: q.rows}, nil
+}
+
+type testTx struct {
+	cfg *testConfig
+}
+
+func (t *testTx) Commit() error {
+	return t.cfg.commitErr
+}
+
+func (t *testTx) Rollback() error {
+	t.cfg.mu.Lock()
+	defer t.cfg.mu.Unlock()
+	t.cfg.rolled = true
+	return nil
+}
+
+type testRows struct {
+	columns []string
+	values  [][]driver.Value
+	idx     int
+}
+
+func (r *testRows) Columns() []string { return r.columns }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	row := r.values[r.idx]
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = row[i]
+		}
+	}
+	r.idx++
+	return nil
+}
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("user_repo_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+const (
+	insertQuery     = "INSERT INTO users (username, password_hash, role, created_at, updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at, updated_at"
+	byUsernameQuery = "SELECT id, username, password_hash, role, created_at, updated_at FROM users WHERE username = $1"
+	listQuery       = "SELECT id, username, role, created_at, updated_at FROM users ORDER BY id ASC"
+	countQuery      = "SELECT COUNT(*) FROM users"
+)
+
+func TestNewUserRepository(t *testing.T) {
+	db := newTestDB(t, &testConfig{})
+	repo := NewUserRepository(db)
+	if repo == nil {
+		t.Fatalf("expected repository")
+	}
+	r, ok := repo.(*userRepository)
+	if !ok {
+		t.Fatalf("expected userRepository")
+	}
+	if r.db != db {
+		t.Fatalf("expected db to match")
+	}
+}
+
+func TestUserRepositoryCreateUser(t *testing.T) {
+	errQuery := errors.New("query")
+	errCommit := errors.New("commit")
+	inserted := map[string]testQuery{insertQuery: {columns: []string{"id", "created_at", "updated_at"}, rows: [][]driver.Value{{int64(3), "2023-01-02T03:04:05Z", "2023-01-02T03:04:05Z"}}}}
+
+	tests := []struct {
+		name         string
+		cfg          *testConfig
+		wantErr      error
+		wantRollback bool
+	}{
+		{name: "ok", cfg: &testConfig{query: inserted}},
+		{name: "query", cfg: &testConfig{query: map[string]testQuery{insertQuery: {queryErr: errQuery}}}, wantErr: errQuery, wantRollback: true},
+		{name: "taken", cfg: &testConfig{query: map[string]testQuery{insertQuery: {queryErr: &pq.Error{Code: "23505", Constraint: "users_username_key"}}}}, wantErr: apperror.ErrConflict, wantRollback: true},
+		{name: "commit", cfg: &testConfig{query: inserted, commitErr: errCommit}, wantErr: errCommit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewUserRepository(db)
+			user := &entity.User{Username: "siti", PasswordHash: "hash", Role: "cashier"}
+			err := repo.CreateUser(context.Background(), user)
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				if tt.wantRollback && !tt.cfg.rolled {
+					t.Fatalf("expected rollback")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if user.ID != 3 || user.CreatedAt != "2023-01-02T03:04:05Z" {
+				t.Fatalf("unexpected user: %+v", user)
+			}
+			wantArgs := []driver.Value{"siti", "hash", "cashier", "now()", "now()"}
+			if args := tt.cfg.queryArgs[insertQuery]; !reflect.DeepEqual(args, wantArgs) {
+				t.Fatalf("unexpected args: %v", args)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryGetUserByUsername(t *testing.T) {
+	errQuery := errors.New("query")
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr string
+		want    *entity.User
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{byUsernameQuery: {
+				columns: []string{"id", "username", "password_hash", "role", "created_at", "updated_at"},
+				rows:    [][]driver.Value{{int64(3), "siti", "hash", "cashier", "2023-01-02T03:04:05Z", "2023-01-03T03:04:05Z"}},
+			}}},
+			want: &entity.User{ID: 3, Username: "siti", PasswordHash: "hash", Role: "cashier", CreatedAt: "2023-01-02T03:04:05Z", UpdatedAt: "2023-01-03T03:04:05Z"},
+		},
+		{name: "missing", cfg: &testConfig{}, wantErr: "user not found"},
+		{name: "query", cfg: &testConfig{query: map[string]testQuery{byUsernameQuery: {queryErr: errQuery}}}, wantErr: errQuery.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewUserRepository(db)
+			got, err := repo.GetUserByUsername(context.Background(), "siti")
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected user: %+v", got)
+			}
+			if args := tt.cfg.queryArgs[byUsernameQuery]; !reflect.DeepEqual(args, []driver.Value{"siti"}) {
+				t.Fatalf("unexpected args: %v", args)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryGetAllUsers(t *testing.T) {
+	errQuery := errors.New("query")
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	createdAt, _ := time.Parse(time.RFC3339, "2023-01-02T03:04:05Z")
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		wantErr error
+		want    []entity.ResponseUser
+	}{
+		{
+			name: "ok",
+			cfg: &testConfig{query: map[string]testQuery{listQuery: {
+				columns: []string{"id", "username", "role", "created_at", "updated_at"},
+				rows: [][]driver.Value{
+					{int64(1), "admin", "admin", "2023-01-02T03:04:05Z", "2023-01-02T03:04:05Z"},
+					{int64(2), "siti", "cashier", "2023-01-02T03:04:05Z", "2023-01-02T03:04:05Z"},
+				},
+			}}},
+			want: []entity.ResponseUser{
+				{ID: 1, Username: "admin", Role: "admin", CreatedAt: createdAt.In(loc), UpdatedAt: createdAt.In(loc)},
+				{ID: 2, Username: "siti", Role: "cashier", CreatedAt: createdAt.In(loc), UpdatedAt: createdAt.In(loc)},
+			},
+		},
+		{name: "empty", cfg: &testConfig{}},
+		{name: "query", cfg: &testConfig{query: map[string]testQuery{listQuery: {queryErr: errQuery}}}, wantErr: errQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewUserRepository(db)
+			got, err := repo.GetAllUsers(context.Background())
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d users, got %d", len(tt.want), len(got))
+			}
+			for i := range got {
+				if !got[i].CreatedAt.Equal(tt.want[i].CreatedAt) || got[i].CreatedAt.Location().String() != loc.String() {
+					t.Fatalf("unexpected created at: %v", got[i].CreatedAt)
+				}
+				got[i].CreatedAt, got[i].UpdatedAt = tt.want[i].CreatedAt, tt.want[i].UpdatedAt
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected users: %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserRepositoryCountUsers(t *testing.T) {
+	errQuery := errors.New("query")
+
+	tests := []struct {
+		name    string
+		cfg     *testConfig
+		want    int
+		wantErr error
+	}{
+		{name: "ok", cfg: &testConfig{query: map[string]testQuery{countQuery: {columns: []string{"count"}, rows: [][]driver.Value{{int64(2)}}}}}, want: 2},
+		{name: "query", cfg: &testConfig{query: map[string]testQuery{countQuery: {queryErr: errQuery}}}, wantErr: errQuery},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t, tt.cfg)
+			repo := NewUserRepository(db)
+			got, err := repo.CountUsers(context.Background())
+			if tt.wantErr != nil {
+				if err == nil || !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected nil error, got %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("count = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
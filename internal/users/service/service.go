@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+)
+
+// MinPasswordLength is the shortest password accepted for a new user.
+const MinPasswordLength = 8
+
+// MaxPasswordBytes is the longest password accepted for a new user, in bytes, which is as much as bcrypt hashes.
+const MaxPasswordBytes = 72
+
+// ErrInvalidCredentials is returned by Login for an unknown username as well as a wrong password, so callers cannot
+// probe which usernames exist.
+var ErrInvalidCredentials = apperror.Unauthorized("invalid username or password")
+
+type userService struct {
+	userRepository repository.UserRepository
+	tokens         *auth.TokenManager
+	ready          func(ctx context.Context) error
+}
+
+type UserService interface {
+	Login(ctx context.Context, request *entity.RequestLogin) (*entity.ResponseLogin, error)
+	CreateUser(ctx context.Context, request *entity.RequestUser) (*entity.ResponseUser, error)
+	GetAllUsers(ctx context.Context) ([]entity.ResponseUser, error)
+	EnsureAdmin(ctx context.Context, username, password string) (bool, error)
+	API(ctx context.Context) entity.HealthCheck
+}
+
+// NewUserService returns a UserService. ready runs the readiness checks whose result API reports.
+func NewUserService(userRepository repository.UserRepository, tokens *auth.TokenManager, ready func(ctx context.Context) error) UserService {
+	return &userService{userRepository: userRepository, tokens: tokens, ready: ready}
+}
+
+// API reports the Users API healthy when the readiness checks of its dependencies pass.
+func (s *userService) API(ctx context.Context) entity.HealthCheck {
+	return entity.HealthCheck{
+		Name:      "Users API",
+		IsHealthy: s.ready(ctx) == nil,
+	}
+}
+
+func (s *userService) Login(ctx context.Context, request *entity.RequestLogin) (*entity.ResponseLogin, error) {
+	user, err := s.userRepository.GetUserByUsername(ctx, strings.TrimSpace(request.Username))
+	if errors.Is(err, apperror.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.CheckPassword(user.PasswordHash, request.Password); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	token, expiresAt, err := s.tokens.Issue(user.ID, user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.ResponseLogin{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		User:        repository.ToResponseUser(*user),
+	}, nil
+}
+
+func (s *userService) CreateUser(ctx context.Context, request *entity.RequestUser) (*entity.ResponseUser, error) {
+	username := strings.TrimSpace(request.Username)
+	role := strings.TrimSpace(request.Role)
+
+	if username == "" {
+		return nil, apperror.Validation("username is required")
+	}
+
+	if len(request.Password) < MinPasswordLength {
+		return nil, apperror.Validation("password must be at least %d characters", MinPasswordLength)
+	}
+
+	if len(request.Password) > MaxPasswordBytes {
+		return nil, apperror.Validation("password must be at most %d bytes", MaxPasswordBytes)
+	}
+
+	if !auth.ValidRole(role) {
+		return nil, apperror.Validation("invalid role: %q, expected admin or cashier", role)
+	}
+
+	_, err := s.userRepository.GetUserByUsername(ctx, username)
+	if err == nil {
+		return nil, apperror.Conflict("username already taken")
+	}
+
+	if !errors.Is(err, apperror.ErrNotFound) {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &entity.User{
+		Username:     username,
+		PasswordHash: hash,
+		Role:         role,
+	}
+
+	if err = s.userRepository.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	response := repository.ToResponseUser(*user)
+	return &response, nil
+}
+
+func (s *userService) GetAllUsers(ctx context.Context) ([]entity.ResponseUser, error) {
+	users, err := s.userRepository.GetAllUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if users == nil {
+		users = []entity.ResponseUser{}
+	}
+
+	return users, nil
+}
+
+// EnsureAdmin creates an admin with the given credentials when there are no users yet, so a fresh install can log
+// in. It reports whether a user was created; an empty username disables it.
+func (s *userService) EnsureAdmin(ctx context.Context, username, password string) (bool, error) {
+	if strings.TrimSpace(username) == "" {
+		return false, nil
+	}
+
+	total, err := s.userRepository.CountUsers(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if total > 0 {
+		return false, nil
+	}
+
+	_, err = s.CreateUser(ctx, &entity.RequestUser{Username: username, Password: password, Role: auth.RoleAdmin})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/entity"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/internal/users/repository"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+)
+
+type mockUserRepository struct {
+	createUserFn        func(user *entity.User) error
+	getUserByUsernameFn func(username string) (*entity.User, error)
+	getAllUsersFn       func() ([]entity.ResponseUser, error)
+	countUsersFn        func() (int, error)
+
+	createUserArg *entity.User
+	createCalls   int
+	usernameArg   string
+}
+
+func (m *mockUserRepository) CreateUser(ctx context.Context, user *entity.User) error {
+	m.createCalls++
+	m.createUserArg = user
+	if m.createUserFn == nil {
+		return nil
+	}
+	return m.createUserFn(user)
+}
+
+func (m *mockUserRepository) GetUserByUsername(ctx context.Context, username string) (*entity.User, error) {
+	m.usernameArg = username
+	if m.getUserByUsernameFn == nil {
+		return nil, apperror.NotFound("user not found")
+	}
+	return m.getUserByUsernameFn(username)
+}
+
+func (m *mockUserRepository) GetAllUsers(ctx context.Context) ([]entity.ResponseUser, error) {
+	if m.getAllUsersFn == nil {
+		return nil, nil
+	}
+	return m.getAllUsersFn()
+}
+
+func (m *mockUserRepository) CountUsers(ctx context.Context) (int, error) {
+	if m.countUsersFn == nil {
+		return 0, nil
+	}
+	return m.countUsersFn()
+}
+
+var _ repository.UserRepository = (*mockUserRepository)(nil)
+
+func newTestTokens(t *testing.T) *auth.TokenManager {
+	t.Helper()
+	tokens, err := auth.NewTokenManager("test-secret", time.Hour)
+	if err != nil {
+		t.Fatalf("new token manager: %v", err)
+	}
+	return tokens
+}
+
+func TestNewUserService(t *testing.T) {
+	repo := &mockUserRepository{}
+	tokens := newTestTokens(t)
+	svc := NewUserService(repo, tokens, func(context.Context) error { return nil })
+	us, ok := svc.(*userService)
+	if !ok {
+		t.Fatalf("expected *userService, got %T", svc)
+	}
+	if us.userRepository != repo || us.tokens != tokens || us.ready == nil {
+		t.Fatal("dependencies not set")
+	}
+}
+
+func TestUserService_API(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "ready", want: true},
+		{name: "not-ready", err: errors.New("database: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &userService{ready: func(context.Context) error { return tt.err }}
+			got := svc.API(context.Background())
+			if got.Name != "Users API" || got.IsHealthy != tt.want {
+				t.Fatalf("unexpected healthcheck: %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserService_Login(t *testing.T) {
+	hash, err := auth.HashPassword("rahasia123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	dbErr := errors.New("db down")
+	stored := &entity.User{ID: 3, Username: "siti", PasswordHash: hash, Role: auth.RoleCashier, CreatedAt: "2023-01-02T03:04:05Z", UpdatedAt: "2023-01-02T03:04:05Z"}
+
+	tests := []struct {
+		name     string
+		req      *entity.RequestLogin
+		repoErr  error
+		wantErr  error
+		wantUser string
+	}{
+		{name: "ok", req: &entity.RequestLogin{Username: " siti ", Password: "rahasia123"}, wantUser: "siti"},
+		{name: "unknown-user", req: &entity.RequestLogin{Username: "budi", Password: "rahasia123"}, repoErr: apperror.NotFound("user not found"), wantErr: ErrInvalidCredentials},
+		{name: "lookup-err", req: &entity.RequestLogin{Username: "siti", Password: "rahasia123"}, repoErr: dbErr, wantErr: dbErr},
+		{name: "wrong-password", req: &entity.RequestLogin{Username: "siti", Password: "salah"}, wantErr: ErrInvalidCredentials},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockUserRepository{
+				getUserByUsernameFn: func(username string) (*entity.User, error) {
+					if tt.repoErr != nil {
+						return nil, tt.repoErr
+					}
+					return stored, nil
+				},
+			}
+			tokens := newTestTokens(t)
+			svc := &userService{userRepository: repo, tokens: tokens}
+			got, err := svc.Login(context.Background(), tt.req)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if repo.usernameArg != "siti" {
+				t.Fatalf("username = %q, want trimmed", repo.usernameArg)
+			}
+			if got.TokenType != "Bearer" || got.User.ID != 3 || got.User.Username != tt.wantUser || got.User.Role != auth.RoleCashier {
+				t.Fatalf("unexpected login: %+v", got)
+			}
+			claims, err := tokens.Parse(got.AccessToken)
+			if err != nil {
+				t.Fatalf("parse token: %v", err)
+			}
+			if claims.UserID != 3 || claims.Username != "siti" || claims.Role != auth.RoleCashier {
+				t.Fatalf("unexpected claims: %+v", claims)
+			}
+			if !claims.ExpiresAt.Time.Equal(got.ExpiresAt.Truncate(time.Second)) {
+				t.Fatalf("expires at = %v, claims %v", got.ExpiresAt, claims.ExpiresAt.Time)
+			}
+		})
+	}
+}
+
+func TestUserService_CreateUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       *entity.RequestUser
+		existing  bool
+		lookupErr error
+		repoErr   error
+		wantErr   string
+		wantCalls int
+	}{
+		{name: "no-username", req: &entity.RequestUser{Username: "  ", Password: "rahasia123", Role: "cashier"}, wantErr: "username is required"},
+		{name: "short-password", req: &entity.RequestUser{Username: "siti", Password: "1234567", Role: "cashier"}, wantErr: "password must be at least 8 characters"},
+		{name: "long-password", req: &entity.RequestUser{Username: "siti", Password: strings.Repeat("a", 73), Role: "cashier"}, wantErr: "password must be at most 72 bytes"},
+		{name: "bad-role", req: &entity.RequestUser{Username: "siti", Password: "rahasia123", Role: "owner"}, wantErr: `invalid role: "owner", expected admin or cashier`},
+		{name: "taken", req: &entity.RequestUser{Username: "siti", Password: "rahasia123", Role: "cashier"}, existing: true, wantErr: "username already taken"},
+		{name: "lookup-err", req: &entity.RequestUser{Username: "siti", Password: "rahasia123", Role: "cashier"}, lookupErr: errors.New("db down"), wantErr: "db down"},
+		{name: "repo-err", req: &entity.RequestUser{Username: "siti", Password: "rahasia123", Role: "cashier"}, repoErr: errors.New("db down"), wantErr: "db down", wantCalls: 1},
+		{name: "ok", req: &entity.RequestUser{Username: " siti ", Password: "rahasia123", Role: " cashier "}, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockUserRepository{
+				createUserFn: func(user *entity.User) error {
+					user.ID = 5
+					return tt.repoErr
+				},
+			}
+			if tt.existing {
+				repo.getUserByUsernameFn = func(username string) (*entity.User, error) {
+					return &entity.User{ID: 1, Username: username}, nil
+				}
+			}
+			if tt.lookupErr != nil {
+				repo.getUserByUsernameFn = func(username string) (*entity.User, error) {
+					return nil, tt.lookupErr
+				}
+			}
+			svc := &userService{userRepository: repo}
+			got, err := svc.CreateUser(context.Background(), tt.req)
+
+			if repo.createCalls != tt.wantCalls {
+				t.Fatalf("create calls = %d, want %d", repo.createCalls, tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			stored := repo.createUserArg
+			if stored.Username != "siti" || stored.Role != auth.RoleCashier {
+				t.Fatalf("unexpected stored user: %+v", stored)
+			}
+			if stored.PasswordHash == tt.req.Password || auth.CheckPassword(stored.PasswordHash, tt.req.Password) != nil {
+				t.Fatalf("password not hashed: %q", stored.PasswordHash)
+			}
+			if got.ID != 5 || got.Username != "siti" || got.Role != auth.RoleCashier {
+				t.Fatalf("unexpected user: %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserService_GetAllUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		users   []entity.ResponseUser
+		repoErr error
+		want    []entity.ResponseUser
+		wantErr string
+	}{
+		{name: "ok", users: []entity.ResponseUser{{ID: 1, Username: "admin"}}, want: []entity.ResponseUser{{ID: 1, Username: "admin"}}},
+		{name: "empty", want: []entity.ResponseUser{}},
+		{name: "err", repoErr: errors.New("boom"), wantErr: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockUserRepository{
+				getAllUsersFn: func() ([]entity.ResponseUser, error) {
+					return tt.users, tt.repoErr
+				},
+			}
+			svc := &userService{userRepository: repo}
+			got, err := svc.GetAllUsers(context.Background())
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("unexpected users: %+v", got)
+			}
+		})
+	}
+}
+
+func TestUserService_EnsureAdmin(t *testing.T) {
+	tests := []struct {
+		name        string
+		username    string
+		password    string
+		count       int
+		countErr    error
+		wantCreated bool
+		wantErr     string
+		wantCalls   int
+	}{
+		{name: "disabled", password: "rahasia123"},
+		{name: "has-users", username: "admin", password: "rahasia123", count: 2},
+		{name: "count-err", username: "admin", password: "rahasia123", countErr: errors.New("boom"), wantErr: "boom"},
+		{name: "weak-password", username: "admin", password: "admin", wantErr: "password must be at least 8 characters"},
+		{name: "created", username: "admin", password: "rahasia123", wantCreated: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockUserRepository{
+				countUsersFn: func() (int, error) {
+					return tt.count, tt.countErr
+				},
+			}
+			svc := &userService{userRepository: repo}
+			created, err := svc.EnsureAdmin(context.Background(), tt.username, tt.password)
+
+			if repo.createCalls != tt.wantCalls {
+				t.Fatalf("create calls = %d, want %d", repo.createCalls, tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if created != tt.wantCreated {
+				t.Fatalf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if created && repo.createUserArg.Role != auth.RoleAdmin {
+				t.Fatalf("expected an admin, got %+v", repo.createUserArg)
+			}
+		})
+	}
+}
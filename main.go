@@ -3,11 +3,16 @@ package main
 import (
 	"fmt"
 	"log"
+	"log/slog"
+	"os"
 
 	"github.com/common-nighthawk/go-figure"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/api"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/migrations"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/config"
 	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/logging"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/migration"
 	"github.com/spf13/viper"
 )
 
@@ -15,8 +20,29 @@ import (
 // @version 1.0
 // @BasePath /
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the token returned by POST /api/auth/login.
+
 func main() {
 	config.InitConfig()
+	logger := logging.New(os.Stdout, viper.GetString("LOG_LEVEL"))
+	slog.SetDefault(logger)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db, err := database.InitDatabase()
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+
+		err = runMigrate(db, os.Args[2:])
+		_ = db.Close()
+		if err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+		return
+	}
 
 	myFigure := figure.NewColorFigure("Kasir API", "", "green", true)
 	myFigure.Print()
@@ -29,13 +55,22 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer func(db *database.DB) {
-		err := db.Close()
+	db.Logger = logger
+
+	if viper.GetBool("DATABASE_AUTO_MIGRATE") {
+		migrator, err := migration.NewMigrator(db, migrations.FS)
 		if err != nil {
-			log.Fatalf("Failed to close database: %v", err)
+			_ = db.Close()
+			log.Fatalf("Failed to load migrations: %v", err)
 		}
-	}(db)
 
+		if err := migrateUp(migrator); err != nil {
+			_ = db.Close()
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	}
+
+	// The server closes db once it has drained in-flight requests on shutdown.
 	server := api.NewAPIServer(fmt.Sprintf(":%s", port), db)
 	if err := server.Run(); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
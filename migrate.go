@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/migrations"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/migration"
+)
+
+const migrateUsage = "usage: kasir migrate up|down|status"
+
+// runMigrate runs the `kasir migrate` subcommand: up applies every pending migration, down reverts the latest applied
+// one and status lists every migration with whether it is applied.
+func runMigrate(db *database.DB, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migration.NewMigrator(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		return migrateUp(migrator)
+	case "down":
+		reverted, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+
+		if reverted == nil {
+			log.Println("No migration to revert")
+			return nil
+		}
+
+		log.Printf("Reverted migration %04d_%s", reverted.Version, reverted.Name)
+		return nil
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, %s", args[0], migrateUsage)
+	}
+}
+
+// migrateUp applies every pending migration and logs each one applied.
+func migrateUp(migrator *migration.Migrator) error {
+	applied, err := migrator.Up()
+	for _, m := range applied {
+		log.Printf("Applied migration %04d_%s", m.Version, m.Name)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(applied) == 0 {
+		log.Println("Database schema is up to date")
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id          BIGSERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS products (
+    id            BIGSERIAL PRIMARY KEY,
+    name          VARCHAR(255) NOT NULL,
+    sku           VARCHAR(64) NOT NULL UNIQUE,
+    barcode       VARCHAR(13) UNIQUE,
+    price         BIGINT NOT NULL CHECK (price >= 0),
+    stock         INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    reorder_level INTEGER NOT NULL DEFAULT 0 CHECK (reorder_level >= 0),
+    category_id   BIGINT NOT NULL REFERENCES categories (id),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS products_category_id_idx ON products (category_id);
+
+-- Databases set up before the migrations existed already have both tables, without the columns added to products
+-- since, so CREATE TABLE IF NOT EXISTS skips them. Existing products get a SKU made from their ID.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+UPDATE products SET sku = 'SKU-' || id WHERE sku IS NULL;
+ALTER TABLE products ALTER COLUMN sku SET NOT NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS barcode VARCHAR(13);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_level INTEGER NOT NULL DEFAULT 0 CHECK (reorder_level >= 0);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'products_sku_key') THEN
+        ALTER TABLE products ADD CONSTRAINT products_sku_key UNIQUE (sku);
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'products_barcode_key') THEN
+        ALTER TABLE products ADD CONSTRAINT products_barcode_key UNIQUE (barcode);
+    END IF;
+END $$;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS transactions (
+    id           BIGSERIAL PRIMARY KEY,
+    total_amount BIGINT NOT NULL CHECK (total_amount >= 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS transactions_created_at_idx ON transactions (created_at);
+
+CREATE TABLE IF NOT EXISTS transaction_details (
+    id             BIGSERIAL PRIMARY KEY,
+    transaction_id BIGINT NOT NULL REFERENCES transactions (id) ON DELETE CASCADE,
+    product_id     BIGINT NOT NULL REFERENCES products (id),
+    quantity       INTEGER NOT NULL CHECK (quantity > 0),
+    price          BIGINT NOT NULL CHECK (price >= 0),
+    subtotal       BIGINT NOT NULL CHECK (subtotal >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS transaction_details_transaction_id_idx ON transaction_details (transaction_id);
+CREATE INDEX IF NOT EXISTS transaction_details_product_id_idx ON transaction_details (product_id);
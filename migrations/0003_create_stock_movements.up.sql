@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id           BIGSERIAL PRIMARY KEY,
+    product_id   BIGINT NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    type         VARCHAR(32) NOT NULL CHECK (type IN ('sale', 'restock', 'adjustment', 'return', 'write_off')),
+    quantity     INTEGER NOT NULL,
+    stock_after  INTEGER NOT NULL,
+    reason       TEXT NOT NULL DEFAULT '',
+    reference_id BIGINT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_product_id_created_at_idx ON stock_movements (product_id, created_at DESC, id DESC);
+
+-- Products that already have stock get an opening balance, so their stock equals the sum of their movements.
+INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason, created_at)
+SELECT id, 'restock', stock, stock, 'opening balance', now()
+FROM products
+WHERE stock > 0
+  AND NOT EXISTS (SELECT 1 FROM stock_movements WHERE stock_movements.product_id = products.id);
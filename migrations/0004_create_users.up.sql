@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS users (
+    id            BIGSERIAL PRIMARY KEY,
+    username      VARCHAR(255) NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    role          VARCHAR(16) NOT NULL CHECK (role IN ('admin', 'cashier')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
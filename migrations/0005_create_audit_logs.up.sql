@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id             BIGSERIAL PRIMARY KEY,
+    actor_id       BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    actor_username VARCHAR(255) NOT NULL,
+    action         VARCHAR(16) NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    entity_type    VARCHAR(32) NOT NULL,
+    entity_id      BIGINT NOT NULL,
+    before         JSONB,
+    after          JSONB,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_entity_idx ON audit_logs (entity_type, entity_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS audit_logs_created_at_idx ON audit_logs (created_at DESC);
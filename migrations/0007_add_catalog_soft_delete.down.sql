@@ -0,0 +1,11 @@
+UPDATE audit_logs SET action = 'update' WHERE action = 'restore';
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_action_check;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_action_check CHECK (action IN ('create', 'update', 'delete'));
+
+DROP INDEX IF EXISTS products_barcode_active_idx;
+DROP INDEX IF EXISTS products_sku_active_idx;
+ALTER TABLE products ADD CONSTRAINT products_sku_key UNIQUE (sku);
+ALTER TABLE products ADD CONSTRAINT products_barcode_key UNIQUE (barcode);
+
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_at;
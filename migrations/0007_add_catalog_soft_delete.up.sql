@@ -0,0 +1,11 @@
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- A deleted product keeps its SKU and barcode, so they only have to be unique among the products that are not deleted.
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_sku_key;
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_barcode_key;
+CREATE UNIQUE INDEX IF NOT EXISTS products_sku_active_idx ON products (sku) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS products_barcode_active_idx ON products (barcode) WHERE deleted_at IS NULL;
+
+ALTER TABLE audit_logs DROP CONSTRAINT IF EXISTS audit_logs_action_check;
+ALTER TABLE audit_logs ADD CONSTRAINT audit_logs_action_check CHECK (action IN ('create', 'update', 'delete', 'restore'));
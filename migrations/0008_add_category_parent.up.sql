@@ -0,0 +1,5 @@
+-- A category can be placed under another one, e.g. "Susu" under "Minuman" under "Makanan & Minuman".
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id BIGINT REFERENCES categories (id);
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_id_check;
+ALTER TABLE categories ADD CONSTRAINT categories_parent_id_check CHECK (parent_id <> id);
+CREATE INDEX IF NOT EXISTS categories_parent_id_idx ON categories (parent_id);
@@ -0,0 +1,8 @@
+// Package migrations embeds the versioned SQL schema migrations. Every version has a NNNN_name.up.sql file that
+// applies it and a NNNN_name.down.sql file that reverts it; they are applied in version order by pkg/migration.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,159 @@
+// Package alert notifies the purchasing team when a product drops to or below its reorder level.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/logging"
+)
+
+// Sources of a low stock event.
+const (
+	SourceCheckout      = "checkout"
+	SourceAdjustment    = "adjustment"
+	SourceProductUpdate = "product_update"
+)
+
+// LowStock is raised when a stock change moves a product from above its reorder level to at or below it.
+type LowStock struct {
+	ProductID    int64  `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	Stock        int    `json:"stock"`
+	ReorderLevel int    `json:"reorder_level"`
+	Source       string `json:"source"`
+}
+
+// Crossed reports whether a change from before to after takes the stock from above reorderLevel to at or below it.
+// Products that were already low do not raise a new event on every sale.
+func Crossed(before, after, reorderLevel int) bool {
+	return before > reorderLevel && after <= reorderLevel
+}
+
+// Notifier sends low stock events. ctx is the context of the request that changed the stock, its request ID is logged
+// with the event.
+type Notifier interface {
+	NotifyLowStock(ctx context.Context, event LowStock)
+	// Close waits until the events still being sent have been sent, or returns the error of ctx when it is done first.
+	Close(ctx context.Context) error
+}
+
+// NewNotifier returns a WebhookNotifier posting to webhookURL, or a LogNotifier when no URL is configured. Both log to
+// logger, or to slog.Default() when it is nil.
+func NewNotifier(webhookURL string, logger *slog.Logger) Notifier {
+	if webhookURL == "" {
+		return LogNotifier{Logger: logger}
+	}
+
+	return NewWebhookNotifier(webhookURL, logger)
+}
+
+// LogNotifier writes every event to the log.
+type LogNotifier struct {
+	Logger *slog.Logger
+}
+
+func (n LogNotifier) NotifyLowStock(ctx context.Context, event LowStock) {
+	loggerOrDefault(n.Logger).LogAttrs(ctx, slog.LevelWarn, "low stock", eventAttrs(ctx, event)...)
+}
+
+// Close returns at once, events are logged before NotifyLowStock returns.
+func (LogNotifier) Close(context.Context) error {
+	return nil
+}
+
+// WebhookNotifier posts every event as JSON to URL. Events are sent in the background so a slow or unavailable
+// receiver never holds up a checkout; failures are logged to Logger. Close waits for the events still being sent, so
+// none is lost on shutdown.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+	Logger *slog.Logger
+
+	pending sync.WaitGroup
+}
+
+func NewWebhookNotifier(url string, logger *slog.Logger) *WebhookNotifier {
+	return &WebhookNotifier{URL: url, Client: &http.Client{Timeout: 5 * time.Second}, Logger: logger}
+}
+
+func (n *WebhookNotifier) NotifyLowStock(ctx context.Context, event LowStock) {
+	// The event is sent after the response, so only the values of ctx are kept and not its cancellation.
+	ctx = context.WithoutCancel(ctx)
+
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+
+		if err := n.post(event); err != nil {
+			attrs := append(eventAttrs(ctx, event), slog.String("error", err.Error()))
+			loggerOrDefault(n.Logger).LogAttrs(ctx, slog.LevelError, "low stock webhook failed", attrs...)
+		}
+	}()
+}
+
+func (n *WebhookNotifier) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		n.pending.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *WebhookNotifier) post(event LowStock) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.Client.Post(n.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return nil
+}
+
+// eventAttrs returns the log attributes of event and of the request ID carried by ctx, if any.
+func eventAttrs(ctx context.Context, event LowStock) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.Int64("product_id", event.ProductID),
+		slog.String("product_name", event.ProductName),
+		slog.Int("stock", event.Stock),
+		slog.Int("reorder_level", event.ReorderLevel),
+		slog.String("source", event.Source),
+	}
+
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String(logging.RequestIDKey, requestID))
+	}
+
+	return attrs
+}
+
+func loggerOrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+
+	return logger
+}
@@ -0,0 +1,195 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/logging"
+)
+
+// logWriter passes every JSON record written to it to records, decoded.
+type logWriter chan map[string]any
+
+func (w logWriter) Write(p []byte) (int, error) {
+	var record map[string]any
+	if err := json.Unmarshal(p, &record); err != nil {
+		return 0, err
+	}
+	delete(record, "time")
+	w <- record
+	return len(p), nil
+}
+
+func TestCrossed(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after int
+		reorderLevel  int
+		want          bool
+	}{
+		{name: "drops-below", before: 10, after: 2, reorderLevel: 5, want: true},
+		{name: "drops-to", before: 6, after: 5, reorderLevel: 5, want: true},
+		{name: "stays-above", before: 10, after: 6, reorderLevel: 5},
+		{name: "already-low", before: 5, after: 3, reorderLevel: 5},
+		{name: "restock", before: 2, after: 12, reorderLevel: 5},
+		{name: "no-level", before: 1, after: 0, reorderLevel: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Crossed(tt.before, tt.after, tt.reorderLevel); got != tt.want {
+				t.Fatalf("Crossed(%d, %d, %d) = %v, want %v", tt.before, tt.after, tt.reorderLevel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	if n, ok := NewNotifier("", logger).(LogNotifier); !ok || n.Logger != logger {
+		t.Fatalf("expected LogNotifier with the logger without a webhook url")
+	}
+
+	n, ok := NewNotifier("http://example.com/hook", logger).(*WebhookNotifier)
+	if !ok {
+		t.Fatalf("expected WebhookNotifier with a webhook url")
+	}
+	if n.URL != "http://example.com/hook" || n.Client == nil || n.Logger != logger {
+		t.Fatalf("unexpected notifier: %+v", n)
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	records := make(logWriter, 1)
+	notifier := LogNotifier{Logger: slog.New(slog.NewJSONHandler(records, nil))}
+
+	notifier.NotifyLowStock(logging.NewContext(context.Background(), "req-1"), LowStock{ProductID: 7, ProductName: "Bebelac", Stock: 2, ReorderLevel: 5, Source: SourceCheckout})
+
+	want := map[string]any{
+		"level":         "WARN",
+		"msg":           "low stock",
+		"product_id":    float64(7),
+		"product_name":  "Bebelac",
+		"stock":         float64(2),
+		"reorder_level": float64(5),
+		"source":        "checkout",
+		"request_id":    "req-1",
+	}
+	if got := <-records; !reflect.DeepEqual(got, want) {
+		t.Fatalf("log = %v, want %v", got, want)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	event := LowStock{ProductID: 7, ProductName: "Bebelac", Stock: 2, ReorderLevel: 5, Source: SourceAdjustment}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantLog map[string]any
+	}{
+		{name: "ok", status: http.StatusNoContent},
+		{
+			name:   "rejected",
+			status: http.StatusBadGateway,
+			wantLog: map[string]any{
+				"level":         "ERROR",
+				"msg":           "low stock webhook failed",
+				"product_id":    float64(7),
+				"product_name":  "Bebelac",
+				"stock":         float64(2),
+				"reorder_level": float64(5),
+				"source":        "adjustment",
+				"request_id":    "req-1",
+				"error":         "unexpected status 502 Bad Gateway",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan LowStock, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var got LowStock
+				if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+					t.Errorf("unexpected request: %s %s", r.Method, r.Header.Get("Content-Type"))
+				}
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("decode body: %v", err)
+				}
+				w.WriteHeader(tt.status)
+				received <- got
+			}))
+			t.Cleanup(server.Close)
+
+			logged := make(logWriter, 1)
+			ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), "req-1"))
+			NewWebhookNotifier(server.URL, slog.New(slog.NewJSONHandler(logged, nil))).NotifyLowStock(ctx, event)
+			// The request may finish before the event is sent.
+			cancel()
+
+			select {
+			case got := <-received:
+				if !reflect.DeepEqual(got, event) {
+					t.Fatalf("payload = %+v, want %+v", got, event)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("webhook not called")
+			}
+
+			if tt.wantLog == nil {
+				return
+			}
+			select {
+			case got := <-logged:
+				if !reflect.DeepEqual(got, tt.wantLog) {
+					t.Fatalf("log = %v, want %v", got, tt.wantLog)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("failure not logged")
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierClose(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	notifier := NewWebhookNotifier(server.URL, slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil)))
+	if err := notifier.Close(context.Background()); err != nil {
+		t.Fatalf("close without pending events: %v", err)
+	}
+
+	notifier.NotifyLowStock(context.Background(), LowStock{ProductID: 7})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := notifier.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("close while sending = %v, want deadline exceeded", err)
+	}
+
+	close(release)
+	if err := notifier.Close(context.Background()); err != nil {
+		t.Fatalf("close after sending: %v", err)
+	}
+}
+
+func TestLogNotifierClose(t *testing.T) {
+	if err := (LogNotifier{}).Close(context.Background()); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
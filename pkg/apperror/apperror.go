@@ -0,0 +1,133 @@
+// Package apperror classifies the errors returned by services, so the delivery layer can answer each kind with its
+// own HTTP status and response code without matching on error messages.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+)
+
+// Kinds of errors. Every error built by this package wraps one of them, so callers test the kind with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrValidation   = errors.New("validation failed")
+	ErrConflict     = errors.New("conflict")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrPrecondition = errors.New("precondition failed")
+)
+
+// Error is an error of a known kind. Its message is shown to the client as is.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+}
+
+// FieldError tells which input of a request is wrong, so a client can point the user at it.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the kind, so errors.Is(err, ErrNotFound) holds for a not found error.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func newError(kind error, format string, args ...any) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// NotFound returns an error for a resource that does not exist, such as the product of GET /products/{id}.
+func NotFound(format string, args ...any) error {
+	return newError(ErrNotFound, format, args...)
+}
+
+// Validation returns an error for a request that is invalid on its own, such as a missing field or an unknown
+// category referenced by the body.
+func Validation(format string, args ...any) error {
+	return newError(ErrValidation, format, args...)
+}
+
+// Invalid returns a validation error for the given field errors. Its message joins the messages of the fields.
+func Invalid(fields ...FieldError) error {
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field.Message
+	}
+
+	return &Error{Kind: ErrValidation, Message: strings.Join(messages, "; "), Fields: fields}
+}
+
+// Fields returns the field errors carried by err, or nil when it has none.
+func Fields(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+
+	return nil
+}
+
+// Conflict returns an error for a valid request that clashes with the current state, such as a duplicate SKU or a
+// checkout for more than the stock on hand.
+func Conflict(format string, args ...any) error {
+	return newError(ErrConflict, format, args...)
+}
+
+// Unauthorized returns an error for credentials that are missing or wrong.
+func Unauthorized(format string, args ...any) error {
+	return newError(ErrUnauthorized, format, args...)
+}
+
+// PreconditionFailed returns an error for a conditional request whose condition no longer holds, such as an
+// If-Match header naming a version of a product that has been changed since.
+func PreconditionFailed(format string, args ...any) error {
+	return newError(ErrPrecondition, format, args...)
+}
+
+// Status returns the HTTP status code for err: 404, 400, 409, 401 or 412 for the kinds of this package and 500 for any
+// other error.
+func Status(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrPrecondition):
+		return http.StatusPreconditionFailed
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Code returns the response code for err, see the error codes in package constants. Errors of no known kind get
+// constants.ErrorCode.
+func Code(err error) int {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return constants.NotFoundErrorCode
+	case errors.Is(err, ErrValidation):
+		return constants.ValidationErrorCode
+	case errors.Is(err, ErrConflict):
+		return constants.ConflictErrorCode
+	case errors.Is(err, ErrUnauthorized):
+		return constants.UnauthorizedErrorCode
+	case errors.Is(err, ErrPrecondition):
+		return constants.PreconditionFailedErrorCode
+	default:
+		return constants.ErrorCode
+	}
+}
@@ -0,0 +1,68 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	constants "github.com/pandusatrianura/code-with-umam-second-meeting/constant"
+)
+
+func TestKinds(t *testing.T) {
+	cases := []struct {
+		name       string
+		err        error
+		wantKind   error
+		wantMsg    string
+		wantStatus int
+		wantCode   int
+	}{
+		{name: "not-found", err: NotFound("product %d not found", 7), wantKind: ErrNotFound, wantMsg: "product 7 not found", wantStatus: http.StatusNotFound, wantCode: constants.NotFoundErrorCode},
+		{name: "validation", err: Validation("sku is required"), wantKind: ErrValidation, wantMsg: "sku is required", wantStatus: http.StatusBadRequest, wantCode: constants.ValidationErrorCode},
+		{name: "conflict", err: Conflict("%s already used by another product", "sku"), wantKind: ErrConflict, wantMsg: "sku already used by another product", wantStatus: http.StatusConflict, wantCode: constants.ConflictErrorCode},
+		{name: "unauthorized", err: Unauthorized("invalid username or password"), wantKind: ErrUnauthorized, wantMsg: "invalid username or password", wantStatus: http.StatusUnauthorized, wantCode: constants.UnauthorizedErrorCode},
+		{name: "precondition", err: PreconditionFailed("product has been changed"), wantKind: ErrPrecondition, wantMsg: "product has been changed", wantStatus: http.StatusPreconditionFailed, wantCode: constants.PreconditionFailedErrorCode},
+		{name: "wrapped", err: fmt.Errorf("checkout: %w", Conflict("insufficient stock")), wantKind: ErrConflict, wantMsg: "checkout: insufficient stock", wantStatus: http.StatusConflict, wantCode: constants.ConflictErrorCode},
+		{name: "unknown", err: errors.New("db down"), wantMsg: "db down", wantStatus: http.StatusInternalServerError, wantCode: constants.ErrorCode},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.err.Error() != tc.wantMsg {
+				t.Fatalf("message = %q, want %q", tc.err.Error(), tc.wantMsg)
+			}
+			if tc.wantKind != nil && !errors.Is(tc.err, tc.wantKind) {
+				t.Fatalf("expected %v to be %v", tc.err, tc.wantKind)
+			}
+			if got := Status(tc.err); got != tc.wantStatus {
+				t.Fatalf("Status = %d, want %d", got, tc.wantStatus)
+			}
+			if got := Code(tc.err); got != tc.wantCode {
+				t.Fatalf("Code = %d, want %d", got, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	fields := []FieldError{{Field: "name", Message: "name is required"}, {Field: "stock", Message: "stock must not be negative"}}
+	err := fmt.Errorf("create product: %w", Invalid(fields...))
+
+	if err.Error() != "create product: name is required; stock must not be negative" {
+		t.Fatalf("unexpected message: %q", err.Error())
+	}
+	if Status(err) != http.StatusBadRequest || Code(err) != constants.ValidationErrorCode {
+		t.Fatalf("status/code = %d/%d, want %d/%d", Status(err), Code(err), http.StatusBadRequest, constants.ValidationErrorCode)
+	}
+	if got := Fields(err); !reflect.DeepEqual(got, fields) {
+		t.Fatalf("fields = %+v, want %+v", got, fields)
+	}
+	if got := Fields(Validation("sku is required")); got != nil {
+		t.Fatalf("expected no fields, got %+v", got)
+	}
+	if got := Fields(errors.New("db down")); got != nil {
+		t.Fatalf("expected no fields, got %+v", got)
+	}
+}
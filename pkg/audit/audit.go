@@ -0,0 +1,138 @@
+// Package audit records who created, updated, deleted or restored a catalog entity together with its state before
+// and after the change.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+)
+
+// Actions recorded in the audit log.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// Entity types recorded in the audit log.
+const (
+	EntityProduct  = "product"
+	EntityCategory = "category"
+)
+
+// EntityTypes lists every entity type, used to validate the entity filter of the audit log list.
+var EntityTypes = []string{EntityProduct, EntityCategory}
+
+// Actor is the user a change is attributed to. ID is 0 for changes that are not made by an authenticated user.
+type Actor struct {
+	ID       int64
+	Username string
+}
+
+// System is the actor of changes made without an authenticated user, such as startup tasks.
+var System = Actor{Username: "system"}
+
+// ActorFromContext returns the authenticated user stored in ctx by the auth middleware, or System when there is none.
+func ActorFromContext(ctx context.Context) Actor {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return System
+	}
+
+	return Actor{ID: claims.UserID, Username: claims.Username}
+}
+
+// Entry describes one change. Before is nil for creates and restores and After is nil for deletes; both are stored as
+// JSON.
+type Entry struct {
+	Actor      Actor
+	Action     string
+	EntityType string
+	EntityID   int64
+	Before     any
+	After      any
+}
+
+// Write inserts entry into audit_logs inside tx, so the entry is committed or rolled back together with the change it
+// describes. A nil entry is ignored.
+func Write(ctx context.Context, tx *database.Tx, entry *Entry) error {
+	var (
+		query  string
+		before interface{}
+		after  interface{}
+		err    error
+	)
+
+	if entry == nil {
+		return nil
+	}
+
+	if before, err = snapshot(entry.Before); err != nil {
+		return err
+	}
+
+	if after, err = snapshot(entry.After); err != nil {
+		return err
+	}
+
+	query = "INSERT INTO audit_logs (actor_id, actor_username, action, entity_type, entity_id, before, after, created_at) VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)"
+
+	return tx.WithStmtContext(ctx, query, func(stmt *database.Stmt) error {
+		_, err = stmt.ExecContext(ctx, entry.Actor.ID, entry.Actor.Username, entry.Action, entry.EntityType, entry.EntityID, before, after, "now()")
+		return err
+	})
+}
+
+// snapshot marshals v to a JSON string, or returns nil so the column is stored as NULL when there is no state.
+func snapshot(v any) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// ChangedFields returns the sorted top-level fields whose values differ between two JSON object snapshots. A missing
+// snapshot counts as an empty object, so every field of a create or delete is reported.
+func ChangedFields(before, after []byte) []string {
+	var (
+		beforeFields map[string]any
+		afterFields  map[string]any
+		changed      []string
+	)
+
+	if len(before) > 0 {
+		_ = json.Unmarshal(before, &beforeFields)
+	}
+
+	if len(after) > 0 {
+		_ = json.Unmarshal(after, &afterFields)
+	}
+
+	for field, value := range afterFields {
+		if previous, ok := beforeFields[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changed = append(changed, field)
+		}
+	}
+
+	for field := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
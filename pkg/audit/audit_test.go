@@ -0,0 +1,173 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/auth"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+)
+
+type testConfig struct {
+	execErr  error
+	execArgs map[string][]driver.Value
+}
+
+type testDriver struct{ cfg *testConfig }
+
+func (d *testDriver) Open(string) (driver.Conn, error) { return &testConn{cfg: d.cfg}, nil }
+
+type testConn struct{ cfg *testConfig }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return testTx{}, nil }
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.cfg.execErr != nil {
+		return nil, s.cfg.execErr
+	}
+	if s.cfg.execArgs == nil {
+		s.cfg.execArgs = make(map[string][]driver.Value)
+	}
+	s.cfg.execArgs[s.query] = append([]driver.Value(nil), args...)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query([]driver.Value) (driver.Rows, error) { return testRows{}, nil }
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+type testRows struct{}
+
+func (testRows) Columns() []string         { return nil }
+func (testRows) Close() error              { return nil }
+func (testRows) Next([]driver.Value) error { return io.EOF }
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("audit_test_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func TestActorFromContext(t *testing.T) {
+	if got := ActorFromContext(context.Background()); got != System {
+		t.Fatalf("actor = %+v, want %+v", got, System)
+	}
+
+	ctx := auth.NewContext(context.Background(), &auth.Claims{UserID: 2, Username: "siti", Role: auth.RoleAdmin})
+	if got := ActorFromContext(ctx); got != (Actor{ID: 2, Username: "siti"}) {
+		t.Fatalf("unexpected actor: %+v", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	query := "INSERT INTO audit_logs (actor_id, actor_username, action, entity_type, entity_id, before, after, created_at) VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8)"
+	errExec := errors.New("exec")
+	type state struct {
+		Name  string `json:"name"`
+		Price int    `json:"price"`
+	}
+
+	tests := []struct {
+		name     string
+		entry    *Entry
+		execErr  error
+		wantArgs []driver.Value
+		wantErr  bool
+		wantIs   error
+	}{
+		{name: "nil"},
+		{
+			name:     "create",
+			entry:    &Entry{Actor: Actor{ID: 2, Username: "siti"}, Action: ActionCreate, EntityType: EntityProduct, EntityID: 5, After: state{Name: "Bebelac", Price: 10}},
+			wantArgs: []driver.Value{int64(2), "siti", ActionCreate, EntityProduct, int64(5), nil, `{"name":"Bebelac","price":10}`, "now()"},
+		},
+		{
+			name:     "delete",
+			entry:    &Entry{Actor: System, Action: ActionDelete, EntityType: EntityCategory, EntityID: 3, Before: state{Name: "Susu"}},
+			wantArgs: []driver.Value{int64(0), "system", ActionDelete, EntityCategory, int64(3), `{"name":"Susu","price":0}`, nil, "now()"},
+		},
+		{name: "marshal", entry: &Entry{After: make(chan int)}, wantErr: true},
+		{name: "exec", entry: &Entry{Action: ActionUpdate}, execErr: errExec, wantErr: true, wantIs: errExec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{execErr: tt.execErr}
+			db := newTestDB(t, cfg)
+			err := db.WithTx(func(tx *database.Tx) error {
+				return Write(context.Background(), tx, tt.entry)
+			})
+
+			if tt.wantErr {
+				if err == nil || (tt.wantIs != nil && !errors.Is(err, tt.wantIs)) {
+					t.Fatalf("expected error %v, got %v", tt.wantIs, err)
+				}
+				if len(cfg.execArgs) != 0 {
+					t.Fatalf("expected no insert, got %v", cfg.execArgs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := cfg.execArgs[query]; !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestChangedFields(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []string
+	}{
+		{name: "update", before: `{"name":"Bebelac","price":10,"stock":2}`, after: `{"name":"Bebelac","price":12,"stock":3}`, want: []string{"price", "stock"}},
+		{name: "unchanged", before: `{"name":"Bebelac"}`, after: `{"name":"Bebelac"}`},
+		{name: "create", after: `{"name":"Susu","description":""}`, want: []string{"description", "name"}},
+		{name: "delete", before: `{"name":"Susu"}`, want: []string{"name"}},
+		{name: "removed-field", before: `{"name":"Susu","old":1}`, after: `{"name":"Susu"}`, want: []string{"old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChangedFields([]byte(tt.before), []byte(tt.after))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ChangedFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
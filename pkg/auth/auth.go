@@ -0,0 +1,62 @@
+// Package auth hashes user passwords and issues and verifies the signed tokens that identify a user and their role.
+package auth
+
+import (
+	"context"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Roles a user can have. Admins manage the catalog and users, cashiers sell and read.
+const (
+	RoleAdmin   = "admin"
+	RoleCashier = "cashier"
+)
+
+// Roles lists every valid role.
+var Roles = []string{RoleAdmin, RoleCashier}
+
+// ValidRole reports whether role is one of Roles.
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HashPassword returns the bcrypt hash of password.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// CheckPassword returns nil when password matches hash.
+func CheckPassword(hash, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the claims of the authenticated user.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// FromContext returns the claims stored by NewContext, if any.
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+// IsAdmin reports whether ctx carries the claims of an admin, for handlers open to every role that offer admins more.
+func IsAdmin(ctx context.Context) bool {
+	claims, ok := FromContext(ctx)
+	return ok && claims.Role == RoleAdmin
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidRole(t *testing.T) {
+	tests := []struct {
+		role string
+		want bool
+	}{
+		{role: RoleAdmin, want: true},
+		{role: RoleCashier, want: true},
+		{role: "Admin"},
+		{role: ""},
+		{role: "owner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			if got := ValidRole(tt.role); got != tt.want {
+				t.Fatalf("ValidRole(%q) = %v, want %v", tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("rahasia123")
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	if hash == "rahasia123" {
+		t.Fatalf("password stored in plain text")
+	}
+	if err := CheckPassword(hash, "rahasia123"); err != nil {
+		t.Fatalf("expected password to match, got %v", err)
+	}
+	if err := CheckPassword(hash, "rahasia124"); err == nil {
+		t.Fatalf("expected wrong password to fail")
+	}
+	if err := CheckPassword("not-a-hash", "rahasia123"); err == nil {
+		t.Fatalf("expected invalid hash to fail")
+	}
+
+	other, _ := HashPassword("rahasia123")
+	if other == hash {
+		t.Fatalf("expected a salted hash")
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatalf("expected no claims")
+	}
+
+	claims := &Claims{UserID: 1, Username: "admin", Role: RoleAdmin}
+	got, ok := FromContext(NewContext(context.Background(), claims))
+	if !ok || got != claims {
+		t.Fatalf("unexpected claims: %+v", got)
+	}
+}
+
+func TestIsAdmin(t *testing.T) {
+	if IsAdmin(context.Background()) {
+		t.Fatalf("expected no admin without claims")
+	}
+
+	if IsAdmin(NewContext(context.Background(), &Claims{Role: RoleCashier})) {
+		t.Fatalf("expected a cashier not to be an admin")
+	}
+
+	if !IsAdmin(NewContext(context.Background(), &Claims{Role: RoleAdmin})) {
+		t.Fatalf("expected an admin")
+	}
+}
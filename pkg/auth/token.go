@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultTokenTTL is how long a token stays valid when no TTL is configured.
+const DefaultTokenTTL = 12 * time.Hour
+
+// Claims identify the user a token was issued to.
+type Claims struct {
+	UserID   int64  `json:"uid"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager issues and verifies HS256 signed JWTs.
+type TokenManager struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewTokenManager returns a TokenManager signing with secret. A ttl of zero or less uses DefaultTokenTTL.
+func NewTokenManager(secret string, ttl time.Duration) (*TokenManager, error) {
+	if secret == "" {
+		return nil, errors.New("token secret is required")
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	return &TokenManager{secret: []byte(secret), ttl: ttl, now: time.Now}, nil
+}
+
+// Issue signs a token for the user and returns it with its expiry time.
+func (m *TokenManager) Issue(userID int64, username, role string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.ttl)
+
+	claims := Claims{
+		UserID:   userID,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.FormatInt(userID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// Parse verifies the signature and expiry of token and returns its claims.
+func (m *TokenManager) Parse(token string) (*Claims, error) {
+	claims := &Claims{}
+
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return m.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired(), jwt.WithTimeFunc(m.now))
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
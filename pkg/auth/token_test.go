@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestNewTokenManager(t *testing.T) {
+	if _, err := NewTokenManager("", time.Hour); err == nil || err.Error() != "token secret is required" {
+		t.Fatalf("expected missing secret error, got %v", err)
+	}
+
+	m, err := NewTokenManager("secret", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.ttl != DefaultTokenTTL {
+		t.Fatalf("ttl = %v, want %v", m.ttl, DefaultTokenTTL)
+	}
+
+	m, _ = NewTokenManager("secret", time.Minute)
+	if m.ttl != time.Minute {
+		t.Fatalf("ttl = %v, want %v", m.ttl, time.Minute)
+	}
+}
+
+func TestTokenManagerIssueParse(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	m, _ := NewTokenManager("secret", time.Hour)
+	m.now = func() time.Time { return now }
+
+	token, expiresAt, err := m.Issue(7, "budi", RoleCashier)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expires at = %v, want %v", expiresAt, now.Add(time.Hour))
+	}
+
+	claims, err := m.Parse(token)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if claims.UserID != 7 || claims.Username != "budi" || claims.Role != RoleCashier || claims.Subject != "7" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+
+	t.Run("expired", func(t *testing.T) {
+		m.now = func() time.Time { return now.Add(2 * time.Hour) }
+		t.Cleanup(func() { m.now = func() time.Time { return now } })
+		if _, err := m.Parse(token); err == nil {
+			t.Fatalf("expected expired token to fail")
+		}
+	})
+
+	t.Run("other-secret", func(t *testing.T) {
+		other, _ := NewTokenManager("other", time.Hour)
+		other.now = m.now
+		if _, err := other.Parse(token); err == nil {
+			t.Fatalf("expected token signed with another secret to fail")
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		parts := strings.Split(token, ".")
+		forged, _, _ := m.Issue(7, "budi", RoleAdmin)
+		parts[1] = strings.Split(forged, ".")[1]
+		if _, err := m.Parse(strings.Join(parts, ".")); err == nil {
+			t.Fatalf("expected tampered token to fail")
+		}
+	})
+
+	t.Run("none-alg", func(t *testing.T) {
+		unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, Claims{
+			UserID:           7,
+			Role:             RoleAdmin,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))},
+		}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		if _, err := m.Parse(unsigned); err == nil {
+			t.Fatalf("expected unsigned token to fail")
+		}
+	})
+
+	t.Run("no-expiry", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: 7, Role: RoleAdmin}).SignedString([]byte("secret"))
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		if _, err := m.Parse(token); err == nil {
+			t.Fatalf("expected token without expiry to fail")
+		}
+	})
+}
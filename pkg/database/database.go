@@ -1,10 +1,12 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/spf13/viper"
 )
 
@@ -17,6 +19,7 @@ func InitDatabase() (*DB, error) {
 	maxLifetimeConnection := viper.GetDuration("DATABASE_MAX_LIFETIME_CONNECTION")
 	maxIdleConnection := viper.GetInt("DATABASE_MAX_IDLE_CONNECTION")
 	maxOpenConnection := viper.GetInt("DATABASE_MAX_OPEN_CONNECTION")
+	queryTimeout := viper.GetDuration("DATABASE_QUERY_TIMEOUT")
 
 	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%d/%s", username, password, host, port, dbname)
 
@@ -33,8 +36,32 @@ func InitDatabase() (*DB, error) {
 	database.DB.SetMaxOpenConns(maxOpenConnection)
 	database.DB.SetMaxIdleConns(maxIdleConnection)
 	database.DB.SetConnMaxLifetime(maxLifetimeConnection)
+	database.QueryTimeout = queryTimeout
+
+	if viper.IsSet("DATABASE_LOGGING") {
+		database.Logging = viper.GetBool("DATABASE_LOGGING")
+	}
 
 	log.Println("Successfully connected to the database!")
 
 	return database, nil
 }
+
+// UniqueViolation reports whether err is a unique violation and returns the name of the constraint or index it
+// violates.
+func UniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint, true
+	}
+
+	return "", false
+}
+
+// likeEscaper escapes the LIKE wildcards and the escape character itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EscapeLike escapes s for use as the literal part of a LIKE or ILIKE pattern with ESCAPE '\'.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
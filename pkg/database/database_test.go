@@ -1,11 +1,14 @@
 package database
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"testing"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/spf13/viper"
 )
 
@@ -72,3 +75,47 @@ func TestInitDatabaseChild(t *testing.T) {
 	_, _ = InitDatabase()
 	t.Fatalf("expected fatal exit")
 }
+
+func TestUniqueViolation(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		wantConstraint string
+		wantOK         bool
+	}{
+		{name: "unique", err: &pq.Error{Code: "23505", Constraint: "users_username_key"}, wantConstraint: "users_username_key", wantOK: true},
+		{name: "wrapped", err: fmt.Errorf("insert: %w", &pq.Error{Code: "23505", Constraint: "products_sku_active_idx"}), wantConstraint: "products_sku_active_idx", wantOK: true},
+		{name: "other-code", err: &pq.Error{Code: "23503", Constraint: "products_category_id_fkey"}},
+		{name: "other-error", err: errors.New("query")},
+		{name: "nil"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint, ok := UniqueViolation(tt.err)
+			if constraint != tt.wantConstraint || ok != tt.wantOK {
+				t.Fatalf("expected (%q, %v), got (%q, %v)", tt.wantConstraint, tt.wantOK, constraint, ok)
+			}
+		})
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "bebe", want: "bebe"},
+		{input: "100%", want: `100\%`},
+		{input: "snack_box", want: `snack\_box`},
+		{input: `a\b`, want: `a\\b`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := EscapeLike(tt.input); got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
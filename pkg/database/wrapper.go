@@ -1,57 +1,120 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"reflect"
 	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/logging"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/metrics"
 )
 
 type DB struct {
 	*sql.DB
+	// Logging turns the query log on or off.
 	Logging bool
+	// Logger receives one record per statement with the query, request ID, duration, row count and error. Nil logs to
+	// slog.Default().
+	Logger *slog.Logger
+	// QueryTimeout bounds every query and exec run through a Stmt. Zero leaves only the caller's context deadline.
+	QueryTimeout time.Duration
 }
 
-var LogFn = log.Printf
-
 func Open(driverName, dataSourceName string) (*DB, error) {
 	db, err := sql.Open(driverName, dataSourceName)
 	return &DB{DB: db, Logging: true}, err
 }
 
 func (db *DB) WithStmt(query string, fn func(stmt *Stmt) error) error {
-	var errMsg string
+	return db.WithStmtContext(context.Background(), query, fn)
+}
+
+// WithStmtContext prepares query with ctx, passes the statement to fn and closes it afterwards. Statements inherit the
+// QueryTimeout of db.
+func (db *DB) WithStmtContext(ctx context.Context, query string, fn func(stmt *Stmt) error) error {
 	began := time.Now()
+	logger := db.queryLogger()
 
-	stmt, err := db.Prepare(query)
+	stmt, err := db.PrepareContext(ctx, query)
 	if err != nil {
+		recordQuery(ctx, logger, query, false, began, 0, err)
 		return err
 	}
 
 	defer stmt.Close()
 
-	err = fn(&Stmt{stmt})
-	if err != nil {
-		errMsg = err.Error()
-	}
+	s := &Stmt{Stmt: stmt, timeout: db.QueryTimeout}
+	err = fn(s)
 
-	LogFn("%s %s %s", query, time.Since(began), errMsg)
+	recordQuery(ctx, logger, query, false, began, s.rows, err)
 
 	return err
 }
 
+// queryLogger returns the logger of the query log, or nil when logging is turned off.
+func (db *DB) queryLogger() *slog.Logger {
+	if !db.Logging {
+		return nil
+	}
+
+	if db.Logger == nil {
+		return slog.Default()
+	}
+
+	return db.Logger
+}
+
+// recordQuery observes the duration of one statement in the query metrics and writes its query log record to logger,
+// unless logger is nil. Failed statements are logged at error level.
+func recordQuery(ctx context.Context, logger *slog.Logger, query string, inTx bool, began time.Time, rows int64, err error) {
+	duration := time.Since(began)
+	metrics.ObserveQuery(query, inTx, duration, err)
+
+	if logger == nil {
+		return
+	}
+
+	level := slog.LevelInfo
+	attrs := []slog.Attr{
+		slog.String("query", query),
+		slog.Bool("tx", inTx),
+		slog.Duration("duration", duration),
+		slog.Int64("rows", rows),
+	}
+
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String(logging.RequestIDKey, requestID))
+	}
+
+	if err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	logger.LogAttrs(ctx, level, "query", attrs...)
+}
+
 func (db *DB) WithTx(fn func(tx *Tx) error) error {
+	return db.WithTxContext(context.Background(), fn)
+}
+
+// WithTxContext begins a transaction bound to ctx and commits it when fn succeeds or rolls it back when fn fails. The
+// transaction is rolled back by the driver when ctx is cancelled before the commit.
+func (db *DB) WithTxContext(ctx context.Context, fn func(tx *Tx) error) error {
 	var err error
 	var tx *sql.Tx
 
-	tx, err = db.Begin()
+	tx, err = db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	if err = fn(&Tx{Tx: tx}); err != nil {
+	if err = fn(&Tx{Tx: tx, timeout: db.QueryTimeout, logger: db.queryLogger()}); err != nil {
 		tx.Rollback()
 		return err
 	}
@@ -65,44 +128,71 @@ func (db *DB) WithTx(fn func(tx *Tx) error) error {
 
 type Tx struct {
 	*sql.Tx
+	timeout time.Duration
+	logger  *slog.Logger
 }
 
 func (tx *Tx) WithStmt(query string, fn func(stmt *Stmt) error) error {
-	var errMsg string
+	return tx.WithStmtContext(context.Background(), query, fn)
+}
+
+// WithStmtContext prepares query inside the transaction with ctx, passes the statement to fn and closes it afterwards.
+func (tx *Tx) WithStmtContext(ctx context.Context, query string, fn func(stmt *Stmt) error) error {
 	began := time.Now()
 
-	stmt, err := tx.Prepare(query)
+	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
+		recordQuery(ctx, tx.logger, query, true, began, 0, err)
 		return err
 	}
 
 	defer stmt.Close()
 
-	err = fn(&Stmt{stmt})
-	if err != nil {
-		errMsg = err.Error()
-	}
+	s := &Stmt{Stmt: stmt, timeout: tx.timeout}
+	err = fn(s)
 
-	LogFn("tx: %s %s %s", query, time.Since(began), errMsg)
+	recordQuery(ctx, tx.logger, query, true, began, s.rows, err)
 
 	return err
 }
 
 type Stmt struct {
 	*sql.Stmt
+	timeout time.Duration
+	// rows counts the rows read or affected by the statement, for the query log.
+	rows int64
+}
+
+// withTimeout bounds ctx by the query timeout of the statement, if any.
+func (stmt *Stmt) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if stmt.timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, stmt.timeout)
 }
 
 func (stmt *Stmt) Query(rowFn func(rows *Rows) error, args ...interface{}) error {
+	return stmt.QueryContext(context.Background(), rowFn, args...)
+}
+
+// QueryContext runs the statement with ctx and calls rowFn for every row. The query, including reading the rows, is
+// cancelled when ctx is done or the query timeout elapses.
+func (stmt *Stmt) QueryContext(ctx context.Context, rowFn func(rows *Rows) error, args ...interface{}) error {
 	var rows *sql.Rows
 	var err error
 
-	if rows, err = stmt.Stmt.Query(args...); err != nil {
+	ctx, cancel := stmt.withTimeout(ctx)
+	defer cancel()
+
+	if rows, err = stmt.Stmt.QueryContext(ctx, args...); err != nil {
 		return err
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
+		stmt.rows++
 		err = rowFn(&Rows{rows})
 
 		if err != nil {
@@ -110,7 +200,28 @@ func (stmt *Stmt) Query(rowFn func(rows *Rows) error, args ...interface{}) error
 		}
 	}
 
-	return nil
+	return rows.Err()
+}
+
+// ExecContext runs the statement with ctx. It is cancelled when ctx is done or the query timeout elapses.
+func (stmt *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := stmt.withTimeout(ctx)
+	defer cancel()
+
+	result, err := stmt.Stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if affected, err := result.RowsAffected(); err == nil {
+		stmt.rows += affected
+	}
+
+	return result, nil
+}
+
+func (stmt *Stmt) Exec(args ...interface{}) (sql.Result, error) {
+	return stmt.ExecContext(context.Background(), args...)
 }
 
 func (stmt *Stmt) QueryRow(args ...interface{}) *Row {
@@ -1,16 +1,22 @@
 package database
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/logging"
 )
 
 type testQuery struct {
@@ -28,8 +34,9 @@ type testConfig struct {
 	commitErr   error
 	rollbackErr error
 
-	mu     sync.Mutex
-	lastTx *testTx
+	mu       sync.Mutex
+	lastTx   *testTx
+	queryCtx context.Context
 }
 
 func (c *testConfig) setLastTx(tx *testTx) {
@@ -112,7 +119,7 @@ type testStmt struct {
 func (s *testStmt) Close() error  { return nil }
 func (s *testStmt) NumInput() int { return -1 }
 func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
-	return nil, errors.New("exec not supported")
+	return driver.RowsAffected(1), nil
 }
 
 func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
@@ -124,6 +131,9 @@ func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
 }
 
 func (s *testStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	s.cfg.mu.Lock()
+	s.cfg.queryCtx = ctx
+	s.cfg.mu.Unlock()
 	vals := make([]driver.Value, len(args))
 	for i, v := range args {
 		vals[i] = v.Value
@@ -227,10 +237,32 @@ func TestOpen(t *testing.T) {
 	})
 }
 
-func TestDBWithStmt(t *testing.T) {
-	origLogFn := LogFn
-	t.Cleanup(func() { LogFn = origLogFn })
+// logRecorder collects the query log records written to its logger.
+type logRecorder struct {
+	buf bytes.Buffer
+}
+
+func (l *logRecorder) logger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(&l.buf, nil))
+}
+
+func (l *logRecorder) records(t *testing.T) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(l.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("decode log record %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
 
+func TestDBWithStmt(t *testing.T) {
 	tests := []struct {
 		name       string
 		prepareErr error
@@ -238,10 +270,12 @@ func TestDBWithStmt(t *testing.T) {
 		wantErr    error
 		wantLog    bool
 		wantLogSub string
+		disabled   bool
 	}{
 		{name: "ok", wantLog: true},
 		{name: "fnerr", fnErr: errors.New("fn"), wantErr: errors.New("fn"), wantLog: true, wantLogSub: "fn"},
-		{name: "prepare", prepareErr: errors.New("prep"), wantErr: errors.New("prep")},
+		{name: "prepare", prepareErr: errors.New("prep"), wantErr: errors.New("prep"), wantLog: true, wantLogSub: "prep"},
+		{name: "disabled", disabled: true},
 	}
 
 	for _, tt := range tests {
@@ -250,13 +284,11 @@ func TestDBWithStmt(t *testing.T) {
 			query := "select 1"
 			cfg := &testConfig{prepareErr: map[string]error{query: tt.prepareErr}}
 			db := newTestDB(t, cfg)
-			var called int
-			var last string
-			LogFn = func(format string, args ...interface{}) {
-				called++
-				last = fmt.Sprintf(format, args...)
-			}
-			err := db.WithStmt(query, func(stmt *Stmt) error {
+			var rec logRecorder
+			db.Logger = rec.logger()
+			db.Logging = !tt.disabled
+			ctx := logging.NewContext(context.Background(), "req-1")
+			err := db.WithStmtContext(ctx, query, func(stmt *Stmt) error {
 				return tt.fnErr
 			})
 			if (err == nil) != (tt.wantErr == nil) {
@@ -265,19 +297,66 @@ func TestDBWithStmt(t *testing.T) {
 			if tt.wantErr != nil && err != nil && err.Error() != tt.wantErr.Error() {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
-			if tt.wantLog && called != 1 {
-				t.Fatalf("expected log call")
+			records := rec.records(t)
+			if tt.wantLog && len(records) != 1 {
+				t.Fatalf("expected one log record, got %v", records)
+			}
+			if !tt.wantLog && len(records) != 0 {
+				t.Fatalf("did not expect log records, got %v", records)
 			}
-			if !tt.wantLog && called != 0 {
-				t.Fatalf("did not expect log call")
+			if !tt.wantLog {
+				return
 			}
-			if tt.wantLog && !strings.Contains(last, query) {
-				t.Fatalf("expected log to contain query")
+			last := records[0]
+			if last["query"] != query || last["tx"] != false || last[logging.RequestIDKey] != "req-1" {
+				t.Fatalf("unexpected log record: %v", last)
 			}
-			if tt.wantLogSub != "" && !strings.Contains(last, tt.wantLogSub) {
-				t.Fatalf("expected log to contain error")
+			if _, ok := last["duration"]; !ok {
+				t.Fatalf("expected log to contain duration: %v", last)
 			}
+			if tt.wantLogSub != "" && (last["error"] != tt.wantLogSub || last["level"] != "ERROR") {
+				t.Fatalf("expected log to contain error: %v", last)
+			}
+		})
+	}
+}
+
+func TestDBQueryLog(t *testing.T) {
+	query := "select id"
+	cfg := &testConfig{query: map[string]testQuery{query: {columns: []string{"id"}, rows: [][]driver.Value{{1}, {2}, {3}}}}}
+	db := newTestDB(t, cfg)
+	var rec logRecorder
+	db.Logger = rec.logger()
+
+	err := db.WithStmt(query, func(stmt *Stmt) error {
+		return stmt.Query(func(rows *Rows) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = db.WithTx(func(tx *Tx) error {
+		return tx.WithStmt("update products", func(stmt *Stmt) error {
+			_, err := stmt.Exec()
+			return err
 		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	records := rec.records(t)
+	if len(records) != 2 {
+		t.Fatalf("expected two log records, got %v", records)
+	}
+	if records[0]["rows"] != float64(3) || records[0]["tx"] != false {
+		t.Fatalf("unexpected query log: %v", records[0])
+	}
+	if _, ok := records[0][logging.RequestIDKey]; ok {
+		t.Fatalf("did not expect a request ID: %v", records[0])
+	}
+	if records[1]["query"] != "update products" || records[1]["rows"] != float64(1) || records[1]["tx"] != true {
+		t.Fatalf("unexpected exec log: %v", records[1])
 	}
 }
 
@@ -322,10 +401,126 @@ func TestDBWithTx(t *testing.T) {
 	}
 }
 
-func TestTxWithStmt(t *testing.T) {
-	origLogFn := LogFn
-	t.Cleanup(func() { LogFn = origLogFn })
+func TestDBWithContext(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("stmt-cancelled", func(t *testing.T) {
+		db := newTestDB(t, &testConfig{})
+		called := false
+		err := db.WithStmtContext(cancelled, "select 1", func(stmt *Stmt) error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) || called {
+			t.Fatalf("expected context.Canceled before fn, got %v (called %v)", err, called)
+		}
+	})
+
+	t.Run("tx-cancelled", func(t *testing.T) {
+		cfg := &testConfig{}
+		db := newTestDB(t, cfg)
+		called := false
+		err := db.WithTxContext(cancelled, func(tx *Tx) error {
+			called = true
+			return nil
+		})
+		if !errors.Is(err, context.Canceled) || called {
+			t.Fatalf("expected context.Canceled before fn, got %v (called %v)", err, called)
+		}
+		if cfg.getLastTx() != nil {
+			t.Fatalf("expected no transaction")
+		}
+	})
+
+	t.Run("timeout-inherited", func(t *testing.T) {
+		db := newTestDB(t, &testConfig{})
+		db.QueryTimeout = time.Minute
+		err := db.WithStmtContext(context.Background(), "select 1", func(stmt *Stmt) error {
+			if stmt.timeout != time.Minute {
+				t.Fatalf("stmt timeout = %v, want %v", stmt.timeout, time.Minute)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		err = db.WithTxContext(context.Background(), func(tx *Tx) error {
+			return tx.WithStmtContext(context.Background(), "select 1", func(stmt *Stmt) error {
+				if stmt.timeout != time.Minute {
+					t.Fatalf("tx stmt timeout = %v, want %v", stmt.timeout, time.Minute)
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestStmtQueryContext(t *testing.T) {
+	query := "select id"
+	ok := testQuery{columns: []string{"id"}, rows: [][]driver.Value{{int64(1)}}}
 
+	tests := []struct {
+		name         string
+		timeout      time.Duration
+		q            testQuery
+		wantDeadline bool
+		wantErr      error
+	}{
+		{name: "no-timeout", q: ok},
+		{name: "timeout", timeout: time.Minute, q: ok, wantDeadline: true},
+		{name: "rows-error", q: testQuery{columns: []string{"id"}, nextErr: errors.New("next")}, wantErr: errors.New("next")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{query: map[string]testQuery{query: tt.q}}
+			db := newTestDB(t, cfg)
+			db.QueryTimeout = tt.timeout
+
+			var rows int
+			err := db.WithStmtContext(context.Background(), query, func(stmt *Stmt) error {
+				return stmt.QueryContext(context.Background(), func(r *Rows) error {
+					rows++
+					return nil
+				})
+			})
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("expected err %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil || rows != 1 {
+				t.Fatalf("rows = %d, err = %v", rows, err)
+			}
+			cfg.mu.Lock()
+			_, hasDeadline := cfg.queryCtx.Deadline()
+			cfg.mu.Unlock()
+			if hasDeadline != tt.wantDeadline {
+				t.Fatalf("deadline = %v, want %v", hasDeadline, tt.wantDeadline)
+			}
+		})
+	}
+}
+
+func TestStmtExecContext(t *testing.T) {
+	db := newTestDB(t, &testConfig{})
+	err := db.WithStmtContext(context.Background(), "update x", func(stmt *Stmt) error {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := stmt.ExecContext(ctx)
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestTxWithStmt(t *testing.T) {
 	tests := []struct {
 		name       string
 		prepareErr error
@@ -336,7 +531,7 @@ func TestTxWithStmt(t *testing.T) {
 	}{
 		{name: "ok", wantLog: true},
 		{name: "fnerr", fnErr: errors.New("fn"), wantErr: errors.New("fn"), wantLog: true, wantLogSub: "fn"},
-		{name: "prepare", prepareErr: errors.New("prep"), wantErr: errors.New("prep")},
+		{name: "prepare", prepareErr: errors.New("prep"), wantErr: errors.New("prep"), wantLog: true, wantLogSub: "prep"},
 	}
 
 	for _, tt := range tests {
@@ -350,14 +545,9 @@ func TestTxWithStmt(t *testing.T) {
 				t.Fatalf("begin: %v", err)
 			}
 			defer func() { _ = sqlTx.Rollback() }()
-			tx := &Tx{Tx: sqlTx}
+			var rec logRecorder
+			tx := &Tx{Tx: sqlTx, logger: rec.logger()}
 
-			var called int
-			var last string
-			LogFn = func(format string, args ...interface{}) {
-				called++
-				last = fmt.Sprintf(format, args...)
-			}
 			err = tx.WithStmt(query, func(stmt *Stmt) error {
 				return tt.fnErr
 			})
@@ -367,17 +557,18 @@ func TestTxWithStmt(t *testing.T) {
 			if tt.wantErr != nil && err != nil && err.Error() != tt.wantErr.Error() {
 				t.Fatalf("expected err %v, got %v", tt.wantErr, err)
 			}
-			if tt.wantLog && called != 1 {
-				t.Fatalf("expected log call")
+			records := rec.records(t)
+			if tt.wantLog && len(records) != 1 {
+				t.Fatalf("expected one log record, got %v", records)
 			}
-			if !tt.wantLog && called != 0 {
-				t.Fatalf("did not expect log call")
+			if !tt.wantLog && len(records) != 0 {
+				t.Fatalf("did not expect log records, got %v", records)
 			}
-			if tt.wantLog && !strings.Contains(last, "tx:") {
-				t.Fatalf("expected tx log prefix")
+			if tt.wantLog && (records[0]["tx"] != true || records[0]["query"] != query) {
+				t.Fatalf("expected tx log record, got %v", records[0])
 			}
-			if tt.wantLogSub != "" && !strings.Contains(last, tt.wantLogSub) {
-				t.Fatalf("expected log to contain error")
+			if tt.wantLogSub != "" && records[0]["error"] != tt.wantLogSub {
+				t.Fatalf("expected log to contain error: %v", records[0])
 			}
 		})
 	}
@@ -5,10 +5,16 @@ import (
 	_ "time/tzdata"
 )
 
+const DateLayout = "2006-01-02"
+
+func location() (*time.Location, error) {
+	return time.LoadLocation("Asia/Jakarta")
+}
+
 func ParseTime(timeString string) (time.Time, error) {
 	layout := time.RFC3339
 
-	loc, err := time.LoadLocation("Asia/Jakarta")
+	loc, err := location()
 	if err != nil {
 		return time.Time{}, err
 	}
@@ -20,3 +26,39 @@ func ParseTime(timeString string) (time.Time, error) {
 
 	return parsedTime.In(loc), nil
 }
+
+// ParseDate parses a YYYY-MM-DD date as midnight in Asia/Jakarta, the store's business day.
+func ParseDate(dateString string) (time.Time, error) {
+	loc, err := location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.ParseInLocation(DateLayout, dateString, loc)
+}
+
+// Today returns midnight of the current business day in Asia/Jakarta.
+func Today() (time.Time, error) {
+	loc, err := location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc), nil
+}
+
+// ParseNullTime parses the value of a nullable timestamp column, returning nil for NULL or a value that is not a
+// timestamp.
+func ParseNullTime(timeString *string) *time.Time {
+	if timeString == nil {
+		return nil
+	}
+
+	parsedTime, err := ParseTime(*timeString)
+	if err != nil {
+		return nil
+	}
+
+	return &parsedTime
+}
@@ -74,3 +74,103 @@ func TestParseTime(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDate(t *testing.T) {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:  "date",
+			input: "2023-01-02",
+			want:  time.Date(2023, 1, 2, 0, 0, 0, 0, loc),
+		},
+		{
+			name:    "empty",
+			input:   "",
+			wantErr: true,
+		},
+		{
+			name:    "rfc3339",
+			input:   "2023-01-02T00:00:00Z",
+			wantErr: true,
+		},
+		{
+			name:    "badday",
+			input:   "2023-02-30",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseDate(tc.input)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Location().String() != "Asia/Jakarta" {
+				t.Fatalf("expected location Asia/Jakarta, got %s", got.Location().String())
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, got)
+			}
+			if got.UTC().Hour() != 17 {
+				t.Fatalf("expected midnight Jakarta to be 17:00 UTC, got %v", got.UTC())
+			}
+		})
+	}
+}
+
+func TestToday(t *testing.T) {
+	got, err := Today()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Location().String() != "Asia/Jakarta" {
+		t.Fatalf("expected location Asia/Jakarta, got %s", got.Location().String())
+	}
+	if got.Hour() != 0 || got.Minute() != 0 || got.Second() != 0 || got.Nanosecond() != 0 {
+		t.Fatalf("expected midnight, got %v", got)
+	}
+	if now := time.Now(); now.Before(got) || now.Sub(got) >= 24*time.Hour {
+		t.Fatalf("expected today, got %v (now %v)", got, now)
+	}
+}
+
+func TestParseNullTime(t *testing.T) {
+	value := "2023-01-02T01:02:03Z"
+	bad := "not-a-time"
+
+	if got := ParseNullTime(nil); got != nil {
+		t.Fatalf("expected nil for NULL, got %v", got)
+	}
+
+	if got := ParseNullTime(&bad); got != nil {
+		t.Fatalf("expected nil for %q, got %v", bad, got)
+	}
+
+	got := ParseNullTime(&value)
+	if got == nil {
+		t.Fatalf("expected a time for %q", value)
+	}
+	if want := time.Date(2023, 1, 2, 1, 2, 3, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("expected %v, got %v", want, *got)
+	}
+	if got.Location().String() != "Asia/Jakarta" {
+		t.Fatalf("expected location Asia/Jakarta, got %s", got.Location().String())
+	}
+}
@@ -0,0 +1,46 @@
+// Package logging builds the structured JSON logger of the service and carries the ID of the current request through
+// a context, so every log line written while handling a request can be traced back to it.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// RequestIDKey is the attribute name of the request ID in log records.
+const RequestIDKey = "request_id"
+
+// New returns a logger writing JSON records to w at level, which is one of debug, info, warn or error. An empty or
+// unknown level logs at info.
+func New(w io.Writer, level string) *slog.Logger {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		lvl = slog.LevelInfo
+	}
+
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: lvl}))
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying requestID.
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, requestID)
+}
+
+// RequestID returns the request ID stored by NewContext, or "" when there is none.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(contextKey{}).(string)
+	return requestID
+}
+
+// NewRequestID returns a random 32 character hex request ID.
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
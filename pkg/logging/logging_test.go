@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		level     string
+		wantDebug bool
+		wantInfo  bool
+	}{
+		{level: "", wantInfo: true},
+		{level: "debug", wantDebug: true, wantInfo: true},
+		{level: "INFO", wantInfo: true},
+		{level: "warn"},
+		{level: "verbose", wantInfo: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := New(&buf, tt.level)
+
+			if got := logger.Enabled(context.Background(), -4); got != tt.wantDebug {
+				t.Fatalf("debug enabled = %v, want %v", got, tt.wantDebug)
+			}
+			if got := logger.Enabled(context.Background(), 0); got != tt.wantInfo {
+				t.Fatalf("info enabled = %v, want %v", got, tt.wantInfo)
+			}
+
+			logger.Error("checkout failed", RequestIDKey, "abc")
+			var record map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+				t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+			}
+			if record["msg"] != "checkout failed" || record[RequestIDKey] != "abc" {
+				t.Fatalf("unexpected record: %v", record)
+			}
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	if got := RequestID(context.Background()); got != "" {
+		t.Fatalf("expected no request ID, got %q", got)
+	}
+
+	if got := RequestID(NewContext(context.Background(), "abc")); got != "abc" {
+		t.Fatalf("request ID = %q, want abc", got)
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	id := NewRequestID()
+	if len(id) != 32 {
+		t.Fatalf("request ID %q has length %d, want 32", id, len(id))
+	}
+	if other := NewRequestID(); other == id {
+		t.Fatalf("expected unique request IDs, got %q twice", id)
+	}
+}
@@ -0,0 +1,145 @@
+// Package metrics defines the Prometheus metrics of the service and the registry they are exposed from on GET /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "kasir"
+
+// UnmatchedRoute is the route label of requests that matched no route, so unknown paths do not each get a series.
+const UnmatchedRoute = "unmatched"
+
+// Registry holds every metric of the service together with the Go runtime and process metrics.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// HTTPRequests counts finished requests by method, route pattern and status code.
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPDuration observes how long requests take by method and route pattern.
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time spent handling HTTP requests, by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// HTTPInFlight is the number of requests being handled.
+	HTTPInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being handled.",
+	})
+
+	// DBQueryDuration observes how long statements take by SQL operation, whether they ran in a transaction and
+	// whether they failed.
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time spent running database statements, by SQL operation, transaction and outcome.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "tx", "outcome"})
+
+	// Checkouts counts checkouts by outcome, success or failed.
+	Checkouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checkouts_total",
+		Help:      "Checkouts attempted, by outcome.",
+	}, []string{"outcome"})
+
+	// Revenue adds up the total amount of successful checkouts.
+	Revenue = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "revenue_total",
+		Help:      "Total amount of successful checkouts.",
+	})
+
+	// ItemsSold adds up the quantity of products sold by successful checkouts.
+	ItemsSold = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "items_sold_total",
+		Help:      "Quantity of products sold by successful checkouts.",
+	})
+)
+
+// Outcomes used as the outcome label.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailed  = "failed"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPDuration,
+		HTTPInFlight,
+		DBQueryDuration,
+		Checkouts,
+		Revenue,
+		ItemsSold,
+	)
+}
+
+// Handler serves the metrics of Registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDB exposes the connection pool statistics of db, such as open and idle connections and the wait count.
+// Registering the same pool twice is a no-op.
+func RegisterDB(db *sql.DB) error {
+	err := Registry.Register(collectors.NewDBStatsCollector(db, namespace))
+
+	var alreadyRegistered prometheus.AlreadyRegisteredError
+	if errors.As(err, &alreadyRegistered) {
+		return nil
+	}
+
+	return err
+}
+
+// ObserveQuery records the duration of one statement. The operation label is the first keyword of query, such as
+// select or insert.
+func ObserveQuery(query string, inTx bool, duration time.Duration, err error) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailed
+	}
+
+	tx := "false"
+	if inTx {
+		tx = "true"
+	}
+
+	DBQueryDuration.WithLabelValues(operation(query), tx, outcome).Observe(duration.Seconds())
+}
+
+// operation returns the lower-cased first word of query, or "other" for anything but the usual statements.
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+
+	switch op := strings.ToLower(fields[0]); op {
+	case "select", "insert", "update", "delete", "with", "create", "alter", "drop":
+		return op
+	default:
+		return "other"
+	}
+}
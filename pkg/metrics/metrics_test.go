@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type testDriver struct{}
+
+func (testDriver) Open(string) (driver.Conn, error) { return nil, errors.New("not supported") }
+
+func init() {
+	sql.Register("metrics_test_driver", testDriver{})
+}
+
+func TestOperation(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "SELECT id FROM products", want: "select"},
+		{query: "\n\t insert into products (name) VALUES ($1)", want: "insert"},
+		{query: "UPDATE products SET stock = $1", want: "update"},
+		{query: "WITH t AS (SELECT 1) SELECT * FROM t", want: "with"},
+		{query: "TRUNCATE products", want: "other"},
+		{query: "", want: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := operation(tt.query); got != tt.want {
+				t.Fatalf("operation(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestObserveQuery(t *testing.T) {
+	// A histogram of its own keeps the test independent of the queries observed by other tests and earlier runs.
+	orig := DBQueryDuration
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "test_db_query_duration_seconds",
+		Help:    "Test histogram.",
+		Buckets: []float64{.002},
+	}, []string{"operation", "tx", "outcome"})
+	t.Cleanup(func() { DBQueryDuration = orig })
+
+	ObserveQuery("SELECT 1", false, 5*time.Millisecond, nil)
+	ObserveQuery("UPDATE products SET stock = 1", true, time.Millisecond, errors.New("boom"))
+	ObserveQuery("select 2", false, 5*time.Millisecond, nil)
+
+	want := `
+# HELP test_db_query_duration_seconds Test histogram.
+# TYPE test_db_query_duration_seconds histogram
+test_db_query_duration_seconds_bucket{operation="select",outcome="success",tx="false",le="0.002"} 0
+test_db_query_duration_seconds_bucket{operation="select",outcome="success",tx="false",le="+Inf"} 2
+test_db_query_duration_seconds_sum{operation="select",outcome="success",tx="false"} 0.01
+test_db_query_duration_seconds_count{operation="select",outcome="success",tx="false"} 2
+test_db_query_duration_seconds_bucket{operation="update",outcome="failed",tx="true",le="0.002"} 1
+test_db_query_duration_seconds_bucket{operation="update",outcome="failed",tx="true",le="+Inf"} 1
+test_db_query_duration_seconds_sum{operation="update",outcome="failed",tx="true"} 0.001
+test_db_query_duration_seconds_count{operation="update",outcome="failed",tx="true"} 1
+`
+	if err := testutil.CollectAndCompare(DBQueryDuration, strings.NewReader(want)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegisterDB(t *testing.T) {
+	db, err := sql.Open("metrics_test_driver", "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	if err := RegisterDB(db); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := RegisterDB(db); err != nil {
+		t.Fatalf("register twice: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{`go_sql_open_connections{db_name="kasir"}`, `go_sql_idle_connections{db_name="kasir"}`, `go_sql_wait_count_total{db_name="kasir"}`} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %q in metrics output", want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	Checkouts.WithLabelValues(OutcomeSuccess).Inc()
+	HTTPRequests.WithLabelValues(http.MethodGet, "/products", "200").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`kasir_checkouts_total{outcome="success"}`,
+		`kasir_http_requests_total{method="GET",route="/products",status="200"}`,
+		"kasir_http_requests_in_flight",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("expected %q in metrics output", want)
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Package migration applies and reverts versioned SQL schema migrations and records the applied versions in the
+// schema_migrations table.
+package migration
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/datetime"
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockKey is the key of the advisory lock held while a migration is applied or reverted.
+const lockKey = 7240800
+
+// Migration is one schema version with the SQL that applies it and the SQL that reverts it.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied and when.
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Load reads the NNNN_name.up.sql and NNNN_name.down.sql files at the root of fsys and returns the migrations sorted
+// by version. Every version must have both files and a single name.
+func Load(fsys fs.FS) ([]Migration, error) {
+	var (
+		byVersion  = make(map[int64]*Migration)
+		migrations []Migration
+	)
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		parts := fileName.FindStringSubmatch(e.Name())
+		if parts == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration version: %s", e.Name())
+		}
+
+		data, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != parts[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, parts[2])
+		}
+
+		if parts[3] == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both an up and a down file", m.Version, m.Name)
+		}
+
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+type Migrator struct {
+	db         *database.DB
+	migrations []Migration
+}
+
+// NewMigrator returns a Migrator applying the migrations found in fsys to db.
+func NewMigrator(db *database.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration in version order and returns the ones it applied. Each migration runs in its
+// own transaction together with its schema_migrations row, so a failing migration leaves the earlier ones applied
+// and nothing of itself. Instances migrating at the same time take turns, see withLock.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+
+	for _, migration := range m.migrations {
+		ran, err := m.up(migration)
+		if err != nil {
+			return applied, err
+		}
+
+		if ran {
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// up applies migration and records it in schema_migrations in one transaction. It reports false when the migration
+// has been applied already.
+func (m *Migrator) up(migration Migration) (bool, error) {
+	var ran bool
+
+	err := m.withLock(func(tx *database.Tx, done map[int64]time.Time) error {
+		if _, ok := done[migration.Version]; ok {
+			return nil
+		}
+
+		if _, err := tx.Exec(migration.Up); err != nil {
+			return err
+		}
+
+		err := tx.WithStmt("INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)", func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(migration.Version, migration.Name, "now()")
+			return err
+		})
+
+		if err != nil {
+			return err
+		}
+
+		ran = true
+		return nil
+	})
+
+	if err != nil {
+		return false, fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+	}
+
+	return ran, nil
+}
+
+// Down reverts the most recently applied migration and returns it, or nil when no migration is applied.
+func (m *Migrator) Down() (*Migration, error) {
+	var latest *Migration
+
+	err := m.withLock(func(tx *database.Tx, done map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := done[m.migrations[i].Version]; ok {
+				latest = &m.migrations[i]
+				break
+			}
+		}
+
+		if latest == nil {
+			return nil
+		}
+
+		if _, err := tx.Exec(latest.Down); err != nil {
+			return err
+		}
+
+		return tx.WithStmt("DELETE FROM schema_migrations WHERE version = $1", func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(latest.Version)
+			return err
+		})
+	})
+
+	if err != nil && latest != nil {
+		return nil, fmt.Errorf("migration %d_%s down: %w", latest.Version, latest.Name, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return latest, nil
+}
+
+// Status lists every known migration in version order with whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	var statuses []Status
+
+	done, err := applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range m.migrations {
+		appliedAt, ok := done[migration.Version]
+		statuses = append(statuses, Status{Version: migration.Version, Name: migration.Name, Applied: ok, AppliedAt: appliedAt})
+	}
+
+	return statuses, nil
+}
+
+// withLock runs fn in a transaction holding a transaction-level advisory lock, passing it the versions applied by
+// then, so when several instances start at once only one of them changes the schema at a time and the others find
+// its migrations applied. The lock belongs to the transaction rather than to a connection of its own, which would
+// leave a pool of a single connection none to run the migration on.
+func (m *Migrator) withLock(fn func(tx *database.Tx, done map[int64]time.Time) error) error {
+	return m.db.WithTx(func(tx *database.Tx) error {
+		err := tx.WithStmt("SELECT pg_advisory_xact_lock($1)", func(stmt *database.Stmt) error {
+			_, err := stmt.Exec(lockKey)
+			return err
+		})
+
+		if err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+
+		done, err := applied(tx)
+		if err != nil {
+			return err
+		}
+
+		return fn(tx, done)
+	})
+}
+
+// stmtRunner runs the statements of applied, either on the database or within a transaction.
+type stmtRunner interface {
+	WithStmt(query string, fn func(stmt *database.Stmt) error) error
+}
+
+// applied creates the schema_migrations table when it is missing and returns the applied versions with the time
+// each was applied.
+func applied(db stmtRunner) (map[int64]time.Time, error) {
+	var (
+		query string
+		done  = make(map[int64]time.Time)
+		err   error
+	)
+
+	query = "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())"
+
+	err = db.WithStmt(query, func(stmt *database.Stmt) error {
+		_, err = stmt.Exec()
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	query = "SELECT version, applied_at FROM schema_migrations ORDER BY version ASC"
+
+	err = db.WithStmt(query, func(stmt *database.Stmt) error {
+		return stmt.Query(func(rows *database.Rows) error {
+			var (
+				version   int64
+				appliedAt string
+			)
+
+			if err := rows.Scan(&version, &appliedAt); err != nil {
+				return err
+			}
+
+			done[version], _ = datetime.ParseTime(appliedAt)
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return done, nil
+}
@@ -0,0 +1,377 @@
+package migration
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/migrations"
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/database"
+)
+
+const (
+	createQuery  = "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now())"
+	appliedQuery = "SELECT version, applied_at FROM schema_migrations ORDER BY version ASC"
+	insertQuery  = "INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)"
+	deleteQuery  = "DELETE FROM schema_migrations WHERE version = $1"
+	lockQuery    = "SELECT pg_advisory_xact_lock($1)"
+)
+
+type testConfig struct {
+	applied  [][]driver.Value
+	execErr  map[string]error
+	queryErr error
+
+	execs    []string
+	execArgs [][]driver.Value
+	commits  int
+	rollback int
+}
+
+type testDriver struct{ cfg *testConfig }
+
+func (d *testDriver) Open(string) (driver.Conn, error) { return &testConn{cfg: d.cfg}, nil }
+
+type testConn struct{ cfg *testConfig }
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{cfg: c.cfg, query: query}, nil
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return &testTx{cfg: c.cfg}, nil }
+
+type testStmt struct {
+	cfg   *testConfig
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.cfg.execErr[s.query]; err != nil {
+		return nil, err
+	}
+	s.cfg.execs = append(s.cfg.execs, s.query)
+	s.cfg.execArgs = append(s.cfg.execArgs, append([]driver.Value(nil), args...))
+	return driver.RowsAffected(0), nil
+}
+
+func (s *testStmt) Query([]driver.Value) (driver.Rows, error) {
+	if s.cfg.queryErr != nil {
+		return nil, s.cfg.queryErr
+	}
+	return &testRows{values: s.cfg.applied}, nil
+}
+
+type testTx struct{ cfg *testConfig }
+
+func (t *testTx) Commit() error   { t.cfg.commits++; return nil }
+func (t *testTx) Rollback() error { t.cfg.rollback++; return nil }
+
+type testRows struct {
+	values [][]driver.Value
+	idx    int
+}
+
+func (r *testRows) Columns() []string { return []string{"version", "applied_at"} }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if r.idx >= len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.idx])
+	r.idx++
+	return nil
+}
+
+var driverCounter int64
+
+func newTestDB(t *testing.T, cfg *testConfig) *database.DB {
+	t.Helper()
+	name := fmt.Sprintf("migration_test_driver_%d", atomic.AddInt64(&driverCounter, 1))
+	sql.Register(name, &testDriver{cfg: cfg})
+	db, err := database.Open(name, "")
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	return db
+}
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"0002_create_products.up.sql":     {Data: []byte("CREATE TABLE products (id BIGSERIAL)")},
+		"0002_create_products.down.sql":   {Data: []byte("DROP TABLE products")},
+		"0001_create_categories.up.sql":   {Data: []byte("CREATE TABLE categories (id BIGSERIAL)")},
+		"0001_create_categories.down.sql": {Data: []byte("DROP TABLE categories")},
+		"README.md":                       {Data: []byte("ignored")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		want    []Migration
+		wantErr string
+	}{
+		{
+			name: "ok",
+			fsys: testFS(),
+			want: []Migration{
+				{Version: 1, Name: "create_categories", Up: "CREATE TABLE categories (id BIGSERIAL)", Down: "DROP TABLE categories"},
+				{Version: 2, Name: "create_products", Up: "CREATE TABLE products (id BIGSERIAL)", Down: "DROP TABLE products"},
+			},
+		},
+		{
+			name:    "missing-down",
+			fsys:    fstest.MapFS{"0001_create_categories.up.sql": {Data: []byte("CREATE TABLE categories (id BIGSERIAL)")}},
+			wantErr: "migration 1_create_categories must have both an up and a down file",
+		},
+		{
+			name: "two-names",
+			fsys: fstest.MapFS{
+				"0001_create_categories.up.sql": {Data: []byte("CREATE TABLE categories (id BIGSERIAL)")},
+				"0001_create_products.down.sql": {Data: []byte("DROP TABLE products")},
+			},
+			wantErr: "migration 1 has two names: create_categories and create_products",
+		},
+		{
+			name:    "zero-version",
+			fsys:    fstest.MapFS{"0000_init.up.sql": {Data: []byte("SELECT 1")}},
+			wantErr: "invalid migration version: 0000_init.up.sql",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(tt.fsys)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("migrations = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	got, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("load embedded migrations: %v", err)
+	}
+	if len(got) == 0 {
+		t.Fatalf("expected embedded migrations")
+	}
+	for i, m := range got {
+		if m.Version != int64(i+1) {
+			t.Fatalf("migration %d_%s out of sequence, want version %d", m.Version, m.Name, i+1)
+		}
+	}
+}
+
+func TestMigratorUp(t *testing.T) {
+	errExec := errors.New("exec")
+
+	tests := []struct {
+		name      string
+		cfg       *testConfig
+		wantErr   error
+		wantApply []int64
+		wantExecs []string
+	}{
+		{
+			name:      "all-pending",
+			cfg:       &testConfig{},
+			wantApply: []int64{1, 2},
+			wantExecs: []string{lockQuery, createQuery, "CREATE TABLE categories (id BIGSERIAL)", insertQuery, lockQuery, createQuery, "CREATE TABLE products (id BIGSERIAL)", insertQuery},
+		},
+		{
+			name:      "one-applied",
+			cfg:       &testConfig{applied: [][]driver.Value{{int64(1), "2024-01-02T03:04:05Z"}}},
+			wantApply: []int64{2},
+			wantExecs: []string{lockQuery, createQuery, lockQuery, createQuery, "CREATE TABLE products (id BIGSERIAL)", insertQuery},
+		},
+		{
+			name:      "up-to-date",
+			cfg:       &testConfig{applied: [][]driver.Value{{int64(1), "2024-01-02T03:04:05Z"}, {int64(2), "2024-01-02T03:04:05Z"}}},
+			wantExecs: []string{lockQuery, createQuery, lockQuery, createQuery},
+		},
+		{
+			name:      "failing",
+			cfg:       &testConfig{execErr: map[string]error{"CREATE TABLE products (id BIGSERIAL)": errExec}},
+			wantErr:   errExec,
+			wantApply: []int64{1},
+			wantExecs: []string{lockQuery, createQuery, "CREATE TABLE categories (id BIGSERIAL)", insertQuery, lockQuery, createQuery},
+		},
+		{name: "create-table", cfg: &testConfig{execErr: map[string]error{createQuery: errExec}}, wantErr: errExec, wantExecs: []string{lockQuery}},
+		{name: "lock", cfg: &testConfig{execErr: map[string]error{lockQuery: errExec}}, wantErr: errExec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrator, err := NewMigrator(newTestDB(t, tt.cfg), testFS())
+			if err != nil {
+				t.Fatalf("new migrator: %v", err)
+			}
+
+			applied, err := migrator.Up()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var versions []int64
+			for _, m := range applied {
+				versions = append(versions, m.Version)
+			}
+			if !reflect.DeepEqual(versions, tt.wantApply) {
+				t.Fatalf("applied = %v, want %v", versions, tt.wantApply)
+			}
+			if !reflect.DeepEqual(tt.cfg.execs, tt.wantExecs) {
+				t.Fatalf("execs = %q, want %q", tt.cfg.execs, tt.wantExecs)
+			}
+			if tt.name == "failing" && tt.cfg.rollback != 1 {
+				t.Fatalf("expected the failing migration to roll back")
+			}
+		})
+	}
+
+	t.Run("records-version", func(t *testing.T) {
+		cfg := &testConfig{applied: [][]driver.Value{{int64(1), "2024-01-02T03:04:05Z"}}}
+		migrator, _ := NewMigrator(newTestDB(t, cfg), testFS())
+		if _, err := migrator.Up(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		want := []driver.Value{int64(2), "create_products", "now()"}
+		if got := cfg.execArgs[len(cfg.execArgs)-1]; !reflect.DeepEqual(got, want) {
+			t.Fatalf("insert args = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("single-connection", func(t *testing.T) {
+		db := newTestDB(t, &testConfig{})
+		db.DB.SetMaxOpenConns(1)
+		migrator, _ := NewMigrator(db, testFS())
+		done := make(chan error, 1)
+		go func() {
+			_, err := migrator.Up()
+			done <- err
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Up blocked on a pool of a single connection")
+		}
+	})
+}
+
+func TestMigratorDown(t *testing.T) {
+	errExec := errors.New("exec")
+
+	tests := []struct {
+		name        string
+		cfg         *testConfig
+		wantErr     error
+		wantVersion int64
+		wantExecs   []string
+	}{
+		{
+			name:        "latest",
+			cfg:         &testConfig{applied: [][]driver.Value{{int64(1), "2024-01-02T03:04:05Z"}, {int64(2), "2024-01-02T03:04:05Z"}}},
+			wantVersion: 2,
+			wantExecs:   []string{lockQuery, createQuery, "DROP TABLE products", deleteQuery},
+		},
+		{name: "none", cfg: &testConfig{}, wantExecs: []string{lockQuery, createQuery}},
+		{
+			name:      "failing",
+			cfg:       &testConfig{applied: [][]driver.Value{{int64(1), "2024-01-02T03:04:05Z"}}, execErr: map[string]error{"DROP TABLE categories": errExec}},
+			wantErr:   errExec,
+			wantExecs: []string{lockQuery, createQuery},
+		},
+		{name: "lock", cfg: &testConfig{execErr: map[string]error{lockQuery: errExec}}, wantErr: errExec},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrator, err := NewMigrator(newTestDB(t, tt.cfg), testFS())
+			if err != nil {
+				t.Fatalf("new migrator: %v", err)
+			}
+
+			reverted, err := migrator.Down()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var version int64
+			if reverted != nil {
+				version = reverted.Version
+			}
+			if version != tt.wantVersion {
+				t.Fatalf("reverted = %d, want %d", version, tt.wantVersion)
+			}
+			if !reflect.DeepEqual(tt.cfg.execs, tt.wantExecs) {
+				t.Fatalf("execs = %q, want %q", tt.cfg.execs, tt.wantExecs)
+			}
+		})
+	}
+}
+
+func TestMigratorStatus(t *testing.T) {
+	cfg := &testConfig{applied: [][]driver.Value{{int64(1), "2024-01-02T03:04:05Z"}}}
+	migrator, err := NewMigrator(newTestDB(t, cfg), testFS())
+	if err != nil {
+		t.Fatalf("new migrator: %v", err)
+	}
+
+	got, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("statuses = %+v", got)
+	}
+	if !got[0].Applied || got[0].Name != "create_categories" || got[0].AppliedAt.UTC().Format("2006-01-02") != "2024-01-02" {
+		t.Fatalf("unexpected applied status: %+v", got[0])
+	}
+	if got[1].Applied || got[1].Version != 2 || !got[1].AppliedAt.IsZero() {
+		t.Fatalf("unexpected pending status: %+v", got[1])
+	}
+
+	t.Run("query-error", func(t *testing.T) {
+		errQuery := errors.New("query")
+		migrator, _ := NewMigrator(newTestDB(t, &testConfig{queryErr: errQuery}), testFS())
+		if _, err := migrator.Status(); !errors.Is(err, errQuery) {
+			t.Fatalf("expected error %v, got %v", errQuery, err)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+// Package pagination parses page, page_size and sort query parameters and builds the page metadata returned with
+// list responses.
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultPage     = 1
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+type Params struct {
+	Page     int
+	PageSize int
+	Sort     []Sort
+}
+
+type Meta struct {
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	TotalItems int  `json:"total_items"`
+	TotalPages int  `json:"total_pages"`
+	NextPage   *int `json:"next_page"`
+}
+
+// Parse reads page, page_size and sort from the query string. sort is a comma separated list of fields, each
+// optionally prefixed with "-" for descending order, and every field must be one of sortable.
+func Parse(query url.Values, sortable ...string) (Params, error) {
+	params := Params{Page: DefaultPage, PageSize: DefaultPageSize}
+
+	if page := query.Get("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return Params{}, fmt.Errorf("page must be a positive integer")
+		}
+		params.Page = value
+	}
+
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		value, err := strconv.Atoi(pageSize)
+		if err != nil || value < 1 || value > MaxPageSize {
+			return Params{}, fmt.Errorf("page_size must be between 1 and %d", MaxPageSize)
+		}
+		params.PageSize = value
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if !contains(sortable, field) {
+				return Params{}, fmt.Errorf("invalid sort field: %s", field)
+			}
+			params.Sort = append(params.Sort, Sort{Field: field, Desc: desc})
+		}
+	}
+
+	return params, nil
+}
+
+func (p Params) Limit() int {
+	return p.PageSize
+}
+
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// OrderBy translates the requested sort fields into an ORDER BY clause using columns to map field names to SQL
+// columns. tiebreaker is always appended so rows keep a stable order across pages.
+func (p Params) OrderBy(columns map[string]string, tiebreaker string) string {
+	var orders []string
+	for _, sort := range p.Sort {
+		column, ok := columns[sort.Field]
+		if !ok {
+			continue
+		}
+		direction := "ASC"
+		if sort.Desc {
+			direction = "DESC"
+		}
+		orders = append(orders, fmt.Sprintf("%s %s", column, direction))
+	}
+
+	orders = append(orders, fmt.Sprintf("%s ASC", tiebreaker))
+	return "ORDER BY " + strings.Join(orders, ", ")
+}
+
+func NewMeta(p Params, totalItems int) *Meta {
+	meta := &Meta{
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		TotalItems: totalItems,
+	}
+
+	if p.PageSize > 0 {
+		meta.TotalPages = (totalItems + p.PageSize - 1) / p.PageSize
+	}
+
+	if p.Page < meta.TotalPages {
+		next := p.Page + 1
+		meta.NextPage = &next
+	}
+
+	return meta
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
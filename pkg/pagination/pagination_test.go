@@ -0,0 +1,108 @@
+package pagination
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    Params
+		wantErr string
+	}{
+		{name: "defaults", want: Params{Page: 1, PageSize: 20}},
+		{name: "page", query: "page=3&page_size=50", want: Params{Page: 3, PageSize: 50}},
+		{
+			name:  "sort",
+			query: "sort=price,-name",
+			want:  Params{Page: 1, PageSize: 20, Sort: []Sort{{Field: "price"}, {Field: "name", Desc: true}}},
+		},
+		{name: "zero-page", query: "page=0", wantErr: "page must be a positive integer"},
+		{name: "bad-page", query: "page=abc", wantErr: "page must be a positive integer"},
+		{name: "big-page-size", query: "page_size=101", wantErr: "page_size must be between 1 and 100"},
+		{name: "zero-page-size", query: "page_size=0", wantErr: "page_size must be between 1 and 100"},
+		{name: "bad-sort", query: "sort=password", wantErr: "invalid sort field: password"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tc.query)
+			got, err := Parse(query, "name", "price")
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParamsLimitOffset(t *testing.T) {
+	p := Params{Page: 3, PageSize: 25}
+	if p.Limit() != 25 {
+		t.Fatalf("expected limit 25, got %d", p.Limit())
+	}
+	if p.Offset() != 50 {
+		t.Fatalf("expected offset 50, got %d", p.Offset())
+	}
+}
+
+func TestParamsOrderBy(t *testing.T) {
+	columns := map[string]string{"name": "products.name", "price": "products.price"}
+
+	tests := []struct {
+		name string
+		sort []Sort
+		want string
+	}{
+		{name: "default", want: "ORDER BY products.id ASC"},
+		{name: "asc", sort: []Sort{{Field: "price"}}, want: "ORDER BY products.price ASC, products.id ASC"},
+		{name: "multi", sort: []Sort{{Field: "name", Desc: true}, {Field: "price"}}, want: "ORDER BY products.name DESC, products.price ASC, products.id ASC"},
+		{name: "unknown", sort: []Sort{{Field: "stock"}}, want: "ORDER BY products.id ASC"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Params{Sort: tc.sort}.OrderBy(columns, "products.id")
+			if got != tc.want {
+				t.Fatalf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewMeta(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name  string
+		p     Params
+		total int
+		want  *Meta
+	}{
+		{name: "first", p: Params{Page: 1, PageSize: 10}, total: 25, want: &Meta{Page: 1, PageSize: 10, TotalItems: 25, TotalPages: 3, NextPage: intPtr(2)}},
+		{name: "last", p: Params{Page: 3, PageSize: 10}, total: 25, want: &Meta{Page: 3, PageSize: 10, TotalItems: 25, TotalPages: 3}},
+		{name: "exact", p: Params{Page: 2, PageSize: 10}, total: 20, want: &Meta{Page: 2, PageSize: 10, TotalItems: 20, TotalPages: 2}},
+		{name: "empty", p: Params{Page: 1, PageSize: 10}, want: &Meta{Page: 1, PageSize: 10}},
+		{name: "past-end", p: Params{Page: 5, PageSize: 10}, total: 5, want: &Meta{Page: 5, PageSize: 10, TotalItems: 5, TotalPages: 1}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NewMeta(tc.p, tc.total)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Package patch decodes JSON Merge Patch (RFC 7396) documents, where a member that is left out keeps its value, a
+// member set to null removes the value and any other member replaces it.
+package patch
+
+import "encoding/json"
+
+// Field is one member of a merge patch. Set reports whether the member was present and Null whether it was null.
+// Value holds the new value of a present, non-null member.
+type Field[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// Of returns a field that sets value, the member a client would send as "name": value.
+func Of[T any](value T) Field[T] {
+	return Field[T]{Set: true, Value: value}
+}
+
+// UnmarshalJSON is only called for members present in the document, which is how Set tells a missing member from
+// one with a zero value.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if string(data) == "null" {
+		f.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Apply writes the patched value to dst: Value for a present member, the zero value for a null one and nothing for
+// a missing one.
+func (f Field[T]) Apply(dst *T) {
+	if !f.Set {
+		return
+	}
+
+	var zero T
+	if f.Null {
+		*dst = zero
+		return
+	}
+
+	*dst = f.Value
+}
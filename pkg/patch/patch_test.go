@@ -0,0 +1,70 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+type document struct {
+	Name    Field[string] `json:"name"`
+	Barcode Field[string] `json:"barcode"`
+	Price   Field[int]    `json:"price"`
+}
+
+func TestFieldUnmarshalJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want document
+	}{
+		{name: "empty", body: `{}`},
+		{name: "value", body: `{"price":0}`, want: document{Price: Of(0)}},
+		{name: "null", body: `{"barcode":null}`, want: document{Barcode: Field[string]{Set: true, Null: true}}},
+		{name: "mixed", body: `{"name":"Susu","barcode":null}`, want: document{Name: Of("Susu"), Barcode: Field[string]{Set: true, Null: true}}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got document
+			if err := json.Unmarshal([]byte(tc.body), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("document = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFieldUnmarshalJSONTypeError(t *testing.T) {
+	var got document
+	err := json.Unmarshal([]byte(`{"price":"ten"}`), &got)
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Type.String() != "int" {
+		t.Fatalf("expected a type error for an int, got %v", err)
+	}
+}
+
+func TestFieldApply(t *testing.T) {
+	cases := []struct {
+		name  string
+		field Field[string]
+		want  string
+	}{
+		{name: "missing", field: Field[string]{}, want: "old"},
+		{name: "null", field: Field[string]{Set: true, Null: true}, want: ""},
+		{name: "value", field: Of("new"), want: "new"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := "old"
+			tc.field.Apply(&got)
+			if got != tc.want {
+				t.Fatalf("value = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -2,15 +2,25 @@ package response
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
 )
 
+// MaxBodyBytes limits the size of a JSON request body read by ParseJSON.
+const MaxBodyBytes = 1 << 20
+
 type APIResponse struct {
-	Code    string      `json:"code"`
-	Message interface{} `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code    string                `json:"code"`
+	Message interface{}           `json:"message"`
+	Data    interface{}           `json:"data,omitempty"`
+	Meta    interface{}           `json:"meta,omitempty"`
+	Errors  []apperror.FieldError `json:"errors,omitempty"`
 }
 
 func WriteJSONResponse(w http.ResponseWriter, status int, v any) {
@@ -19,11 +29,79 @@ func WriteJSONResponse(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
+// ParseJSON decodes the JSON object in the body of r into payload. The body must hold a single object of at most
+// MaxBodyBytes bytes without fields unknown to payload. Every error is a validation error, unknown fields and values of
+// the wrong type carry the field they were found in.
 func ParseJSON(r *http.Request, payload any) error {
 	if r.Body == nil {
-		return fmt.Errorf("missing body request")
+		return apperror.Validation("missing body request")
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(payload); err != nil {
+		return decodeError(err)
+	}
+
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return apperror.Validation("body must contain a single JSON object")
+	}
+
+	return nil
+}
+
+// SetETag sets the ETag header of w to version, the strong entity tag a client sends back in If-Match so its write
+// only applies while the resource is unchanged.
+func SetETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// IfMatch returns the version named by the If-Match header of r, or zero when the header is missing or "*" and the
+// write is unconditional. A header that is not a single ETag set by SetETag is a validation error.
+func IfMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return 0, nil
+	}
+
+	tag, quoted := strings.CutPrefix(value, `"`)
+	tag, closed := strings.CutSuffix(tag, `"`)
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if !quoted || !closed || err != nil || version <= 0 {
+		return 0, apperror.Validation(`If-Match must be "*" or a single ETag such as "3"`)
+	}
+
+	return version, nil
+}
+
+// decodeError turns an error of json.Decoder into a validation error a client can act on.
+func decodeError(err error) error {
+	var (
+		typeErr     *json.UnmarshalTypeError
+		maxBytesErr *http.MaxBytesError
+	)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return apperror.Validation("missing body request")
+	case errors.As(err, &maxBytesErr):
+		return apperror.Validation("body must not be larger than %d bytes", maxBytesErr.Limit)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return apperror.Invalid(apperror.FieldError{
+			Field:   typeErr.Field,
+			Message: fmt.Sprintf("%s must be of type %s", typeErr.Field, typeErr.Type),
+		})
+	case errors.As(err, &typeErr):
+		// Values decoded by a json.Unmarshaler, such as the members of a merge patch, are reported without the field.
+		return apperror.Validation("value must be of type %s", typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no error type for unknown fields, the field name is only part of the message.
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apperror.Invalid(apperror.FieldError{Field: field, Message: fmt.Sprintf("unknown field %q", field)})
+	default:
+		return apperror.Validation("malformed JSON: %v", err)
 	}
-	return json.NewDecoder(r.Body).Decode(payload)
 }
 
 func Success(w http.ResponseWriter, status int, code int, message string, v any) {
@@ -34,6 +112,18 @@ func Success(w http.ResponseWriter, status int, code int, message string, v any)
 	})
 }
 
+// SuccessWithMeta writes a success envelope carrying metadata about the data, such as pagination.
+func SuccessWithMeta(w http.ResponseWriter, status int, code int, message string, v any, meta any) {
+	WriteJSONResponse(w, status, APIResponse{
+		Code:    strconv.Itoa(code),
+		Message: message,
+		Data:    v,
+		Meta:    meta,
+	})
+}
+
+// Error writes an error envelope whose message is message followed by err. The field errors of a validation error,
+// see apperror.Fields, are listed in errors.
 func Error(w http.ResponseWriter, status int, code int, message string, err error) {
 	var e interface{}
 	if err != nil {
@@ -42,5 +132,6 @@ func Error(w http.ResponseWriter, status int, code int, message string, err erro
 	WriteJSONResponse(w, status, APIResponse{
 		Code:    strconv.Itoa(code),
 		Message: fmt.Sprintf("%s: %s", message, e),
+		Errors:  apperror.Fields(err),
 	})
 }
@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
 )
 
 type samplePayload struct {
@@ -58,15 +60,21 @@ func TestWriteJSONResponse(t *testing.T) {
 
 func TestParseJSON(t *testing.T) {
 	cases := []struct {
-		name      string
-		body      string
-		bodyNil   bool
-		wantErr   bool
-		wantValue samplePayload
+		name       string
+		body       string
+		bodyNil    bool
+		wantErr    string
+		wantFields []apperror.FieldError
+		wantValue  samplePayload
 	}{
 		{name: "valid", body: `{"a":"x","b":"y"}`, wantValue: samplePayload{A: "x", B: "y"}},
-		{name: "invalid", body: `{"a":`, wantErr: true},
-		{name: "nil-body", bodyNil: true, wantErr: true},
+		{name: "invalid", body: `{"a":`, wantErr: "malformed JSON: unexpected EOF"},
+		{name: "nil-body", bodyNil: true, wantErr: "missing body request"},
+		{name: "empty-body", body: "", wantErr: "missing body request"},
+		{name: "unknown-field", body: `{"a":"x","c":"z"}`, wantErr: `unknown field "c"`, wantFields: []apperror.FieldError{{Field: "c", Message: `unknown field "c"`}}},
+		{name: "wrong-type", body: `{"a":1}`, wantErr: "a must be of type string", wantFields: []apperror.FieldError{{Field: "a", Message: "a must be of type string"}}},
+		{name: "trailing-object", body: `{"a":"x"}{"b":"y"}`, wantErr: "body must contain a single JSON object"},
+		{name: "too-large", body: `{"a":"` + strings.Repeat("x", MaxBodyBytes) + `"}`, wantErr: "body must not be larger than 1048576 bytes"},
 	}
 
 	for _, tc := range cases {
@@ -80,9 +88,15 @@ func TestParseJSON(t *testing.T) {
 
 			var payload samplePayload
 			err := ParseJSON(req, &payload)
-			if tc.wantErr {
-				if err == nil {
-					t.Fatalf("expected error")
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+				}
+				if !errors.Is(err, apperror.ErrValidation) {
+					t.Fatalf("expected a validation error, got %v", err)
+				}
+				if fields := apperror.Fields(err); !reflect.DeepEqual(fields, tc.wantFields) {
+					t.Fatalf("fields = %+v, want %+v", fields, tc.wantFields)
 				}
 				return
 			}
@@ -96,6 +110,57 @@ func TestParseJSON(t *testing.T) {
 	}
 }
 
+func TestSetETag(t *testing.T) {
+	rr := httptest.NewRecorder()
+	SetETag(rr, 42)
+
+	if got := rr.Header().Get("ETag"); got != `"42"` {
+		t.Fatalf("ETag = %q, want %q", got, `"42"`)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	cases := []struct {
+		name    string
+		header  string
+		want    int64
+		wantErr bool
+	}{
+		{name: "missing"},
+		{name: "any", header: "*"},
+		{name: "etag", header: `"3"`, want: 3},
+		{name: "spaces", header: ` "12" `, want: 12},
+		{name: "unquoted", header: "3", wantErr: true},
+		{name: "weak", header: `W/"3"`, wantErr: true},
+		{name: "list", header: `"3", "4"`, wantErr: true},
+		{name: "not-a-version", header: `"abc"`, wantErr: true},
+		{name: "zero", header: `"0"`, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("If-Match", tc.header)
+			}
+
+			got, err := IfMatch(req)
+			if tc.wantErr {
+				if !errors.Is(err, apperror.ErrValidation) {
+					t.Fatalf("expected a validation error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Fatalf("version = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestSuccess(t *testing.T) {
 	cases := []struct {
 		name    string
@@ -139,17 +204,47 @@ func TestSuccess(t *testing.T) {
 	}
 }
 
+func TestSuccessWithMeta(t *testing.T) {
+	rec := httptest.NewRecorder()
+	SuccessWithMeta(rec, http.StatusOK, 1, "done", []string{"a"}, map[string]int{"page": 2})
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var got APIResponse
+	decodeBody(t, rec.Body, &got)
+	if got.Message != "done" {
+		t.Fatalf("message = %v, want done", got.Message)
+	}
+	if !reflect.DeepEqual(got.Data, []any{"a"}) {
+		t.Fatalf("data = %+v", got.Data)
+	}
+	if !reflect.DeepEqual(got.Meta, map[string]any{"page": float64(2)}) {
+		t.Fatalf("meta = %+v", got.Meta)
+	}
+}
+
 func TestError(t *testing.T) {
 	cases := []struct {
-		name    string
-		status  int
-		code    int
-		message string
-		err     error
-		wantMsg string
+		name       string
+		status     int
+		code       int
+		message    string
+		err        error
+		wantMsg    string
+		wantFields []apperror.FieldError
 	}{
 		{name: "with-error", status: http.StatusBadRequest, code: 9, message: "bad", err: errors.New("boom"), wantMsg: "bad: boom"},
 		{name: "nil-error", status: http.StatusInternalServerError, code: 500, message: "oops", err: nil, wantMsg: "oops: %!s(<nil>)"},
+		{
+			name:       "field-errors",
+			status:     http.StatusBadRequest,
+			code:       2001,
+			message:    "invalid",
+			err:        apperror.Invalid(apperror.FieldError{Field: "name", Message: "name is required"}, apperror.FieldError{Field: "price", Message: "price must not be negative"}),
+			wantMsg:    "invalid: name is required; price must not be negative",
+			wantFields: []apperror.FieldError{{Field: "name", Message: "name is required"}, {Field: "price", Message: "price must not be negative"}},
+		},
 	}
 
 	for _, tc := range cases {
@@ -171,6 +266,9 @@ func TestError(t *testing.T) {
 			if got.Data != nil {
 				t.Fatalf("data = %v, want nil", got.Data)
 			}
+			if !reflect.DeepEqual(got.Errors, tc.wantFields) {
+				t.Fatalf("errors = %+v, want %+v", got.Errors, tc.wantFields)
+			}
 		})
 	}
 }
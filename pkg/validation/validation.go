@@ -0,0 +1,75 @@
+// Package validation checks the fields of a request and reports every invalid field at once, see
+// apperror.FieldError.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+)
+
+// Validator collects the field errors of one request. Only the first error of a field is kept, so a missing name is
+// not also reported as too short.
+//
+//	v := validation.New()
+//	v.Required("name", request.Name)
+//	v.NonNegative("price", request.Price)
+//	return v.Err()
+type Validator struct {
+	fields []apperror.FieldError
+}
+
+// New returns a Validator without errors.
+func New() *Validator {
+	return &Validator{}
+}
+
+// Check records message for field unless ok holds.
+func (v *Validator) Check(ok bool, field, format string, args ...any) {
+	if ok || v.has(field) {
+		return
+	}
+
+	v.fields = append(v.fields, apperror.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Required checks that value is not blank.
+func (v *Validator) Required(field, value string) {
+	v.Check(strings.TrimSpace(value) != "", field, "%s is required", field)
+}
+
+// MaxLength checks that value has at most max characters.
+func (v *Validator) MaxLength(field, value string, max int) {
+	v.Check(utf8.RuneCountInString(value) <= max, field, "%s must be at most %d characters", field, max)
+}
+
+// NonNegative checks that value is zero or more.
+func (v *Validator) NonNegative(field string, value int) {
+	v.Check(value >= 0, field, "%s must not be negative", field)
+}
+
+// Positive checks that value is more than zero. Use it for IDs, whose zero value means the field is missing.
+func (v *Validator) Positive(field string, value int) {
+	v.Check(value > 0, field, "%s is required", field)
+}
+
+// Err returns an apperror.Invalid error with the collected field errors, or nil when every check passed.
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return apperror.Invalid(v.fields...)
+}
+
+func (v *Validator) has(field string) bool {
+	for _, f := range v.fields {
+		if f.Field == field {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pandusatrianura/code-with-umam-second-meeting/pkg/apperror"
+)
+
+func TestValidator(t *testing.T) {
+	cases := []struct {
+		name       string
+		check      func(v *Validator)
+		wantErr    string
+		wantFields []apperror.FieldError
+	}{
+		{
+			name: "valid",
+			check: func(v *Validator) {
+				v.Required("name", "Susu")
+				v.MaxLength("name", "Susu", 4)
+				v.NonNegative("price", 0)
+				v.Positive("category_id", 1)
+				v.Check(true, "sku", "never reported")
+			},
+		},
+		{
+			name: "every-field",
+			check: func(v *Validator) {
+				v.Required("name", "  ")
+				v.NonNegative("price", -1)
+				v.Positive("category_id", 0)
+			},
+			wantErr: "name is required; price must not be negative; category_id is required",
+			wantFields: []apperror.FieldError{
+				{Field: "name", Message: "name is required"},
+				{Field: "price", Message: "price must not be negative"},
+				{Field: "category_id", Message: "category_id is required"},
+			},
+		},
+		{
+			name: "first-error-per-field",
+			check: func(v *Validator) {
+				v.Required("name", "")
+				v.MaxLength("name", "", 0)
+				v.Check(false, "name", "name is %s", "wrong")
+			},
+			wantErr:    "name is required",
+			wantFields: []apperror.FieldError{{Field: "name", Message: "name is required"}},
+		},
+		{
+			name: "max-length-counts-characters",
+			check: func(v *Validator) {
+				v.MaxLength("name", "Kopi Gayo Aceh ☕", 16)
+				v.MaxLength("description", strings.Repeat("a", 6), 5)
+			},
+			wantErr:    "description must be at most 5 characters",
+			wantFields: []apperror.FieldError{{Field: "description", Message: "description must be at most 5 characters"}},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := New()
+			tc.check(v)
+			err := v.Err()
+
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.wantErr {
+				t.Fatalf("expected error %q, got %v", tc.wantErr, err)
+			}
+			if !errors.Is(err, apperror.ErrValidation) {
+				t.Fatalf("expected a validation error, got %v", err)
+			}
+			if fields := apperror.Fields(err); !reflect.DeepEqual(fields, tc.wantFields) {
+				t.Fatalf("fields = %+v, want %+v", fields, tc.wantFields)
+			}
+		})
+	}
+}